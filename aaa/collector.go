@@ -1,6 +1,7 @@
 package aaa
 
 import (
+	gocontext "context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 
@@ -131,13 +132,11 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx gocontext.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
 	sshCtx := connector.NewSSHCommandContext("show aaa servers")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	radiusServers := make(chan *RadiusServer)
 	radiusServersParsingDone := make(chan struct{})
@@ -146,73 +145,74 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 	for {
 		select {
 		case radiusServer := <-radiusServers:
-			generateMetrics(ctx, radiusServer)
+			generateMetrics(collectCtx, result, radiusServer)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping aaa metrics: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping aaa metrics: %v", err))
 		case <-radiusServersParsingDone:
-			return
+			return result
 		}
 	}
 }
 
-func generateMetrics(ctx *collector.CollectContext, radiusServer *RadiusServer) {
-	l := append(ctx.LabelValues, radiusServer.ID, radiusServer.Priority, radiusServer.Host, radiusServer.AuthPort, radiusServer.AccountingPort)
-	ctx.Metrics <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, radiusServer.Up, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(upDurationDesc, prometheus.GaugeValue, radiusServer.UpDuration, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(deadTotalTimeDesc, prometheus.GaugeValue, radiusServer.DeadTotalTime, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(deadCountDesc, prometheus.GaugeValue, radiusServer.DeadCount, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(quarantinedDesc, prometheus.GaugeValue, radiusServer.Quarantined, l...)
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, radiusServer *RadiusServer) {
+	collectCtx.Record(radiusServer)
+	l := append(collectCtx.LabelValues, radiusServer.ID, radiusServer.Priority, radiusServer.Host, radiusServer.AuthPort, radiusServer.AccountingPort)
+	result.AddMetric(prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, radiusServer.Up, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(upDurationDesc, prometheus.GaugeValue, radiusServer.UpDuration, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(deadTotalTimeDesc, prometheus.GaugeValue, radiusServer.DeadTotalTime, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(deadCountDesc, prometheus.GaugeValue, radiusServer.DeadCount, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(quarantinedDesc, prometheus.GaugeValue, radiusServer.Quarantined, l...))
 
 	for subsystem, value := range radiusServer.Requests {
-		ctx.Metrics <- prometheus.MustNewConstMetric(requestsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(requestsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.Timeouts {
-		ctx.Metrics <- prometheus.MustNewConstMetric(timeoutsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(timeoutsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.Failovers {
-		ctx.Metrics <- prometheus.MustNewConstMetric(failoversDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(failoversDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.Retransmissions {
-		ctx.Metrics <- prometheus.MustNewConstMetric(retransmissionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(retransmissionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 
 	for subsystem, responses := range radiusServer.Responses {
 		l1 := append(l, subsystem)
 		for responseType, value := range responses {
-			ctx.Metrics <- prometheus.MustNewConstMetric(responsesDesc, prometheus.GaugeValue, value, append(l1, responseType)...)
+			result.AddMetric(prometheus.MustNewConstMetric(responsesDesc, prometheus.GaugeValue, value, append(l1, responseType)...))
 		}
 	}
 
 	for subsystem, value := range radiusServer.ResponseTime {
-		ctx.Metrics <- prometheus.MustNewConstMetric(responseTimeDesc, prometheus.GaugeValue, value/1000, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(responseTimeDesc, prometheus.GaugeValue, value/1000, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.SuccessfullTransactions {
-		ctx.Metrics <- prometheus.MustNewConstMetric(successfullTransactionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(successfullTransactionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.FailedTransactions {
-		ctx.Metrics <- prometheus.MustNewConstMetric(failedTransactionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(failedTransactionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 
 	for subsystem, value := range radiusServer.ThrottledTransactions {
-		ctx.Metrics <- prometheus.MustNewConstMetric(throttledTransactionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(throttledTransactionsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.ThrottledTimeouts {
-		ctx.Metrics <- prometheus.MustNewConstMetric(throttledTimeoutsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(throttledTimeoutsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.ThrottledFailures {
-		ctx.Metrics <- prometheus.MustNewConstMetric(throttledFailuresDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(throttledFailuresDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.MalformedResponses {
-		ctx.Metrics <- prometheus.MustNewConstMetric(malformedResponsesDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(malformedResponsesDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
 	for subsystem, value := range radiusServer.BadAuthenticators {
-		ctx.Metrics <- prometheus.MustNewConstMetric(badAuthenticatorsDesc, prometheus.GaugeValue, value, append(l, subsystem)...)
+		result.AddMetric(prometheus.MustNewConstMetric(badAuthenticatorsDesc, prometheus.GaugeValue, value, append(l, subsystem)...))
 	}
-	ctx.Metrics <- prometheus.MustNewConstMetric(estimatedOutstandingAccessTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedOutstandingAccessTransactions, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(estimatedOutstandingAccountingTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedOutstandingAccountingTransactions, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(estimatedThrottledAccessTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedThrottledAccessTransactions, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(estimatedThrottledAccountingTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedThrottledAccountingTransactions, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(requestsPerMinuteDesc, prometheus.GaugeValue, radiusServer.RequestsPerMinuteHigh, append(l, "high")...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(requestsPerMinuteDesc, prometheus.GaugeValue, radiusServer.RequestsPerMinuteLow, append(l, "low")...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(requestsPerMinuteDesc, prometheus.GaugeValue, radiusServer.RequestsPerMinuteAverage, append(l, "average")...)
+	result.AddMetric(prometheus.MustNewConstMetric(estimatedOutstandingAccessTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedOutstandingAccessTransactions, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(estimatedOutstandingAccountingTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedOutstandingAccountingTransactions, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(estimatedThrottledAccessTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedThrottledAccessTransactions, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(estimatedThrottledAccountingTransactionsDesc, prometheus.GaugeValue, radiusServer.EstimatedThrottledAccountingTransactions, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(requestsPerMinuteDesc, prometheus.GaugeValue, radiusServer.RequestsPerMinuteHigh, append(l, "high")...))
+	result.AddMetric(prometheus.MustNewConstMetric(requestsPerMinuteDesc, prometheus.GaugeValue, radiusServer.RequestsPerMinuteLow, append(l, "low")...))
+	result.AddMetric(prometheus.MustNewConstMetric(requestsPerMinuteDesc, prometheus.GaugeValue, radiusServer.RequestsPerMinuteAverage, append(l, "average")...))
 }
@@ -0,0 +1,98 @@
+// Package asa holds what the collectors of Cisco ASAs share.
+package asa
+
+import (
+	"context"
+	"regexp"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var usageRegexp = regexp.MustCompile(`^\s*(\d+) in use, (\d+) most used`)
+
+// Usage is the usage of a resource in a security context as printed by commands like `show conn count`.
+type Usage struct {
+	Context  string
+	InUse    float64
+	MostUsed float64
+}
+
+// UsageCollector gathers the usage of a resource of an ASA by running a command printing `N in use, M most used`
+// in each security context.
+type UsageCollector struct {
+	name         string
+	command      string
+	resource     string
+	inUseDesc    *prometheus.Desc
+	mostUsedDesc *prometheus.Desc
+}
+
+// NewUsageCollector returns a collector named name running command. The metrics start with prefix,
+// resource describes what is used, e.g. `connections`.
+func NewUsageCollector(name string, command string, prefix string, resource string) *UsageCollector {
+	l := []string{"target", "context"}
+	return &UsageCollector{
+		name:         name,
+		command:      command,
+		resource:     resource,
+		inUseDesc:    prometheus.NewDesc(prefix+"in_use", "Number of "+resource+" in use", l, nil),
+		mostUsedDesc: prometheus.NewDesc(prefix+"most_used", "Highest number of "+resource+" in use since the last reboot or clear", l, nil),
+	}
+}
+
+// Name implements the collector.Collector interface's Name function
+func (c *UsageCollector) Name() string {
+	return c.name
+}
+
+// Describe implements the collector.Collector interface's Describe function
+func (c *UsageCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.inUseDesc
+	ch <- c.mostUsedDesc
+}
+
+// Collect implements the collector.Collector interface's Collect function
+func (c *UsageCollector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	for _, securityContext := range collectCtx.SecurityContexts() {
+		sshCtx := connector.NewSSHCommandContextInSecurityContext(collectCtx.Connection.Info(), securityContext, c.command)
+		go collectCtx.Connection.RunCommand(ctx, sshCtx)
+		if usage := c.parse(sshCtx, securityContext, result); usage != nil {
+			collectCtx.Record(usage)
+			result.AddMetric(prometheus.MustNewConstMetric(c.inUseDesc, prometheus.GaugeValue, usage.InUse, append(collectCtx.LabelValues, securityContext)...))
+			result.AddMetric(prometheus.MustNewConstMetric(c.mostUsedDesc, prometheus.GaugeValue, usage.MostUsed, append(collectCtx.LabelValues, securityContext)...))
+		}
+	}
+	return result
+}
+
+// parse returns the usage printed by the command of sshCtx, nil if there is none.
+func (c *UsageCollector) parse(sshCtx *connector.SSHCommandContext, securityContext string, result *collector.Result) *Usage {
+	var usage *Usage
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			if usage == nil {
+				result.AddError(errors.Errorf("No count of %s was extracted", c.resource))
+			}
+			return usage
+		case err := <-sshCtx.Errors:
+			result.AddError(errors.Wrapf(err, "Error scraping the count of %s: %v", c.resource, err))
+		case line := <-sshCtx.Output:
+			if matches := usageRegexp.FindStringSubmatch(line); matches != nil && usage == nil {
+				usage = &Usage{
+					Context:  securityContext,
+					InUse:    util.Str2float64(matches[1]),
+					MostUsed: util.Str2float64(matches[2]),
+				}
+			}
+		}
+	}
+}
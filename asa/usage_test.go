@@ -0,0 +1,84 @@
+package asa
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/config"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+// outputConnection answers every command with output.
+type outputConnection struct {
+	connector.ConnectionInfo
+	output string
+}
+
+func (c *outputConnection) RunCommand(ctx context.Context, sshCtx *connector.SSHCommandContext) {
+	sshCtx.Output <- c.output
+	sshCtx.Done <- struct{}{}
+}
+
+func (c *outputConnection) IsConnected() bool     { return true }
+func (c *outputConnection) IsAuthenticated() bool { return true }
+func (c *outputConnection) Terminate()            {}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected *Usage
+	}{
+		{"count", "1234 in use, 56789 most used", &Usage{Context: "customer-a", InUse: 1234, MostUsed: 56789}},
+		{"indented", "  0 in use, 3 most used", &Usage{Context: "customer-a", InUse: 0, MostUsed: 3}},
+		{"no count", "ERROR: % Invalid input detected at '^' marker.", nil},
+	}
+
+	c := NewUsageCollector("connections", "show conn count", "cisco_asa_connections_", "connections")
+	for _, test := range tests {
+		ctx := util.PrepareOutputForTesting(test.output)
+		result := collector.NewResult()
+		usage := c.parse(&ctx, "customer-a", result)
+		if !reflect.DeepEqual(usage, test.expected) {
+			t.Errorf("%s: Expected %+v, got %+v", test.name, test.expected, usage)
+		}
+		if expectedErrors := map[bool]int{true: 1, false: 0}[test.expected == nil]; len(result.Errors) != expectedErrors {
+			t.Errorf("%s: Expected %d errors, got %v", test.name, expectedErrors, result.Errors)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		collector *UsageCollector
+		expected  map[string]float64
+	}{
+		{
+			collector: NewUsageCollector("connections", "show conn count", "cisco_asa_connections_", "connections"),
+			expected: map[string]float64{
+				"cisco_asa_connections_in_use{context=customer-a,target=test.test}":    12,
+				"cisco_asa_connections_most_used{context=customer-a,target=test.test}": 345,
+			},
+		},
+		{
+			collector: NewUsageCollector("xlate", "show xlate count", "cisco_asa_xlates_", "address translations"),
+			expected: map[string]float64{
+				"cisco_asa_xlates_in_use{context=customer-a,target=test.test}":    12,
+				"cisco_asa_xlates_most_used{context=customer-a,target=test.test}": 345,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		collectCtx := &collector.CollectContext{
+			Connection:  &outputConnection{output: "12 in use, 345 most used"},
+			Module:      &config.ModuleConfig{SecurityContexts: []string{"customer-a"}},
+			LabelValues: []string{"test.test"},
+		}
+		result := test.collector.Collect(context.Background(), collectCtx)
+		util.CompareMetrics(util.PrepareResultForTesting(result, t), test.expected, t)
+	}
+}
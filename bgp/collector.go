@@ -1,8 +1,11 @@
 package bgp
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
 
 	"github.com/pkg/errors"
 
@@ -112,18 +115,35 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function.
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
-	c.collect(ctx, "ipv6 unicast")
-	c.collect(ctx, "ipv4 unicast")
+	// A single request retrieves the neighbors of all address families.
+	if netconf, ok := collectCtx.Connection.(*connector.NETCONFConnection); ok {
+		if err := c.collectNETCONF(ctx, collectCtx, result, netconf); err != nil {
+			result.AddError(errors.Wrap(err, "Error scraping BGP metrics"))
+		}
+		return result
+	}
+
+	c.collect(ctx, collectCtx, result, "ipv6 unicast")
+	c.collect(ctx, collectCtx, result, "ipv4 unicast")
+	return result
 }
 
-func (c *Collector) collect(ctx *collector.CollectContext, addressFamily string) {
+func (c *Collector) collect(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, addressFamily string) {
+	if collectCtx.Connection.Info().DeviceInfo.OSVersion == config.NXOS {
+		err := c.collectNXOSJSON(ctx, collectCtx, result, addressFamily)
+		if errors.Cause(err) != nxos.ErrJSONUnsupported {
+			if err != nil {
+				result.AddError(errors.Wrap(err, "Error scraping BGP metrics"))
+			}
+			return
+		}
+	}
+
 	sshCtx := connector.NewSSHCommandContext("show bgp " + addressFamily + " neighbors")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	neighbors := make(chan *Neighbor)
 	neighborsParsingDone := make(chan struct{}, 1)
@@ -132,80 +152,96 @@ func (c *Collector) collect(ctx *collector.CollectContext, addressFamily string)
 	for {
 		select {
 		case neighbor := <-neighbors:
-			generateMetrics(ctx, neighbor)
+			generateMetrics(collectCtx, result, neighbor)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping BGP metrics: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping BGP metrics: %v", err))
 		case <-neighborsParsingDone:
 			return
 		}
 	}
 }
 
-func generateMetrics(ctx *collector.CollectContext, neighbor *Neighbor) {
-	l := append(ctx.LabelValues, neighbor.RemoteAS, neighbor.RemoteIP, neighbor.Description)
+func (c *Collector) collectNXOSJSON(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, addressFamily string) error {
+	data := &NXOSNeighbors{}
+	if err := nxos.RunJSON(ctx, collectCtx.Connection, "show bgp "+addressFamily+" neighbors", data); err != nil {
+		return err
+	}
+	neighbors, err := data.Neighbors()
+	if err != nil {
+		return err
+	}
+	for _, neighbor := range neighbors {
+		generateMetrics(collectCtx, result, neighbor)
+	}
+	return nil
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, neighbor *Neighbor) {
+	collectCtx.Record(neighbor)
+	l := append(collectCtx.LabelValues, neighbor.RemoteAS, neighbor.RemoteIP, neighbor.Description)
 	sentLabels := append(l, "sent")
 	rcvdLabels := append(l, "recvd")
-	ctx.Metrics <- prometheus.MustNewConstMetric(bgpVersionDesc, prometheus.GaugeValue, neighbor.BGPVersion, l...)
+	result.AddMetric(prometheus.MustNewConstMetric(bgpVersionDesc, prometheus.GaugeValue, neighbor.BGPVersion, l...))
 	stateDescLabels := append(l, neighbor.State)
-	ctx.Metrics <- prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, 1, stateDescLabels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(adminShutdownDesc, prometheus.GaugeValue, neighbor.AdminShutdown, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(holdTimeDesc, prometheus.GaugeValue, neighbor.HoldTime, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(keepaliveIntervalDesc, prometheus.GaugeValue, neighbor.KeepaliveInterval, l...)
+	result.AddMetric(prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, 1, stateDescLabels...))
+	result.AddMetric(prometheus.MustNewConstMetric(adminShutdownDesc, prometheus.GaugeValue, neighbor.AdminShutdown, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(holdTimeDesc, prometheus.GaugeValue, neighbor.HoldTime, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(keepaliveIntervalDesc, prometheus.GaugeValue, neighbor.KeepaliveInterval, l...))
 
-	ctx.Metrics <- prometheus.MustNewConstMetric(opensDesc, prometheus.GaugeValue, neighbor.OpensSent, sentLabels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(opensDesc, prometheus.GaugeValue, neighbor.OpensRcvd, rcvdLabels...)
+	result.AddMetric(prometheus.MustNewConstMetric(opensDesc, prometheus.GaugeValue, neighbor.OpensSent, sentLabels...))
+	result.AddMetric(prometheus.MustNewConstMetric(opensDesc, prometheus.GaugeValue, neighbor.OpensRcvd, rcvdLabels...))
 
-	ctx.Metrics <- prometheus.MustNewConstMetric(notificationsDesc, prometheus.GaugeValue, neighbor.NotificationsSent, sentLabels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(notificationsDesc, prometheus.GaugeValue, neighbor.NotificationsRcvd, rcvdLabels...)
+	result.AddMetric(prometheus.MustNewConstMetric(notificationsDesc, prometheus.GaugeValue, neighbor.NotificationsSent, sentLabels...))
+	result.AddMetric(prometheus.MustNewConstMetric(notificationsDesc, prometheus.GaugeValue, neighbor.NotificationsRcvd, rcvdLabels...))
 
-	ctx.Metrics <- prometheus.MustNewConstMetric(updatesDesc, prometheus.GaugeValue, neighbor.UpdatesSent, sentLabels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(updatesDesc, prometheus.GaugeValue, neighbor.UpdatesRcvd, rcvdLabels...)
+	result.AddMetric(prometheus.MustNewConstMetric(updatesDesc, prometheus.GaugeValue, neighbor.UpdatesSent, sentLabels...))
+	result.AddMetric(prometheus.MustNewConstMetric(updatesDesc, prometheus.GaugeValue, neighbor.UpdatesRcvd, rcvdLabels...))
 
-	ctx.Metrics <- prometheus.MustNewConstMetric(keepalivesDesc, prometheus.GaugeValue, neighbor.KeepalivesSent, sentLabels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(keepalivesDesc, prometheus.GaugeValue, neighbor.KeepalivesRcvd, rcvdLabels...)
+	result.AddMetric(prometheus.MustNewConstMetric(keepalivesDesc, prometheus.GaugeValue, neighbor.KeepalivesSent, sentLabels...))
+	result.AddMetric(prometheus.MustNewConstMetric(keepalivesDesc, prometheus.GaugeValue, neighbor.KeepalivesRcvd, rcvdLabels...))
 
-	ctx.Metrics <- prometheus.MustNewConstMetric(routeRefreshsDesc, prometheus.GaugeValue, neighbor.RouteRefreshsSent, sentLabels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(routeRefreshsDesc, prometheus.GaugeValue, neighbor.RouteRefreshsRcvd, rcvdLabels...)
+	result.AddMetric(prometheus.MustNewConstMetric(routeRefreshsDesc, prometheus.GaugeValue, neighbor.RouteRefreshsSent, sentLabels...))
+	result.AddMetric(prometheus.MustNewConstMetric(routeRefreshsDesc, prometheus.GaugeValue, neighbor.RouteRefreshsRcvd, rcvdLabels...))
 
 	for addressFamily, value := range neighbor.PrefixesCurrentBytes {
-		ctx.Metrics <- prometheus.MustNewConstMetric(prefixesCurrentBytesDesc, prometheus.GaugeValue, value, append(l, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(prefixesCurrentBytesDesc, prometheus.GaugeValue, value, append(l, addressFamily)...))
 	}
 	for addressFamily, value := range neighbor.PrefixesCurrentSent {
-		ctx.Metrics <- prometheus.MustNewConstMetric(prefixesCurrentDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(prefixesCurrentDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...))
 	}
 	for addressFamily, value := range neighbor.PrefixesCurrentRcvd {
-		ctx.Metrics <- prometheus.MustNewConstMetric(prefixesCurrentDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(prefixesCurrentDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...))
 	}
 
 	for addressFamily, value := range neighbor.PrefixesTotalSent {
-		ctx.Metrics <- prometheus.MustNewConstMetric(prefixesTotalDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(prefixesTotalDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...))
 	}
 	for addressFamily, value := range neighbor.PrefixesTotalRcvd {
-		ctx.Metrics <- prometheus.MustNewConstMetric(prefixesTotalDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(prefixesTotalDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...))
 	}
 
 	for addressFamily, value := range neighbor.ImplicitWithdrawSent {
-		ctx.Metrics <- prometheus.MustNewConstMetric(implicitWithdrawDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(implicitWithdrawDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...))
 	}
 	for addressFamily, value := range neighbor.ImplicitWithdrawRcvd {
-		ctx.Metrics <- prometheus.MustNewConstMetric(implicitWithdrawDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(implicitWithdrawDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...))
 	}
 
 	for addressFamily, value := range neighbor.ExplicitWithdrawSent {
-		ctx.Metrics <- prometheus.MustNewConstMetric(explicitWithdrawDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(explicitWithdrawDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...))
 	}
 	for addressFamily, value := range neighbor.ExplicitWithdrawRcvd {
-		ctx.Metrics <- prometheus.MustNewConstMetric(explicitWithdrawDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(explicitWithdrawDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...))
 	}
 
 	for addressFamily, value := range neighbor.UsedAsBestpath {
-		ctx.Metrics <- prometheus.MustNewConstMetric(usedAsBestpathDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(usedAsBestpathDesc, prometheus.GaugeValue, value, append(sentLabels, addressFamily)...))
 	}
 	for addressFamily, value := range neighbor.UsedAsMultipath {
-		ctx.Metrics <- prometheus.MustNewConstMetric(usedAsMultipathDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(usedAsMultipathDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...))
 	}
 	for addressFamily, value := range neighbor.UsedAsSecondary {
-		ctx.Metrics <- prometheus.MustNewConstMetric(usedAsSecondaryDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...)
+		result.AddMetric(prometheus.MustNewConstMetric(usedAsSecondaryDesc, prometheus.GaugeValue, value, append(rcvdLabels, addressFamily)...))
 	}
-	ctx.Metrics <- prometheus.MustNewConstMetric(uptimeDesc, prometheus.GaugeValue, neighbor.Uptime, l...)
+	result.AddMetric(prometheus.MustNewConstMetric(uptimeDesc, prometheus.GaugeValue, neighbor.Uptime, l...))
 }
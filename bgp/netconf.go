@@ -0,0 +1,157 @@
+package bgp
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"github.com/pkg/errors"
+)
+
+// netconfFilter selects the neighbors of the default VRF in Cisco-IOS-XE-bgp-oper.
+const netconfFilter = `<bgp-state-data xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-bgp-oper"><neighbors><neighbor>` +
+	`<vrf-name>default</vrf-name><afi-safi/><neighbor-id/><description/><bgp-version/><up-time/><session-state/><as/>` +
+	`<negotiated-keepalive-timers/><bgp-neighbor-counters/><connection/><prefix-activity/>` +
+	`</neighbor></neighbors></bgp-state-data>`
+
+var (
+	// netconfAddressFamilies maps the afi-safi of Cisco-IOS-XE-bgp-oper to the address families printed by the CLI.
+	netconfAddressFamilies = map[string]string{
+		"ipv4-unicast":   "IPv4 Unicast",
+		"ipv4-multicast": "IPv4 Multicast",
+		"ipv6-unicast":   "IPv6 Unicast",
+		"ipv6-multicast": "IPv6 Multicast",
+		"vpnv4-unicast":  "VPNv4 Unicast",
+		"vpnv6-unicast":  "VPNv6 Unicast",
+		"l2vpn-evpn":     "L2VPN E-VPN",
+	}
+	// netconfStates maps the session-state of Cisco-IOS-XE-bgp-oper to the states printed by the CLI.
+	netconfStates = map[string]string{
+		"fsm-idle":        "Idle",
+		"fsm-connect":     "Connect",
+		"fsm-active":      "Active",
+		"fsm-opensent":    "OpenSent",
+		"fsm-openconfirm": "OpenConfirm",
+		"fsm-established": "Established",
+	}
+)
+
+type netconfMessageCounters struct {
+	Opens         float64 `xml:"opens"`
+	Updates       float64 `xml:"updates"`
+	Notifications float64 `xml:"notifications"`
+	Keepalives    float64 `xml:"keepalives"`
+	RouteRefreshs float64 `xml:"route-refreshes"`
+}
+
+type netconfPrefixActivity struct {
+	CurrentPrefixes  float64 `xml:"current-prefixes"`
+	TotalPrefixes    float64 `xml:"total-prefixes"`
+	ImplicitWithdraw float64 `xml:"implicit-withdraw"`
+	ExplicitWithdraw float64 `xml:"explicit-withdraw"`
+	Bestpaths        float64 `xml:"bestpaths"`
+	Multipaths       float64 `xml:"multipaths"`
+}
+
+// netconfNeighbors is the relevant part of Cisco-IOS-XE-bgp-oper. Neighbors are listed once per address family.
+type netconfNeighbors struct {
+	Entries []struct {
+		AddressFamily string  `xml:"afi-safi"`
+		NeighborID    string  `xml:"neighbor-id"`
+		Description   string  `xml:"description"`
+		BGPVersion    float64 `xml:"bgp-version"`
+		UpTime        string  `xml:"up-time"`
+		SessionState  string  `xml:"session-state"`
+		RemoteAS      string  `xml:"as"`
+		Timers        struct {
+			HoldTime          float64 `xml:"hold-time"`
+			KeepaliveInterval float64 `xml:"keepalive-interval"`
+		} `xml:"negotiated-keepalive-timers"`
+		Counters struct {
+			Sent     netconfMessageCounters `xml:"sent"`
+			Received netconfMessageCounters `xml:"received"`
+		} `xml:"bgp-neighbor-counters"`
+		Connection struct {
+			TotalEstablished float64 `xml:"total-established"`
+			TotalDropped     float64 `xml:"total-dropped"`
+		} `xml:"connection"`
+		PrefixActivity struct {
+			Sent     netconfPrefixActivity `xml:"sent"`
+			Received netconfPrefixActivity `xml:"received"`
+		} `xml:"prefix-activity"`
+	} `xml:"bgp-state-data>neighbors>neighbor"`
+}
+
+func (c *Collector) collectNETCONF(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, conn *connector.NETCONFConnection) error {
+	reply, err := conn.Get(ctx, netconfFilter)
+	if err != nil {
+		return err
+	}
+	data := &netconfNeighbors{}
+	if err := xml.Unmarshal(reply, data); err != nil {
+		return errors.Wrap(err, "Could not decode bgp-state-data")
+	}
+	for _, neighbor := range data.neighbors() {
+		generateMetrics(collectCtx, result, neighbor)
+	}
+	return nil
+}
+
+// neighbors merges the entries of every address family of a neighbor.
+func (data *netconfNeighbors) neighbors() []*Neighbor {
+	neighbors := make([]*Neighbor, 0)
+	byID := make(map[string]*Neighbor)
+	for _, entry := range data.Entries {
+		neighbor, found := byID[entry.NeighborID]
+		if !found {
+			neighbor = NewNeighbor()
+			neighbor.RemoteIP = entry.NeighborID
+			neighbor.RemoteAS = entry.RemoteAS
+			neighbor.Description = entry.Description
+			neighbor.BGPVersion = entry.BGPVersion
+			neighbor.State = netconfStates[entry.SessionState]
+			if neighbor.State == "" {
+				neighbor.State = strings.TrimPrefix(entry.SessionState, "fsm-")
+			}
+			neighbor.HoldTime = entry.Timers.HoldTime
+			neighbor.KeepaliveInterval = entry.Timers.KeepaliveInterval
+			neighbor.OpensSent = entry.Counters.Sent.Opens
+			neighbor.OpensRcvd = entry.Counters.Received.Opens
+			neighbor.NotificationsSent = entry.Counters.Sent.Notifications
+			neighbor.NotificationsRcvd = entry.Counters.Received.Notifications
+			neighbor.UpdatesSent = entry.Counters.Sent.Updates
+			neighbor.UpdatesRcvd = entry.Counters.Received.Updates
+			neighbor.KeepalivesSent = entry.Counters.Sent.Keepalives
+			neighbor.KeepalivesRcvd = entry.Counters.Received.Keepalives
+			neighbor.RouteRefreshsSent = entry.Counters.Sent.RouteRefreshs
+			neighbor.RouteRefreshsRcvd = entry.Counters.Received.RouteRefreshs
+			neighbor.ConnectionsEstablished = entry.Connection.TotalEstablished
+			neighbor.ConnectionsDropped = entry.Connection.TotalDropped
+			if neighbor.State == "Established" {
+				neighbor.Uptime = parseXRUptime(entry.UpTime).Seconds()
+			}
+			byID[entry.NeighborID] = neighbor
+			neighbors = append(neighbors, neighbor)
+		}
+
+		addressFamily, found := netconfAddressFamilies[entry.AddressFamily]
+		if !found {
+			addressFamily = entry.AddressFamily
+		}
+		sent, received := entry.PrefixActivity.Sent, entry.PrefixActivity.Received
+		neighbor.PrefixesCurrentSent[addressFamily] = sent.CurrentPrefixes
+		neighbor.PrefixesCurrentRcvd[addressFamily] = received.CurrentPrefixes
+		neighbor.PrefixesTotalSent[addressFamily] = sent.TotalPrefixes
+		neighbor.PrefixesTotalRcvd[addressFamily] = received.TotalPrefixes
+		neighbor.ImplicitWithdrawSent[addressFamily] = sent.ImplicitWithdraw
+		neighbor.ImplicitWithdrawRcvd[addressFamily] = received.ImplicitWithdraw
+		neighbor.ExplicitWithdrawSent[addressFamily] = sent.ExplicitWithdraw
+		neighbor.ExplicitWithdrawRcvd[addressFamily] = received.ExplicitWithdraw
+		neighbor.UsedAsBestpath[addressFamily] = received.Bestpaths
+		neighbor.UsedAsMultipath[addressFamily] = received.Multipaths
+	}
+	return neighbors
+}
@@ -0,0 +1,99 @@
+package bgp_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/bgp"
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/connector/netconftest"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const netconfNeighbor = `
+    <vrf-name>default</vrf-name>
+    <neighbor-id>192.0.2.1</neighbor-id>
+    <description>transit</description>
+    <bgp-version>4</bgp-version>
+    <up-time>1w2d</up-time>
+    <session-state>fsm-established</session-state>
+    <as>64496</as>
+    <negotiated-keepalive-timers>
+      <hold-time>180</hold-time>
+      <keepalive-interval>60</keepalive-interval>
+    </negotiated-keepalive-timers>
+    <bgp-neighbor-counters>
+      <sent><opens>3</opens><updates>12</updates><notifications>1</notifications><keepalives>18000</keepalives><route-refreshes>0</route-refreshes></sent>
+      <received><opens>2</opens><updates>45000</updates><notifications>0</notifications><keepalives>17990</keepalives><route-refreshes>1</route-refreshes></received>
+    </bgp-neighbor-counters>
+    <connection>
+      <state>established</state>
+      <total-established>2</total-established>
+      <total-dropped>1</total-dropped>
+    </connection>`
+
+const netconfOutput = `<bgp-state-data xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-bgp-oper">
+  <neighbors>
+    <neighbor>
+      <afi-safi>ipv4-unicast</afi-safi>` + netconfNeighbor + `
+      <prefix-activity>
+        <sent><current-prefixes>10</current-prefixes><total-prefixes>12</total-prefixes><implicit-withdraw>0</implicit-withdraw><explicit-withdraw>2</explicit-withdraw><bestpaths>0</bestpaths><multipaths>0</multipaths></sent>
+        <received><current-prefixes>850000</current-prefixes><total-prefixes>900000</total-prefixes><implicit-withdraw>40000</implicit-withdraw><explicit-withdraw>10000</explicit-withdraw><bestpaths>800000</bestpaths><multipaths>3</multipaths></received>
+      </prefix-activity>
+    </neighbor>
+    <neighbor>
+      <afi-safi>ipv6-unicast</afi-safi>` + netconfNeighbor + `
+      <prefix-activity>
+        <sent><current-prefixes>4</current-prefixes><total-prefixes>4</total-prefixes><implicit-withdraw>0</implicit-withdraw><explicit-withdraw>0</explicit-withdraw><bestpaths>0</bestpaths><multipaths>0</multipaths></sent>
+        <received><current-prefixes>150000</current-prefixes><total-prefixes>160000</total-prefixes><implicit-withdraw>0</implicit-withdraw><explicit-withdraw>10000</explicit-withdraw><bestpaths>140000</bestpaths><multipaths>0</multipaths></received>
+      </prefix-activity>
+    </neighbor>
+    <neighbor>
+      <afi-safi>ipv4-unicast</afi-safi>
+      <vrf-name>default</vrf-name>
+      <neighbor-id>198.51.100.7</neighbor-id>
+      <bgp-version>4</bgp-version>
+      <up-time>never</up-time>
+      <session-state>fsm-active</session-state>
+      <as>64497</as>
+    </neighbor>
+  </neighbors>
+</bgp-state-data>`
+
+func TestCollectNETCONF(t *testing.T) {
+	server := netconftest.NewServer(t, map[string]string{"bgp-state-data": netconfOutput})
+	conn, err := connector.NewConnectionManager().GetConnection("127.0.0.1", server.Device())
+	if err != nil {
+		t.Fatalf("Could not connect to the NETCONF server: %v", err)
+	}
+	defer conn.Terminate()
+
+	result := bgp.NewCollector().Collect(context.Background(), &collector.CollectContext{Connection: conn, LabelValues: []string{"test.test"}})
+	got := util.PrepareResultForTesting(result, t)
+	util.CompareMetrics(got, map[string]float64{
+		"cisco_bgp_version{description=transit,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":                                                      4,
+		"cisco_bgp_state_info{description=transit,remote_as=64496,remote_ip=192.0.2.1,state=Established,target=test.test}":                                 1,
+		"cisco_bgp_holdtime_seconds{description=transit,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":                                             180,
+		"cisco_bgp_keepalive_interval_seconds{description=transit,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":                                   60,
+		"cisco_bgp_opens_total{description=transit,direction=sent,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":                                   3,
+		"cisco_bgp_updates_total{description=transit,direction=recvd,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":                                45000,
+		"cisco_bgp_route_refreshs_total{description=transit,direction=recvd,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":                         1,
+		"cisco_bgp_prefixes_current{address_family=IPv4 Unicast,description=transit,direction=recvd,remote_as=64496,remote_ip=192.0.2.1,target=test.test}": 850000,
+		"cisco_bgp_prefixes_current{address_family=IPv6 Unicast,description=transit,direction=recvd,remote_as=64496,remote_ip=192.0.2.1,target=test.test}": 150000,
+		"cisco_bgp_prefixes_total{address_family=IPv4 Unicast,description=transit,direction=sent,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":    12,
+		"cisco_bgp_uptime_seconds{description=transit,remote_as=64496,remote_ip=192.0.2.1,target=test.test}":                                               9 * 24 * 60 * 60,
+		"cisco_bgp_state_info{description=,remote_as=64497,remote_ip=198.51.100.7,state=Active,target=test.test}":                                          1,
+		"cisco_bgp_uptime_seconds{description=,remote_as=64497,remote_ip=198.51.100.7,target=test.test}":                                                   0,
+	}, t)
+	states := 0
+	for _, metric := range result.Metrics {
+		if strings.Contains(metric.Desc().String(), `"cisco_bgp_state_info"`) {
+			states++
+		}
+	}
+	if states != 2 {
+		t.Errorf("Expected the address families of a neighbor to be merged, got %d states", states)
+	}
+}
@@ -0,0 +1,125 @@
+package bgp
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+var nxosElapsedTimeFieldRegexp = regexp.MustCompile(`(\d+)([DHMS])`)
+
+// NXOSNeighbors is the relevant part of `show bgp <address family> neighbors | json` on NX-OS devices.
+type NXOSNeighbors struct {
+	Table struct {
+		Rows nxos.Rows `json:"ROW_neighbor"`
+	} `json:"TABLE_neighbor"`
+}
+
+type nxosNeighbor struct {
+	Neighbor         string       `json:"neighbor"`
+	RemoteAS         nxos.String  `json:"remoteas"`
+	Description      string       `json:"description"`
+	Version          *nxos.Number `json:"version"`
+	State            string       `json:"state"`
+	ElapsedTime      string       `json:"elapsedtime"`
+	HoldTime         *nxos.Number `json:"holdtime"`
+	KeepaliveTime    *nxos.Number `json:"keepalivetime"`
+	OpensSent        *nxos.Number `json:"opensent"`
+	OpensRcvd        *nxos.Number `json:"openrecvd"`
+	NotificationSent *nxos.Number `json:"notificationsent"`
+	NotificationRcvd *nxos.Number `json:"notificationrcvd"`
+	UpdatesSent      *nxos.Number `json:"updatesent"`
+	UpdatesRcvd      *nxos.Number `json:"updaterecvd"`
+	KeepalivesSent   *nxos.Number `json:"keepalivesent"`
+	KeepalivesRcvd   *nxos.Number `json:"keepaliverecvd"`
+	RefreshsSent     *nxos.Number `json:"rtrefreshsent"`
+	RefreshsRcvd     *nxos.Number `json:"rtrefreshrecvd"`
+	ConnsEstablished *nxos.Number `json:"connsestablished"`
+	ConnsDropped     *nxos.Number `json:"connsdropped"`
+	AddressFamilies  struct {
+		Rows nxos.Rows `json:"ROW_af"`
+	} `json:"TABLE_af"`
+}
+
+type nxosAddressFamily struct {
+	SubAddressFamilies struct {
+		Rows nxos.Rows `json:"ROW_saf"`
+	} `json:"TABLE_saf"`
+}
+
+type nxosSubAddressFamily struct {
+	Name          string       `json:"af-name"`
+	AcceptedPaths *nxos.Number `json:"acceptedpaths"`
+}
+
+// Neighbors returns the neighbors contained in the JSON output.
+func (n *NXOSNeighbors) Neighbors() ([]*Neighbor, error) {
+	var rows []nxosNeighbor
+	if err := n.Table.Rows.Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	neighbors := make([]*Neighbor, 0, len(rows))
+	for _, row := range rows {
+		neighbor := NewNeighbor()
+		neighbor.RemoteIP = row.Neighbor
+		neighbor.RemoteAS = string(row.RemoteAS)
+		neighbor.Description = row.Description
+		neighbor.BGPVersion = row.Version.Value()
+		neighbor.State = row.State
+		if strings.Contains(row.State, "(Admin)") {
+			neighbor.AdminShutdown = 1
+		}
+		neighbor.HoldTime = row.HoldTime.Value()
+		neighbor.KeepaliveInterval = row.KeepaliveTime.Value()
+		neighbor.OpensSent = row.OpensSent.Value()
+		neighbor.OpensRcvd = row.OpensRcvd.Value()
+		neighbor.NotificationsSent = row.NotificationSent.Value()
+		neighbor.NotificationsRcvd = row.NotificationRcvd.Value()
+		neighbor.UpdatesSent = row.UpdatesSent.Value()
+		neighbor.UpdatesRcvd = row.UpdatesRcvd.Value()
+		neighbor.KeepalivesSent = row.KeepalivesSent.Value()
+		neighbor.KeepalivesRcvd = row.KeepalivesRcvd.Value()
+		neighbor.RouteRefreshsSent = row.RefreshsSent.Value()
+		neighbor.RouteRefreshsRcvd = row.RefreshsRcvd.Value()
+		neighbor.ConnectionsEstablished = row.ConnsEstablished.Value()
+		neighbor.ConnectionsDropped = row.ConnsDropped.Value()
+		neighbor.Uptime = parseNXOSElapsedTime(row.ElapsedTime).Seconds()
+
+		var addressFamilies []nxosAddressFamily
+		if err := row.AddressFamilies.Rows.Decode(&addressFamilies); err != nil {
+			return nil, err
+		}
+		for _, addressFamily := range addressFamilies {
+			var subAddressFamilies []nxosSubAddressFamily
+			if err := addressFamily.SubAddressFamilies.Rows.Decode(&subAddressFamilies); err != nil {
+				return nil, err
+			}
+			for _, subAddressFamily := range subAddressFamilies {
+				if subAddressFamily.Name != "" && subAddressFamily.AcceptedPaths.Valid() {
+					neighbor.PrefixesCurrentRcvd[subAddressFamily.Name] = subAddressFamily.AcceptedPaths.Value()
+				}
+			}
+		}
+		neighbors = append(neighbors, neighbor)
+	}
+	return neighbors, nil
+}
+
+// parseNXOSElapsedTime parses the session uptime, which is either an ISO 8601 duration like `P1DT2H3M4S`
+// or printed like on the CLI, e.g. `00:12:34` or `1w2d`.
+func parseNXOSElapsedTime(elapsed string) time.Duration {
+	if !strings.HasPrefix(elapsed, "P") {
+		return parseXRUptime(elapsed)
+	}
+
+	units := map[string]time.Duration{"D": 24 * time.Hour, "H": time.Hour, "M": time.Minute, "S": time.Second}
+	var d time.Duration
+	for _, field := range nxosElapsedTimeFieldRegexp.FindAllStringSubmatch(elapsed, -1) {
+		d += time.Duration(util.Str2float64(field[1])) * units[field[2]]
+	}
+	return d
+}
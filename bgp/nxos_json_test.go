@@ -0,0 +1,101 @@
+package bgp_test
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/bgp"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+)
+
+const nxosJSON = `{
+  "TABLE_neighbor": {
+    "ROW_neighbor": [
+      {
+        "neighbor": "192.0.2.1",
+        "version": 4,
+        "remoteas": 65001,
+        "description": "transit-a",
+        "state": "Established",
+        "elapsedtime": "P1DT2H3M4S",
+        "holdtime": "180",
+        "keepalivetime": "60",
+        "connsestablished": 2,
+        "connsdropped": 1,
+        "opensent": 2,
+        "openrecvd": 2,
+        "notificationsent": 1,
+        "notificationrcvd": 0,
+        "updatesent": 120,
+        "updaterecvd": 4711,
+        "keepalivesent": 1500,
+        "keepaliverecvd": 1499,
+        "rtrefreshsent": 0,
+        "rtrefreshrecvd": 3,
+        "TABLE_af": {
+          "ROW_af": {
+            "af-afi": 1,
+            "TABLE_saf": {
+              "ROW_saf": {"af-safi": 1, "af-name": "IPv4 Unicast", "acceptedpaths": 812345}
+            }
+          }
+        }
+      },
+      {
+        "neighbor": "192.0.2.5",
+        "version": 4,
+        "remoteas": "65002",
+        "state": "Shut (Admin)",
+        "elapsedtime": "1w2d",
+        "holdtime": "180",
+        "keepalivetime": "60"
+      }
+    ]
+  }
+}`
+
+func TestParseNXOSJSON(t *testing.T) {
+	first := bgp.NewNeighbor()
+	first.RemoteIP = "192.0.2.1"
+	first.RemoteAS = "65001"
+	first.Description = "transit-a"
+	first.BGPVersion = 4
+	first.State = "Established"
+	first.HoldTime = 180
+	first.KeepaliveInterval = 60
+	first.ConnectionsEstablished = 2
+	first.ConnectionsDropped = 1
+	first.OpensSent = 2
+	first.OpensRcvd = 2
+	first.NotificationsSent = 1
+	first.UpdatesSent = 120
+	first.UpdatesRcvd = 4711
+	first.KeepalivesSent = 1500
+	first.KeepalivesRcvd = 1499
+	first.RouteRefreshsRcvd = 3
+	first.Uptime = 26*3600 + 3*60 + 4
+	first.PrefixesCurrentRcvd["IPv4 Unicast"] = 812345
+
+	second := bgp.NewNeighbor()
+	second.RemoteIP = "192.0.2.5"
+	second.RemoteAS = "65002"
+	second.BGPVersion = 4
+	second.State = "Shut (Admin)"
+	second.AdminShutdown = 1
+	second.HoldTime = 180
+	second.KeepaliveInterval = 60
+	second.Uptime = 9 * 24 * 3600
+
+	data := &bgp.NXOSNeighbors{}
+	if err := nxos.Decode(nxosJSON, data); err != nil {
+		t.Fatal(err)
+	}
+	neighbors, err := data.Neighbors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*bgp.Neighbor{first, second}
+	if !reflect.DeepEqual(neighbors, expected) {
+		t.Errorf("Got unexpected neighbors, expected %+v, got %+v", expected, neighbors)
+	}
+}
@@ -4,6 +4,19 @@ import (
 	"gitlab.com/wobcom/cisco-exporter/connector"
 	"gitlab.com/wobcom/cisco-exporter/util"
 	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	xrUptimeRegexp      = regexp.MustCompile(`^(\d+):(\d+):(\d+)$`)
+	xrUptimeFieldRegexp = regexp.MustCompile(`(\d+)([ywdh])`)
+	xrUptimeUnits       = map[string]time.Duration{
+		"y": 365 * 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+		"d": 24 * time.Hour,
+		"h": time.Hour,
+	}
 )
 
 // Parse parses cli output and tries to find interfaces with related stats
@@ -13,21 +26,27 @@ func Parse(sshCtx *connector.SSHCommandContext, neighbors chan *Neighbor, done c
 	}()
 	newNeighborRegexp := regexp.MustCompile(`^BGP neighbor is `)
 	neighborRegexp := regexp.MustCompile(`^BGP neighbor is (.*),\s+remote AS (\d+)`)
+	xrNeighborRegexp := regexp.MustCompile(`^BGP neighbor is (\S+)\s*$`)
+	xrRemoteASRegexp := regexp.MustCompile(`^ Remote AS (\d+),`)
 	descriptionRegexp := regexp.MustCompile(`^ Description: (.*)$`)
 	bgpVersionRegexp := regexp.MustCompile(`^  BGP version (\d+\.?\d?),`)
-	bgpStateRegexp := regexp.MustCompile(`^  BGP state = (\S*),`)
+	bgpStateRegexp := regexp.MustCompile(`^  BGP state = ([^\s,]*)(?:, up for (\S+))?`)
 	bgpAdminShutdownRegexp := regexp.MustCompile(`^\s+Administratively shut down`)
-	timersRegexp := regexp.MustCompile(`hold time is (\d+), keepalive interval is (\d+)`)
+	timersRegexp := regexp.MustCompile(`(?i)hold time is (\d+), keepalive interval is (\d+)`)
 	opensRegexp := regexp.MustCompile(`^    Opens:\s+(\d+)\s+(\d+)`)
 	notificationsRegexp := regexp.MustCompile(`^    Notifications:\s+(\d+)\s+(\d+)`)
 	updatesRegexp := regexp.MustCompile(`^    Updates:\s+(\d+)\s+(\d+)`)
 	keepalivesRegexp := regexp.MustCompile(`^    Keepalives:\s+(\d+)\s+(\d+)`)
+	xrMessagesReceivedRegexp := regexp.MustCompile(`^\s+Received \d+ messages, (\d+) notifications`)
+	xrMessagesSentRegexp := regexp.MustCompile(`^\s+Sent \d+ messages, (\d+) notifications`)
 	routeRefreshsRegexp := regexp.MustCompile(`^    Route Refresh:\s+(\d+)\s+(\d+)`)
-	addressFamilyRegexp := regexp.MustCompile(`^ For address family: (.*)`)
+	addressFamilyRegexp := regexp.MustCompile(`(?i)^ For address family: (.*)`)
 	prefixesCurrentRegexp := regexp.MustCompile(`^\s+Prefixes Current:\s+(\d+)\s+(\d+)\s+\(Consumes (\d+)`)
 	prefixesTotalRegexp := regexp.MustCompile(`^\s+Prefixes Total:\s+(\d+)\s+(\d+)`)
 	implicitWithdrawRegexp := regexp.MustCompile(`^\s+Implicit Withdraw:\s+(\d+)\s+(\d+)`)
 	explicitWithdrawRegexp := regexp.MustCompile(`^\s+Explicit Withdraw:\s+(\d+)\s+(\d+)`)
+	xrAcceptedPrefixesRegexp := regexp.MustCompile(`^\s+(\d+) accepted prefixes, (\d+) are bestpaths`)
+	xrAdvertisedPrefixesRegexp := regexp.MustCompile(`^\s+Prefix advertised (\d+), suppressed \d+, withdrawn (\d+)`)
 	bestpathRegexp := regexp.MustCompile(`^\s+Used as bestpath:.*?(\d+)`)
 	multipathRegexp := regexp.MustCompile(`^\s+Used as multipath:.*?(\d+)`)
 	secondaryRegexp := regexp.MustCompile(`^\s+Used as secondary:.*?(\d+)`)
@@ -49,13 +68,15 @@ func Parse(sshCtx *connector.SSHCommandContext, neighbors chan *Neighbor, done c
 				if current.RemoteAS != "" {
 					neighbors <- current
 				}
-				matches := neighborRegexp.FindStringSubmatch(line)
-				if matches == nil {
-					continue
-				}
 				current = NewNeighbor()
-				current.RemoteIP = matches[1]
-				current.RemoteAS = matches[2]
+				if matches := neighborRegexp.FindStringSubmatch(line); matches != nil {
+					current.RemoteIP = matches[1]
+					current.RemoteAS = matches[2]
+				} else if matches := xrNeighborRegexp.FindStringSubmatch(line); matches != nil {
+					// IOS XR prints the remote AS on the following line.
+					current.RemoteIP = matches[1]
+				}
+				continue
 			}
 			if current.RemoteIP == "" {
 				continue
@@ -65,12 +86,17 @@ func Parse(sshCtx *connector.SSHCommandContext, neighbors chan *Neighbor, done c
 				current.AdminShutdown = 1
 			}
 
-			if matches := descriptionRegexp.FindStringSubmatch(line); matches != nil {
+			if matches := xrRemoteASRegexp.FindStringSubmatch(line); matches != nil {
+				current.RemoteAS = matches[1]
+			} else if matches := descriptionRegexp.FindStringSubmatch(line); matches != nil {
 				current.Description = matches[1]
 			} else if matches := bgpVersionRegexp.FindStringSubmatch(line); matches != nil {
 				current.BGPVersion = util.Str2float64(matches[1])
 			} else if matches := bgpStateRegexp.FindStringSubmatch(line); matches != nil {
 				current.State = matches[1]
+				if matches[2] != "" {
+					current.Uptime = parseXRUptime(matches[2]).Seconds()
+				}
 			} else if matches := timersRegexp.FindStringSubmatch(line); matches != nil {
 				current.HoldTime = util.Str2float64(matches[1])
 				current.KeepaliveInterval = util.Str2float64(matches[2])
@@ -86,6 +112,10 @@ func Parse(sshCtx *connector.SSHCommandContext, neighbors chan *Neighbor, done c
 			} else if matches := keepalivesRegexp.FindStringSubmatch(line); matches != nil {
 				current.KeepalivesSent = util.Str2float64(matches[1])
 				current.KeepalivesRcvd = util.Str2float64(matches[2])
+			} else if matches := xrMessagesReceivedRegexp.FindStringSubmatch(line); matches != nil {
+				current.NotificationsRcvd = util.Str2float64(matches[1])
+			} else if matches := xrMessagesSentRegexp.FindStringSubmatch(line); matches != nil {
+				current.NotificationsSent = util.Str2float64(matches[1])
 			} else if matches := routeRefreshsRegexp.FindStringSubmatch(line); matches != nil {
 				current.RouteRefreshsSent = util.Str2float64(matches[1])
 				current.RouteRefreshsRcvd = util.Str2float64(matches[2])
@@ -104,6 +134,12 @@ func Parse(sshCtx *connector.SSHCommandContext, neighbors chan *Neighbor, done c
 			} else if matches := explicitWithdrawRegexp.FindStringSubmatch(line); matches != nil {
 				current.ExplicitWithdrawSent[currentAddressFamily] = util.Str2float64(matches[1])
 				current.ExplicitWithdrawRcvd[currentAddressFamily] = util.Str2float64(matches[2])
+			} else if matches := xrAcceptedPrefixesRegexp.FindStringSubmatch(line); matches != nil {
+				current.PrefixesCurrentRcvd[currentAddressFamily] = util.Str2float64(matches[1])
+				current.UsedAsBestpath[currentAddressFamily] = util.Str2float64(matches[2])
+			} else if matches := xrAdvertisedPrefixesRegexp.FindStringSubmatch(line); matches != nil {
+				current.PrefixesTotalSent[currentAddressFamily] = util.Str2float64(matches[1])
+				current.ExplicitWithdrawSent[currentAddressFamily] = util.Str2float64(matches[2])
 			} else if matches := bestpathRegexp.FindStringSubmatch(line); matches != nil {
 				current.UsedAsBestpath[currentAddressFamily] = util.Str2float64(matches[1])
 			} else if matches := multipathRegexp.FindStringSubmatch(line); matches != nil {
@@ -119,3 +155,17 @@ func Parse(sshCtx *connector.SSHCommandContext, neighbors chan *Neighbor, done c
 		}
 	}
 }
+
+// parseXRUptime parses the session uptime as printed by IOS XR and IOS, e.g. `00:12:34`, `1d02h` or `2w3d`.
+func parseXRUptime(uptime string) time.Duration {
+	if matches := xrUptimeRegexp.FindStringSubmatch(uptime); matches != nil {
+		return time.Duration(util.Str2float64(matches[1]))*time.Hour +
+			time.Duration(util.Str2float64(matches[2]))*time.Minute +
+			time.Duration(util.Str2float64(matches[3]))*time.Second
+	}
+	var d time.Duration
+	for _, field := range xrUptimeFieldRegexp.FindAllStringSubmatch(strings.ToLower(uptime), -1) {
+		d += time.Duration(util.Str2float64(field[1])) * xrUptimeUnits[field[2]]
+	}
+	return d
+}
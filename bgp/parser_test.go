@@ -7,8 +7,58 @@ import (
 
 	"gitlab.com/wobcom/cisco-exporter/bgp"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
 )
 
+const xrInput = `
+BGP neighbor is 192.0.2.1
+ Remote AS 65001, local AS 65000, external link
+ Description: transit-a
+ Remote router ID 192.0.2.1
+  BGP state = Established, up for 2w3d
+  NSR State: None
+  Last read 00:00:12, Last read before reset 00:00:00
+  Hold time is 180, keepalive interval is 60 seconds
+  Configured hold time: 180, keepalive: 60, min acceptable hold time: 3
+  Last write 00:00:12, attempted 19, written 19
+  Received 123456 messages, 0 notifications, 0 in queue
+  Sent 23456 messages, 1 notifications, 0 in queue
+  Minimum time between advertisement runs is 30 secs
+
+ For Address Family: IPv4 Unicast
+  BGP neighbor version 1234
+  Update group: 0.2 Filter-group: 0.1  No Refresh request being processed
+  Route refresh request: received 0, sent 0
+  Policy for incoming advertisements is TRANSIT-IN
+  Policy for outgoing advertisements is TRANSIT-OUT
+  812345 accepted prefixes, 812000 are bestpaths
+  Exact no. of prefixes denied : 0.
+  Cumulative no. of prefixes denied: 0.
+  Prefix advertised 12, suppressed 0, withdrawn 3
+  Maximum prefixes allowed 1000000
+  Threshold for warning message 75%, restart interval 0 min
+
+  Connections established 3; dropped 2
+  Local host: 192.0.2.2, Local port: 179, IF Handle: 0x00000000
+  Foreign host: 192.0.2.1, Foreign port: 43211
+  Last reset 2w3d, due to BGP Notification sent: hold time expired
+  External BGP neighbor may be up to 1 hops away.
+
+BGP neighbor is 192.0.2.5
+ Remote AS 65002, local AS 65000, external link
+ Remote router ID 0.0.0.0
+  BGP state = Idle (Neighbor is shutdown)
+  Hold time is 180, keepalive interval is 60 seconds
+  Received 0 messages, 0 notifications, 0 in queue
+  Sent 0 messages, 0 notifications, 0 in queue
+
+ For Address Family: IPv4 Unicast
+  0 accepted prefixes, 0 are bestpaths
+  Prefix advertised 0, suppressed 0, withdrawn 0
+
+  Connections established 0; dropped 0
+`
+
 func inputContext() connector.SSHCommandContext {
 	const input = `
 BGP neighbor is 1.2.3.4,  remote AS 9136, internal link
@@ -191,10 +241,46 @@ func TestParse(t *testing.T) {
 	}
 
 	ctx := inputContext()
+	performTest(&ctx, neighbors, t)
+}
+
+func TestParseXR(t *testing.T) {
+	first := bgp.NewNeighbor()
+	first.RemoteAS = "65001"
+	first.RemoteIP = "192.0.2.1"
+	first.Description = "transit-a"
+	first.State = "Established"
+	first.HoldTime = 180
+	first.KeepaliveInterval = 60
+	first.NotificationsSent = 1
+	first.PrefixesCurrentRcvd["IPv4 Unicast"] = 812345
+	first.UsedAsBestpath["IPv4 Unicast"] = 812000
+	first.PrefixesTotalSent["IPv4 Unicast"] = 12
+	first.ExplicitWithdrawSent["IPv4 Unicast"] = 3
+	first.ConnectionsEstablished = 3
+	first.ConnectionsDropped = 2
+	first.Uptime = 17 * 24 * 60 * 60
+
+	second := bgp.NewNeighbor()
+	second.RemoteAS = "65002"
+	second.RemoteIP = "192.0.2.5"
+	second.State = "Idle"
+	second.HoldTime = 180
+	second.KeepaliveInterval = 60
+	second.PrefixesCurrentRcvd["IPv4 Unicast"] = 0
+	second.UsedAsBestpath["IPv4 Unicast"] = 0
+	second.PrefixesTotalSent["IPv4 Unicast"] = 0
+	second.ExplicitWithdrawSent["IPv4 Unicast"] = 0
+
+	ctx := util.PrepareOutputForTesting(xrInput)
+	performTest(&ctx, []bgp.Neighbor{*first, *second}, t)
+}
+
+func performTest(ctx *connector.SSHCommandContext, neighbors []bgp.Neighbor, t *testing.T) {
 	neighborsChan := make(chan *bgp.Neighbor)
 	done := make(chan struct{})
 
-	go bgp.Parse(&ctx, neighborsChan, done)
+	go bgp.Parse(ctx, neighborsChan, done)
 
 	at := 0
 	for {
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"fmt"
+
 	"gitlab.com/wobcom/cisco-exporter/local_pools"
 	"sync"
 	"time"
@@ -9,9 +12,11 @@ import (
 	"gitlab.com/wobcom/cisco-exporter/bgp"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/config"
+	"gitlab.com/wobcom/cisco-exporter/connections"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 	"gitlab.com/wobcom/cisco-exporter/cpu"
 	"gitlab.com/wobcom/cisco-exporter/environment"
+	"gitlab.com/wobcom/cisco-exporter/failover"
 	"gitlab.com/wobcom/cisco-exporter/interfaces"
 	"gitlab.com/wobcom/cisco-exporter/memory"
 	"gitlab.com/wobcom/cisco-exporter/mpls"
@@ -19,9 +24,13 @@ import (
 	"gitlab.com/wobcom/cisco-exporter/optics-ios"
 	"gitlab.com/wobcom/cisco-exporter/optics-nxos"
 	"gitlab.com/wobcom/cisco-exporter/optics-xe"
+	"gitlab.com/wobcom/cisco-exporter/optics-xr"
 	"gitlab.com/wobcom/cisco-exporter/pppoe"
+	"gitlab.com/wobcom/cisco-exporter/resources"
 	"gitlab.com/wobcom/cisco-exporter/users"
 	"gitlab.com/wobcom/cisco-exporter/vlans"
+	"gitlab.com/wobcom/cisco-exporter/vpnsessions"
+	"gitlab.com/wobcom/cisco-exporter/xlate"
 
 	"github.com/pkg/errors"
 
@@ -33,32 +42,47 @@ const prefix = "cisco_"
 
 var (
 	upDesc                      *prometheus.Desc
+	downReasonDesc              *prometheus.Desc
 	versionDesc                 *prometheus.Desc
+	bootTimeDesc                *prometheus.Desc
+	privilegeLevelDesc          *prometheus.Desc
 	errorsDesc                  *prometheus.Desc
 	retryCountDesc              *prometheus.Desc
 	scrapeCollectorDurationDesc *prometheus.Desc
 	scrapeDurationDesc          *prometheus.Desc
+	lastSuccessDesc             *prometheus.Desc
 )
 
 func init() {
 	upDesc = prometheus.NewDesc(prefix+"up", "Scrape of target was successful", []string{"target"}, nil)
-	versionDesc = prometheus.NewDesc(prefix+"version_info", "Information about the running operating system", []string{"target", "os_name"}, nil)
+	downReasonDesc = prometheus.NewDesc(prefix+"down_reason_info", "Reason why the connection to the target could not be established, exported as label", []string{"target", "reason"}, nil)
+	versionDesc = prometheus.NewDesc(prefix+"version_info", "Information about the running operating system and hardware", []string{"target", "os_name", "version", "platform", "serial"}, nil)
+	bootTimeDesc = prometheus.NewDesc(prefix+"boot_time_seconds", "Time the target booted, derived from its uptime", []string{"target"}, nil)
+	privilegeLevelDesc = prometheus.NewDesc(prefix+"privilege_level", "Privilege level of the SSH session on the target", []string{"target"}, nil)
 	retryCountDesc = prometheus.NewDesc(prefix+"retry_total", "Counts the retries of a collector", []string{"target", "collector"}, nil)
 	errorsDesc = prometheus.NewDesc(prefix+"collector_errors", "Error counter of a scrape by collector and target", []string{"target", "collector"}, nil)
 	scrapeDurationDesc = prometheus.NewDesc(prefix+"collector_duration_seconds", "Duration of a collector scrape for one target", []string{"target"}, nil)
 	scrapeCollectorDurationDesc = prometheus.NewDesc(prefix+"collect_duration_seconds", "Duration of a scrape by collector and target", []string{"target", "collector"}, nil)
+	lastSuccessDesc = prometheus.NewDesc(prefix+"collector_last_success_timestamp_seconds", "Time of the last successful background poll by collector and target", []string{"target", "collector"}, nil)
 }
 
 // CiscoCollector bundles all available Collectors and runs them against multiple devices
 type CiscoCollector struct {
+	ctx                 context.Context
+	configuration       *config.Config
 	devices             []string
 	deviceGroups        []*config.DeviceGroupConfig
 	connectionManager   *connector.SSHConnectionManager
+	poller              *Poller
+	selection           *collectorSelection
 	collectors          map[string]collector.Collector
 	collectorsForDevice map[string][]collector.Collector
 }
 
-func newCiscoCollector(targets []string, connectionManager *connector.SSHConnectionManager) *CiscoCollector {
+// newCiscoCollector returns a CiscoCollector for the given targets. Scrapes are aborted once ctx is done.
+// Targets of device groups with polling enabled are answered from the poller's cache, if a poller is given.
+// If selection is not nil, only the selected collectors are run, using the options of the selected module.
+func newCiscoCollector(ctx context.Context, targets []string, connectionManager *connector.SSHConnectionManager, poller *Poller, selection *collectorSelection) *CiscoCollector {
 	collectors := make(map[string]collector.Collector)
 	collectorsForDevice := make(map[string][]collector.Collector)
 
@@ -71,12 +95,18 @@ func newCiscoCollector(targets []string, connectionManager *connector.SSHConnect
 	opticsIOSCollector := opticsios.NewCollector()
 	opticsXECollector := opticsxe.NewCollector()
 	opticsNXOSCollector := opticsnxos.NewCollector()
+	opticsXRCollector := opticsxr.NewCollector()
 	aaaCollector := aaa.NewCollector()
 	usersCollector := users.NewCollector()
 	pppoeCollector := pppoe.NewCollector()
 	mplsCollector := mpls.NewCollector()
 	natCollector := nat.NewCollector()
 	poolCollector := local_pools.NewCollector()
+	connectionsCollector := connections.NewCollector()
+	failoverCollector := failover.NewCollector()
+	vpnSessionsCollector := vpnsessions.NewCollector()
+	resourcesCollector := resources.NewCollector()
+	xlateCollector := xlate.NewCollector()
 
 	collectors[memoryCollector.Name()] = memoryCollector
 	collectors[cpuCollector.Name()] = cpuCollector
@@ -90,52 +120,97 @@ func newCiscoCollector(targets []string, connectionManager *connector.SSHConnect
 	collectors[mplsCollector.Name()] = mplsCollector
 	collectors[natCollector.Name()] = natCollector
 	collectors[poolCollector.Name()] = poolCollector
+	collectors[connectionsCollector.Name()] = connectionsCollector
+	collectors[failoverCollector.Name()] = failoverCollector
+	collectors[vpnSessionsCollector.Name()] = vpnSessionsCollector
+	collectors[resourcesCollector.Name()] = resourcesCollector
+	collectors[xlateCollector.Name()] = xlateCollector
+	collectors["optics"] = &opticsCollector{
+		collectors: map[config.OSVersion]collector.Collector{
+			config.NXOS:  opticsNXOSCollector,
+			config.IOS:   opticsIOSCollector,
+			config.IOSXE: opticsXECollector,
+			config.IOSXR: opticsXRCollector,
+		},
+	}
 
+	configuration := getConfiguration()
 	for _, target := range targets {
 
 		deviceGroup := configuration.GetDeviceGroup(target)
 
 		for _, collectorName := range deviceGroup.EnabledCollectors {
+			if !selection.allows(collectorName) {
+				continue
+			}
 			collector, found := collectors[collectorName]
 			if !found {
-				if collectorName == "optics" {
-					switch deviceGroup.OSVersion {
-					case config.NXOS:
-						collector = opticsNXOSCollector
-					case config.IOS:
-						collector = opticsIOSCollector
-					case config.IOSXE:
-						collector = opticsXECollector
-					}
-				} else {
-					log.Errorf("Configured collector '%s' for device '%s'. No such collector", collectorName, target)
-					continue
-				}
-			}
-			if collector != nil {
-				collectorsForDevice[target] = append(collectorsForDevice[target], collector)
+				log.Errorf("Configured collector '%s' for device '%s'. No such collector", collectorName, target)
+				continue
 			}
+			collectorsForDevice[target] = append(collectorsForDevice[target], collector)
 		}
 	}
 
 	return &CiscoCollector{
+		ctx:                 ctx,
+		configuration:       configuration,
 		devices:             targets,
 		connectionManager:   connectionManager,
+		poller:              poller,
+		selection:           selection,
 		collectors:          collectors,
 		collectorsForDevice: collectorsForDevice,
 	}
 }
 
+// collectorNames returns the names of all collectors which can be enabled in the configuration.
+func collectorNames() []string {
+	names := []string{}
+	for name := range newCiscoCollector(context.Background(), nil, nil, nil, nil).collectors {
+		names = append(names, name)
+	}
+	return names
+}
+
+// opticsCollector runs the optics collector matching the OS of the connected device.
+type opticsCollector struct {
+	collectors map[config.OSVersion]collector.Collector
+}
+
+func (c *opticsCollector) Name() string {
+	return "optics"
+}
+
+// Describe sends no descriptors, as the optics collectors of the different OSes share metric names with different labels.
+func (c *opticsCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (c *opticsCollector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	osVersion := collectCtx.Connection.Info().DeviceInfo.OSVersion
+	specificCollector, found := c.collectors[osVersion]
+	if !found {
+		result := collector.NewResult()
+		result.AddError(fmt.Errorf("No optics collector available for OS '%s'", osVersion))
+		return result
+	}
+	return specificCollector.Collect(ctx, collectCtx)
+}
+
 // Describe sends the super-set of all possible descriptors of metrics
 // collected by this Collector to the provided channel and returns once
 // the last descriptor has been sent.
 func (c *CiscoCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- upDesc
+	ch <- downReasonDesc
 	ch <- versionDesc
+	ch <- bootTimeDesc
+	ch <- privilegeLevelDesc
 	ch <- retryCountDesc
 	ch <- errorsDesc
 	ch <- scrapeDurationDesc
 	ch <- scrapeCollectorDurationDesc
+	ch <- lastSuccessDesc
 
 	for _, col := range c.collectors {
 		col.Describe(ch)
@@ -144,19 +219,26 @@ func (c *CiscoCollector) Describe(ch chan<- *prometheus.Desc) {
 
 // Collect provides all the metrics from all devices to the chanell
 func (c *CiscoCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(c.ctx, *scrapeTimeout)
+	defer cancel()
+
 	wg := &sync.WaitGroup{}
 
 	wg.Add(len(c.devices))
 
 	for _, target := range c.devices {
-		dg := configuration.GetDeviceGroup(target)
-		go c.collectForDevice(target, dg, ch, wg)
+		dg := c.configuration.GetDeviceGroup(target)
+		if c.poller != nil && dg.Polling.Enabled() {
+			go c.poller.collectForDevice(target, dg, c.selection, ch, wg)
+			continue
+		}
+		go c.collectForDevice(ctx, target, dg, ch, wg)
 	}
 
 	wg.Wait()
 }
 
-func (c *CiscoCollector) createCollectContext(target string, deviceGroupConfig *config.DeviceGroupConfig, ch chan<- prometheus.Metric) (*collector.CollectContext, error) {
+func (c *CiscoCollector) createCollectContext(target string, deviceGroupConfig *config.DeviceGroupConfig) (*collector.CollectContext, error) {
 	connection, err := c.connectionManager.GetConnection(target, deviceGroupConfig)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not get connection for device %s: %v", target, err)
@@ -165,69 +247,186 @@ func (c *CiscoCollector) createCollectContext(target string, deviceGroupConfig *
 	return &collector.CollectContext{
 		Connection:  connection,
 		LabelValues: []string{target},
-		Metrics:     ch,
-		Errors:      make(chan error),
-		Done:        make(chan struct{}),
+		Module:      c.selection.getModule(),
 	}, nil
 }
 
-func runCollector(collector collector.Collector, collectorContext *collector.CollectContext) []error {
-	errs := make([]error, 0)
+// runCollector runs the collector and returns its result.
+// If ctx is done before the collector returns, the collector is abandoned and the result only holds the reason.
+func runCollector(ctx context.Context, specificCollector collector.Collector, collectorContext *collector.CollectContext) *collector.Result {
+	ctx = connector.WithCollector(ctx, specificCollector.Name())
+	results := make(chan *collector.Result, 1)
+	go func() {
+		results <- specificCollector.Collect(ctx, collectorContext)
+	}()
+
+	var result *collector.Result
+	select {
+	case result = <-results:
+	case <-ctx.Done():
+		result = collector.NewResult()
+		result.AddError(errors.Wrapf(ctx.Err(), "Collector aborted"))
+	}
+
+	for _, err := range result.Errors {
+		log.Errorf("Error while running collector %s on device %s: %v", specificCollector.Name(), collectorContext.Connection.Info().Target, err)
+	}
+	return result
+}
+
+// deviceState tracks the state of the connection to a target while running its collectors.
+type deviceState struct {
+	up             float64
+	downReason     string
+	privilegeLevel int
+	// info is nil until connected to the target.
+	info *connector.DeviceInfo
+}
+
+func newDeviceState() *deviceState {
+	return &deviceState{
+		up:             1,
+		privilegeLevel: -1,
+	}
+}
+
+// merge applies what a collector run observed in other. A run which did not try to connect, e.g. because the scrape
+// was aborted, leaves the state unchanged. It returns whether other observed anything.
+func (s *deviceState) merge(other *deviceState) bool {
+	if other.up == 0 {
+		s.up = 0
+		s.downReason = other.downReason
+		return true
+	}
+	if other.info == nil {
+		return false
+	}
+	s.up = 1
+	s.downReason = ""
+	s.privilegeLevel = other.privilegeLevel
+	s.info = other.info
+	return true
+}
 
-	go collector.Collect(collectorContext)
+func (s *deviceState) collect(ch chan<- prometheus.Metric, target string) {
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, s.up, target)
+	if s.up == 0 {
+		ch <- prometheus.MustNewConstMetric(downReasonDesc, prometheus.GaugeValue, 1, target, s.downReason)
+	} else if s.privilegeLevel >= 0 {
+		ch <- prometheus.MustNewConstMetric(privilegeLevelDesc, prometheus.GaugeValue, float64(s.privilegeLevel), target)
+	}
+	if s.info == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(versionDesc, prometheus.GaugeValue, 1, target, s.info.OSVersion.String(), s.info.Version, s.info.Platform, s.info.Serial)
+	if bootTime := s.info.BootTime(); !bootTime.IsZero() {
+		ch <- prometheus.MustNewConstMetric(bootTimeDesc, prometheus.GaugeValue, float64(bootTime.Unix()), target)
+	}
+}
+
+// collectorRun is the outcome of running a collector against a target, including retries.
+type collectorRun struct {
+	metrics  []prometheus.Metric
+	errors   float64
+	duration float64
+	success  bool
+	// lastError is the last error of the last attempt, nil if it succeeded.
+	lastError error
+}
+
+// runCollectorWithRetries runs the collector against the target, retrying once on errors.
+// Only the metrics of the last attempt are part of the outcome.
+func (c *CiscoCollector) runCollectorWithRetries(ctx context.Context, target string, deviceGroup *config.DeviceGroupConfig, specificCollector collector.Collector, state *deviceState) *collectorRun {
+	startTime := time.Now()
+	run := &collectorRun{}
 
-	for {
-		select {
-		case <-collectorContext.Done:
-			return errs
-		case err := <-collectorContext.Errors:
-			log.Errorf("Error while running collector %s on device %s: %v", collector.Name(), collectorContext.Connection.Target, err)
-			errs = append(errs, err)
+	for retryCount := 0; retryCount < 2 && !run.success; retryCount++ {
+		if ctx.Err() != nil {
+			log.Errorf("Ran into scrape timeout for device %s: %v", target, ctx.Err())
+			run.lastError = errors.Wrap(ctx.Err(), "Scrape aborted")
+			break
+		}
+
+		collectContext, err := c.createCollectContext(target, deviceGroup)
+		if err != nil {
+			state.up = 0
+			state.downReason = connector.FailureReason(err)
+			run.lastError = err
+			log.Errorf("Could not create CollectContext for device %s: %v", target, err)
 			continue
+		} else {
+			state.up = 1
+			state.privilegeLevel = collectContext.Connection.Info().PrivilegeLevel
+			state.info = &collectContext.Connection.Info().DeviceInfo
+		}
+
+		result := runCollector(ctx, specificCollector, collectContext)
+		run.metrics = result.Metrics
+		run.errors += float64(len(result.Errors))
+		run.success = len(result.Errors) == 0
+		run.lastError = nil
+		if !run.success {
+			run.lastError = result.Errors[len(result.Errors)-1]
 		}
 	}
+
+	run.duration = time.Since(startTime).Seconds()
+	targetStatuses.record(target, specificCollector.Name(), run)
+	return run
 }
 
-func (c *CiscoCollector) collectForDevice(target string, deviceGroup *config.DeviceGroupConfig, ch chan<- prometheus.Metric, wg *sync.WaitGroup) {
+func (c *CiscoCollector) collectForDevice(ctx context.Context, target string, deviceGroup *config.DeviceGroupConfig, ch chan<- prometheus.Metric, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	ciscoUp := 1.0
+	state := newDeviceState()
 	startTime := time.Now()
 
 	defer func() {
 		ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(startTime).Seconds(), target)
-		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, ciscoUp, target)
-		ch <- prometheus.MustNewConstMetric(versionDesc, prometheus.GaugeValue, 2, target, deviceGroup.OSVersion.String())
+		state.collect(ch, target)
 	}()
 
-	for _, specificCollector := range c.collectorsForDevice[target] {
-		startTimeCollector := time.Now()
-		totalCollectorErrors := 0.0
-		success := false
-
-		for retryCount := 0; retryCount < 2 && !success; retryCount++ {
-			if time.Since(startTime) > *scrapeTimeout {
-				log.Errorf("Ran into scrape timeout for device %s", target)
-				return
-			}
-
-			collectContext, err := c.createCollectContext(target, deviceGroup, ch)
-			if err != nil {
-				ciscoUp = 0
-				log.Errorf("Could not create CollectContext for device %s: %v", target, err)
-				continue
-			} else {
-				ciscoUp = 1
+	collectors := c.collectorsForDevice[target]
+	runs := make([]*collectorRun, len(collectors))
+	if deviceGroup.MaxSessions > 1 {
+		// Commands run in their own exec channels, the collectors do not have to wait for each other.
+		// The states seen by the collectors are merged in their order, like when running them one after another.
+		// Collectors which did not try to connect leave the state unchanged.
+		states := make([]*deviceState, len(collectors))
+		collectorsWg := sync.WaitGroup{}
+		for i := range collectors {
+			i := i
+			states[i] = newDeviceState()
+			collectorsWg.Add(1)
+			go func() {
+				defer collectorsWg.Done()
+				runs[i] = c.runCollectorWithRetries(ctx, target, deviceGroup, collectors[i], states[i])
+			}()
+		}
+		collectorsWg.Wait()
+		for _, s := range states {
+			state.merge(s)
+		}
+	} else {
+		for i, specificCollector := range collectors {
+			if ctx.Err() != nil {
+				log.Errorf("Ran into scrape timeout for device %s: %v", target, ctx.Err())
+				break
 			}
+			runs[i] = c.runCollectorWithRetries(ctx, target, deviceGroup, specificCollector, state)
+		}
+	}
 
-			errs := runCollector(specificCollector, collectContext)
-			totalCollectorErrors += float64(len(errs))
-			success = len(errs) == 0
+	for i, run := range runs {
+		if run == nil {
+			break
+		}
+		for _, metric := range run.metrics {
+			ch <- metric
 		}
 
-		labels := []string{target, specificCollector.Name()}
-		elapsedSeconds := time.Since(startTimeCollector).Seconds()
-		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.GaugeValue, totalCollectorErrors, labels...)
-		ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, elapsedSeconds, labels...)
+		labels := []string{target, collectors[i].Name()}
+		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.GaugeValue, run.errors, labels...)
+		ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, run.duration, labels...)
 	}
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var testCollectorDesc = prometheus.NewDesc("cisco_test_value", "Value reported by a test collector", []string{"target"}, nil)
+
+// testCollector runs `show version` and reports a metric, or err if it is set.
+type testCollector struct {
+	name string
+	err  error
+}
+
+func (c *testCollector) Name() string {
+	return c.name
+}
+
+func (c *testCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- testCollectorDesc
+}
+
+func (c *testCollector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+	sshCtx := connector.NewSSHCommandContext("show version")
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
+	for {
+		select {
+		case <-sshCtx.Output:
+		case err := <-sshCtx.Errors:
+			result.AddError(err)
+		case <-sshCtx.Done:
+			if c.err != nil {
+				result.AddError(c.err)
+				return result
+			}
+			result.AddMetric(prometheus.MustNewConstMetric(testCollectorDesc, prometheus.GaugeValue, 1, collectCtx.LabelValues...))
+			return result
+		}
+	}
+}
+
+// collectTarget runs the collectors against the target the way a scrape does and returns the metrics.
+func collectTarget(t *testing.T, c *CiscoCollector, target string) map[string]float64 {
+	ch := make(chan prometheus.Metric, 100)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	c.collectForDevice(context.Background(), target, c.configuration.GetDeviceGroup(target), ch, wg)
+	close(ch)
+	return util.PrepareMetricsForTesting(ch, t)
+}
+
+func TestDeviceStateMerge(t *testing.T) {
+	connected := &deviceState{up: 1, privilegeLevel: 15, info: &connector.DeviceInfo{Version: "12.2(55)SE12"}}
+	down := &deviceState{up: 0, downReason: connector.ReasonAuth, privilegeLevel: -1}
+
+	tests := []struct {
+		name     string
+		states   []*deviceState
+		expected deviceState
+	}{
+		{"aborted after down", []*deviceState{down, newDeviceState()}, *down},
+		{"aborted after connected", []*deviceState{connected, newDeviceState()}, *connected},
+		{"connected after down", []*deviceState{down, connected}, *connected},
+		{"down after connected", []*deviceState{connected, down}, deviceState{up: 0, downReason: connector.ReasonAuth, privilegeLevel: 15, info: connected.info}},
+		{"aborted only", []*deviceState{newDeviceState()}, *newDeviceState()},
+	}
+
+	for _, test := range tests {
+		state := newDeviceState()
+		for _, s := range test.states {
+			state.merge(s)
+		}
+		if *state != test.expected {
+			t.Errorf("%s: Expected %+v, got %+v", test.name, test.expected, *state)
+		}
+	}
+}
+
+func TestCollectForDeviceInParallel(t *testing.T) {
+	device := newTestDevice(t, nil)
+	loadTestConfiguration(t, `
+devices:
+  127.0.0.1:`+device.groupConfig()+`
+    max_sessions: 2
+    enabled_collectors: [cpu]
+`)
+	ciscoCollector := newCiscoCollector(context.Background(), []string{"127.0.0.1"}, connectionManager, nil, nil)
+	ciscoCollector.collectorsForDevice["127.0.0.1"] = []collector.Collector{
+		&testCollector{name: "succeeding"},
+		&testCollector{name: "failing", err: errors.New("No metrics")},
+	}
+
+	got := collectTarget(t, ciscoCollector, "127.0.0.1")
+	expected := map[string]float64{
+		"cisco_up{target=127.0.0.1}":                                                              1,
+		"cisco_privilege_level{target=127.0.0.1}":                                                 15,
+		"cisco_test_value{target=127.0.0.1}":                                                      1,
+		"cisco_collector_errors{collector=succeeding,target=127.0.0.1}":                           0,
+		"cisco_collector_errors{collector=failing,target=127.0.0.1}":                              2,
+		"cisco_version_info{os_name=ios,platform=,serial=,target=127.0.0.1,version=12.2(55)SE12}": 1,
+	}
+	util.CompareMetrics(got, expected, t)
+	for name := range got {
+		if strings.HasPrefix(name, "cisco_down_reason_info") {
+			t.Errorf("Expected no down reason, got %v", got)
+		}
+	}
+}
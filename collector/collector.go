@@ -1,6 +1,10 @@
 package collector
 
 import (
+	"context"
+	"sync"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -8,11 +12,99 @@ import (
 
 // CollectContext provides context passed as an argument to the specific collectors.
 type CollectContext struct {
-	Connection  *connector.SSHConnection
+	Connection  connector.Connection
 	LabelValues []string
-	Metrics     chan<- prometheus.Metric
-	Errors      chan error
-	Done        chan struct{}
+	// Module is the module selected by the scrape, its options take precedence over the ones of the device group.
+	Module *config.ModuleConfig
+	// Debug is set if the collector runs for the debug endpoint, it receives the values passed to Record.
+	Debug *Debug
+}
+
+// Debug holds the values parsed by a collector run for debugging.
+type Debug struct {
+	mu     sync.Mutex
+	parsed []interface{}
+}
+
+// Parsed returns the recorded values in the order they were recorded.
+func (d *Debug) Parsed() []interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]interface{}{}, d.parsed...)
+}
+
+// Record records a value parsed from the output of the remote device, e.g. a *bgp.Neighbor, if the collector runs for debugging.
+// It is safe to be called concurrently.
+func (c *CollectContext) Record(value interface{}) {
+	if c.Debug == nil {
+		return
+	}
+	c.Debug.mu.Lock()
+	defer c.Debug.mu.Unlock()
+	c.Debug.parsed = append(c.Debug.parsed, value)
+}
+
+// SecurityContexts returns the security contexts ASA specific commands are run in.
+// If none are configured, a single empty context refers to the current one.
+func (c *CollectContext) SecurityContexts() []string {
+	if c.Module != nil && len(c.Module.SecurityContexts) > 0 {
+		return c.Module.SecurityContexts
+	}
+	if c.Connection == nil || len(c.Connection.Info().Device().SecurityContexts) == 0 {
+		return []string{""}
+	}
+	return c.Connection.Info().Device().SecurityContexts
+}
+
+// Interfaces returns the interfaces to collect, all interfaces if none are configured.
+func (c *CollectContext) Interfaces() []string {
+	if c.Module != nil && len(c.Module.Interfaces) > 0 {
+		return c.Module.Interfaces
+	}
+	if c.Connection == nil {
+		return nil
+	}
+	return c.Connection.Info().Device().Interfaces
+}
+
+// EnabledVLANs returns the VLANs to collect, all VLANs if none are configured.
+func (c *CollectContext) EnabledVLANs() []string {
+	if c.Module != nil && len(c.Module.EnabledVLANs) > 0 {
+		return c.Module.EnabledVLANs
+	}
+	if c.Connection == nil {
+		return nil
+	}
+	return c.Connection.Info().Device().EnabledVLANs
+}
+
+// Result holds the metrics and errors gathered by a collector.
+type Result struct {
+	mu      sync.Mutex
+	Metrics []prometheus.Metric
+	Errors  []error
+}
+
+// NewResult returns an empty Result.
+func NewResult() *Result {
+	return &Result{
+		Metrics: make([]prometheus.Metric, 0),
+		Errors:  make([]error, 0),
+	}
+}
+
+// AddMetric adds a metric to the result. It is safe to be called concurrently.
+func (r *Result) AddMetric(metric prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Metrics = append(r.Metrics, metric)
+}
+
+// AddError adds an error to the result. It is safe to be called concurrently.
+func (r *Result) AddError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.Errors = append(r.Errors, err)
 }
 
 // Collector is an interface that each of the specific collector must implement.
@@ -24,8 +116,9 @@ type Collector interface {
 	// collected by this Collector to the provided channel and returns once
 	// the last descriptor has been sent.
 	Describe(ch chan<- *prometheus.Desc)
-	// Collect is called by the cisco_collector. The implementation sends
-	// errors and metrics to the corresponding channels in the CollectContext.
-	// The collector signals being done by writing an empty struct to the Done channel.
-	Collect(ctx *CollectContext)
+	// Collect is called by the cisco_collector. The implementation returns the
+	// metrics and errors gathered from the device in the CollectContext.
+	// Once ctx is done, commands still running are aborted and Collect returns
+	// what has been gathered so far.
+	Collect(ctx context.Context, collectCtx *CollectContext) *Result
 }
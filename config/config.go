@@ -1,20 +1,44 @@
 package config
 
 import (
-	"github.com/gobwas/glob"
+	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/secrets"
+
+	"github.com/gobwas/glob"
 
-	"gopkg.in/yaml.v2"
+	"gopkg.in/yaml.v3"
 )
 
 const defaultConnectTimeout int = 5
 const defaultCommandTimeout int = 20
 const defaultPort int = 22
+const defaultNETCONFPort int = 830
+const defaultTelnetPort int = 23
 
 // Config provides means of reading the configuration file
 type Config struct {
-	DeviceGroups map[string]*DeviceGroupConfig `yaml:"devices,omitempty"`
+	DeviceGroups map[string]*DeviceGroupConfig
+	Modules      map[string]*ModuleConfig
+	// groups holds the device groups in the order they are matched against a device.
+	groups []*DeviceGroupConfig
+}
+
+// rawConfig is the structure of the configuration file.
+// Device groups are kept as YAML nodes, so that their order and inheritance can be resolved.
+type rawConfig struct {
+	Devices   yaml.Node                `yaml:"devices"`
+	Templates yaml.Node                `yaml:"templates"`
+	Modules   map[string]*ModuleConfig `yaml:"modules"`
 }
 
 // OSVersion is a type to refere to the remote device's operating system.
@@ -30,11 +54,15 @@ const (
 	IOS OSVersion = 2
 	// NXOS The remote device is running NX OS.
 	NXOS OSVersion = 3
+	// IOSXR The remote device is running IOS XR.
+	IOSXR OSVersion = 4
+	// ASA The remote device is a Cisco ASA firewall.
+	ASA OSVersion = 5
 )
 
 // GetAllOsVersions returns all known and valid os version
 func GetAllOsVersions() []OSVersion {
-	return []OSVersion{IOSXE, IOS, NXOS}
+	return []OSVersion{IOSXE, IOS, NXOS, IOSXR, ASA}
 }
 
 // OSVersionToString converts OSVersion to a string
@@ -43,6 +71,8 @@ func (o OSVersion) String() string {
 		IOSXE: "ios-xe",
 		IOS:   "ios",
 		NXOS:  "nxos",
+		IOSXR: "ios-xr",
+		ASA:   "asa",
 	}
 	name, found := mapping[o]
 	if found {
@@ -51,33 +81,182 @@ func (o OSVersion) String() string {
 	return "unknown/invalid"
 }
 
+// UnmarshalYAML reads an OSVersion by its name, e.g. `ios-xe`. `auto` fingerprints the OS on connect.
+func (o *OSVersion) UnmarshalYAML(node *yaml.Node) error {
+	if node.Value == "auto" || node.Value == "" {
+		*o = INVALID
+		return nil
+	}
+	for _, version := range GetAllOsVersions() {
+		if node.Value == version.String() {
+			*o = version
+			return nil
+		}
+	}
+	return fmt.Errorf("line %d: unknown os_version '%s'", node.Line, node.Value)
+}
+
+// MarshalYAML returns the name of the OSVersion.
+func (o OSVersion) MarshalYAML() (interface{}, error) {
+	if o == INVALID {
+		return "auto", nil
+	}
+	return o.String(), nil
+}
+
+// Authentication methods which can be listed in auth_methods.
+const (
+	// AuthAgent authenticates using the keys and certificates held by an SSH agent.
+	AuthAgent string = "agent"
+	// AuthCertificate authenticates using an OpenSSH user certificate and its private key.
+	AuthCertificate string = "certificate"
+	// AuthKey authenticates using a (possibly encrypted) private key.
+	AuthKey string = "key"
+	// AuthKeyboardInteractive answers keyboard-interactive challenges with the configured password.
+	AuthKeyboardInteractive string = "keyboard_interactive"
+	// AuthPassword authenticates using the configured password.
+	AuthPassword string = "password"
+)
+
+// Transports which can be selected using transport.
+const (
+	// TransportSSH runs commands in an interactive SSH session.
+	TransportSSH string = "ssh"
+	// TransportNXAPI runs commands using NX-API on NX-OS devices.
+	TransportNXAPI string = "nxapi"
+	// TransportNETCONF retrieves operational data using NETCONF on IOS XE devices.
+	TransportNETCONF string = "netconf"
+	// TransportTelnet runs commands in an interactive Telnet session, for devices without usable SSH.
+	TransportTelnet string = "telnet"
+)
+
+const (
+	// HostKeyInsecure accepts any host key presented by the remote device.
+	HostKeyInsecure string = "insecure"
+	// HostKeyStrict only accepts host keys listed in the known_hosts file or pinned by their fingerprint.
+	HostKeyStrict string = "strict"
+	// HostKeyTrustOnFirstUse accepts and persists the host key of unknown devices, but rejects changed host keys.
+	HostKeyTrustOnFirstUse string = "tofu"
+)
+
+// HostKeyConfig describes how the host key presented by a remote device is verified.
+type HostKeyConfig struct {
+	Mode           string   `yaml:"mode,omitempty"`
+	KnownHostsFile string   `yaml:"known_hosts_file,omitempty"`
+	Fingerprints   []string `yaml:"fingerprints,flow,omitempty"`
+}
+
+// AuthConfig holds the credentials used to log into a remote device or jump host.
+type AuthConfig struct {
+	Username        string   `yaml:"username"`
+	KeyFile         string   `yaml:"key_file,omitempty"`
+	KeyPassphrase   Secret   `yaml:"key_passphrase,omitempty"`
+	CertificateFile string   `yaml:"certificate_file,omitempty"`
+	AgentSocket     string   `yaml:"agent_socket,omitempty"`
+	Password        Secret   `yaml:"password,omitempty"`
+	PasswordFile    string   `yaml:"password_file,omitempty"`
+	AuthMethods     []string `yaml:"auth_methods,flow,omitempty"`
+}
+
+// NXAPIConfig describes how NX-API is reached if transport is nxapi.
+type NXAPIConfig struct {
+	Scheme             string `yaml:"scheme,omitempty"`
+	Port               int    `yaml:"port,omitempty"`
+	CAFile             string `yaml:"ca_file,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+}
+
+// JumpHostConfig describes an intermediate SSH host the connection to a remote device is tunneled through.
+type JumpHostConfig struct {
+	Host       string `yaml:"host"`
+	Port       int    `yaml:"port,omitempty"`
+	AuthConfig `yaml:",inline"`
+	HostKey    HostKeyConfig `yaml:"host_key,omitempty"`
+}
+
+// Address returns the host:port the jump host is reachable at.
+func (j *JumpHostConfig) Address() string {
+	return net.JoinHostPort(j.Host, strconv.Itoa(j.Port))
+}
+
 // DeviceGroupConfig is used to read device configuration from the config file
 // DeviceGroupConfig describe how to connect to a remote device and what metrics
 // to extract from the remote device.
 type DeviceGroupConfig struct {
-	OSVersion         OSVersion
-	StaticName        *string   `yaml:"-"`
-	Matcher           glob.Glob `yaml:"-"`
-	Port              int       `yaml:"port,omitempty"`
-	Username          string    `yaml:"username"`
-	KeyFile           string    `yaml:"key_file,omitempty"`
-	Password          string    `yaml:"password,omitempty"`
-	ConnectTimeout    int       `yaml:"connect_timeout,omitempty"`
-	CommandTimeout    int       `yaml:"command_timeout,omitempty"`
-	EnabledCollectors []string  `yaml:"enabled_collectors,flow"`
-	Interfaces        []string  `yaml:"interfaces,flow"`
-	EnabledVLANs      []string  `yaml:"enabled_vlans,flow"`
+	// OSVersion skips fingerprinting the OS of the devices if set.
+	OSVersion         OSVersion   `yaml:"os_version,omitempty"`
+	Name              string      `yaml:"-"`
+	StaticName        *string     `yaml:"-"`
+	Matcher           glob.Glob   `yaml:"-"`
+	Extends           string      `yaml:"extends,omitempty"`
+	Priority          int         `yaml:"priority,omitempty"`
+	Port              int         `yaml:"port,omitempty"`
+	Transport         string      `yaml:"transport,omitempty"`
+	NXAPI             NXAPIConfig `yaml:"nxapi,omitempty"`
+	AuthConfig        `yaml:",inline"`
+	ProxyJump         []*JumpHostConfig `yaml:"proxy_jump,omitempty"`
+	EnablePassword    Secret            `yaml:"enable_password,omitempty"`
+	EnableSecretFile  string            `yaml:"enable_secret_file,omitempty"`
+	ConnectTimeout    int               `yaml:"connect_timeout,omitempty"`
+	CommandTimeout    int               `yaml:"command_timeout,omitempty"`
+	MaxSessions       int               `yaml:"max_sessions,omitempty"`
+	EnabledCollectors []string          `yaml:"enabled_collectors,flow"`
+	Interfaces        []string          `yaml:"interfaces,flow"`
+	EnabledVLANs      []string          `yaml:"enabled_vlans,flow"`
+	SecurityContexts  []string          `yaml:"security_contexts,flow"`
+	HostKey           HostKeyConfig     `yaml:"host_key,omitempty"`
+	Polling           PollingConfig     `yaml:"polling,omitempty"`
+	// node is the YAML node the device group was decoded from, used to report line numbers.
+	node *yaml.Node
+}
+
+// ModuleConfig bundles collectors and their options. A scrape selects it using the module query parameter.
+// Options which are set override the ones of the device group.
+type ModuleConfig struct {
+	Name             string   `yaml:"-"`
+	Collectors       []string `yaml:"collectors,flow"`
+	Interfaces       []string `yaml:"interfaces,flow,omitempty"`
+	EnabledVLANs     []string `yaml:"enabled_vlans,flow,omitempty"`
+	SecurityContexts []string `yaml:"security_contexts,flow,omitempty"`
+}
+
+// PollingConfig enables polling the collectors of a device group in the background.
+// Scrapes are then answered with the last successful result of each collector.
+type PollingConfig struct {
+	Interval   time.Duration            `yaml:"interval,omitempty"`
+	Collectors map[string]time.Duration `yaml:"collectors,omitempty"`
+}
+
+// Enabled returns whether the device group is polled in the background.
+func (p *PollingConfig) Enabled() bool {
+	return p.Interval > 0
+}
+
+// IntervalFor returns the polling interval of the given collector.
+func (p *PollingConfig) IntervalFor(collector string) time.Duration {
+	if interval, found := p.Collectors[collector]; found {
+		return interval
+	}
+	return p.Interval
 }
 
 func newConfig() *Config {
 	config := &Config{
 		DeviceGroups: make(map[string]*DeviceGroupConfig, 0),
+		Modules:      make(map[string]*ModuleConfig, 0),
 	}
 	return config
 }
 
+// GetModule returns the module with the given name or nil if there is none.
+func (c *Config) GetModule(name string) *ModuleConfig {
+	return c.Modules[name]
+}
+
+// GetDeviceGroup returns the device group the given device belongs to.
+// Static device groups are matched first, globs by descending priority and in the order of the configuration file.
 func (c *Config) GetDeviceGroup(device string) *DeviceGroupConfig {
-	for _, config := range c.DeviceGroups {
+	for _, config := range c.groups {
 		if config.Matcher == nil {
 			continue
 		}
@@ -91,10 +270,11 @@ func (c *Config) GetDeviceGroup(device string) *DeviceGroupConfig {
 
 }
 
+// GetStaticDevices returns the names of all static devices in the order of the configuration file.
 func (c *Config) GetStaticDevices() []string {
 	staticDeviceNames := make([]string, 0)
 
-	for _, config := range c.DeviceGroups {
+	for _, config := range c.groups {
 		if config.StaticName != nil {
 			staticDeviceNames = append(staticDeviceNames, *config.StaticName)
 		}
@@ -103,22 +283,61 @@ func (c *Config) GetStaticDevices() []string {
 	return staticDeviceNames
 }
 
+// Errors holds all errors found in a configuration file, one per line.
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
 // Load loads the configuration from the given reader.
+// Unknown options are rejected. The returned error holds all errors found, prefixed by their line number.
 func Load(reader io.Reader) (*Config, error) {
 	content, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return nil, err
 	}
 
-	config := newConfig()
-	err = yaml.Unmarshal(content, config)
+	// The device groups are decoded from YAML nodes below, which does not reject unknown options.
+	// Decoding them strictly upfront reports unknown options together with their line numbers.
+	var strict struct {
+		Devices   map[string]*DeviceGroupConfig `yaml:"devices"`
+		Templates map[string]*DeviceGroupConfig `yaml:"templates"`
+		Modules   map[string]*ModuleConfig      `yaml:"modules"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&strict); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	var raw rawConfig
+	err = yaml.Unmarshal(content, &raw)
 	if err != nil {
 		return nil, err
 	}
 
-	for matchStr, groupConfig := range config.DeviceGroups {
+	config := newConfig()
+	cache := secrets.NewCache()
+	var errs Errors
+	for i := 0; i+1 < len(raw.Devices.Content); i += 2 {
+		key, node := raw.Devices.Content[i], raw.Devices.Content[i+1]
+		matchStr := key.Value
+		groupConfig := &DeviceGroupConfig{Name: matchStr, node: node}
+		if err := raw.decodeDeviceGroup(node, groupConfig, []string{matchStr}); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid configuration for '%s': %v", matchStr, err))
+			continue
+		}
 
-		groupConfig.Matcher = glob.MustCompile(matchStr)
+		groupConfig.Matcher, err = glob.Compile(matchStr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: Invalid glob '%s': %v", key.Line, matchStr, err))
+			continue
+		}
 
 		// A glob is static, if there are no special meta signs to quote.
 		// Therefore, QuoteMeta should be a no op for static strings.
@@ -127,16 +346,434 @@ func Load(reader io.Reader) (*Config, error) {
 			groupConfig.StaticName = &s
 		}
 
-		if groupConfig.ConnectTimeout == 0 {
-			groupConfig.ConnectTimeout = defaultConnectTimeout
+		if option, err := groupConfig.resolveSecrets(cache); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: Invalid %s for '%s': %v", groupConfig.line(option, -1), option, matchStr, err))
+			continue
 		}
-		if groupConfig.CommandTimeout == 0 {
-			groupConfig.CommandTimeout = defaultCommandTimeout
+
+		if err := groupConfig.setDefaults(); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: Invalid configuration for '%s': %v", key.Line, matchStr, err))
+			continue
 		}
-		if groupConfig.Port == 0 {
-			groupConfig.Port = defaultPort
+
+		config.DeviceGroups[matchStr] = groupConfig
+		config.groups = append(config.groups, groupConfig)
+	}
+	moduleNames := make([]string, 0, len(raw.Modules))
+	for name := range raw.Modules {
+		moduleNames = append(moduleNames, name)
+	}
+	sort.Strings(moduleNames)
+	for _, name := range moduleNames {
+		module := raw.Modules[name]
+		if module == nil || len(module.Collectors) == 0 {
+			errs = append(errs, fmt.Errorf("Invalid module '%s': collectors: at least one collector is required", name))
+			continue
 		}
+		module.Name = name
+		config.Modules[name] = module
 	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	sort.SliceStable(config.groups, func(i, j int) bool {
+		a, b := config.groups[i], config.groups[j]
+		if (a.StaticName != nil) != (b.StaticName != nil) {
+			return a.StaticName != nil
+		}
+		return a.Priority > b.Priority
+	})
 
 	return config, nil
 }
+
+// Validate checks the configuration beyond its syntax:
+// Whether the enabled collectors and the collectors of modules are among the given known collectors
+// and whether the referenced files are readable.
+func (c *Config) Validate(knownCollectors []string) error {
+	known := make(map[string]bool, len(knownCollectors))
+	for _, name := range knownCollectors {
+		known[name] = true
+	}
+
+	var errs Errors
+	for _, groupConfig := range c.groups {
+		for i, name := range groupConfig.EnabledCollectors {
+			if !known[name] {
+				errs = append(errs, fmt.Errorf("line %d: Unknown collector '%s' enabled for '%s'", groupConfig.line("enabled_collectors", i), name, groupConfig.Name))
+			}
+		}
+
+		type file struct{ option, path string }
+		files := []file{
+			{"key_file", groupConfig.KeyFile},
+			{"certificate_file", groupConfig.CertificateFile},
+			{"enable_secret_file", groupConfig.EnableSecretFile},
+		}
+		if groupConfig.Transport == TransportNXAPI {
+			files = append(files, file{"nxapi", groupConfig.NXAPI.CAFile})
+		}
+		if groupConfig.HostKey.Mode == HostKeyStrict {
+			files = append(files, file{"host_key", groupConfig.HostKey.KnownHostsFile})
+		}
+		for _, jumpHost := range groupConfig.ProxyJump {
+			files = append(files, file{"proxy_jump", jumpHost.KeyFile}, file{"proxy_jump", jumpHost.CertificateFile})
+		}
+		for _, f := range files {
+			if f.path == "" {
+				continue
+			}
+			if _, err := ioutil.ReadFile(f.path); err != nil {
+				errs = append(errs, fmt.Errorf("line %d: Invalid %s for '%s': %v", groupConfig.line(f.option, -1), f.option, groupConfig.Name, err))
+			}
+		}
+	}
+
+	names := make([]string, 0, len(c.Modules))
+	for name := range c.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, collector := range c.Modules[name].Collectors {
+			if !known[collector] {
+				errs = append(errs, fmt.Errorf("Unknown collector '%s' in module '%s'", collector, name))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// line returns the line number of the given option of the device group, or of the index-th item if it is a list.
+// If the option is inherited, the line number of the device group is returned.
+func (d *DeviceGroupConfig) line(option string, index int) int {
+	if d.node == nil {
+		return 0
+	}
+	for i := 0; i+1 < len(d.node.Content); i += 2 {
+		if d.node.Content[i].Value != option {
+			continue
+		}
+		value := d.node.Content[i+1]
+		if index >= 0 && index < len(value.Content) {
+			return value.Content[index].Line
+		}
+		return d.node.Content[i].Line
+	}
+	return d.node.Line
+}
+
+// resolveSecrets resolves all secrets of the device group and its jump hosts.
+// On error, the option holding the secret is returned.
+func (d *DeviceGroupConfig) resolveSecrets(cache *secrets.Cache) (string, error) {
+	if option, err := d.AuthConfig.resolveSecrets(cache); err != nil {
+		return option, err
+	}
+	if err := d.EnablePassword.resolve(cache); err != nil {
+		return "enable_password", err
+	}
+	for _, jumpHost := range d.ProxyJump {
+		if _, err := jumpHost.resolveSecrets(cache); err != nil {
+			return "proxy_jump", fmt.Errorf("jump host '%s': %v", jumpHost.Host, err)
+		}
+	}
+	return "", nil
+}
+
+func (d *AuthConfig) resolveSecrets(cache *secrets.Cache) (string, error) {
+	if d.PasswordFile != "" {
+		if d.Password.IsSet() {
+			return "password_file", fmt.Errorf("password and password_file are mutually exclusive")
+		}
+		d.Password = Secret{provider: "file", reference: d.PasswordFile}
+	}
+	if err := d.Password.resolve(cache); err != nil {
+		return "password", err
+	}
+	if err := d.KeyPassphrase.resolve(cache); err != nil {
+		return "key_passphrase", err
+	}
+	return "", nil
+}
+
+// setDefaults sets the defaults of options not configured and checks whether the configured options are consistent.
+func (d *DeviceGroupConfig) setDefaults() error {
+	if d.ConnectTimeout == 0 {
+		d.ConnectTimeout = defaultConnectTimeout
+	}
+	if d.CommandTimeout == 0 {
+		d.CommandTimeout = defaultCommandTimeout
+	}
+	if d.Port == 0 {
+		d.Port = defaultPort
+		switch d.Transport {
+		case TransportNETCONF:
+			d.Port = defaultNETCONFPort
+		case TransportTelnet:
+			d.Port = defaultTelnetPort
+		}
+	}
+	if err := d.setTransportDefaults(); err != nil {
+		return err
+	}
+	if err := d.setAuthDefaults(); err != nil {
+		return fmt.Errorf("authentication: %v", err)
+	}
+	if len(d.AuthMethods) == 0 {
+		return fmt.Errorf("authentication: no auth method configured, set key_file, password or auth_methods")
+	}
+	if err := d.HostKey.setDefaults(); err != nil {
+		return fmt.Errorf("host_key: %v", err)
+	}
+	for _, jumpHost := range d.ProxyJump {
+		if err := jumpHost.setDefaults(); err != nil {
+			return fmt.Errorf("proxy_jump: %v", err)
+		}
+	}
+	for collector, interval := range d.Polling.Collectors {
+		if interval <= 0 {
+			return fmt.Errorf("polling: invalid interval for collector '%s'", collector)
+		}
+		if !d.Polling.Enabled() {
+			return fmt.Errorf("polling: collector intervals require polling.interval")
+		}
+	}
+	if d.EnablePassword.IsSet() && d.EnableSecretFile != "" {
+		return fmt.Errorf("enable_password and enable_secret_file are mutually exclusive")
+	}
+	if d.MaxSessions < 0 {
+		return fmt.Errorf("max_sessions: must not be negative")
+	}
+	if d.MaxSessions > 0 && d.Transport != TransportSSH {
+		return fmt.Errorf("max_sessions: requires transport '%s'", TransportSSH)
+	}
+	return nil
+}
+
+// decodeDeviceGroup decodes the device group in node into groupConfig.
+// If it extends another device group or template, the extended one is decoded first, so that the options of node take precedence.
+// chain holds the names of the device groups extending node and is used to detect cycles.
+func (raw *rawConfig) decodeDeviceGroup(node *yaml.Node, groupConfig *DeviceGroupConfig, chain []string) error {
+	var header struct {
+		Extends  string `yaml:"extends"`
+		Priority int    `yaml:"priority"`
+	}
+	if err := node.Decode(&header); err != nil {
+		return err
+	}
+
+	if header.Extends != "" {
+		for _, name := range chain {
+			if name == header.Extends {
+				return fmt.Errorf("line %d: extends '%s' results in a cycle: %s", node.Line, header.Extends, strings.Join(append(chain, header.Extends), " -> "))
+			}
+		}
+		parent := raw.lookup(header.Extends)
+		if parent == nil {
+			return fmt.Errorf("line %d: extends unknown template or device group '%s'", node.Line, header.Extends)
+		}
+		if err := raw.decodeDeviceGroup(parent, groupConfig, append(chain, header.Extends)); err != nil {
+			return err
+		}
+	}
+
+	if err := node.Decode(groupConfig); err != nil {
+		return err
+	}
+	// The priority only applies to the device group it is set on.
+	groupConfig.Extends = header.Extends
+	groupConfig.Priority = header.Priority
+	return nil
+}
+
+// lookup returns the template or device group with the given name. Templates take precedence.
+func (raw *rawConfig) lookup(name string) *yaml.Node {
+	for _, groups := range []*yaml.Node{&raw.Templates, &raw.Devices} {
+		for i := 0; i+1 < len(groups.Content); i += 2 {
+			if groups.Content[i].Value == name {
+				return groups.Content[i+1]
+			}
+		}
+	}
+	return nil
+}
+
+// SameConnection returns whether a connection established using d can be kept for other,
+// i.e. whether both configure the same port, transport, credentials, jump hosts, host key verification, os_version and max_sessions.
+func (d *DeviceGroupConfig) SameConnection(other *DeviceGroupConfig) bool {
+	return d.Port == other.Port &&
+		d.Transport == other.Transport &&
+		d.MaxSessions == other.MaxSessions &&
+		d.NXAPI == other.NXAPI &&
+		d.OSVersion == other.OSVersion &&
+		d.ConnectTimeout == other.ConnectTimeout &&
+		reflect.DeepEqual(d.EnablePassword, other.EnablePassword) &&
+		d.EnableSecretFile == other.EnableSecretFile &&
+		reflect.DeepEqual(d.AuthConfig, other.AuthConfig) &&
+		reflect.DeepEqual(d.HostKey, other.HostKey) &&
+		reflect.DeepEqual(d.ProxyJump, other.ProxyJump)
+}
+
+// Equal returns whether d and other were loaded from the same configuration.
+func (d *DeviceGroupConfig) Equal(other *DeviceGroupConfig) bool {
+	a, b := *d, *other
+	a.StaticName, b.StaticName = nil, nil
+	a.Matcher, b.Matcher = nil, nil
+	a.node, b.node = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// Redacted returns the options of the device group with secrets replaced by a placeholder.
+func (d *DeviceGroupConfig) Redacted() (map[string]interface{}, error) {
+	content, err := yaml.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	options := make(map[string]interface{})
+	if err := yaml.Unmarshal(content, &options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+// GetEnablePassword returns the password used to enter privileged EXEC mode.
+// The enable_secret_file is read on every call, so that the secret can be rotated without restarting the exporter.
+// The returned bool is false if neither enable_password nor enable_secret_file is configured.
+func (d *DeviceGroupConfig) GetEnablePassword() (string, bool, error) {
+	if d.EnableSecretFile != "" {
+		content, err := ioutil.ReadFile(d.EnableSecretFile)
+		if err != nil {
+			return "", true, err
+		}
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+	return d.EnablePassword.Value(), d.EnablePassword.IsSet(), nil
+}
+
+// setTransportDefaults checks the transport and sets the defaults of the NX-API options.
+func (d *DeviceGroupConfig) setTransportDefaults() error {
+	switch d.Transport {
+	case "":
+		d.Transport = TransportSSH
+	case TransportSSH:
+	case TransportNXAPI:
+		if d.OSVersion != INVALID && d.OSVersion != NXOS {
+			return fmt.Errorf("transport: '%s' requires os_version nxos", d.Transport)
+		}
+		if !d.Password.IsSet() && d.PasswordFile == "" {
+			return fmt.Errorf("transport: '%s' requires password or password_file", d.Transport)
+		}
+		if len(d.ProxyJump) > 0 {
+			return fmt.Errorf("transport: '%s' does not support proxy_jump", d.Transport)
+		}
+	case TransportNETCONF:
+		if d.OSVersion != INVALID && d.OSVersion != IOSXE {
+			return fmt.Errorf("transport: '%s' requires os_version ios-xe", d.Transport)
+		}
+	case TransportTelnet:
+		if !d.Password.IsSet() && d.PasswordFile == "" {
+			return fmt.Errorf("transport: '%s' requires password or password_file", d.Transport)
+		}
+	default:
+		return fmt.Errorf("transport: unknown transport '%s'", d.Transport)
+	}
+
+	switch d.NXAPI.Scheme {
+	case "":
+		d.NXAPI.Scheme = "https"
+	case "http", "https":
+	default:
+		return fmt.Errorf("nxapi: unknown scheme '%s'", d.NXAPI.Scheme)
+	}
+	if d.NXAPI.Port == 0 {
+		d.NXAPI.Port = 443
+		if d.NXAPI.Scheme == "http" {
+			d.NXAPI.Port = 80
+		}
+	}
+	return nil
+}
+
+func (h *HostKeyConfig) setDefaults() error {
+	if h.Mode == "" {
+		if h.KnownHostsFile != "" || len(h.Fingerprints) > 0 {
+			h.Mode = HostKeyStrict
+		} else {
+			h.Mode = HostKeyInsecure
+		}
+	}
+
+	switch h.Mode {
+	case HostKeyInsecure:
+	case HostKeyStrict:
+		if h.KnownHostsFile == "" && len(h.Fingerprints) == 0 {
+			return fmt.Errorf("mode '%s' requires known_hosts_file or fingerprints", h.Mode)
+		}
+	case HostKeyTrustOnFirstUse:
+		if h.KnownHostsFile == "" {
+			return fmt.Errorf("mode '%s' requires known_hosts_file", h.Mode)
+		}
+	default:
+		return fmt.Errorf("unknown mode '%s'", h.Mode)
+	}
+	return nil
+}
+
+func (j *JumpHostConfig) setDefaults() error {
+	if j.Host == "" {
+		return fmt.Errorf("jump host without host")
+	}
+	if j.Port == 0 {
+		j.Port = defaultPort
+	}
+	if err := j.setAuthDefaults(); err != nil {
+		return fmt.Errorf("jump host '%s': %v", j.Host, err)
+	}
+	if err := j.HostKey.setDefaults(); err != nil {
+		return fmt.Errorf("jump host '%s': host_key: %v", j.Host, err)
+	}
+	return nil
+}
+
+// setAuthDefaults derives the authentication methods from the configured credentials if auth_methods is not set.
+// Otherwise it checks whether the listed methods are known and the credentials they need are configured.
+func (d *AuthConfig) setAuthDefaults() error {
+	if len(d.AuthMethods) == 0 {
+		if d.CertificateFile != "" {
+			d.AuthMethods = append(d.AuthMethods, AuthCertificate)
+		}
+		if d.KeyFile != "" {
+			d.AuthMethods = append(d.AuthMethods, AuthKey)
+		}
+		if d.Password.IsSet() {
+			d.AuthMethods = append(d.AuthMethods, AuthPassword)
+		}
+		return nil
+	}
+
+	for _, method := range d.AuthMethods {
+		switch method {
+		case AuthAgent:
+		case AuthCertificate:
+			if d.CertificateFile == "" || d.KeyFile == "" {
+				return fmt.Errorf("auth method '%s' requires certificate_file and key_file", method)
+			}
+		case AuthKey:
+			if d.KeyFile == "" {
+				return fmt.Errorf("auth method '%s' requires key_file", method)
+			}
+		case AuthKeyboardInteractive, AuthPassword:
+			if !d.Password.IsSet() {
+				return fmt.Errorf("auth method '%s' requires password or password_file", method)
+			}
+		default:
+			return fmt.Errorf("unknown auth method '%s'", method)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,418 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const reloadBase = `
+devices:
+  router.example.com:
+    username: monitoring
+    password: secret
+    enabled_collectors: [cpu]
+`
+
+func loadString(t *testing.T, content string) *DeviceGroupConfig {
+	c, err := Load(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+	return c.GetDeviceGroup("router.example.com")
+}
+
+func TestSameConnection(t *testing.T) {
+	base := loadString(t, reloadBase)
+
+	tests := []struct {
+		name           string
+		content        string
+		sameConnection bool
+		equal          bool
+	}{
+		{"unchanged", reloadBase, true, true},
+		{"collectors changed", strings.Replace(reloadBase, "[cpu]", "[cpu, memory]", 1), true, false},
+		{"password changed", strings.Replace(reloadBase, "password: secret", "password: other", 1), false, false},
+		{"port changed", reloadBase + "    port: 2222\n", false, false},
+		{"os_version changed", reloadBase + "    os_version: ios-xe\n", false, false},
+		{"transport changed", reloadBase + "    transport: nxapi\n", false, false},
+	}
+
+	for _, test := range tests {
+		other := loadString(t, test.content)
+		if got := base.SameConnection(other); got != test.sameConnection {
+			t.Errorf("%s: SameConnection() = %v, want %v", test.name, got, test.sameConnection)
+		}
+		if got := base.Equal(other); got != test.equal {
+			t.Errorf("%s: Equal() = %v, want %v", test.name, got, test.equal)
+		}
+	}
+}
+
+const orderedConfig = `
+templates:
+  common:
+    username: monitoring
+    password: secret
+    enabled_collectors: [cpu, memory]
+  bng:
+    extends: common
+    enabled_collectors: [cpu, pppoe]
+devices:
+  "*.host.tld":
+    extends: common
+  "*.example.host.tld":
+    extends: bng
+    priority: 10
+  "foo.example.host.tld":
+    extends: "*.host.tld"
+    port: 2222
+  "*.tld":
+    username: other
+    password: other
+`
+
+func TestGetDeviceGroupOrder(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		c, err := Load(strings.NewReader(orderedConfig))
+		if err != nil {
+			t.Fatalf("Could not load configuration: %v", err)
+		}
+
+		expected := map[string]string{
+			"foo.example.host.tld": "foo.example.host.tld",
+			"bar.example.host.tld": "*.example.host.tld",
+			"bar.host.tld":         "*.host.tld",
+			"bar.tld":              "*.tld",
+		}
+		for device, name := range expected {
+			if group := c.GetDeviceGroup(device); group == nil || group.Name != name {
+				t.Fatalf("Device '%s' matched %+v, want '%s'", device, group, name)
+			}
+		}
+	}
+}
+
+func TestExtends(t *testing.T) {
+	c, err := Load(strings.NewReader(orderedConfig))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+
+	foo := c.GetDeviceGroup("foo.example.host.tld")
+	if foo.Username != "monitoring" || foo.Port != 2222 || strings.Join(foo.EnabledCollectors, ",") != "cpu,memory" {
+		t.Errorf("Unexpected device group: %+v", foo)
+	}
+	bng := c.GetDeviceGroup("bar.example.host.tld")
+	if bng.Username != "monitoring" || bng.Priority != 10 || strings.Join(bng.EnabledCollectors, ",") != "cpu,pppoe" {
+		t.Errorf("Unexpected device group: %+v", bng)
+	}
+	if static := c.GetStaticDevices(); len(static) != 1 || static[0] != "foo.example.host.tld" {
+		t.Errorf("Unexpected static devices: %v", static)
+	}
+}
+
+func TestTransportDefaults(t *testing.T) {
+	c, err := Load(strings.NewReader(`
+devices:
+  ssh:
+    username: monitoring
+    password: secret
+  netconf:
+    username: monitoring
+    password: secret
+    transport: netconf
+  telnet:
+    username: monitoring
+    password: secret
+    transport: telnet
+`))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+
+	if ssh := c.GetDeviceGroup("ssh"); ssh.Transport != TransportSSH || ssh.Port != 22 {
+		t.Errorf("Unexpected device group: %+v", ssh)
+	}
+	if netconf := c.GetDeviceGroup("netconf"); netconf.Transport != TransportNETCONF || netconf.Port != 830 {
+		t.Errorf("Unexpected device group: %+v", netconf)
+	}
+	if telnet := c.GetDeviceGroup("telnet"); telnet.Transport != TransportTelnet || telnet.Port != 23 {
+		t.Errorf("Unexpected device group: %+v", telnet)
+	}
+}
+
+func TestExtendsErrors(t *testing.T) {
+	tests := map[string]string{
+		"cycle": `
+templates:
+  a:
+    extends: b
+  b:
+    extends: a
+devices:
+  foo:
+    extends: a
+`,
+		"unknown": `
+devices:
+  foo:
+    extends: missing
+`,
+	}
+
+	for name, content := range tests {
+		if _, err := Load(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		errors  []string
+	}{
+		"unknown field": {`
+devices:
+  foo:
+    username: monitoring
+    ConnectTimeout: 5
+`, []string{"line 5: field ConnectTimeout not found"}},
+		"no auth method": {`
+devices:
+  foo:
+    username: monitoring
+  bar:
+    username: monitoring
+    password: secret
+  "[bar":
+    password: secret
+`, []string{"line 3: Invalid configuration for 'foo'", "line 8: Invalid glob '[bar'"}},
+		"nxapi without password": {`
+devices:
+  foo:
+    username: monitoring
+    key_file: /etc/cisco-exporter/id_ed25519
+    transport: nxapi
+`, []string{"transport: 'nxapi' requires password or password_file"}},
+		"nxapi with other os": {`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+    transport: nxapi
+    os_version: ios-xe
+`, []string{"transport: 'nxapi' requires os_version nxos"}},
+		"netconf with other os": {`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+    transport: netconf
+    os_version: nxos
+`, []string{"transport: 'netconf' requires os_version ios-xe"}},
+		"telnet without password": {`
+devices:
+  foo:
+    username: monitoring
+    key_file: /dev/null
+    transport: telnet
+`, []string{"transport: 'telnet' requires password or password_file"}},
+		"negative max_sessions": {`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+    max_sessions: -1
+`, []string{"max_sessions: must not be negative"}},
+		"max_sessions with telnet": {`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+    transport: telnet
+    max_sessions: 4
+`, []string{"max_sessions: requires transport 'ssh'"}},
+		"module without collectors": {`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+modules:
+  slow:
+    interfaces: [Gi0/1]
+`, []string{"Invalid module 'slow': collectors: at least one collector is required"}},
+		"unknown module option": {`
+modules:
+  slow:
+    collectors: [environment]
+    interval: 5m
+`, []string{"field interval not found"}},
+		"unknown transport": {`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+    transport: restconf
+`, []string{"transport: unknown transport 'restconf'"}},
+	}
+
+	for name, test := range tests {
+		_, err := Load(strings.NewReader(test.content))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		for _, expected := range test.errors {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("%s: expected '%s' in error: %v", name, expected, err)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c, err := Load(strings.NewReader(`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+    key_file: /nonexistent/key
+    enabled_collectors:
+      - cpu
+      - unknown
+`))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+
+	err = c.Validate([]string{"cpu", "memory"})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	for _, expected := range []string{"line 9: Unknown collector 'unknown'", "line 6: Invalid key_file"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected '%s' in error: %v", expected, err)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	c, err := Load(strings.NewReader(`
+devices:
+  foo:
+    username: monitoring
+    password: secret
+    enabled_collectors: [interfaces, environment, optics]
+modules:
+  fast:
+    collectors: [interfaces]
+    interfaces: [TenGigabitEthernet0/0/0]
+  slow:
+    collectors: [environment, optics, unknown]
+`))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+
+	fast := c.GetModule("fast")
+	if fast == nil || fast.Name != "fast" || !reflect.DeepEqual(fast.Collectors, []string{"interfaces"}) || !reflect.DeepEqual(fast.Interfaces, []string{"TenGigabitEthernet0/0/0"}) {
+		t.Errorf("Unexpected module: %+v", fast)
+	}
+	if c.GetModule("unknown") != nil {
+		t.Errorf("Expected no module 'unknown'")
+	}
+
+	err = c.Validate([]string{"interfaces", "environment", "optics"})
+	if err == nil || !strings.Contains(err.Error(), "Unknown collector 'unknown' in module 'slow'") {
+		t.Errorf("Expected unknown collector of module to be reported, got %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	c, err := Load(strings.NewReader(`
+devices:
+  foo:
+    username: monitoring
+    password: hunter2
+    enable_password: enable-hunter2
+    proxy_jump:
+      - host: bastion
+        username: jump
+        password: jump-hunter2
+`))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+	options, err := c.GetDeviceGroup("foo").Redacted()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump := fmt.Sprintf("%v", options); strings.Contains(dump, "hunter2") {
+		t.Errorf("Expected secrets to be redacted: %s", dump)
+	}
+	if options["username"] != "monitoring" || options["password"] != "<secret>" || options["enable_password"] != "<secret>" {
+		t.Errorf("Unexpected options %v", options)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	passwordFile := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(passwordFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("CONFIG_TEST_SECRET", "from-env")
+	defer os.Unsetenv("CONFIG_TEST_SECRET")
+
+	c, err := Load(strings.NewReader(`
+devices:
+  foo:
+    username: monitoring
+    password_file: ` + passwordFile + `
+    enable_password: "pre-${CONFIG_TEST_SECRET}-$${LITERAL}"
+  bar:
+    username: monitoring
+    password: {exec: "echo from-exec"}
+    key_file: /path/to/key
+    key_passphrase: ${CONFIG_TEST_SECRET}
+`))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+
+	foo := c.GetDeviceGroup("foo")
+	if foo.Password.Value() != "from-file" || foo.EnablePassword.Value() != "pre-from-env-${LITERAL}" {
+		t.Errorf("Unexpected secrets: '%s', '%s'", foo.Password.Value(), foo.EnablePassword.Value())
+	}
+	bar := c.GetDeviceGroup("bar")
+	if bar.Password.Value() != "from-exec" || bar.KeyPassphrase.Value() != "from-env" {
+		t.Errorf("Unexpected secrets: '%s', '%s'", bar.Password.Value(), bar.KeyPassphrase.Value())
+	}
+
+	for _, printed := range []string{fmt.Sprintf("%v", *foo), fmt.Sprintf("%+v", *bar), fmt.Sprintf("%#v", *bar)} {
+		if strings.Contains(printed, "from-") {
+			t.Errorf("Secret printed: %s", printed)
+		}
+	}
+
+	_, err = Load(strings.NewReader(`
+devices:
+  foo:
+    username: monitoring
+    password: ${CONFIG_TEST_UNSET}
+`))
+	if err == nil || !strings.Contains(err.Error(), "line 5: Invalid password") {
+		t.Errorf("Expected an error for an unset environment variable, got %v", err)
+	}
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+
+	"gitlab.com/wobcom/cisco-exporter/secrets"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted is printed instead of the value of a Secret.
+const redacted = "<secret>"
+
+// interpolationRegexp matches `${ENV_VAR}` and `$${`, which is replaced by a literal `${`.
+var interpolationRegexp = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// Secret is a configuration value which must not be logged, like a password.
+// In the configuration file it is either a string, in which `${ENV_VAR}` is replaced by the environment variable's value,
+// or a mapping of a secret provider to its reference, e.g. `{file: /path/to/secret}` or `{exec: "pass show monitoring"}`.
+type Secret struct {
+	provider  string
+	reference string
+	value     string
+}
+
+// NewSecret returns a resolved Secret holding value.
+func NewSecret(value string) Secret {
+	return Secret{value: value, reference: value}
+}
+
+// Value returns the resolved secret.
+func (s Secret) Value() string {
+	return s.value
+}
+
+// IsSet returns whether the secret is configured.
+func (s Secret) IsSet() bool {
+	return s.provider != "" || s.reference != ""
+}
+
+// IsZero returns whether the secret is not configured, so that configured secrets are not omitted when marshaled.
+func (s Secret) IsZero() bool {
+	return !s.IsSet()
+}
+
+// String returns a placeholder, so that the secret does not end up in logs.
+func (s Secret) String() string {
+	if !s.IsSet() {
+		return ""
+	}
+	return redacted
+}
+
+// GoString returns a placeholder, so that the secret does not end up in logs.
+func (s Secret) GoString() string {
+	return s.String()
+}
+
+// MarshalYAML returns a placeholder instead of the secret.
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// UnmarshalYAML reads the reference of the secret. The secret is resolved once the configuration is loaded.
+func (s *Secret) UnmarshalYAML(node *yaml.Node) error {
+	*s = Secret{}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		s.reference = node.Value
+	case yaml.MappingNode:
+		if len(node.Content) != 2 || node.Content[1].Kind != yaml.ScalarNode {
+			return fmt.Errorf("line %d: a secret must be a string or a mapping of one provider to its reference", node.Line)
+		}
+		s.provider = node.Content[0].Value
+		s.reference = node.Content[1].Value
+	default:
+		return fmt.Errorf("line %d: a secret must be a string or a mapping of one provider to its reference", node.Line)
+	}
+	return nil
+}
+
+// resolve resolves the secret using the given cache.
+func (s *Secret) resolve(cache *secrets.Cache) error {
+	if s.provider != "" {
+		value, err := cache.Resolve(s.provider, s.reference)
+		if err != nil {
+			return err
+		}
+		s.value = value
+		return nil
+	}
+
+	var err error
+	s.value = interpolationRegexp.ReplaceAllStringFunc(s.reference, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		value, resolveErr := cache.Resolve("env", interpolationRegexp.FindStringSubmatch(match)[1])
+		if resolveErr != nil && err == nil {
+			err = resolveErr
+		}
+		return value
+	})
+	return err
+}
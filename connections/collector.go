@@ -0,0 +1,11 @@
+package connections
+
+import (
+	"gitlab.com/wobcom/cisco-exporter/asa"
+	"gitlab.com/wobcom/cisco-exporter/collector"
+)
+
+// NewCollector returns a collector gathering the number of connections through an ASA by running `show conn count`.
+func NewCollector() collector.Collector {
+	return asa.NewUsageCollector("connections", "show conn count", "cisco_asa_connections_", "connections")
+}
@@ -0,0 +1,173 @@
+package connector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/pkg/errors"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// makeAuth returns the authentication methods configured for a device in the configured order.
+// The returned function releases resources (e.g. the connection to the SSH agent) and must be called once the
+// SSH handshake is done.
+//
+// The ssh package tries each method type only once, therefore the signers of the agent, certificate and key
+// methods are combined into a single public key method placed where the first of them is configured.
+func makeAuth(device *config.AuthConfig) ([]ssh.AuthMethod, func(), error) {
+	var authMethods []ssh.AuthMethod
+	var signerFuncs []func() ([]ssh.Signer, error)
+	publicKeysAdded := false
+	cleanup := func() {}
+
+	addPublicKeys := func() {
+		if publicKeysAdded {
+			return
+		}
+		publicKeysAdded = true
+		authMethods = append(authMethods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+			var signers []ssh.Signer
+			for _, signerFunc := range signerFuncs {
+				s, err := signerFunc()
+				if err != nil {
+					return nil, err
+				}
+				signers = append(signers, s...)
+			}
+			return signers, nil
+		}))
+	}
+
+	for _, method := range device.AuthMethods {
+		switch method {
+		case config.AuthAgent:
+			agentConn, err := dialAgent(device.AgentSocket)
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			previousCleanup := cleanup
+			cleanup = func() {
+				agentConn.Close()
+				previousCleanup()
+			}
+			signerFuncs = append(signerFuncs, agent.NewClient(agentConn).Signers)
+			addPublicKeys()
+		case config.AuthCertificate:
+			signer, err := loadCertificateSigner(device.CertificateFile, device.KeyFile, device.KeyPassphrase.Value())
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			signerFuncs = append(signerFuncs, staticSigners(signer))
+			addPublicKeys()
+		case config.AuthKey:
+			signer, err := loadPrivateKey(device.KeyFile, device.KeyPassphrase.Value())
+			if err != nil {
+				cleanup()
+				return nil, nil, err
+			}
+			signerFuncs = append(signerFuncs, staticSigners(signer))
+			addPublicKeys()
+		case config.AuthKeyboardInteractive:
+			authMethods = append(authMethods, ssh.KeyboardInteractive(keyboardInteractivePassword(device.Password.Value())))
+		case config.AuthPassword:
+			authMethods = append(authMethods, ssh.Password(device.Password.Value()))
+		default:
+			cleanup()
+			return nil, nil, fmt.Errorf("Unknown authentication method '%s'", method)
+		}
+	}
+
+	if len(authMethods) == 0 {
+		return nil, nil, errors.New(fmt.Sprintf("I don't know how to authenticate as '%s'", device.Username))
+	}
+
+	return authMethods, cleanup, nil
+}
+
+func staticSigners(signers ...ssh.Signer) func() ([]ssh.Signer, error) {
+	return func() ([]ssh.Signer, error) {
+		return signers, nil
+	}
+}
+
+func dialAgent(socket string) (net.Conn, error) {
+	if socket == "" {
+		socket = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if socket == "" {
+		return nil, errors.New("No agent_socket configured and SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not connect to SSH agent '%s'", socket)
+	}
+	return conn, nil
+}
+
+func loadPrivateKey(keyFile string, passphrase string) (ssh.Signer, error) {
+	keyFileContents, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading private key file '%s'", keyFile)
+	}
+
+	var key ssh.Signer
+	if passphrase != "" {
+		key, err = ssh.ParsePrivateKeyWithPassphrase(keyFileContents, []byte(passphrase))
+	} else {
+		key, err = ssh.ParsePrivateKey(keyFileContents)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing private key file '%s'", keyFile)
+	}
+	return key, nil
+}
+
+func loadCertificateSigner(certificateFile string, keyFile string, passphrase string) (ssh.Signer, error) {
+	key, err := loadPrivateKey(keyFile, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	certificateFileContents, err := ioutil.ReadFile(certificateFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error reading certificate file '%s'", certificateFile)
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(certificateFileContents)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Error parsing certificate file '%s'", certificateFile)
+	}
+	certificate, ok := publicKey.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("File '%s' does not contain an OpenSSH certificate", certificateFile))
+	}
+
+	signer, err := ssh.NewCertSigner(certificate, key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Certificate '%s' does not match private key '%s'", certificateFile, keyFile)
+	}
+	return signer, nil
+}
+
+// keyboardInteractivePassword answers every keyboard-interactive challenge asking for a password with the given password.
+// TACACS+ backed devices usually send a single "Password:" challenge.
+func keyboardInteractivePassword(password string) ssh.KeyboardInteractiveChallenge {
+	return func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		answers := make([]string, len(questions))
+		for i, question := range questions {
+			if echos[i] && !strings.Contains(strings.ToLower(question), "password") {
+				return nil, errors.New(fmt.Sprintf("Unexpected keyboard-interactive challenge '%s'", question))
+			}
+			answers[i] = password
+		}
+		return answers, nil
+	}
+}
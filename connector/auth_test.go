@@ -0,0 +1,250 @@
+package connector
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const testPassword = "correcthorsebatterystaple"
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "cisco-exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// writePrivateKey writes a new ECDSA key to dir, encrypted if passphrase is not empty.
+func writePrivateKey(t *testing.T, dir string, passphrase string) (string, ssh.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	if passphrase != "" {
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, der, []byte(passphrase), x509.PEMCipherAES256)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(dir, "id_ecdsa")
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, signer
+}
+
+func connectToTestServer(server *testServer, device *config.DeviceGroupConfig) error {
+	device.Username = "monitoring"
+	device.Port = server.Port()
+	device.ConnectTimeout = 5
+	device.HostKey.Mode = config.HostKeyInsecure
+
+	client, _, _, err := NewConnectionManager().makeSSHClient("127.0.0.1", device)
+	if err != nil {
+		return err
+	}
+	client.Close()
+	return nil
+}
+
+func acceptPublicKey(expected ssh.PublicKey) func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+	return func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		if bytes.Equal(key.Marshal(), expected.Marshal()) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unknown key")
+	}
+}
+
+func TestAuthPassword(t *testing.T) {
+	server := newTestServer(t, &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == testPassword {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+	}, nil)
+	defer server.Close()
+
+	if err := connectToTestServer(server, &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{Password: config.NewSecret(testPassword), AuthMethods: []string{config.AuthPassword}}}); err != nil {
+		t.Errorf("Expected password authentication to succeed: %v", err)
+	}
+
+	err := connectToTestServer(server, &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{Password: config.NewSecret("wrong"), AuthMethods: []string{config.AuthPassword}}})
+	if err == nil {
+		t.Fatalf("Expected authentication with a wrong password to fail")
+	}
+	if reason := FailureReason(err); reason != ReasonAuth {
+		t.Errorf("Expected failure reason '%s', got '%s'", ReasonAuth, reason)
+	}
+}
+
+func TestAuthKeyboardInteractive(t *testing.T) {
+	server := newTestServer(t, &ssh.ServerConfig{
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "TACACS authentication", []string{"Password: "}, []bool{false})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) == 1 && answers[0] == testPassword {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+	}, nil)
+	defer server.Close()
+
+	device := &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{Password: config.NewSecret(testPassword), AuthMethods: []string{config.AuthPassword, config.AuthKeyboardInteractive}}}
+	if err := connectToTestServer(server, device); err != nil {
+		t.Errorf("Expected keyboard-interactive authentication to succeed: %v", err)
+	}
+}
+
+func TestAuthEncryptedKey(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	keyFile, signer := writePrivateKey(t, dir, "secret")
+
+	server := newTestServer(t, &ssh.ServerConfig{PublicKeyCallback: acceptPublicKey(signer.PublicKey())}, nil)
+	defer server.Close()
+
+	device := &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{KeyFile: keyFile, KeyPassphrase: config.NewSecret("secret"), AuthMethods: []string{config.AuthKey}}}
+	if err := connectToTestServer(server, device); err != nil {
+		t.Errorf("Expected authentication with encrypted key to succeed: %v", err)
+	}
+
+	device = &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{KeyFile: keyFile, KeyPassphrase: config.NewSecret("wrong"), AuthMethods: []string{config.AuthKey}}}
+	if err := connectToTestServer(server, device); err == nil {
+		t.Errorf("Expected decrypting the key with a wrong passphrase to fail")
+	}
+}
+
+func TestAuthCertificate(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	keyFile, signer := writePrivateKey(t, dir, "")
+
+	_, caKey, _ := ed25519.GenerateKey(rand.Reader)
+	caSigner, _ := ssh.NewSignerFromKey(caKey)
+	certificate := &ssh.Certificate{
+		Key:             signer.PublicKey(),
+		CertType:        ssh.UserCert,
+		KeyId:           "monitoring",
+		ValidPrincipals: []string{"monitoring"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := certificate.SignCert(rand.Reader, caSigner); err != nil {
+		t.Fatal(err)
+	}
+	certificateFile := filepath.Join(dir, "id_ecdsa-cert.pub")
+	if err := ioutil.WriteFile(certificateFile, ssh.MarshalAuthorizedKey(certificate), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	certChecker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), caSigner.PublicKey().Marshal())
+		},
+	}
+	server := newTestServer(t, &ssh.ServerConfig{PublicKeyCallback: certChecker.Authenticate}, nil)
+	defer server.Close()
+
+	device := &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{KeyFile: keyFile, CertificateFile: certificateFile, AuthMethods: []string{config.AuthCertificate}}}
+	if err := connectToTestServer(server, device); err != nil {
+		t.Errorf("Expected certificate authentication to succeed: %v", err)
+	}
+
+	device = &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{KeyFile: keyFile, AuthMethods: []string{config.AuthKey}}}
+	if err := connectToTestServer(server, device); err == nil {
+		t.Errorf("Expected authentication with the plain key to fail")
+	}
+}
+
+func TestAuthAgent(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatal(err)
+	}
+	socket := filepath.Join(dir, "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+
+	signer, _ := ssh.NewSignerFromKey(key)
+	server := newTestServer(t, &ssh.ServerConfig{PublicKeyCallback: acceptPublicKey(signer.PublicKey())}, nil)
+	defer server.Close()
+
+	device := &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{AgentSocket: socket, AuthMethods: []string{config.AuthAgent}}}
+	if err := connectToTestServer(server, device); err != nil {
+		t.Errorf("Expected agent authentication to succeed: %v", err)
+	}
+}
+
+func TestAuthMethodOrder(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	keyFile, signer := writePrivateKey(t, dir, "")
+
+	var attempted []string
+	server := newTestServer(t, &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			attempted = append(attempted, config.AuthPassword)
+			return nil, fmt.Errorf("password authentication disabled")
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			attempted = append(attempted, config.AuthKey)
+			return acceptPublicKey(signer.PublicKey())(conn, key)
+		},
+	}, nil)
+	defer server.Close()
+
+	device := &config.DeviceGroupConfig{AuthConfig: config.AuthConfig{KeyFile: keyFile, Password: config.NewSecret(testPassword), AuthMethods: []string{config.AuthPassword, config.AuthKey}}}
+	if err := connectToTestServer(server, device); err != nil {
+		t.Fatalf("Expected fallback to key authentication to succeed: %v", err)
+	}
+	if len(attempted) < 2 || attempted[0] != config.AuthPassword || attempted[len(attempted)-1] != config.AuthKey {
+		t.Errorf("Expected password to be tried before key, got %v", attempted)
+	}
+}
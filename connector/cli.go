@@ -0,0 +1,184 @@
+package connector
+
+import (
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// initialPromptRegexp matches a CLI prompt like `router>` or `router#` at the end of the received output.
+	initialPromptRegexp = regexp.MustCompile(`(?:^|\n)([^\s>#]+?)(?:\([^)\s]*\))?([>#]) ?$`)
+	moreRegexp          = regexp.MustCompile(` ?(?:--More--|<--- More --->) ?[\x08]*[ ]*[\x08]*`)
+	moreAtEndRegexp     = regexp.MustCompile(`(?:--More--|<--- More --->) *$`)
+	escapeRegexp        = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+	authExpiredRegexp   = regexp.MustCompile(`[aA]uthentication [eE]xpired`)
+	// asaContextPromptRegexp matches the hostname of an ASA in multiple context mode like `fw/admin`.
+	asaContextPromptRegexp = regexp.MustCompile(`^([^/:]+)/([^/:]+)$`)
+)
+
+// cliSession frames the output of commands sent to an interactive Cisco CLI.
+// After login the prompt of the device is learned. The output of a command ends once the prompt reappears.
+type cliSession struct {
+	reader io.Reader
+	writer io.Writer
+	// hostname is the part of the prompt in front of the mode (`(config)`) and `>` or `#`.
+	hostname string
+	// loginContext is the security context of an ASA in multiple context mode the session logged into,
+	// empty if it logged into the system context.
+	loginContext string
+	// prompt matches the prompt at the start of a line, promptAtEnd a prompt waiting for input.
+	prompt      *regexp.Regexp
+	promptAtEnd *regexp.Regexp
+	// pending holds received output which was not yet processed.
+	pending string
+}
+
+func newCLISession(reader io.Reader, writer io.Writer) *cliSession {
+	return &cliSession{
+		reader: reader,
+		writer: writer,
+	}
+}
+
+// read receives the next chunk of output from the remote device, dropping carriage returns.
+func (s *cliSession) read(buf []byte) error {
+	n, err := s.reader.Read(buf)
+	if n > 0 {
+		s.pending += strings.Replace(string(buf[:n]), "\r", "", -1)
+	}
+	if err != nil && n == 0 {
+		return err
+	}
+	return nil
+}
+
+// received returns the pending output without escape sequences.
+// Escape sequences are only removed here, as they might be split across multiple reads.
+func (s *cliSession) received() string {
+	return escapeRegexp.ReplaceAllString(s.pending, "")
+}
+
+// readUntil reads the output of the remote device until it matches one of the given patterns, discarding everything read.
+// It returns the index of the matching pattern and its submatches.
+func (s *cliSession) readUntil(patterns ...*regexp.Regexp) (int, []string, error) {
+	buf := make([]byte, 4096)
+	for {
+		for i, pattern := range patterns {
+			if match := pattern.FindStringSubmatch(s.received()); match != nil {
+				s.pending = ""
+				return i, match, nil
+			}
+		}
+		if err := s.read(buf); err != nil {
+			return -1, nil, err
+		}
+	}
+}
+
+// learnPrompt reads the banner sent after login until the first prompt and remembers the prompt.
+// It returns the prompt's last character, which is `>` in user EXEC mode and `#` in privileged EXEC mode.
+func (s *cliSession) learnPrompt() (string, error) {
+	_, match, err := s.readUntil(initialPromptRegexp)
+	if err != nil {
+		return "", err
+	}
+	s.hostname = match[1]
+	baseHostname := s.hostname
+	if match := asaContextPromptRegexp.FindStringSubmatch(s.hostname); match != nil {
+		baseHostname = match[1]
+		s.loginContext = match[2]
+	}
+	// ASAs in multiple context mode append the current security context, which changes on `changeto`.
+	// The system context has no suffix, so it is accepted whichever context the session logged into.
+	hostnamePattern := regexp.QuoteMeta(baseHostname) + `(?:/[^\s>#()/:]+)?`
+	pattern := hostnamePattern + `(?:\([^)\s]*\))?([>#]) ?`
+	s.prompt = regexp.MustCompile(`^` + pattern)
+	s.promptAtEnd = regexp.MustCompile(`(?:^|\n)` + pattern + `$`)
+	return match[2], nil
+}
+
+// waitForPrompt reads until the learned prompt or one of the given patterns appears at the end of the output.
+// It returns the index of the matching pattern, or -1 and the prompt's last character if the prompt appeared.
+func (s *cliSession) waitForPrompt(patterns ...*regexp.Regexp) (int, string, error) {
+	index, match, err := s.readUntil(append([]*regexp.Regexp{s.promptAtEnd}, patterns...)...)
+	if err != nil {
+		return -1, "", err
+	}
+	if index == 0 {
+		return -1, match[1], nil
+	}
+	return index - 1, "", nil
+}
+
+// run sends command to the remote device and writes every line of its output to the output channel.
+// A command consisting of multiple lines ends once the prompt appeared for each of them.
+// Command echoes, prompts and `--More--` paginator leftovers are not part of the output.
+// If abort is closed, run stops sending output and returns.
+func (s *cliSession) run(command string, output chan<- string, abort <-chan struct{}) error {
+	if s.prompt == nil {
+		return errors.New("The prompt of the remote device is unknown")
+	}
+
+	commandLines := strings.Split(strings.TrimRight(command, "\n"), "\n")
+	if _, err := io.WriteString(s.writer, strings.Join(commandLines, "\n")+"\n"); err != nil {
+		return errors.Wrapf(err, "Could not send command")
+	}
+
+	prompts := 0
+	moreAnswered := false
+	echo := strings.TrimSpace(commandLines[0])
+	buf := make([]byte, 4096)
+	for {
+		for {
+			newline := strings.IndexByte(s.pending, '\n')
+			if newline < 0 {
+				break
+			}
+			line := s.pending[:newline]
+			s.pending = s.pending[newline+1:]
+			moreAnswered = false
+
+			line = escapeRegexp.ReplaceAllString(line, "")
+			line = strings.Replace(moreRegexp.ReplaceAllString(line, ""), "\x08", "", -1)
+			if authExpiredRegexp.MatchString(line) {
+				authenticationExpired.Inc()
+				return errors.New("Authentication Expired")
+			}
+			if s.prompt.MatchString(line) {
+				// The prompt followed by the echo of the next command line.
+				prompts++
+				continue
+			}
+			if echo != "" && strings.TrimSpace(line) == echo {
+				echo = ""
+				continue
+			}
+			echo = ""
+
+			select {
+			case output <- line:
+			case <-abort:
+				return nil
+			}
+		}
+
+		if !moreAnswered && moreAtEndRegexp.MatchString(s.received()) {
+			// The paginator is still active, request the next page. The line is cleaned up once it is complete.
+			moreAnswered = true
+			io.WriteString(s.writer, " ")
+		} else if s.promptAtEnd.MatchString(s.received()) && prompts+1 >= len(commandLines) {
+			s.pending = ""
+			return nil
+		}
+
+		if err := s.read(buf); err != nil {
+			if err == io.EOF {
+				return errors.New("Reached EOF")
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package connector
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// runTranscript replays a transcript one byte at a time, learns the prompt and runs command.
+func runTranscript(t *testing.T, transcript string, command string) ([]string, string, error) {
+	written := &bytes.Buffer{}
+	session := newCLISession(iotest.OneByteReader(strings.NewReader(transcript)), written)
+	if _, err := session.learnPrompt(); err != nil {
+		t.Fatalf("Could not learn the prompt: %v", err)
+	}
+
+	output := make(chan string)
+	result := make(chan error, 1)
+	go func() {
+		result <- session.run(command, output, make(chan struct{}))
+		close(output)
+	}()
+
+	lines := make([]string, 0)
+	for line := range output {
+		lines = append(lines, line)
+	}
+	return lines, written.String(), <-result
+}
+
+func TestCLITranscripts(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+		command    string
+		expected   []string
+		written    string
+	}{
+		{
+			name:       "ios banner and timestamp in output",
+			transcript: iosShowVersion,
+			command:    "show version",
+			expected: []string{
+				"Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M), Version 12.2(55)SE12, RELEASE SOFTWARE (fc2)",
+				"Technical Support: http://www.cisco.com/techsupport",
+				"",
+				"*10:00:00.000 UTC Sun Oct 18 2026: uptime counters reset",
+				"router uptime is 1 week, 2 days, 3 hours, 4 minutes",
+			},
+			written: "show version\n",
+		},
+		{
+			name:       "ios multi-line command",
+			transcript: iosMultiLine,
+			command:    "terminal shell\nterminal length 0",
+			expected:   []string{},
+			written:    "terminal shell\nterminal length 0\n",
+		},
+		{
+			name:       "ios paginator",
+			transcript: iosMore,
+			command:    "show running-config | include interface",
+			expected: []string{
+				"interface GigabitEthernet0/1",
+				"interface GigabitEthernet0/2",
+				"interface GigabitEthernet0/3",
+			},
+			written: "show running-config | include interface\n ",
+		},
+		{
+			name:       "nxos paginator with escape sequences",
+			transcript: nxosMore,
+			command:    "show interface brief",
+			expected: []string{
+				"",
+				"Port   VRF          Status IP Address                              Speed    MTU",
+				"mgmt0  --           up     192.0.2.10                              1000     1500",
+			},
+			written: "show interface brief\n ",
+		},
+		{
+			name:       "ios-xr",
+			transcript: xrShowClock,
+			command:    "show clock",
+			expected: []string{
+				"Sun Oct 18 10:00:00.000 UTC",
+				"10:00:00.000 UTC Sun Oct 18 2026",
+			},
+			written: "show clock\n",
+		},
+		{
+			name:       "asa changeto context with paginator",
+			transcript: asaChangetoContext,
+			command:    "changeto context customer-a\nshow conn count\nchangeto context admin",
+			expected: []string{
+				"12 in use, 345 most used",
+			},
+			written: "changeto context customer-a\nshow conn count\nchangeto context admin\n ",
+		},
+		{
+			name:       "asa changeto context after a system login",
+			transcript: asaSystemChangetoContext,
+			command:    "changeto context customer-a\nshow conn count\nchangeto system",
+			expected: []string{
+				"12 in use, 345 most used",
+			},
+			written: "changeto context customer-a\nshow conn count\nchangeto system\n",
+		},
+	}
+
+	for _, test := range tests {
+		lines, written, err := runTranscript(t, test.transcript, test.command)
+		if err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.name, err)
+		}
+		if !reflect.DeepEqual(lines, test.expected) {
+			t.Errorf("%s: Expected output %q, got %q", test.name, test.expected, lines)
+		}
+		if written != test.written {
+			t.Errorf("%s: Expected %q to be sent, got %q", test.name, test.written, written)
+		}
+	}
+}
+
+func TestCLILoginContext(t *testing.T) {
+	tests := []struct {
+		transcript string
+		expected   string
+	}{
+		{transcript: asaChangetoContext, expected: "admin"},
+		{transcript: asaSystemChangetoContext, expected: ""},
+		{transcript: iosShowVersion, expected: ""},
+	}
+
+	for _, test := range tests {
+		session := newCLISession(strings.NewReader(test.transcript), &bytes.Buffer{})
+		if _, err := session.learnPrompt(); err != nil {
+			t.Fatalf("Could not learn the prompt: %v", err)
+		}
+		if session.loginContext != test.expected {
+			t.Errorf("Expected login context %q, got %q", test.expected, session.loginContext)
+		}
+	}
+}
+
+func TestNewSSHCommandContextInSecurityContext(t *testing.T) {
+	tests := []struct {
+		loginContext string
+		expected     string
+	}{
+		{loginContext: "", expected: "changeto context customer-a\nshow conn count\nchangeto system"},
+		{loginContext: "admin", expected: "changeto context customer-a\nshow conn count\nchangeto context admin"},
+	}
+
+	for _, test := range tests {
+		info := &ConnectionInfo{SecurityContext: test.loginContext}
+		sshCtx := NewSSHCommandContextInSecurityContext(info, "customer-a", "show conn count")
+		if sshCtx.Command != test.expected {
+			t.Errorf("Expected command %q after logging into %q, got %q", test.expected, test.loginContext, sshCtx.Command)
+		}
+		if strings.Contains(sshCtx.template(), "customer-a") {
+			t.Errorf("Expected the security context not to be part of the template, got %q", sshCtx.template())
+		}
+	}
+	if sshCtx := NewSSHCommandContextInSecurityContext(&ConnectionInfo{}, "", "show conn count"); sshCtx.Command != "show conn count" {
+		t.Errorf("Expected the command to run in the current context, got %q", sshCtx.Command)
+	}
+}
+
+func TestCLIAuthenticationExpired(t *testing.T) {
+	_, _, err := runTranscript(t, iosAuthenticationExpired, "show clock")
+	if err == nil {
+		t.Errorf("Expected an error for expired authentication")
+	}
+}
+
+func TestCLIUnknownPrompt(t *testing.T) {
+	session := newCLISession(strings.NewReader(""), &bytes.Buffer{})
+	if err := session.run("show clock", make(chan string), make(chan struct{})); err == nil {
+		t.Errorf("Expected an error if the prompt was not learned")
+	}
+}
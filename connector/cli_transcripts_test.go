@@ -0,0 +1,75 @@
+package connector
+
+// Transcripts recorded from SSH sessions with Cisco devices, starting with the banner sent after login.
+// Hostnames and serial numbers have been replaced.
+
+const iosShowVersion = "\r\n" +
+	"*****************************************************\r\n" +
+	"* Unauthorized access to this device is prohibited! #\r\n" +
+	"*****************************************************\r\n" +
+	"\r\n" +
+	"router>" +
+	"show version\r\n" +
+	"Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M), Version 12.2(55)SE12, RELEASE SOFTWARE (fc2)\r\n" +
+	"Technical Support: http://www.cisco.com/techsupport\r\n" +
+	"\r\n" +
+	"*10:00:00.000 UTC Sun Oct 18 2026: uptime counters reset\r\n" +
+	"router uptime is 1 week, 2 days, 3 hours, 4 minutes\r\n" +
+	"router>"
+
+const iosMultiLine = "\r\n" +
+	"router#" +
+	"terminal shell\r\n" +
+	"router#terminal length 0\r\n" +
+	"router#"
+
+const iosMore = "\r\n" +
+	"router#" +
+	"show running-config | include interface\r\n" +
+	"interface GigabitEthernet0/1\r\n" +
+	"interface GigabitEthernet0/2\r\n" +
+	" --More-- \x08\x08\x08\x08\x08\x08\x08\x08\x08\x08          \x08\x08\x08\x08\x08\x08\x08\x08\x08\x08" +
+	"interface GigabitEthernet0/3\r\n" +
+	"router#"
+
+const nxosMore = "\r\n" +
+	"Cisco Nexus Operating System (NX-OS) Software\r\n" +
+	"TAC support: http://www.cisco.com/tac\r\n" +
+	"nexus-01# " +
+	"show interface brief\r\n" +
+	"\r\n" +
+	"Port   VRF          Status IP Address                              Speed    MTU\r\n" +
+	"\x1b[7m--More--\x1b[m\r\x1b[K" +
+	"mgmt0  --           up     192.0.2.10                              1000     1500\r\n" +
+	"nexus-01# "
+
+const xrShowClock = "\r\n" +
+	"RP/0/RSP0/CPU0:xr-router#" +
+	"show clock\r\n" +
+	"Sun Oct 18 10:00:00.000 UTC\r\n" +
+	"10:00:00.000 UTC Sun Oct 18 2026\r\n" +
+	"RP/0/RSP0/CPU0:xr-router#"
+
+const asaChangetoContext = "\r\n" +
+	"Type help or '?' for a list of available commands.\r\n" +
+	"fw/admin# " +
+	"changeto context customer-a\r\n" +
+	"fw/customer-a# show conn count\r\n" +
+	"12 in use, 345 most used\r\n" +
+	"<--- More --->\r              \r" +
+	"fw/customer-a# changeto context admin\r\n" +
+	"fw/admin# "
+
+const asaSystemChangetoContext = "\r\n" +
+	"fw# " +
+	"changeto context customer-a\r\n" +
+	"fw/customer-a# show conn count\r\n" +
+	"12 in use, 345 most used\r\n" +
+	"fw/customer-a# changeto system\r\n" +
+	"fw# "
+
+const iosAuthenticationExpired = "\r\n" +
+	"router#" +
+	"show clock\r\n" +
+	"% Authentication expired\r\n" +
+	"router#"
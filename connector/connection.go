@@ -1,12 +1,9 @@
 package connector
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"io"
 	"net"
-	"regexp"
-	"strings"
 	"sync"
 	"time"
 
@@ -20,15 +17,20 @@ import (
 )
 
 // SSHConnection wraps an *ssh.Client and provides functions for executing commands on the remote device.
+// Devices using the telnet transport are logged into using Telnet instead, their sshClient is nil.
 type SSHConnection struct {
-	stdout              io.Reader
-	stdin               io.WriteCloser
+	cli                 *cliSession
 	sshClient           *ssh.Client
 	mu                  sync.Mutex
 	transportConnection net.Conn
-	Target              string
-	Device              *config.DeviceGroupConfig
+	tunnel              *tunnel
+	connectionManager   *SSHConnectionManager
 	done                chan struct{}
+	// exec is set if commands are run in exec channels, see max_sessions.
+	exec *execSessions
+	// transport is ssh or telnet.
+	transport string
+	ConnectionInfo
 }
 
 // SSHCommandContext provides context for running a command on the remote device.
@@ -37,10 +39,13 @@ type SSHConnection struct {
 // Once the command finished execution, an empty struct is written to the Done chan.
 type SSHCommandContext struct {
 	Command string
-	Output  chan string
-	Errors  chan error
-	Done    chan struct{}
-	Timeout int
+	// Template is the command without arguments naming an entity, like `show interface <name>`,
+	// which labels the command metrics instead of Command. Command is used if it is empty.
+	Template string
+	Output   chan string
+	Errors   chan error
+	Done     chan struct{}
+	Timeout  int
 }
 
 // NewSSHCommandContext initializes the channels and returns a new SSHCommandContext.
@@ -53,6 +58,30 @@ func NewSSHCommandContext(command string) *SSHCommandContext {
 	}
 }
 
+// NewSSHCommandContextInSecurityContext returns a new SSHCommandContext running command in a security context of an ASA
+// in multiple context mode using `changeto context`. Afterwards it changes back to the context the session of info
+// logged into, so that other commands keep running there. If securityContext is empty, command runs in the current context.
+func NewSSHCommandContextInSecurityContext(info *ConnectionInfo, securityContext string, command string) *SSHCommandContext {
+	if securityContext == "" {
+		return NewSSHCommandContext(command)
+	}
+	changeBack, changeBackTemplate := "changeto system", "changeto system"
+	if info.SecurityContext != "" {
+		changeBack, changeBackTemplate = "changeto context "+info.SecurityContext, "changeto context <context>"
+	}
+	sshCtx := NewSSHCommandContext("changeto context " + securityContext + "\n" + command + "\n" + changeBack)
+	sshCtx.Template = "changeto context <context>\n" + command + "\n" + changeBackTemplate
+	return sshCtx
+}
+
+// template returns the command labelling the command metrics.
+func (ctx *SSHCommandContext) template() string {
+	if ctx.Template != "" {
+		return ctx.Template
+	}
+	return ctx.Command
+}
+
 // IgnoreOutputs ignores the outputs received from an SSHCommandContext and logs erros to the CLI.
 func (ctx *SSHCommandContext) IgnoreOutputs() {
 	go func() {
@@ -69,23 +98,13 @@ func (ctx *SSHCommandContext) IgnoreOutputs() {
 	}()
 }
 
-// IsConnected returns whether the SSHConnection is still up and the remote end connected.
-func (conn *SSHConnection) IsConnected() bool {
-	return conn.transportConnection != nil
-}
-
-// IsAuthenticated tests if the authentication for this SSH Session has not yet expired
-func (conn *SSHConnection) IsAuthenticated() bool {
-	sshCtx := NewSSHCommandContext("")
-	sshCtx.Timeout = 2
-	go conn.RunCommand(sshCtx)
-
-	var lastErr error = nil
-
+// lastError waits for the command of sshCtx to finish, discarding its output, and returns its last error.
+func lastError(sshCtx *SSHCommandContext) error {
+	var lastErr error
 	for {
 		select {
 		case <-sshCtx.Done:
-			return lastErr == nil
+			return lastErr
 		case lastErr = <-sshCtx.Errors:
 			continue
 		case <-sshCtx.Output:
@@ -94,33 +113,27 @@ func (conn *SSHConnection) IsAuthenticated() bool {
 	}
 }
 
-// IdentifyOSVersion fingerprints the remote operating system.
-func (conn *SSHConnection) IdentifyOSVersion() (config.OSVersion, error) {
-	sshCtx := NewSSHCommandContext("show version")
+// IsConnected returns whether the SSHConnection is still up and the remote end connected.
+func (conn *SSHConnection) IsConnected() bool {
+	return conn.transportConnection != nil && conn.tunnel.isAlive()
+}
+
+// IsAuthenticated tests if the authentication for this SSH Session has not yet expired
+func (conn *SSHConnection) IsAuthenticated() bool {
+	sshCtx := NewSSHCommandContext("")
 	sshCtx.Timeout = 2
-	go conn.RunCommand(sshCtx)
+	go conn.RunCommand(context.Background(), sshCtx)
 
 	var lastErr error = nil
 
-	detectedVersion := config.INVALID
-	versions := map[string]config.OSVersion{
-		"IOS XE":       config.IOSXE,
-		"NX-OS":        config.NXOS,
-		"IOS Software": config.IOS,
-	}
-
 	for {
 		select {
 		case <-sshCtx.Done:
-			return detectedVersion, lastErr
-		case line := <-sshCtx.Output:
-			for fingerprint, version := range versions {
-				if strings.Contains(line, fingerprint) && detectedVersion == config.INVALID {
-					detectedVersion = version
-				}
-			}
+			return lastErr == nil
 		case lastErr = <-sshCtx.Errors:
 			continue
+		case <-sshCtx.Output:
+			continue
 		}
 	}
 }
@@ -128,11 +141,16 @@ func (conn *SSHConnection) IdentifyOSVersion() (config.OSVersion, error) {
 // DisablePagination disables the paginator on the remote end.
 // This is required to parse the whole output of a command.
 // Note that for `terminal length 0` certain privileges are required on the remote device.
+// ASAs use `terminal pager 0` instead.
 func (conn *SSHConnection) DisablePagination() error {
-	sshCtx := NewSSHCommandContext("terminal shell\nterminal length 0")
+	command := "terminal shell\nterminal length 0"
+	if conn.DeviceInfo.OSVersion == config.ASA {
+		command = "terminal pager 0"
+	}
+	sshCtx := NewSSHCommandContext(command)
 	sshCtx.Timeout = 2
 	var lastErr error = nil
-	go conn.RunCommand(sshCtx)
+	go conn.RunCommand(context.Background(), sshCtx)
 
 	for {
 		select {
@@ -147,81 +165,80 @@ func (conn *SSHConnection) DisablePagination() error {
 }
 
 // RunCommand runs a command on the remote device. All events / outputs are received to the provided context.
-func (conn *SSHConnection) RunCommand(ctx *SSHCommandContext) {
+// The output of the command ends once the prompt of the remote device reappears.
+// If ctx is done before, Done is signaled right away. The remaining output is discarded until the prompt reappears,
+// so the session can be used for the next command.
+// If max_sessions is set, single commands run in their own exec channel instead, without waiting for other commands.
+func (conn *SSHConnection) RunCommand(ctx context.Context, sshCtx *SSHCommandContext) {
+	sshCtx = record(ctx, sshCtx)
+	defer observeCommand(ctx, sshCtx.template(), time.Now())
+	if conn.exec != nil && conn.exec.supports(sshCtx.Command) && conn.runExec(ctx, sshCtx) {
+		return
+	}
+
 	conn.mu.Lock()
 	defer conn.mu.Unlock()
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
 
-	if ctx.Timeout == 0 {
-		ctx.Timeout = conn.Device.CommandTimeout
+	doneSignaled := false
+	signalDone := func() {
+		if !doneSignaled {
+			doneSignaled = true
+			sshCtx.Done <- struct{}{}
+		}
 	}
-
-	if conn.transportConnection == nil {
-		ctx.Errors <- errors.New(fmt.Sprintf("Cannot run command '%s' on target '%s': Not connected.", ctx.Command, conn.Target))
-		return
+	defer signalDone()
+	sendError := func(err error) {
+		select {
+		case sshCtx.Errors <- err:
+		case <-ctx.Done():
+		}
 	}
 
-	reader := bufio.NewReader(conn.stdout)
-	if ctx.Command == "" {
-		ctx.Command = "show clock\n"
-	} else {
-		ctx.Command = ctx.Command + " ; show clock\n"
+	if sshCtx.Timeout == 0 {
+		sshCtx.Timeout = conn.Device().CommandTimeout
 	}
-	io.WriteString(conn.stdin, ctx.Command)
-
-	errorChan := make(chan error)
-	scannerDone := make(chan struct{}, 1)
-	abortSignal := false
-	go conn.scanLines(scannerDone, &abortSignal, reader, ctx.Output, errorChan)
 
-	select {
-	case err := <-errorChan:
-		ctx.Errors <- errors.Wrapf(err, "Error reading from stdout: %v", err)
-		abortSignal = true
-		conn.terminate()
-		return
-	case <-scannerDone:
-		return
-	case <-time.After(time.Duration(ctx.Timeout) * time.Second):
-		ctx.Errors <- errors.New(fmt.Sprintf("Timeout reached for '%s' on %s", ctx.Command, conn.Target))
-		abortSignal = true
-		conn.terminate()
+	if conn.transportConnection == nil {
+		sendError(errors.New(fmt.Sprintf("Cannot run command '%s' on target '%s': Not connected.", sshCtx.Command, conn.Target)))
 		return
 	}
-}
 
-func (conn *SSHConnection) scanLines(done chan struct{}, abortSignal *bool, reader *bufio.Reader, output chan<- string, errorChan chan error) {
-	defer func() {
-		done <- struct{}{}
-		reader.Reset(nil)
+	abort := make(chan struct{})
+	output := make(chan string)
+	result := make(chan error, 1)
+	go func() {
+		result <- conn.cli.run(sshCtx.Command, output, abort)
 	}()
-	cmdLineRegex := regexp.MustCompile(`^[\*\.]?\d{2}:\d{2}:\d{2}.\d{3}`)
-	authenticationRegexp := regexp.MustCompile(`[aA]uthentication [eE]xpired`)
-	scanner := bufio.NewScanner(reader)
-	scanner.Split(bufio.ScanLines)
 
+	timeout := time.After(time.Duration(sshCtx.Timeout) * time.Second)
+	cancelled := ctx.Done()
 	for {
-		if !scanner.Scan() {
-			if err := scanner.Err(); err != nil {
-				errorChan <- errors.Wrapf(err, "Scanner received err: %v", err)
-			} else {
-				errorChan <- errors.New("Scanner reached EOF")
+		select {
+		case line := <-output:
+			if doneSignaled {
+				continue
+			}
+			select {
+			case sshCtx.Output <- line:
+			case <-ctx.Done():
+			}
+		case <-cancelled:
+			log.Debugf("Command '%s' on %s was cancelled, discarding its remaining output", sshCtx.Command, conn.Target)
+			cancelled = nil
+			signalDone()
+		case err := <-result:
+			if err != nil {
+				sendError(errors.Wrapf(err, "Error reading from stdout: %v", err))
+				conn.terminate()
 			}
 			return
-		}
-		line := scanner.Text()
-		if cmdLineRegex.MatchString(line) {
-			return
-		} else if authenticationRegexp.MatchString(line) {
-			errorChan <- errors.New("Authentication Expired")
+		case <-timeout:
+			close(abort)
+			commandTimedOut(ctx, sshCtx.template())
+			sendError(errors.New(fmt.Sprintf("Timeout reached for '%s' on %s", sshCtx.Command, conn.Target)))
+			conn.terminate()
 			return
 		}
-		if *abortSignal {
-			return
-		}
-		output <- line
 	}
 }
 
@@ -236,8 +253,16 @@ func (conn *SSHConnection) terminate() {
 	if conn.transportConnection == nil {
 		return
 	}
-	conn.sshClient.Close()
+	if conn.sshClient != nil {
+		conn.sshClient.Close()
+	}
 	conn.transportConnection.Close()
 	conn.sshClient = nil
 	conn.transportConnection = nil
+	close(conn.done)
+	openConnections.WithLabelValues(conn.transport).Dec()
+	if conn.tunnel != nil {
+		conn.connectionManager.releaseTunnel(conn.tunnel)
+		conn.tunnel = nil
+	}
 }
@@ -1,10 +1,10 @@
 package connector
 
 import (
+	"bufio"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net"
-	"os"
 	"strconv"
 	"sync"
 	"time"
@@ -44,23 +44,35 @@ func WithKeepAliveTimeout(duration time.Duration) Option {
 	}
 }
 
-// SSHConnectionManager provides means of establishing and maintaining an SSH Connection to a remote deivce.
+// SSHConnectionManager provides means of establishing and maintaining a Connection to a remote deivce,
+// which is an interactive SSH or Telnet session unless NX-API or NETCONF is selected as transport.
 // SSH Connections are intentionally left open as long as possible, to reduce the number of logged logins as well as load on the TACACS server and the remote device.
 type SSHConnectionManager struct {
-	connections       map[string]*SSHConnection
-	connectionsMutex  sync.Mutex
+	connections      map[string]Connection
+	connectionsMutex sync.Mutex
+	// lastErrors holds the error of the last failed connection attempt by target, until a connection is established.
+	lastErrors        map[string]error
 	reconnectInterval time.Duration
 	keepAliveInterval time.Duration
 	keepAliveTimeout  time.Duration
 	mutexesMutex      sync.Mutex
-	mutexes           map[string]sync.Mutex
+	mutexes           map[string]*sync.Mutex
+	tunnelsMutex      sync.Mutex
+	tunnels           map[string]*tunnel
+	// tunnelDials holds the tunnels being dialed, closed once the dial finished.
+	tunnelDials    map[string]chan struct{}
+	tunnelFailures map[string]time.Time
 }
 
 // NewConnectionManager applies the specified options and returns a new SSHConnectionManager
 func NewConnectionManager(options ...Option) *SSHConnectionManager {
 	connectionManager := &SSHConnectionManager{
-		connections:       make(map[string]*SSHConnection),
-		mutexes:           make(map[string]sync.Mutex),
+		connections:       make(map[string]Connection),
+		lastErrors:        make(map[string]error),
+		mutexes:           make(map[string]*sync.Mutex),
+		tunnels:           make(map[string]*tunnel),
+		tunnelDials:       make(map[string]chan struct{}),
+		tunnelFailures:    make(map[string]time.Time),
 		reconnectInterval: 30 * time.Second,
 		keepAliveInterval: 15 * time.Second,
 		keepAliveTimeout:  15 * time.Second,
@@ -73,16 +85,16 @@ func NewConnectionManager(options ...Option) *SSHConnectionManager {
 	return connectionManager
 }
 
-// GetConnection returns an SSHConnection to the given device.
+// GetConnection returns a Connection to the given device.
 // If the connection has not yet been established (or lost), establishing a connection is attempted.
 // In case of error nil and the error are returned.
-func (connMan *SSHConnectionManager) GetConnection(target string, deviceGroup *config.DeviceGroupConfig) (*SSHConnection, error) {
+func (connMan *SSHConnectionManager) GetConnection(target string, deviceGroup *config.DeviceGroupConfig) (Connection, error) {
 	connMan.mutexesMutex.Lock()
-	_, found := connMan.mutexes[target]
+	mutex, found := connMan.mutexes[target]
 	if !found {
-		connMan.mutexes[target] = sync.Mutex{}
+		mutex = &sync.Mutex{}
+		connMan.mutexes[target] = mutex
 	}
-	mutex, _ := connMan.mutexes[target]
 	connMan.mutexesMutex.Unlock()
 
 	mutex.Lock()
@@ -96,44 +108,239 @@ func (connMan *SSHConnectionManager) GetConnection(target string, deviceGroup *c
 	if found {
 		if !connection.IsConnected() {
 			log.Errorf("Connection to '%s' was lost, reconnecting.", target)
-			connection, err = connMan.establishConnection(target, deviceGroup)
+			reconnects.WithLabelValues(transportName(deviceGroup)).Inc()
+			connection, err = connMan.connect(target, deviceGroup)
 		} else if !connection.IsAuthenticated() {
 			log.Errorf("Connection to '%s' is no longer authenticated, reconnecting.", target)
+			reconnects.WithLabelValues(transportName(deviceGroup)).Inc()
 			connection.Terminate()
-			connection, err = connMan.establishConnection(target, deviceGroup)
+			connection, err = connMan.connect(target, deviceGroup)
 		} else {
 			return connection, nil
 		}
-		if err != nil {
-			return nil, err
-		}
-		connMan.connectionsMutex.Lock()
-		connMan.connections[target] = connection
-		connMan.connectionsMutex.Unlock()
 	} else {
-		connection, err = connMan.establishConnection(target, deviceGroup)
-		if err != nil {
-			return nil, err
-		}
+		connection, err = connMan.connect(target, deviceGroup)
+	}
+
+	connMan.connectionsMutex.Lock()
+	defer connMan.connectionsMutex.Unlock()
+	if err != nil {
+		connMan.lastErrors[target] = err
+		return nil, err
+	}
+	if connection == nil {
+		err = errors.Errorf("No connection to '%s' was established", target)
+		log.Error(err)
+		connMan.lastErrors[target] = err
+		return nil, err
+	}
+	connection.Info().Established = time.Now()
+	connMan.connections[target] = connection
+	delete(connMan.lastErrors, target)
+	return connection, nil
+}
+
+// ConnectionState describes the connection to a target.
+type ConnectionState struct {
+	// Connected is set if commands can be run on the connection.
+	Connected bool
+	// Info is a copy of what is known about the connected device, nil if no connection was established yet.
+	Info *ConnectionInfo
+	// LastError is the error of the last failed connection attempt, nil if the last attempt succeeded.
+	LastError error
+}
+
+// ConnectionState returns the state of the connection to the target.
+func (connMan *SSHConnectionManager) ConnectionState(target string) ConnectionState {
+	connMan.connectionsMutex.Lock()
+	defer connMan.connectionsMutex.Unlock()
+
+	state := ConnectionState{LastError: connMan.lastErrors[target]}
+	if connection, found := connMan.connections[target]; found {
+		info := *connection.Info()
+		state.Info = &info
+		state.Connected = connection.IsConnected()
+	}
+	return state
+}
+
+// Close terminates the connection to the target and forgets about it, e.g. once a dynamic target is no longer scraped.
+func (connMan *SSHConnectionManager) Close(target string) {
+	connMan.mutexesMutex.Lock()
+	mutex, found := connMan.mutexes[target]
+	connMan.mutexesMutex.Unlock()
+	if !found {
+		return
+	}
+
+	mutex.Lock()
+	connMan.connectionsMutex.Lock()
+	connection, found := connMan.connections[target]
+	delete(connMan.connections, target)
+	delete(connMan.lastErrors, target)
+	connMan.connectionsMutex.Unlock()
+	mutex.Unlock()
+
+	if found {
+		connection.Terminate()
+	}
+}
+
+// UpdateDevices applies a reloaded configuration to the established connections.
+// Connections to devices which are no longer configured or whose connection parameters changed are terminated.
+// The other connections are kept and use the new device group configuration from now on.
+func (connMan *SSHConnectionManager) UpdateDevices(configuration *config.Config) {
+	connMan.connectionsMutex.Lock()
+	targets := make([]string, 0, len(connMan.connections))
+	for target := range connMan.connections {
+		targets = append(targets, target)
+	}
+	connMan.connectionsMutex.Unlock()
+
+	for _, target := range targets {
+		connMan.mutexesMutex.Lock()
+		mutex := connMan.mutexes[target]
+		connMan.mutexesMutex.Unlock()
+
+		mutex.Lock()
 		connMan.connectionsMutex.Lock()
-		connMan.connections[target] = connection
+		connection := connMan.connections[target]
+		deviceGroup := configuration.GetDeviceGroup(target)
+		keep := deviceGroup != nil && connection.Info().Device().SameConnection(deviceGroup)
+		if keep {
+			connection.Info().setDevice(deviceGroup)
+		} else {
+			delete(connMan.connections, target)
+		}
 		connMan.connectionsMutex.Unlock()
+		mutex.Unlock()
+
+		if !keep {
+			// Commands still running on the connection are finished first.
+			log.Infof("Configuration of '%s' changed, closing its connection.", target)
+			connection.Terminate()
+		}
 	}
-	if connection == nil {
-		log.Error("bleep hang")
+}
+
+// transportName returns the transport configured for the device, SSH if none is set.
+func transportName(device *config.DeviceGroupConfig) string {
+	if device.Transport == "" {
+		return config.TransportSSH
+	}
+	return device.Transport
+}
+
+// connect establishes a connection using the transport configured for the device and counts the attempt.
+func (connMan *SSHConnectionManager) connect(target string, device *config.DeviceGroupConfig) (Connection, error) {
+	transport := transportName(device)
+	connectionAttempts.WithLabelValues(transport).Inc()
+	connection, err := connMan.connectUsing(target, device)
+	if err != nil {
+		connectionFailures.WithLabelValues(transport, FailureReason(err)).Inc()
+		return nil, err
+	}
+	return connection, nil
+}
+
+func (connMan *SSHConnectionManager) connectUsing(target string, device *config.DeviceGroupConfig) (Connection, error) {
+	switch device.Transport {
+	case config.TransportNXAPI:
+		return connMan.establishNXAPIConnection(target, device)
+	case config.TransportNETCONF:
+		return connMan.establishNETCONFConnection(target, device)
+	case config.TransportTelnet:
+		return connMan.establishTelnetConnection(target, device)
 	}
-	return connection, err
+	return connMan.establishConnection(target, device)
+}
+
+func (connMan *SSHConnectionManager) establishNXAPIConnection(target string, device *config.DeviceGroupConfig) (*NXAPIConnection, error) {
+	connection, err := newNXAPIConnection(target, device)
+	if err != nil {
+		return nil, newConnectError(target, ReasonDial, err)
+	}
+
+	// Fingerprinting verifies NX-API is reachable and accepts the credentials.
+	info, err := identifyDevice(connection, device.CommandTimeout)
+	if err != nil {
+		connection.Terminate()
+		reason := ReasonDial
+		if errors.Cause(err) == errNXAPIUnauthorized {
+			reason = ReasonAuth
+		}
+		return nil, newConnectError(target, reason, errors.Wrapf(err, "Could not reach NX-API of '%s'", target))
+	}
+	connection.DeviceInfo = info
+	if device.OSVersion != config.INVALID {
+		connection.DeviceInfo.OSVersion = device.OSVersion
+	}
+
+	log.Infof("Established an NX-API connection with '%s'", target)
+	return connection, nil
+}
+
+func (connMan *SSHConnectionManager) establishNETCONFConnection(target string, device *config.DeviceGroupConfig) (*NETCONFConnection, error) {
+	sshClient, transportConnection, jumpTunnel, err := connMan.makeSSHClient(target, device)
+	if err != nil {
+		return nil, err
+	}
+
+	sshSession, err := sshClient.NewSession()
+	if err != nil {
+		sshClient.Close()
+		connMan.releaseTunnel(jumpTunnel)
+		return nil, newConnectError(target, ReasonSession, errors.Wrapf(err, "Could not open a new session for '%s'", target))
+	}
+	stdin, _ := sshSession.StdinPipe()
+	stdout, _ := sshSession.StdoutPipe()
+
+	connection := &NETCONFConnection{
+		sshClient:           sshClient,
+		transportConnection: transportConnection,
+		tunnel:              jumpTunnel,
+		connectionManager:   connMan,
+		reader:              bufio.NewReader(newCountingReader(stdout, config.TransportNETCONF)),
+		writer:              stdin,
+		done:                make(chan struct{}),
+		ConnectionInfo:      ConnectionInfo{Target: target, device: device, PrivilegeLevel: -1},
+	}
+	openConnections.WithLabelValues(config.TransportNETCONF).Inc()
+	if err := sshSession.RequestSubsystem("netconf"); err != nil {
+		connection.Terminate()
+		return nil, newConnectError(target, ReasonSession, errors.Wrapf(err, "Could not start the NETCONF subsystem on '%s'", target))
+	}
+
+	// Connections tunneled through a jump host do not support deadlines, the error is therefore ignored.
+	transportConnection.SetDeadline(time.Now().Add(time.Duration(device.ConnectTimeout) * time.Second))
+	err = connection.hello()
+	transportConnection.SetDeadline(time.Time{})
+	if err != nil {
+		connection.Terminate()
+		return nil, newConnectError(target, ReasonSession, errors.Wrapf(err, "Could not establish a NETCONF session with '%s'", target))
+	}
+
+	connection.DeviceInfo, err = connection.identifyDevice()
+	if err != nil {
+		connection.Terminate()
+		return nil, newConnectError(target, ReasonFingerprint, errors.Wrapf(err, "Could not identify '%s'", target))
+	}
+
+	log.Infof("Established a NETCONF session with '%s'", target)
+	return connection, nil
 }
 
 func (connMan *SSHConnectionManager) establishConnection(target string, device *config.DeviceGroupConfig) (*SSHConnection, error) {
-	sshClient, transportConnection, err := connMan.makeSSHClient(target, device)
+	sshClient, transportConnection, jumpTunnel, err := connMan.makeSSHClient(target, device)
 	if err != nil {
 		return nil, err
 	}
 
 	sshSession, err := sshClient.NewSession()
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not open a new session for '%s'", target)
+		sshClient.Close()
+		connMan.releaseTunnel(jumpTunnel)
+		return nil, newConnectError(target, ReasonSession, errors.Wrapf(err, "Could not open a new session for '%s'", target))
 	}
 
 	stdin, _ := sshSession.StdinPipe()
@@ -150,94 +357,190 @@ func (connMan *SSHConnectionManager) establishConnection(target string, device *
 	sshConnection := &SSHConnection{
 		transportConnection: transportConnection,
 		sshClient:           sshClient,
-		Device:              device,
+		tunnel:              jumpTunnel,
+		connectionManager:   connMan,
+		ConnectionInfo:      ConnectionInfo{Target: target, device: device},
 		done:                make(chan struct{}),
-		stdin:               stdin,
-		stdout:              stdout,
+		cli:                 newCLISession(newCountingReader(stdout, config.TransportSSH), stdin),
+		transport:           config.TransportSSH,
+	}
+	openConnections.WithLabelValues(config.TransportSSH).Inc()
+	if err := connMan.setUpCLI(sshConnection); err != nil {
+		return nil, err
 	}
+
+	log.Infof("Established an SSH connection with '%s'", target)
+	return sshConnection, nil
+}
+
+// setUpCLI prepares the CLI of a freshly logged in connection: it enters privileged EXEC mode, identifies the device,
+// disables the paginator and identifies the privilege level. The connection is terminated if this fails.
+func (connMan *SSHConnectionManager) setUpCLI(sshConnection *SSHConnection) error {
+	target, device := sshConnection.Target, sshConnection.Device()
 	go connMan.keepAlive(sshConnection)
 
+	enabled, err := sshConnection.Enable()
+	if err != nil {
+		sshConnection.Terminate()
+		return newConnectError(target, ReasonPrivilege, errors.Wrapf(err, "Could not enter privileged EXEC mode on '%s'", target))
+	}
+
+	// The paginator depends on the OS, `show version` is paged through.
+	if device.OSVersion != config.INVALID {
+		sshConnection.DeviceInfo = DeviceInfo{OSVersion: device.OSVersion, IdentifiedAt: time.Now()}
+	} else {
+		sshConnection.DeviceInfo, err = identifyDevice(sshConnection, 2)
+		if err != nil {
+			sshConnection.Terminate()
+			return newConnectError(target, ReasonFingerprint, errors.Wrapf(err, "Could not identify os version on '%s'", target))
+		}
+	}
 	err = sshConnection.DisablePagination()
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not disable pagination on '%s': %v", target, err)
+		sshConnection.Terminate()
+		return newConnectError(target, ReasonPagination, errors.Wrapf(err, "Could not disable pagination on '%s'", target))
 	}
-	device.OSVersion, err = sshConnection.IdentifyOSVersion()
+	privilegeLevel, err := sshConnection.IdentifyPrivilegeLevel()
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not identify os version on '%s': %s", target, err)
+		log.Errorf("Could not identify the privilege level on '%s': %v", target, err)
+	}
+	if enabled && privilegeLevel < PrivilegeLevelEnabled {
+		sshConnection.Terminate()
+		return newConnectError(target, ReasonPrivilege, fmt.Errorf("Privilege level on '%s' is %d after enable", target, privilegeLevel))
 	}
 
-	log.Infof("Established an SSH connection with '%s'", target)
-	return sshConnection, nil
+	if device.MaxSessions > 0 && sshConnection.sshClient != nil {
+		if enabled {
+			log.Infof("Not using exec channels on '%s', as they would not be in privileged EXEC mode", target)
+		} else {
+			sshConnection.exec = newExecSessions(sshConnection.sshClient, device.MaxSessions)
+		}
+	}
+	return nil
 }
 
-func (connMan *SSHConnectionManager) makeSSHClient(target string, device *config.DeviceGroupConfig) (*ssh.Client, net.Conn, error) {
-	clientConfig, err := connMan.makeSSHConfig(device)
-	if err != nil {
-		return nil, nil, err
+// establishTelnetConnection logs into the CLI using Telnet. Apart from the login, the session is handled like an SSH session.
+func (connMan *SSHConnectionManager) establishTelnetConnection(target string, device *config.DeviceGroupConfig) (*SSHConnection, error) {
+	timeout := time.Duration(device.ConnectTimeout) * time.Second
+	var jumpTunnel *tunnel
+	if len(device.ProxyJump) > 0 {
+		var err error
+		jumpTunnel, err = connMan.acquireTunnel(target, device.ProxyJump, timeout)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	transportConnection, err := net.DialTimeout("tcp", net.JoinHostPort(target, strconv.Itoa(device.Port)), time.Duration(device.ConnectTimeout)*time.Second)
+	address := net.JoinHostPort(target, strconv.Itoa(device.Port))
+	transportConnection, err := jumpTunnel.dial(address, timeout)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, fmt.Sprintf("Could not connect to device '%s'", target))
+		connMan.releaseTunnel(jumpTunnel)
+		return nil, newConnectError(target, ReasonDial, errors.Wrap(err, fmt.Sprintf("Could not connect to device '%s'", target)))
 	}
+	log.Warnf("Credentials for '%s' are sent in clear text using Telnet.", target)
 
-	c, chans, reqs, err := ssh.NewClientConn(transportConnection, target, clientConfig)
+	telnet := newTelnetConn(transportConnection)
+	sshConnection := &SSHConnection{
+		transportConnection: transportConnection,
+		tunnel:              jumpTunnel,
+		connectionManager:   connMan,
+		ConnectionInfo:      ConnectionInfo{Target: target, device: device},
+		done:                make(chan struct{}),
+		cli:                 newCLISession(newCountingReader(telnet, config.TransportTelnet), telnet),
+		transport:           config.TransportTelnet,
+	}
+	openConnections.WithLabelValues(config.TransportTelnet).Inc()
+	err = sshConnection.withTimeout(timeout, func() error {
+		return sshConnection.cli.login(device.Username, device.Password.Value())
+	})
 	if err != nil {
-		return nil, nil, errors.Wrap(err, fmt.Sprintf("Could not establish SSH connection with '%s'", target))
+		sshConnection.Terminate()
+		reason := ReasonSession
+		if err == errLoginFailed {
+			reason = ReasonAuth
+		}
+		return nil, newConnectError(target, reason, errors.Wrapf(err, "Could not log into '%s' using Telnet", target))
+	}
+	if err := connMan.setUpCLI(sshConnection); err != nil {
+		return nil, err
 	}
 
-	client := ssh.NewClient(c, chans, reqs)
-	return client, transportConnection, nil
+	log.Infof("Established a Telnet connection with '%s'", target)
+	return sshConnection, nil
 }
 
-func (connMan *SSHConnectionManager) makeSSHConfig(device *config.DeviceGroupConfig) (*ssh.ClientConfig, error) {
+func (connMan *SSHConnectionManager) makeSSHClient(target string, device *config.DeviceGroupConfig) (*ssh.Client, net.Conn, *tunnel, error) {
 	if device.Port == 0 {
 		device.Port = defaultPort
 	}
+	timeout := time.Duration(device.ConnectTimeout) * time.Second
 
-	var config ssh.ClientConfig
-	config.SetDefaults()
-
-	auth, err := makeAuth(device)
-	config.User = device.Username
-	config.Auth = auth
-	config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
-	config.Ciphers = append(config.Ciphers, "aes128-cbc", "aes256-cbc", "3des-cbc")
-	return &config, err
-}
-
-func makeAuth(device *config.DeviceGroupConfig) ([]ssh.AuthMethod, error) {
-	var authMethods []ssh.AuthMethod
-
-	if device.KeyFile != "" {
-		keyFile, err := os.Open(device.KeyFile)
-		if err != nil {
-			return nil, err
-		}
-		defer keyFile.Close()
-
-		keyFileContents, err := ioutil.ReadAll(keyFile)
+	var jumpTunnel *tunnel
+	if len(device.ProxyJump) > 0 {
+		var err error
+		jumpTunnel, err = connMan.acquireTunnel(target, device.ProxyJump, timeout)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Error reading private key file '%s'", device.KeyFile)
+			return nil, nil, nil, err
 		}
+	}
 
-		key, err := ssh.ParsePrivateKey(keyFileContents)
-		if err != nil {
-			return nil, errors.Wrapf(err, "Error parsing private key file '%s'", device.KeyFile)
-		}
+	address := net.JoinHostPort(target, strconv.Itoa(device.Port))
+	transportConnection, err := jumpTunnel.dial(address, timeout)
+	if err != nil {
+		connMan.releaseTunnel(jumpTunnel)
+		return nil, nil, nil, newConnectError(target, ReasonDial, errors.Wrap(err, fmt.Sprintf("Could not connect to device '%s'", target)))
+	}
 
-		authMethods = append(authMethods, ssh.PublicKeys(key))
+	client, err := connMan.handshake(target, transportConnection, address, &device.AuthConfig, &device.HostKey, timeout)
+	if err != nil {
+		transportConnection.Close()
+		connMan.releaseTunnel(jumpTunnel)
+		return nil, nil, nil, err
 	}
+	return client, transportConnection, jumpTunnel, nil
+}
 
-	if device.Password != "" {
-		authMethods = append(authMethods, ssh.Password(device.Password))
+// handshake establishes an SSH connection with the host at address over the given transport connection.
+func (connMan *SSHConnectionManager) handshake(target string, transportConnection net.Conn, address string, auth *config.AuthConfig, hostKey *config.HostKeyConfig, timeout time.Duration) (*ssh.Client, error) {
+	clientConfig, cleanup, err := connMan.makeSSHConfig(auth)
+	if err != nil {
+		return nil, newConnectError(target, ReasonAuth, err)
+	}
+	defer cleanup()
+	if hostKey.Mode == config.HostKeyInsecure {
+		log.Warnf("Host key of '%s' is not verified. Configure host_key to verify it.", address)
 	}
+	hostKeyVerifier := newHostKeyVerifier(hostKey)
+	clientConfig.HostKeyCallback = hostKeyVerifier.Callback
+
+	// Connections tunneled through a jump host do not support deadlines, the error is therefore ignored.
+	transportConnection.SetDeadline(time.Now().Add(timeout))
+	defer transportConnection.SetDeadline(time.Time{})
 
-	if len(authMethods) == 0 {
-		return nil, errors.New(fmt.Sprintf("I don't know how to authenticate with '%s'", device.Matcher))
+	c, chans, reqs, err := ssh.NewClientConn(transportConnection, address, clientConfig)
+	if err != nil {
+		if hostKeyVerifier.err != nil {
+			return nil, newConnectError(target, ReasonHostKey, hostKeyVerifier.err)
+		}
+		return nil, handshakeError(target, errors.Wrap(err, fmt.Sprintf("Could not establish SSH connection with '%s'", address)))
 	}
 
-	return authMethods, nil
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// makeSSHConfig returns the ssh.ClientConfig for the given credentials. The returned function must be called once the handshake is done.
+func (connMan *SSHConnectionManager) makeSSHConfig(auth *config.AuthConfig) (*ssh.ClientConfig, func(), error) {
+	var config ssh.ClientConfig
+	config.SetDefaults()
+
+	authMethods, cleanup, err := makeAuth(auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.User = auth.Username
+	config.Auth = authMethods
+	config.Ciphers = append(config.Ciphers, "aes128-cbc", "aes256-cbc", "3des-cbc")
+	return &config, cleanup, nil
 }
 
 func (connMan *SSHConnectionManager) keepAlive(connection *SSHConnection) {
@@ -248,9 +551,12 @@ func (connMan *SSHConnectionManager) keepAlive(connection *SSHConnection) {
 				break
 			}
 			connection.transportConnection.SetDeadline(time.Now().Add(connMan.keepAliveTimeout))
-			ctx := NewSSHCommandContext("")
-			ctx.IgnoreOutputs()
-			connection.RunCommand(ctx)
+			sshCtx := NewSSHCommandContext("")
+			go connection.RunCommand(context.Background(), sshCtx)
+			if err := lastError(sshCtx); err != nil {
+				keepAliveFailures.Inc()
+				log.Errorf("Keep alive on '%s' failed: %v", connection.Target, err)
+			}
 		case <-connection.done:
 			return
 		}
@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+func collectOutput(sshCtx *SSHCommandContext) ([]string, []error) {
+	lines := make([]string, 0)
+	errs := make([]error, 0)
+	for {
+		select {
+		case line := <-sshCtx.Output:
+			lines = append(lines, line)
+		case err := <-sshCtx.Errors:
+			errs = append(errs, err)
+		case <-sshCtx.Done:
+			return lines, errs
+		}
+	}
+}
+
+func TestRunCommandCancel(t *testing.T) {
+	shell := &ciscoShell{
+		hostname:   "router",
+		privileged: true,
+		outputs: map[string]string{
+			"show slow":    "slow output\r\n",
+			"show version": "Cisco IOS Software, C2960 Software\r\n",
+		},
+		delays: map[string]time.Duration{"show slow": 500 * time.Millisecond},
+	}
+	conn, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{})
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer conn.Terminate()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	startTime := time.Now()
+	sshCtx := NewSSHCommandContext("show slow")
+	go conn.RunCommand(ctx, sshCtx)
+	lines, _ := collectOutput(sshCtx)
+	if elapsed := time.Since(startTime); elapsed > 400*time.Millisecond {
+		t.Errorf("Expected a cancelled command to return right away, took %s", elapsed)
+	}
+	if len(lines) != 0 {
+		t.Errorf("Expected no output of a cancelled command, got %q", lines)
+	}
+
+	sshCtx = NewSSHCommandContext("show version")
+	go conn.RunCommand(context.Background(), sshCtx)
+	lines, errs := collectOutput(sshCtx)
+	if len(errs) != 0 {
+		t.Errorf("Expected the session to be usable after cancelling a command: %v", errs)
+	}
+	if len(lines) != 1 || lines[0] != "Cisco IOS Software, C2960 Software" {
+		t.Errorf("Expected the output of the next command only, got %q", lines)
+	}
+	if !conn.IsConnected() {
+		t.Errorf("Expected the connection to be kept")
+	}
+}
+
+func TestConnectionState(t *testing.T) {
+	standIn := newTelnetStandIn(t, &ciscoShell{hostname: "switch", outputs: map[string]string{"show version": "Cisco IOS Software, C2950 Software\r\n"}}, false)
+	connectionManager := NewConnectionManager()
+	if state := connectionManager.ConnectionState("127.0.0.1"); state.Info != nil || state.Connected || state.LastError != nil {
+		t.Errorf("Expected no state before connecting, got %+v", state)
+	}
+
+	device := standIn.device()
+	device.Password = config.NewSecret("wrong")
+	connectionManager.GetConnection("127.0.0.1", device)
+	if state := connectionManager.ConnectionState("127.0.0.1"); state.Info != nil || state.LastError == nil {
+		t.Errorf("Expected the failed attempt to be reported, got %+v", state)
+	}
+
+	connection, err := connectionManager.GetConnection("127.0.0.1", standIn.device())
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	state := connectionManager.ConnectionState("127.0.0.1")
+	if !state.Connected || state.LastError != nil || state.Info == nil || state.Info.Established.IsZero() || state.Info.DeviceInfo.OSVersion != config.IOS {
+		t.Errorf("Expected an established connection, got %+v", state)
+	}
+
+	connection.Terminate()
+	if state := connectionManager.ConnectionState("127.0.0.1"); state.Connected || state.Info == nil {
+		t.Errorf("Expected a terminated connection, got %+v", state)
+	}
+
+	connection, err = connectionManager.GetConnection("127.0.0.1", standIn.device())
+	if err != nil {
+		t.Fatalf("Expected reconnecting to succeed: %v", err)
+	}
+	connectionManager.Close("127.0.0.1")
+	if state := connectionManager.ConnectionState("127.0.0.1"); state.Info != nil || connection.IsConnected() {
+		t.Errorf("Expected a closed connection to be forgotten, got %+v", state)
+	}
+}
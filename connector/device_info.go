@@ -0,0 +1,152 @@
+package connector
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+// DeviceInfo holds what is known about the remote device behind a connection, as fingerprinted by `show version`.
+type DeviceInfo struct {
+	OSVersion config.OSVersion
+	// Version is the software version, e.g. `16.9.4` or `9.3(5)`.
+	Version string
+	// Platform is the hardware model, e.g. `ASR1002-X` or `Nexus9000 C93180YC-EX`.
+	Platform string
+	Serial   string
+	// Uptime is the uptime of the device at IdentifiedAt.
+	Uptime       time.Duration
+	IdentifiedAt time.Time
+}
+
+// BootTime returns when the remote device booted, or the zero time if its uptime is unknown.
+func (i *DeviceInfo) BootTime() time.Time {
+	if i.Uptime == 0 {
+		return time.Time{}
+	}
+	return i.IdentifiedAt.Add(-i.Uptime)
+}
+
+var (
+	osFingerprints = []struct {
+		fingerprint string
+		osVersion   config.OSVersion
+	}{
+		{"IOS XR", config.IOSXR},
+		{"IOS-XR", config.IOSXR},
+		{"IOS XE", config.IOSXE},
+		{"IOS-XE", config.IOSXE},
+		{"NX-OS", config.NXOS},
+		{"IOS Software", config.IOS},
+		{"Adaptive Security Appliance", config.ASA},
+	}
+	softwareVersionRegexp = regexp.MustCompile(`(?i)\bversion:?\s+([0-9][^\s,\[]*)`)
+	// bootloaderRegexp matches lines holding the version of the bootloader instead of the software.
+	bootloaderRegexp  = regexp.MustCompile(`(?i)BIOS|ROM:|loader|bootflash`)
+	platformRegexp    = regexp.MustCompile(`(?i)^\s*cisco\s+(.+?)\s+(?:\(.*\)\s+processor|chassis)`)
+	serialRegexp      = regexp.MustCompile(`(?i)processor board id\s+(\S+)`)
+	uptimeRegexp      = regexp.MustCompile(`uptime is (.+)$`)
+	uptimeFieldRegexp = regexp.MustCompile(`(\d+)\s*(year|week|day|hour|min|sec)`)
+	uptimeUnits       = map[string]time.Duration{
+		"year": 365 * 24 * time.Hour,
+		"week": 7 * 24 * time.Hour,
+		"day":  24 * time.Hour,
+		"hour": time.Hour,
+		"min":  time.Minute,
+		"sec":  time.Second,
+	}
+	// The ASA prints its platform, serial and uptime differently.
+	asaPlatformRegexp = regexp.MustCompile(`^Hardware:\s+([^,]+),`)
+	asaSerialRegexp   = regexp.MustCompile(`^Serial Number:\s+(\S+)`)
+	asaUptimeRegexp   = regexp.MustCompile(`^\S+ up ((?:\d+ \w+\s*)+)$`)
+)
+
+// parseShowVersionLine adds what line of the output of `show version` reveals to the DeviceInfo.
+// Only the first match of each field is kept.
+func (i *DeviceInfo) parseShowVersionLine(line string) {
+	if i.OSVersion == config.INVALID {
+		for _, f := range osFingerprints {
+			if strings.Contains(line, f.fingerprint) {
+				i.OSVersion = f.osVersion
+				break
+			}
+		}
+	}
+	if i.Version == "" && !bootloaderRegexp.MatchString(line) {
+		if match := softwareVersionRegexp.FindStringSubmatch(line); match != nil {
+			i.Version = match[1]
+		}
+	}
+	if i.OSVersion == config.ASA {
+		i.parseASAShowVersionLine(line)
+		return
+	}
+	if i.Platform == "" {
+		if match := platformRegexp.FindStringSubmatch(line); match != nil {
+			i.Platform = match[1]
+		}
+	}
+	if i.Serial == "" {
+		if match := serialRegexp.FindStringSubmatch(line); match != nil {
+			i.Serial = match[1]
+		}
+	}
+	if i.Uptime == 0 {
+		if match := uptimeRegexp.FindStringSubmatch(line); match != nil {
+			i.Uptime = parseUptime(match[1])
+		}
+	}
+}
+
+func (i *DeviceInfo) parseASAShowVersionLine(line string) {
+	if i.Platform == "" {
+		if match := asaPlatformRegexp.FindStringSubmatch(line); match != nil {
+			i.Platform = match[1]
+		}
+	}
+	if i.Serial == "" {
+		if match := asaSerialRegexp.FindStringSubmatch(line); match != nil {
+			i.Serial = match[1]
+		}
+	}
+	if i.Uptime == 0 {
+		if match := asaUptimeRegexp.FindStringSubmatch(line); match != nil {
+			i.Uptime = parseUptime(match[1])
+		}
+	}
+}
+
+// parseUptime parses uptimes like `1 year, 2 weeks, 3 days, 4 hours, 5 minutes`, `123 day(s), 4 hour(s)` or `2 hours 5 mins`.
+func parseUptime(uptime string) time.Duration {
+	var duration time.Duration
+	for _, match := range uptimeFieldRegexp.FindAllStringSubmatch(uptime, -1) {
+		value, _ := strconv.Atoi(match[1])
+		duration += time.Duration(value) * uptimeUnits[match[2]]
+	}
+	return duration
+}
+
+// identifyDevice fingerprints the remote device by running `show version` with the timeout in seconds.
+func identifyDevice(conn Connection, timeout int) (DeviceInfo, error) {
+	sshCtx := NewSSHCommandContext("show version")
+	sshCtx.Timeout = timeout
+	go conn.RunCommand(context.Background(), sshCtx)
+
+	var lastErr error = nil
+	info := DeviceInfo{IdentifiedAt: time.Now()}
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			return info, lastErr
+		case line := <-sshCtx.Output:
+			info.parseShowVersionLine(line)
+		case lastErr = <-sshCtx.Errors:
+			continue
+		}
+	}
+}
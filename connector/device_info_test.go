@@ -0,0 +1,132 @@
+package connector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+const showVersionIOS = `Cisco IOS Software, C3750E Software (C3750E-UNIVERSALK9-M), Version 15.0(2)SE11, RELEASE SOFTWARE (fc3)
+Technical Support: http://www.cisco.com/techsupport
+ROM: Bootstrap program is C3750E boot loader
+BOOTLDR: C3750E Boot Loader (C3750X-HBOOT-M) Version 12.2(58r)SE, RELEASE SOFTWARE (fc1)
+
+switch uptime is 1 year, 2 weeks, 3 days, 4 hours, 5 minutes
+System returned to ROM by power-on
+cisco WS-C3750X-48P (PowerPC405) processor (revision W0) with 262144K bytes of memory.
+Processor board ID FDO1234X5YZ`
+
+const showVersionIOSXE = `Cisco IOS XE Software, Version 16.09.04
+Cisco IOS Software [Fuji], ASR1000 Software (X86_64_LINUX_IOSD-UNIVERSALK9-M), Version 16.9.4, RELEASE SOFTWARE (fc2)
+ROM: IOS-XE ROMMON
+router uptime is 5 weeks, 1 day, 2 hours, 3 minutes
+cisco ASR1002-X (2RU-X) processor (revision 2KP) with 3776838K/6147K bytes of memory.
+Processor board ID FOX1234ABCD`
+
+const showVersionNXOS = `Cisco Nexus Operating System (NX-OS) Software
+Software
+  BIOS: version 07.67
+  NXOS: version 9.3(5)
+Hardware
+  cisco Nexus9000 C93180YC-EX chassis
+  Intel(R) Xeon(R) CPU  @ 1.80GHz with 24632700 kB of memory.
+  Processor Board ID FDO21231ABC
+Kernel uptime is 123 day(s), 4 hour(s), 5 minute(s), 6 second(s)`
+
+const showVersionIOSXR = `Cisco IOS XR Software, Version 6.5.3[Default]
+Copyright (c) 2013-2019 by Cisco Systems, Inc.
+
+ROM: System Bootstrap, Version 0.73(c) 1994-2012 by Cisco Systems,  Inc.
+
+core-1 uptime is 12 weeks, 3 days, 4 hours, 15 minutes
+System image file is "bootflash:disk0/asr9k-os-mbi-6.5.3/0x100305/mbiasr9k-rsp3.vm"
+
+cisco ASR9K Series (Intel 686 F6M14S4) processor with 12582912K bytes of memory.
+Intel 686 F6M14S4 processor at 2134MHz, Revision 2.174`
+
+const showVersionASA = `
+Cisco Adaptive Security Appliance Software Version 9.12(4)24
+SSP Operating System Version 2.6(1.224)
+Device Manager Version 7.12(2)
+
+Compiled on Tue 01-Mar-22 12:07 GMT by builders
+System image file is "disk0:/asa9-12-4-24-lfbff-k8.SPA"
+Config file at boot was "startup-config"
+
+fw up 35 days 2 hours
+failover cluster up 35 days 2 hours
+
+Hardware:   ASA5516, 8192 MB RAM, CPU Atom C2000 series 2416 MHz, 1 CPU (8 cores)
+Internal ATA Compact Flash, 8192MB
+BIOS Flash M25P64 @ 0xfed01000, 16384KB
+
+Serial Number: JAD123456AB
+Configuration register is 0x1`
+
+func TestParseShowVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected DeviceInfo
+	}{
+		{"IOS", showVersionIOS, DeviceInfo{
+			OSVersion: config.IOS,
+			Version:   "15.0(2)SE11",
+			Platform:  "WS-C3750X-48P",
+			Serial:    "FDO1234X5YZ",
+			Uptime:    (365+14+3)*24*time.Hour + 4*time.Hour + 5*time.Minute,
+		}},
+		{"IOS XE", showVersionIOSXE, DeviceInfo{
+			OSVersion: config.IOSXE,
+			Version:   "16.09.04",
+			Platform:  "ASR1002-X",
+			Serial:    "FOX1234ABCD",
+			Uptime:    36*24*time.Hour + 2*time.Hour + 3*time.Minute,
+		}},
+		{"NX-OS", showVersionNXOS, DeviceInfo{
+			OSVersion: config.NXOS,
+			Version:   "9.3(5)",
+			Platform:  "Nexus9000 C93180YC-EX",
+			Serial:    "FDO21231ABC",
+			Uptime:    123*24*time.Hour + 4*time.Hour + 5*time.Minute + 6*time.Second,
+		}},
+		{"IOS XR", showVersionIOSXR, DeviceInfo{
+			OSVersion: config.IOSXR,
+			Version:   "6.5.3",
+			Platform:  "ASR9K Series",
+			Uptime:    87*24*time.Hour + 4*time.Hour + 15*time.Minute,
+		}},
+		{"ASA", showVersionASA, DeviceInfo{
+			OSVersion: config.ASA,
+			Version:   "9.12(4)24",
+			Platform:  "ASA5516",
+			Serial:    "JAD123456AB",
+			Uptime:    35*24*time.Hour + 2*time.Hour,
+		}},
+	}
+
+	for _, test := range tests {
+		info := DeviceInfo{}
+		for _, line := range strings.Split(test.output, "\n") {
+			info.parseShowVersionLine(line)
+		}
+		if info != test.expected {
+			t.Errorf("%s: got %+v, want %+v", test.name, info, test.expected)
+		}
+	}
+}
+
+func TestConfiguredOSVersionSkipsDetection(t *testing.T) {
+	shell := &ciscoShell{hostname: "router", privileged: true}
+	conn, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{OSVersion: config.NXOS})
+	if err != nil {
+		t.Fatalf("Could not connect: %v", err)
+	}
+	defer conn.Terminate()
+
+	if conn.DeviceInfo.OSVersion != config.NXOS {
+		t.Errorf("Expected the configured os_version, got %s", conn.DeviceInfo.OSVersion)
+	}
+}
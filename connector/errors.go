@@ -0,0 +1,61 @@
+package connector
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Reasons for a failed connection attempt as returned by FailureReason.
+const (
+	ReasonDial        = "dial"
+	ReasonHandshake   = "handshake"
+	ReasonAuth        = "auth"
+	ReasonHostKey     = "host_key"
+	ReasonSession     = "session"
+	ReasonPagination  = "pagination"
+	ReasonFingerprint = "fingerprint"
+	ReasonUnknown     = "unknown"
+)
+
+// ConnectError is returned by the SSHConnectionManager if a connection to a remote device could not be established.
+// Reason classifies the step the connection attempt failed at.
+type ConnectError struct {
+	Target string
+	Reason string
+	Err    error
+}
+
+func (e *ConnectError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+func newConnectError(target string, reason string, err error) *ConnectError {
+	return &ConnectError{
+		Target: target,
+		Reason: reason,
+		Err:    err,
+	}
+}
+
+// handshakeError classifies an error returned by ssh.NewClientConn.
+func handshakeError(target string, err error) *ConnectError {
+	if strings.Contains(err.Error(), "unable to authenticate") {
+		return newConnectError(target, ReasonAuth, err)
+	}
+	return newConnectError(target, ReasonHandshake, err)
+}
+
+// FailureReason returns the reason of a failed connection attempt or ReasonUnknown if err is not caused by a ConnectError.
+func FailureReason(err error) string {
+	var connectErr *ConnectError
+	if errors.As(err, &connectErr) {
+		return connectErr.Reason
+	}
+	return ReasonUnknown
+}
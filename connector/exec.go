@@ -0,0 +1,149 @@
+package connector
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/log"
+	"golang.org/x/crypto/ssh"
+)
+
+// execSessions runs commands in exec channels of an SSH connection instead of its interactive shell,
+// so that multiple commands can run at the same time. At most max_sessions channels are open at a time.
+type execSessions struct {
+	client *ssh.Client
+	slots  chan struct{}
+	mu     sync.Mutex
+	// unsupported is set once the remote device rejected an exec request, the interactive shell is used from then on.
+	unsupported bool
+}
+
+func newExecSessions(client *ssh.Client, maxSessions int) *execSessions {
+	return &execSessions{
+		client: client,
+		slots:  make(chan struct{}, maxSessions),
+	}
+}
+
+// supports returns whether command can be run in an exec channel. Commands consisting of multiple lines
+// depend on each other, e.g. `changeto context`, and are run in the interactive shell.
+func (e *execSessions) supports(command string) bool {
+	if strings.TrimSpace(command) == "" || strings.Contains(strings.TrimRight(command, "\n"), "\n") {
+		return false
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.unsupported
+}
+
+func (e *execSessions) setUnsupported() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.unsupported = true
+}
+
+// runExec runs the command of sshCtx in its own exec channel. It returns false without signaling Done
+// if the command has to be run in the interactive shell instead.
+func (conn *SSHConnection) runExec(ctx context.Context, sshCtx *SSHCommandContext) bool {
+	sendError := func(err error) {
+		select {
+		case sshCtx.Errors <- err:
+		case <-ctx.Done():
+		}
+	}
+	command := strings.TrimSpace(sshCtx.Command)
+	if sshCtx.Timeout == 0 {
+		sshCtx.Timeout = conn.Device().CommandTimeout
+	}
+	timeout := time.NewTimer(time.Duration(sshCtx.Timeout) * time.Second)
+	defer timeout.Stop()
+
+	select {
+	case conn.exec.slots <- struct{}{}:
+		defer func() { <-conn.exec.slots }()
+	case <-ctx.Done():
+		sshCtx.Done <- struct{}{}
+		return true
+	case <-timeout.C:
+		commandTimedOut(ctx, sshCtx.template())
+		sendError(fmt.Errorf("Timeout reached waiting for a session to run '%s' on %s", command, conn.Target))
+		sshCtx.Done <- struct{}{}
+		return true
+	}
+
+	session, err := conn.exec.client.NewSession()
+	if err != nil {
+		// The device might limit the number of channels, which does not affect the interactive shell.
+		log.Debugf("Could not open an exec channel on %s, using the interactive shell: %v", conn.Target, err)
+		return false
+	}
+	defer session.Close()
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return false
+	}
+	if err := session.Start(command); err != nil {
+		log.Warnf("'%s' rejected running '%s' in an exec channel, using the interactive shell from now on: %v", conn.Target, command, err)
+		conn.exec.setUnsupported()
+		return false
+	}
+	defer func() {
+		sshCtx.Done <- struct{}{}
+	}()
+
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		scanner := bufio.NewScanner(newCountingReader(stdout, config.TransportSSH))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines <- escapeRegexp.ReplaceAllString(strings.TrimRight(scanner.Text(), "\r"), "")
+		}
+		readErr <- scanner.Err()
+		close(lines)
+	}()
+	// Closing the session unblocks the reader once the command is aborted.
+	defer func() {
+		session.Close()
+		for range lines {
+		}
+	}()
+
+	for {
+		select {
+		case line, more := <-lines:
+			if !more {
+				if err := <-readErr; err != nil {
+					sendError(errors.Wrapf(err, "Error reading the output of '%s' on %s", command, conn.Target))
+					return true
+				}
+				if err := session.Wait(); err != nil {
+					if _, missing := err.(*ssh.ExitMissingError); !missing {
+						sendError(errors.Wrapf(err, "'%s' failed on %s", command, conn.Target))
+					}
+				}
+				return true
+			}
+			select {
+			case sshCtx.Output <- line:
+			case <-ctx.Done():
+				log.Debugf("Command '%s' on %s was cancelled, closing its exec channel", command, conn.Target)
+				return true
+			}
+		case <-ctx.Done():
+			log.Debugf("Command '%s' on %s was cancelled, closing its exec channel", command, conn.Target)
+			return true
+		case <-timeout.C:
+			commandTimedOut(ctx, sshCtx.template())
+			sendError(fmt.Errorf("Timeout reached for '%s' on %s", command, conn.Target))
+			return true
+		}
+	}
+}
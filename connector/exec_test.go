@@ -0,0 +1,132 @@
+package connector
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func connectWithExec(t *testing.T, shell *ciscoShell, exec bool, maxSessions int) *SSHConnection {
+	var execFunc func(command string) (string, uint32)
+	if exec {
+		execFunc = shell.exec
+	}
+	server := newExecTestServer(t, passwordServerConfig(testPassword), func(channel ssh.Channel) { shell.serve(channel) }, execFunc)
+	t.Cleanup(server.Close)
+
+	device := &config.DeviceGroupConfig{
+		Port:           server.Port(),
+		ConnectTimeout: 5,
+		CommandTimeout: 5,
+		MaxSessions:    maxSessions,
+		AuthConfig: config.AuthConfig{
+			Username:    "monitoring",
+			Password:    config.NewSecret(testPassword),
+			AuthMethods: []string{config.AuthPassword},
+		},
+	}
+	device.HostKey.Mode = config.HostKeyInsecure
+	conn, err := NewConnectionManager().establishConnection("127.0.0.1", device)
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	t.Cleanup(conn.Terminate)
+	return conn
+}
+
+func execTestShell() *ciscoShell {
+	return &ciscoShell{
+		hostname:   "router",
+		privileged: true,
+		outputs: map[string]string{
+			"show version":        "Cisco IOS Software, C2960 Software\r\n",
+			"show interfaces":     "GigabitEthernet0/1 is up, line protocol is up\r\n",
+			"show ip bgp summary": "BGP router identifier 192.0.2.1, local AS number 65000\r\n",
+			"show processes cpu":  "CPU utilization for five seconds: 3%/0%; one minute: 4%; five minutes: 5%\r\n",
+		},
+		delays: map[string]time.Duration{
+			"show interfaces":     200 * time.Millisecond,
+			"show ip bgp summary": 200 * time.Millisecond,
+			"show processes cpu":  200 * time.Millisecond,
+		},
+	}
+}
+
+func TestExecSessionsConcurrent(t *testing.T) {
+	shell := execTestShell()
+	conn := connectWithExec(t, shell, true, 2)
+
+	commands := []string{"show interfaces", "show ip bgp summary", "show processes cpu"}
+	outputs := make([][]string, len(commands))
+	wg := sync.WaitGroup{}
+	for i, command := range commands {
+		wg.Add(1)
+		go func(i int, command string) {
+			defer wg.Done()
+			sshCtx := NewSSHCommandContext(command)
+			go conn.RunCommand(context.Background(), sshCtx)
+			lines, errs := collectOutput(sshCtx)
+			if len(errs) != 0 {
+				t.Errorf("Unexpected errors running '%s': %v", command, errs)
+			}
+			outputs[i] = lines
+		}(i, command)
+	}
+	wg.Wait()
+
+	for i, command := range commands {
+		if len(outputs[i]) != 1 || outputs[i][0]+"\r\n" != shell.outputs[command] {
+			t.Errorf("Unexpected output of '%s': %q", command, outputs[i])
+		}
+	}
+	if max := atomic.LoadInt32(&shell.maxRunning); max != 2 {
+		t.Errorf("Expected 2 commands to run at the same time, got %d", max)
+	}
+}
+
+func TestExecSessionsCommandFailed(t *testing.T) {
+	conn := connectWithExec(t, execTestShell(), true, 1)
+
+	sshCtx := NewSSHCommandContext("show unknown")
+	go conn.RunCommand(context.Background(), sshCtx)
+	_, errs := collectOutput(sshCtx)
+	if len(errs) != 1 {
+		t.Errorf("Expected the exit status to be reported, got %v", errs)
+	}
+}
+
+func TestExecSessionsFallback(t *testing.T) {
+	conn := connectWithExec(t, execTestShell(), false, 2)
+
+	for i := 0; i < 2; i++ {
+		sshCtx := NewSSHCommandContext("show processes cpu")
+		go conn.RunCommand(context.Background(), sshCtx)
+		lines, errs := collectOutput(sshCtx)
+		if len(errs) != 0 || len(lines) != 1 || lines[0] != "CPU utilization for five seconds: 3%/0%; one minute: 4%; five minutes: 5%" {
+			t.Errorf("Expected the interactive shell to be used, got %q: %v", lines, errs)
+		}
+	}
+	if conn.exec.supports("show processes cpu") {
+		t.Errorf("Expected exec channels to be disabled after the device rejected one")
+	}
+}
+
+func TestExecSessionsSupports(t *testing.T) {
+	exec := newExecSessions(nil, 1)
+	for command, expected := range map[string]bool{
+		"show version":                         true,
+		"show version\n":                       true,
+		"":                                     false,
+		"changeto context admin\nshow version": false,
+	} {
+		if got := exec.supports(command); got != expected {
+			t.Errorf("Expected supports(%q) to be %v", command, expected)
+		}
+	}
+}
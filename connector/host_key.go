@@ -0,0 +1,128 @@
+package connector
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/prometheus/common/log"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// knownHostsMutex serializes writes to known_hosts files in trust on first use mode.
+var knownHostsMutex sync.Mutex
+
+// HostKeyError is returned if the host key presented by a remote device could not be verified.
+type HostKeyError struct {
+	Address     string
+	Fingerprint string
+	Err         error
+}
+
+func (e *HostKeyError) Error() string {
+	return fmt.Sprintf("Host key verification failed for '%s' (%s): %v", e.Address, e.Fingerprint, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HostKeyError) Unwrap() error {
+	return e.Err
+}
+
+// hostKeyVerifier verifies host keys according to a config.HostKeyConfig and remembers the last verification error.
+// The ssh package does not preserve errors returned by the HostKeyCallback, so the error is kept here
+// to tell host key mismatches apart from other handshake failures.
+type hostKeyVerifier struct {
+	config *config.HostKeyConfig
+	err    *HostKeyError
+}
+
+func newHostKeyVerifier(hostKeyConfig *config.HostKeyConfig) *hostKeyVerifier {
+	return &hostKeyVerifier{
+		config: hostKeyConfig,
+	}
+}
+
+// Callback implements ssh.HostKeyCallback.
+func (v *hostKeyVerifier) Callback(address string, remote net.Addr, key ssh.PublicKey) error {
+	err := v.verify(address, remote, key)
+	if err != nil {
+		v.err = &HostKeyError{
+			Address:     address,
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Err:         err,
+		}
+		return v.err
+	}
+	return nil
+}
+
+func (v *hostKeyVerifier) verify(address string, remote net.Addr, key ssh.PublicKey) error {
+	switch v.config.Mode {
+	case config.HostKeyStrict:
+		if v.matchesFingerprint(key) {
+			return nil
+		}
+		if v.config.KnownHostsFile == "" {
+			return fmt.Errorf("fingerprint is not pinned")
+		}
+		callback, err := knownhosts.New(v.config.KnownHostsFile)
+		if err != nil {
+			return err
+		}
+		return callback(address, remote, key)
+	case config.HostKeyTrustOnFirstUse:
+		if v.matchesFingerprint(key) {
+			return nil
+		}
+		return v.trustOnFirstUse(address, remote, key)
+	case config.HostKeyInsecure, "":
+		return nil
+	default:
+		return fmt.Errorf("unknown host key verification mode '%s'", v.config.Mode)
+	}
+}
+
+func (v *hostKeyVerifier) matchesFingerprint(key ssh.PublicKey) bool {
+	sha256Fingerprint := ssh.FingerprintSHA256(key)
+	md5Fingerprint := ssh.FingerprintLegacyMD5(key)
+	for _, fingerprint := range v.config.Fingerprints {
+		fingerprint = strings.TrimPrefix(strings.TrimSpace(fingerprint), "MD5:")
+		if fingerprint == sha256Fingerprint || fingerprint == md5Fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *hostKeyVerifier) trustOnFirstUse(address string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsMutex.Lock()
+	defer knownHostsMutex.Unlock()
+
+	knownHostsFile, err := os.OpenFile(v.config.KnownHostsFile, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer knownHostsFile.Close()
+
+	callback, err := knownhosts.New(v.config.KnownHostsFile)
+	if err != nil {
+		return err
+	}
+
+	err = callback(address, remote, key)
+	keyErr, ok := err.(*knownhosts.KeyError)
+	if !ok || len(keyErr.Want) > 0 {
+		// Either the key is known, revoked or it changed.
+		return err
+	}
+
+	log.Warnf("Trusting previously unknown host key %s of '%s' on first use", ssh.FingerprintSHA256(key), address)
+	_, err = fmt.Fprintln(knownHostsFile, knownhosts.Line([]string{address}, key))
+	return err
+}
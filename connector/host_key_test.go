@@ -0,0 +1,105 @@
+package connector
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func generateHostKey(t *testing.T) ssh.PublicKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Could not create signer: %v", err)
+	}
+	return signer.PublicKey()
+}
+
+var remoteAddr = &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+
+func TestHostKeyFingerprint(t *testing.T) {
+	key := generateHostKey(t)
+	otherKey := generateHostKey(t)
+
+	verifier := newHostKeyVerifier(&config.HostKeyConfig{
+		Mode:         config.HostKeyStrict,
+		Fingerprints: []string{ssh.FingerprintSHA256(key)},
+	})
+	if err := verifier.Callback("router:22", remoteAddr, key); err != nil {
+		t.Errorf("Expected pinned key to be accepted, got %v", err)
+	}
+	if err := verifier.Callback("router:22", remoteAddr, otherKey); err == nil {
+		t.Errorf("Expected unpinned key to be rejected")
+	}
+	if verifier.err == nil || verifier.err.Fingerprint != ssh.FingerprintSHA256(otherKey) {
+		t.Errorf("Expected verifier to remember the rejected fingerprint")
+	}
+}
+
+func TestHostKeyKnownHosts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "known_hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := generateHostKey(t)
+	knownHostsFile := filepath.Join(dir, "known_hosts")
+	if err := ioutil.WriteFile(knownHostsFile, []byte("router "+string(ssh.MarshalAuthorizedKey(key))), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := newHostKeyVerifier(&config.HostKeyConfig{
+		Mode:           config.HostKeyStrict,
+		KnownHostsFile: knownHostsFile,
+	})
+	if err := verifier.Callback("router:22", remoteAddr, key); err != nil {
+		t.Errorf("Expected known key to be accepted, got %v", err)
+	}
+	if err := verifier.Callback("router:22", remoteAddr, generateHostKey(t)); err == nil {
+		t.Errorf("Expected changed key to be rejected")
+	}
+	if err := verifier.Callback("unknown:22", remoteAddr, key); err == nil {
+		t.Errorf("Expected key of unknown host to be rejected")
+	}
+}
+
+func TestHostKeyTrustOnFirstUse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "known_hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	key := generateHostKey(t)
+	hostKeyConfig := &config.HostKeyConfig{
+		Mode:           config.HostKeyTrustOnFirstUse,
+		KnownHostsFile: filepath.Join(dir, "known_hosts"),
+	}
+
+	if err := newHostKeyVerifier(hostKeyConfig).Callback("router:2222", remoteAddr, key); err != nil {
+		t.Errorf("Expected unknown key to be trusted on first use, got %v", err)
+	}
+	if err := newHostKeyVerifier(hostKeyConfig).Callback("router:2222", remoteAddr, key); err != nil {
+		t.Errorf("Expected persisted key to be accepted, got %v", err)
+	}
+
+	verifier := newHostKeyVerifier(hostKeyConfig)
+	if err := verifier.Callback("router:2222", remoteAddr, generateHostKey(t)); err == nil {
+		t.Errorf("Expected changed key to be rejected")
+	}
+	if verifier.err == nil {
+		t.Errorf("Expected a HostKeyError")
+	}
+}
@@ -0,0 +1,113 @@
+package connector
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsPrefix = "cisco_exporter_"
+
+var (
+	connectionAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + "connection_attempts_total",
+		Help: "Number of attempts to connect to a device",
+	}, []string{"transport"})
+	connectionFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + "connection_failures_total",
+		Help: "Number of failed attempts to connect to a device by reason",
+	}, []string{"transport", "reason"})
+	reconnects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + "reconnects_total",
+		Help: "Number of times a connection was re-established because it was lost or no longer authenticated",
+	}, []string{"transport"})
+	keepAliveFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: metricsPrefix + "keepalive_failures_total",
+		Help: "Number of keep alive messages which were not answered",
+	})
+	authenticationExpired = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: metricsPrefix + "authentication_expired_total",
+		Help: "Number of times a device reported that the authentication of a session expired",
+	})
+	openConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: metricsPrefix + "open_connections",
+		Help: "Number of open connections to devices",
+	}, []string{"transport"})
+	commandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    metricsPrefix + "command_duration_seconds",
+		Help:    "Duration of commands run on devices by collector and command",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"collector", "command"})
+	commandTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + "command_timeouts_total",
+		Help: "Number of commands which ran into their timeout by collector and command",
+	}, []string{"collector", "command"})
+	bytesRead = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: metricsPrefix + "read_bytes_total",
+		Help: "Number of bytes read from devices",
+	}, []string{"transport"})
+)
+
+// Collectors returns the collectors of the metrics about connections and commands, to be registered by the exporter.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		connectionAttempts,
+		connectionFailures,
+		reconnects,
+		keepAliveFailures,
+		authenticationExpired,
+		openConnections,
+		commandDuration,
+		commandTimeouts,
+		bytesRead,
+	}
+}
+
+type collectorKey struct{}
+
+// WithCollector returns a context attributing the commands run with it to the collector in the command metrics.
+func WithCollector(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, collectorKey{}, name)
+}
+
+// commandLabels returns the label values of the command metrics for the template of a command, see SSHCommandContext.
+// Commands run while connecting have no collector.
+func commandLabels(ctx context.Context, command string) []string {
+	name, _ := ctx.Value(collectorKey{}).(string)
+	return []string{name, strings.Replace(strings.TrimSpace(command), "\n", "; ", -1)}
+}
+
+// observeCommand records the duration of a command started at start. Empty commands, i.e. keep alives, are not recorded.
+func observeCommand(ctx context.Context, command string, start time.Time) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+	commandDuration.WithLabelValues(commandLabels(ctx, command)...).Observe(time.Since(start).Seconds())
+}
+
+// commandTimedOut counts a command which ran into its timeout.
+func commandTimedOut(ctx context.Context, command string) {
+	if strings.TrimSpace(command) == "" {
+		return
+	}
+	commandTimeouts.WithLabelValues(commandLabels(ctx, command)...).Inc()
+}
+
+// countingReader counts the bytes read from a device.
+type countingReader struct {
+	io.Reader
+	counter prometheus.Counter
+}
+
+func newCountingReader(reader io.Reader, transport string) *countingReader {
+	return &countingReader{Reader: reader, counter: bytesRead.WithLabelValues(transport)}
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.counter.Add(float64(n))
+	return n, err
+}
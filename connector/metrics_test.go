@@ -0,0 +1,101 @@
+package connector
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func observations(t *testing.T, collector string, command string) uint64 {
+	metric := &dto.Metric{}
+	if err := commandDuration.WithLabelValues(collector, command).(prometheus.Histogram).Write(metric); err != nil {
+		t.Fatal(err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestCommandMetrics(t *testing.T) {
+	shell := &ciscoShell{
+		hostname:   "router",
+		privileged: true,
+		outputs: map[string]string{
+			"show slow":            "slow output\r\n",
+			"show version":         "Cisco IOS Software, C2960 Software\r\n",
+			"show interface Gi0/1": "GigabitEthernet0/1 is up, line protocol is up\r\n",
+			"show interface Gi0/2": "GigabitEthernet0/2 is down, line protocol is down\r\n",
+		},
+		delays: map[string]time.Duration{"show slow": 1500 * time.Millisecond},
+	}
+	open := testutil.ToFloat64(openConnections.WithLabelValues(config.TransportSSH))
+	read := testutil.ToFloat64(bytesRead.WithLabelValues(config.TransportSSH))
+	conn, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{})
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer conn.Terminate()
+	if value := testutil.ToFloat64(openConnections.WithLabelValues(config.TransportSSH)); value != open+1 {
+		t.Errorf("Expected %v open connections, got %v", open+1, value)
+	}
+
+	ctx := WithCollector(context.Background(), "test")
+	sshCtx := NewSSHCommandContext("show version")
+	go conn.RunCommand(ctx, sshCtx)
+	collectOutput(sshCtx)
+	if count := observations(t, "test", "show version"); count != 1 {
+		t.Errorf("Expected one observed command, got %d", count)
+	}
+	if value := testutil.ToFloat64(bytesRead.WithLabelValues(config.TransportSSH)); value <= read {
+		t.Errorf("Expected bytes read to increase from %v, got %v", read, value)
+	}
+
+	for _, name := range []string{"Gi0/1", "Gi0/2"} {
+		sshCtx = NewSSHCommandContext("show interface " + name)
+		sshCtx.Template = "show interface <name>"
+		go conn.RunCommand(ctx, sshCtx)
+		collectOutput(sshCtx)
+	}
+	if count := observations(t, "test", "show interface <name>"); count != 2 {
+		t.Errorf("Expected both commands to be observed by their template, got %d", count)
+	}
+
+	sshCtx = NewSSHCommandContext("show slow")
+	sshCtx.Timeout = 1
+	go conn.RunCommand(ctx, sshCtx)
+	collectOutput(sshCtx)
+	if value := testutil.ToFloat64(commandTimeouts.WithLabelValues("test", "show slow")); value != 1 {
+		t.Errorf("Expected one command timeout, got %v", value)
+	}
+	// The connection is terminated on timeouts.
+	if value := testutil.ToFloat64(openConnections.WithLabelValues(config.TransportSSH)); value != open {
+		t.Errorf("Expected %v open connections after the timeout, got %v", open, value)
+	}
+}
+
+func TestConnectionMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	attempts := testutil.ToFloat64(connectionAttempts.WithLabelValues(config.TransportSSH))
+	failures := testutil.ToFloat64(connectionFailures.WithLabelValues(config.TransportSSH, ReasonDial))
+	device := &config.DeviceGroupConfig{Port: port, ConnectTimeout: 1}
+	if _, err := NewConnectionManager().GetConnection("127.0.0.1", device); err == nil {
+		t.Fatalf("Expected connecting to a closed port to fail")
+	}
+	if value := testutil.ToFloat64(connectionAttempts.WithLabelValues(config.TransportSSH)); value != attempts+1 {
+		t.Errorf("Expected %v connection attempts, got %v", attempts+1, value)
+	}
+	if value := testutil.ToFloat64(connectionFailures.WithLabelValues(config.TransportSSH, ReasonDial)); value != failures+1 {
+		t.Errorf("Expected %v dial failures, got %v", failures+1, value)
+	}
+}
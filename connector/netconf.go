@@ -0,0 +1,362 @@
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/log"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	netconfBase10 = "urn:ietf:params:netconf:base:1.0"
+	netconfBase11 = "urn:ietf:params:netconf:base:1.1"
+	// netconfEOM terminates messages using the framing of base:1.0 and the hello messages.
+	netconfEOM       = "]]>]]>"
+	netconfNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+)
+
+var netconfHello = `<?xml version="1.0" encoding="UTF-8"?>
+<hello xmlns="` + netconfNamespace + `"><capabilities><capability>` + netconfBase10 + `</capability><capability>` + netconfBase11 + `</capability></capabilities></hello>`
+
+// NETCONFConnection retrieves operational data from IOS XE devices using NETCONF, which runs as the `netconf` SSH subsystem.
+// CLI commands cannot be run on it, collectors supporting NETCONF use Get instead.
+type NETCONFConnection struct {
+	sshClient           *ssh.Client
+	transportConnection net.Conn
+	tunnel              *tunnel
+	connectionManager   *SSHConnectionManager
+	reader              *bufio.Reader
+	writer              io.Writer
+	// chunked is set if both ends announced base:1.1, which replaces the end-of-message framing by the chunked framing.
+	chunked   bool
+	messageID int
+	// Capabilities holds the capabilities the remote device announced in its hello message.
+	Capabilities []string
+	SessionID    int
+	mu           sync.Mutex
+	done         chan struct{}
+	closeOnce    sync.Once
+	ConnectionInfo
+}
+
+// NETCONFError is an rpc-error returned by the remote device.
+type NETCONFError struct {
+	Type     string `xml:"error-type"`
+	Tag      string `xml:"error-tag"`
+	Severity string `xml:"error-severity"`
+	Path     string `xml:"error-path"`
+	Message  string `xml:"error-message"`
+}
+
+func (e *NETCONFError) Error() string {
+	message := fmt.Sprintf("%s %s: %s", e.Type, e.Severity, e.Tag)
+	if e.Message != "" {
+		message += ": " + strings.TrimSpace(e.Message)
+	}
+	if e.Path != "" {
+		message += " (" + strings.TrimSpace(e.Path) + ")"
+	}
+	return message
+}
+
+type netconfHelloMessage struct {
+	Capabilities []string `xml:"capabilities>capability"`
+	SessionID    int      `xml:"session-id"`
+}
+
+type netconfReply struct {
+	MessageID string         `xml:"message-id,attr"`
+	Errors    []NETCONFError `xml:"rpc-error"`
+	Data      *struct {
+		Content []byte `xml:",innerxml"`
+	} `xml:"data"`
+}
+
+// hello exchanges the hello messages and selects the framing used afterwards.
+func (conn *NETCONFConnection) hello() error {
+	if _, err := io.WriteString(conn.writer, netconfHello+netconfEOM); err != nil {
+		return errors.Wrap(err, "Could not send hello")
+	}
+	message, err := conn.readEOM()
+	if err != nil {
+		return errors.Wrap(err, "Could not receive hello")
+	}
+	hello := netconfHelloMessage{}
+	if err := xml.Unmarshal(message, &hello); err != nil {
+		return errors.Wrap(err, "Could not decode hello")
+	}
+
+	supported := false
+	for _, capability := range hello.Capabilities {
+		capability = strings.TrimSpace(capability)
+		switch capability {
+		case netconfBase10:
+			supported = true
+		case netconfBase11:
+			supported = true
+			conn.chunked = true
+		}
+		conn.Capabilities = append(conn.Capabilities, capability)
+	}
+	if !supported {
+		return fmt.Errorf("Remote device supports neither %s nor %s", netconfBase10, netconfBase11)
+	}
+	conn.SessionID = hello.SessionID
+	return nil
+}
+
+// Get retrieves the operational data selected by the subtree filter. It returns the `<data>` element of the reply.
+// If ctx is done before the reply is received, the reply is discarded once it arrives.
+func (conn *NETCONFConnection) Get(ctx context.Context, filter string) ([]byte, error) {
+	type reply struct {
+		data []byte
+		err  error
+	}
+	replies := make(chan reply, 1)
+	defer observeCommand(ctx, "get", time.Now())
+
+	conn.mu.Lock()
+	go func() {
+		defer conn.mu.Unlock()
+		data, err := conn.get(filter)
+		replies <- reply{data, err}
+	}()
+
+	select {
+	case r := <-replies:
+		recordNETCONF(ctx, filter, r.data, r.err)
+		return r.data, r.err
+	case <-ctx.Done():
+		log.Debugf("NETCONF request on %s was cancelled, discarding its reply", conn.Target)
+		return nil, ctx.Err()
+	}
+}
+
+func (conn *NETCONFConnection) get(filter string) ([]byte, error) {
+	if !conn.IsConnected() {
+		return nil, fmt.Errorf("Cannot send NETCONF request to target '%s': Not connected.", conn.Target)
+	}
+
+	// Closing the transport connection aborts waiting for the reply.
+	timeout := time.Duration(conn.Device().CommandTimeout) * time.Second
+	timer := time.AfterFunc(timeout, func() {
+		conn.transportConnection.Close()
+	})
+	reply, err := conn.rpc(`<get><filter type="subtree">` + filter + `</filter></get>`)
+	if !timer.Stop() {
+		conn.Terminate()
+		return nil, fmt.Errorf("Timeout reached for NETCONF request on %s", conn.Target)
+	}
+	if err != nil {
+		conn.Terminate()
+		return nil, errors.Wrapf(err, "NETCONF request on %s failed", conn.Target)
+	}
+
+	for i := range reply.Errors {
+		if reply.Errors[i].Severity != "warning" {
+			return nil, &reply.Errors[i]
+		}
+	}
+	if reply.Data == nil {
+		return []byte("<data></data>"), nil
+	}
+	return append(append([]byte("<data>"), reply.Data.Content...), "</data>"...), nil
+}
+
+// rpc sends an rpc holding operation and returns its reply.
+func (conn *NETCONFConnection) rpc(operation string) (*netconfReply, error) {
+	conn.messageID++
+	messageID := strconv.Itoa(conn.messageID)
+	request := `<rpc message-id="` + messageID + `" xmlns="` + netconfNamespace + `">` + operation + `</rpc>`
+	if err := conn.writeMessage([]byte(request)); err != nil {
+		return nil, err
+	}
+
+	message, err := conn.readMessage()
+	if err != nil {
+		return nil, err
+	}
+	reply := &netconfReply{}
+	if err := xml.Unmarshal(message, reply); err != nil {
+		return nil, errors.Wrap(err, "Could not decode rpc-reply")
+	}
+	if reply.MessageID != messageID {
+		return nil, fmt.Errorf("Expected reply to message %s, got %s", messageID, reply.MessageID)
+	}
+	return reply, nil
+}
+
+func (conn *NETCONFConnection) writeMessage(message []byte) error {
+	var err error
+	if conn.chunked {
+		_, err = fmt.Fprintf(conn.writer, "\n#%d\n%s\n##\n", len(message), message)
+	} else {
+		_, err = fmt.Fprintf(conn.writer, "%s%s", message, netconfEOM)
+	}
+	return err
+}
+
+func (conn *NETCONFConnection) readMessage() ([]byte, error) {
+	if conn.chunked {
+		return conn.readChunked()
+	}
+	return conn.readEOM()
+}
+
+// readEOM reads a message terminated by `]]>]]>`.
+func (conn *NETCONFConnection) readEOM() ([]byte, error) {
+	message := make([]byte, 0)
+	for {
+		part, err := conn.reader.ReadSlice('>')
+		message = append(message, part...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasSuffix(message, []byte(netconfEOM)) {
+			return message[:len(message)-len(netconfEOM)], nil
+		}
+	}
+}
+
+// readChunked reads a message using the chunked framing of RFC 6242, i.e. chunks of the form `\n#<size>\n<data>` ending with `\n##\n`.
+func (conn *NETCONFConnection) readChunked() ([]byte, error) {
+	message := make([]byte, 0)
+	for {
+		start := make([]byte, 2)
+		if _, err := io.ReadFull(conn.reader, start); err != nil {
+			return nil, err
+		}
+		if string(start) != "\n#" {
+			return nil, fmt.Errorf("Invalid chunk start %q", start)
+		}
+		header, err := conn.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimSuffix(header, "\n")
+		if header == "#" {
+			return message, nil
+		}
+		size, err := strconv.Atoi(header)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("Invalid chunk size '%s'", header)
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(conn.reader, chunk); err != nil {
+			return nil, err
+		}
+		message = append(message, chunk...)
+	}
+}
+
+// RunCommand implements the Connection interface's RunCommand function.
+// CLI commands are not supported over NETCONF, an error is reported instead.
+func (conn *NETCONFConnection) RunCommand(ctx context.Context, sshCtx *SSHCommandContext) {
+	sshCtx = record(ctx, sshCtx)
+	defer func() {
+		sshCtx.Done <- struct{}{}
+	}()
+	if strings.TrimSpace(sshCtx.Command) == "" {
+		return
+	}
+	select {
+	case sshCtx.Errors <- fmt.Errorf("Cannot run '%s' on %s: CLI commands are not supported over NETCONF", sshCtx.Command, conn.Target):
+	case <-ctx.Done():
+	}
+}
+
+// IsConnected implements the Connection interface's IsConnected function.
+func (conn *NETCONFConnection) IsConnected() bool {
+	select {
+	case <-conn.done:
+		return false
+	default:
+		return conn.tunnel.isAlive()
+	}
+}
+
+// IsAuthenticated implements the Connection interface's IsAuthenticated function.
+// The NETCONF session stays authenticated as long as it is up.
+func (conn *NETCONFConnection) IsAuthenticated() bool {
+	return true
+}
+
+// Terminate implements the Connection interface's Terminate function.
+func (conn *NETCONFConnection) Terminate() {
+	conn.closeOnce.Do(func() {
+		close(conn.done)
+		openConnections.WithLabelValues(config.TransportNETCONF).Dec()
+		conn.sshClient.Close()
+		conn.transportConnection.Close()
+		if conn.tunnel != nil {
+			conn.connectionManager.releaseTunnel(conn.tunnel)
+		}
+	})
+}
+
+// deviceHardwareFilter selects the software version and the inventory of Cisco-IOS-XE-device-hardware-oper.
+const deviceHardwareFilter = `<device-hardware-data xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-device-hardware-oper"><device-hardware>` +
+	`<device-inventory><hw-type/><part-number/><serial-number/></device-inventory>` +
+	`<device-system-data><boot-time/><software-version/></device-system-data>` +
+	`</device-hardware></device-hardware-data>`
+
+type netconfDeviceHardware struct {
+	Inventory []struct {
+		HWType       string `xml:"hw-type"`
+		PartNumber   string `xml:"part-number"`
+		SerialNumber string `xml:"serial-number"`
+	} `xml:"device-hardware-data>device-hardware>device-inventory"`
+	System struct {
+		BootTime        string `xml:"boot-time"`
+		SoftwareVersion string `xml:"software-version"`
+	} `xml:"device-hardware-data>device-hardware>device-system-data"`
+}
+
+// identifyDevice fingerprints the remote device using Cisco-IOS-XE-device-hardware-oper.
+// Devices not implementing the model are only known to run IOS XE.
+func (conn *NETCONFConnection) identifyDevice() (DeviceInfo, error) {
+	info := DeviceInfo{OSVersion: config.IOSXE, IdentifiedAt: time.Now()}
+	data, err := conn.Get(context.Background(), deviceHardwareFilter)
+	if err != nil {
+		if _, ok := err.(*NETCONFError); ok {
+			log.Debugf("Could not fingerprint '%s' using NETCONF: %v", conn.Target, err)
+			return info, nil
+		}
+		return info, err
+	}
+
+	hardware := netconfDeviceHardware{}
+	if err := xml.Unmarshal(data, &hardware); err != nil {
+		return info, errors.Wrap(err, "Could not decode device-hardware-data")
+	}
+	if match := softwareVersionRegexp.FindStringSubmatch(hardware.System.SoftwareVersion); match != nil {
+		info.Version = match[1]
+	}
+	for _, inventory := range hardware.Inventory {
+		if inventory.HWType == "hw-type-chassis" {
+			info.Platform = inventory.PartNumber
+			info.Serial = inventory.SerialNumber
+			break
+		}
+	}
+	if bootTime, err := time.Parse(time.RFC3339, hardware.System.BootTime); err == nil {
+		info.Uptime = info.IdentifiedAt.Sub(bootTime)
+	}
+	return info, nil
+}
@@ -0,0 +1,122 @@
+package connector
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+	"gitlab.com/wobcom/cisco-exporter/connector/netconftest"
+)
+
+const netconfDeviceHardwareData = `<device-hardware-data xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-device-hardware-oper">
+  <device-hardware>
+    <device-inventory>
+      <hw-type>hw-type-chassis</hw-type>
+      <hw-dev-index>0</hw-dev-index>
+      <part-number>ISR4331/K9</part-number>
+      <serial-number>FDO21520TGH</serial-number>
+    </device-inventory>
+    <device-inventory>
+      <hw-type>hw-type-module</hw-type>
+      <hw-dev-index>0</hw-dev-index>
+      <part-number>ISR4331-3x1GE</part-number>
+      <serial-number>JAE12345678</serial-number>
+    </device-inventory>
+    <device-system-data>
+      <boot-time>2026-10-01T12:00:00+00:00</boot-time>
+      <software-version>Cisco IOS Software [Amsterdam], ISR Software (X86_64_LINUX_IOSD-UNIVERSALK9-M), Version 17.3.4a, RELEASE SOFTWARE (fc3)</software-version>
+    </device-system-data>
+  </device-hardware>
+</device-hardware-data>`
+
+const netconfCPUUsage = `<cpu-usage xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-process-cpu-oper"><cpu-utilization><five-seconds>3</five-seconds></cpu-utilization></cpu-usage>`
+
+func TestNETCONFConnection(t *testing.T) {
+	for _, capabilities := range [][]string{
+		{"urn:ietf:params:netconf:base:1.0", "urn:ietf:params:netconf:base:1.1"},
+		{"urn:ietf:params:netconf:base:1.0"},
+	} {
+		server := netconftest.NewServer(t, map[string]string{
+			"device-hardware-data": netconfDeviceHardwareData,
+			"cpu-usage":            netconfCPUUsage,
+		}, capabilities...)
+		connection, err := NewConnectionManager().GetConnection("127.0.0.1", server.Device())
+		if err != nil {
+			t.Fatalf("Expected connection to succeed: %v", err)
+		}
+		netconf := connection.(*NETCONFConnection)
+
+		if chunked := len(capabilities) == 2; netconf.chunked != chunked {
+			t.Errorf("Expected chunked framing to be %v for %v", chunked, capabilities)
+		}
+		if netconf.SessionID != 42 {
+			t.Errorf("Expected session id 42, got %d", netconf.SessionID)
+		}
+
+		info := netconf.Info().DeviceInfo
+		if info.OSVersion != config.IOSXE || info.Version != "17.3.4a" || info.Platform != "ISR4331/K9" || info.Serial != "FDO21520TGH" {
+			t.Errorf("Unexpected device info %+v", info)
+		}
+		if bootTime := info.BootTime(); !bootTime.Equal(time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected boot time %v", bootTime)
+		}
+
+		data, err := netconf.Get(context.Background(), `<cpu-usage xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-process-cpu-oper"><cpu-utilization/></cpu-usage>`)
+		if err != nil {
+			t.Fatalf("Expected get to succeed: %v", err)
+		}
+		cpu := struct {
+			FiveSeconds int `xml:"cpu-usage>cpu-utilization>five-seconds"`
+		}{}
+		if err := xml.Unmarshal(data, &cpu); err != nil || cpu.FiveSeconds != 3 {
+			t.Errorf("Unexpected data %s: %v", data, err)
+		}
+
+		_, err = netconf.Get(context.Background(), `<bgp-state-data xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-bgp-oper"/>`)
+		if _, ok := err.(*NETCONFError); !ok {
+			t.Errorf("Expected rpc-error, got %v", err)
+		}
+		if !netconf.IsConnected() {
+			t.Errorf("Expected an rpc-error to keep the session")
+		}
+
+		sshCtx := NewSSHCommandContext("show version")
+		go netconf.RunCommand(context.Background(), sshCtx)
+		if _, errs := collectOutput(sshCtx); len(errs) != 1 {
+			t.Errorf("Expected CLI commands to fail, got %v", errs)
+		}
+
+		netconf.Terminate()
+		if netconf.IsConnected() {
+			t.Errorf("Expected terminated connection to be disconnected")
+		}
+		if _, err := netconf.Get(context.Background(), `<cpu-usage/>`); err == nil || !strings.Contains(err.Error(), "Not connected") {
+			t.Errorf("Expected get on terminated connection to fail, got %v", err)
+		}
+	}
+}
+
+func TestNETCONFConnectionWithoutDeviceHardware(t *testing.T) {
+	server := netconftest.NewServer(t, map[string]string{})
+	connection, err := NewConnectionManager().GetConnection("127.0.0.1", server.Device())
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer connection.Terminate()
+	if info := connection.Info().DeviceInfo; info.OSVersion != config.IOSXE || info.Version != "" {
+		t.Errorf("Unexpected device info %+v", info)
+	}
+}
+
+func TestNETCONFConnectionUnauthorized(t *testing.T) {
+	server := netconftest.NewServer(t, map[string]string{})
+	device := server.Device()
+	device.Password = config.NewSecret("wrong")
+	_, err := NewConnectionManager().GetConnection("127.0.0.1", device)
+	if reason := FailureReason(err); reason != ReasonAuth {
+		t.Errorf("Expected failure reason '%s', got '%s': %v", ReasonAuth, reason, err)
+	}
+}
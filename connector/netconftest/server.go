@@ -0,0 +1,313 @@
+// Package netconftest provides an in-process NETCONF server, which the NETCONF transport and the collectors supporting it are tested against.
+package netconftest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Credentials accepted by the Server.
+const (
+	Username = "monitoring"
+	Password = "netconf"
+)
+
+const (
+	base10 = "urn:ietf:params:netconf:base:1.0"
+	base11 = "urn:ietf:params:netconf:base:1.1"
+	eom    = "]]>]]>"
+)
+
+// Server is a NETCONF server answering `<get>` requests with fixed data.
+type Server struct {
+	listener     net.Listener
+	config       *ssh.ServerConfig
+	data         map[string]string
+	capabilities []string
+	mu           sync.Mutex
+	filters      []string
+}
+
+// NewServer starts a Server announcing the given capabilities, base:1.0 and base:1.1 if none are given.
+// The data returned for a `<get>` is looked up by the name of the top-level element of its subtree filter.
+// Filters selecting anything else are answered with an rpc-error.
+func NewServer(t *testing.T, data map[string]string, capabilities ...string) *Server {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not generate host key: %v", err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Could not create host key signer: %v", err)
+	}
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == Username && string(password) == Password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("password rejected for %s", conn.User())
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	if len(capabilities) == 0 {
+		capabilities = []string{base10, base11}
+	}
+
+	server := &Server{
+		listener:     listener,
+		config:       serverConfig,
+		data:         data,
+		capabilities: capabilities,
+	}
+	go server.serve()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Port returns the TCP port the server is listening on.
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// Close stops accepting connections.
+func (s *Server) Close() {
+	s.listener.Close()
+}
+
+// Device returns the configuration of a device group connecting to the server.
+func (s *Server) Device() *config.DeviceGroupConfig {
+	return &config.DeviceGroupConfig{
+		Transport:      config.TransportNETCONF,
+		Port:           s.Port(),
+		ConnectTimeout: 5,
+		CommandTimeout: 5,
+		AuthConfig: config.AuthConfig{
+			Username:    Username,
+			Password:    config.NewSecret(Password),
+			AuthMethods: []string{config.AuthPassword},
+		},
+		HostKey: config.HostKeyConfig{Mode: config.HostKeyInsecure},
+	}
+}
+
+// Filters returns the subtree filters of the `<get>` requests received so far.
+func (s *Server) Filters() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.filters...)
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				var payload struct{ Name string }
+				if req.Type != "subsystem" || ssh.Unmarshal(req.Payload, &payload) != nil || payload.Name != "netconf" {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+				go func() {
+					s.session(channel)
+					channel.Close()
+				}()
+			}
+		}()
+	}
+}
+
+// session speaks NETCONF on channel until the client closes the session.
+func (s *Server) session(channel ssh.Channel) {
+	hello := `<?xml version="1.0" encoding="UTF-8"?><hello xmlns="urn:ietf:params:xml:ns:netconf:base:1.0"><capabilities>`
+	for _, capability := range s.capabilities {
+		hello += "<capability>" + capability + "</capability>"
+	}
+	hello += "</capabilities><session-id>42</session-id></hello>"
+	if _, err := io.WriteString(channel, hello+eom); err != nil {
+		return
+	}
+
+	reader := bufio.NewReader(channel)
+	message, err := readEOM(reader)
+	if err != nil {
+		return
+	}
+	clientHello := struct {
+		Capabilities []string `xml:"capabilities>capability"`
+	}{}
+	if err := xml.Unmarshal(message, &clientHello); err != nil {
+		return
+	}
+	chunked := contains(s.capabilities, base11) && contains(clientHello.Capabilities, base11)
+
+	for {
+		var message []byte
+		if chunked {
+			message, err = readChunked(reader)
+		} else {
+			message, err = readEOM(reader)
+		}
+		if err != nil {
+			return
+		}
+
+		reply, closeSession := s.handle(message)
+		if chunked {
+			fmt.Fprintf(channel, "\n#%d\n%s\n##\n", len(reply), reply)
+		} else {
+			fmt.Fprintf(channel, "%s%s", reply, eom)
+		}
+		if closeSession {
+			return
+		}
+	}
+}
+
+// handle returns the rpc-reply to the rpc in message and whether the session is closed.
+func (s *Server) handle(message []byte) (string, bool) {
+	rpc := struct {
+		MessageID string `xml:"message-id,attr"`
+		Get       *struct {
+			Filter struct {
+				Type    string `xml:"type,attr"`
+				Content []byte `xml:",innerxml"`
+			} `xml:"filter"`
+		} `xml:"get"`
+		CloseSession *struct{} `xml:"close-session"`
+	}{}
+	if err := xml.Unmarshal(message, &rpc); err != nil {
+		return rpcError("", "malformed-message", err.Error()), true
+	}
+
+	switch {
+	case rpc.CloseSession != nil:
+		return reply(rpc.MessageID, "<ok/>"), true
+	case rpc.Get != nil && rpc.Get.Filter.Type == "subtree":
+		filter := string(rpc.Get.Filter.Content)
+		s.mu.Lock()
+		s.filters = append(s.filters, filter)
+		s.mu.Unlock()
+
+		data, found := s.data[topLevelElement(filter)]
+		if !found {
+			return rpcError(rpc.MessageID, "unknown-element", "No data for filter "+filter), false
+		}
+		return reply(rpc.MessageID, "<data>"+data+"</data>"), false
+	}
+	return rpcError(rpc.MessageID, "operation-not-supported", "Unsupported operation"), false
+}
+
+func reply(messageID string, content string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?><rpc-reply message-id="` + messageID + `" xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">` + content + `</rpc-reply>`
+}
+
+func rpcError(messageID string, tag string, message string) string {
+	return reply(messageID, "<rpc-error><error-type>application</error-type><error-tag>"+tag+"</error-tag>"+
+		"<error-severity>error</error-severity><error-message>"+message+"</error-message></rpc-error>")
+}
+
+func topLevelElement(filter string) string {
+	decoder := xml.NewDecoder(strings.NewReader(filter))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local
+		}
+	}
+}
+
+func readEOM(reader *bufio.Reader) ([]byte, error) {
+	message := make([]byte, 0)
+	for !bytes.HasSuffix(message, []byte(eom)) {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		message = append(message, b)
+	}
+	return message[:len(message)-len(eom)], nil
+}
+
+func readChunked(reader *bufio.Reader) ([]byte, error) {
+	message := make([]byte, 0)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if header == "\n" {
+			continue
+		}
+		header = strings.TrimSuffix(strings.TrimPrefix(header, "#"), "\n")
+		if header == "#" {
+			return message, nil
+		}
+		size, err := strconv.Atoi(header)
+		if err != nil {
+			return nil, err
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, err
+		}
+		message = append(message, chunk...)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) == value {
+			return true
+		}
+	}
+	return false
+}
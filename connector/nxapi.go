@@ -0,0 +1,259 @@
+package connector
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/pkg/errors"
+)
+
+const nxapiJSONSuffix = "| json"
+
+var errNXAPIUnauthorized = errors.New("NX-API rejected the credentials")
+
+// NXAPIConnection runs commands on NX-OS devices using NX-API instead of an interactive SSH session.
+// Commands are sent as `cli_show_ascii` requests. Commands ending with `| json` are sent as `cli_show` requests instead,
+// their output is the JSON body returned by NX-API.
+type NXAPIConnection struct {
+	client *http.Client
+	url    string
+	mu     sync.Mutex
+	closed bool
+	ConnectionInfo
+}
+
+// nxapiRequest is the body of an NX-API request in the `ins_api` format.
+type nxapiRequest struct {
+	InsAPI struct {
+		Version      string `json:"version"`
+		Type         string `json:"type"`
+		Chunk        string `json:"chunk"`
+		SID          string `json:"sid"`
+		Input        string `json:"input"`
+		OutputFormat string `json:"output_format"`
+	} `json:"ins_api"`
+}
+
+type nxapiResponse struct {
+	InsAPI struct {
+		Outputs struct {
+			// Output is a single object if one command was sent, otherwise an array.
+			Output json.RawMessage `json:"output"`
+		} `json:"outputs"`
+	} `json:"ins_api"`
+}
+
+type nxapiOutput struct {
+	Input    string          `json:"input"`
+	Code     string          `json:"code"`
+	Msg      string          `json:"msg"`
+	CLIError string          `json:"clierror"`
+	Body     json.RawMessage `json:"body"`
+}
+
+func newNXAPIConnection(target string, device *config.DeviceGroupConfig) (*NXAPIConnection, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: device.NXAPI.InsecureSkipVerify}
+	if device.NXAPI.CAFile != "" {
+		pem, err := ioutil.ReadFile(device.NXAPI.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not read ca_file of '%s'", target)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No certificate found in ca_file of '%s'", target)
+		}
+	}
+
+	openConnections.WithLabelValues(config.TransportNXAPI).Inc()
+	return &NXAPIConnection{
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+				DialContext: (&net.Dialer{
+					Timeout: time.Duration(device.ConnectTimeout) * time.Second,
+				}).DialContext,
+				MaxIdleConnsPerHost: 4,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		url:            device.NXAPI.Scheme + "://" + net.JoinHostPort(target, strconv.Itoa(device.NXAPI.Port)) + "/ins",
+		ConnectionInfo: ConnectionInfo{Target: target, device: device, PrivilegeLevel: -1},
+	}, nil
+}
+
+// RunCommand implements the Connection interface's RunCommand function.
+// Multiple commands separated by newlines are sent in one request.
+func (conn *NXAPIConnection) RunCommand(ctx context.Context, sshCtx *SSHCommandContext) {
+	sshCtx = record(ctx, sshCtx)
+	defer observeCommand(ctx, sshCtx.template(), time.Now())
+	defer func() {
+		sshCtx.Done <- struct{}{}
+	}()
+	sendError := func(err error) {
+		select {
+		case sshCtx.Errors <- err:
+		case <-ctx.Done():
+		}
+	}
+
+	commands := make([]string, 0)
+	for _, command := range strings.Split(sshCtx.Command, "\n") {
+		if command = strings.TrimSpace(command); command != "" {
+			commands = append(commands, command)
+		}
+	}
+	if len(commands) == 0 {
+		return
+	}
+	if !conn.IsConnected() {
+		sendError(fmt.Errorf("Cannot run command '%s' on target '%s': Not connected.", sshCtx.Command, conn.Target))
+		return
+	}
+
+	timeout := sshCtx.Timeout
+	if timeout == 0 {
+		timeout = conn.Device().CommandTimeout
+	}
+	requestCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	outputs, err := conn.post(requestCtx, commands)
+	if err != nil {
+		if requestCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			commandTimedOut(ctx, sshCtx.template())
+		}
+		sendError(errors.Wrapf(err, "Error running '%s' on %s", sshCtx.Command, conn.Target))
+		return
+	}
+
+	for _, output := range outputs {
+		if output.Code != "200" {
+			sendError(fmt.Errorf("'%s' failed on %s: %s %s", output.Input, conn.Target, output.Msg, strings.TrimSpace(output.CLIError)))
+			continue
+		}
+		for _, line := range output.lines() {
+			select {
+			case sshCtx.Output <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// post sends the commands in a single request. All of them must either be sent as `cli_show` or as `cli_show_ascii`.
+func (conn *NXAPIConnection) post(ctx context.Context, commands []string) ([]nxapiOutput, error) {
+	request := nxapiRequest{}
+	request.InsAPI.Version = "1.0"
+	request.InsAPI.Type = "cli_show_ascii"
+	request.InsAPI.Chunk = "0"
+	request.InsAPI.SID = "1"
+	request.InsAPI.OutputFormat = "json"
+	if strings.HasSuffix(commands[0], nxapiJSONSuffix) {
+		request.InsAPI.Type = "cli_show"
+		for i, command := range commands {
+			commands[i] = strings.TrimSpace(strings.TrimSuffix(command, nxapiJSONSuffix))
+		}
+	}
+	request.InsAPI.Input = strings.Join(commands, " ;")
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequest(http.MethodPost, conn.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest = httpRequest.WithContext(ctx)
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.SetBasicAuth(conn.Device().Username, conn.Device().Password.Value())
+
+	httpResponse, err := conn.client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+	content, err := ioutil.ReadAll(newCountingReader(httpResponse.Body, config.TransportNXAPI))
+	if err != nil {
+		return nil, err
+	}
+	if httpResponse.StatusCode == http.StatusUnauthorized {
+		return nil, errNXAPIUnauthorized
+	}
+
+	// Failing commands are reported with status 400 or 500, but still have a regular response body.
+	response := nxapiResponse{}
+	if err := json.Unmarshal(content, &response); err != nil {
+		return nil, fmt.Errorf("Unexpected response with status %s", httpResponse.Status)
+	}
+	outputs := make([]nxapiOutput, 0)
+	output := bytes.TrimSpace(response.InsAPI.Outputs.Output)
+	if len(output) > 0 && output[0] == '{' {
+		output = append(append([]byte("["), output...), ']')
+	}
+	if err := json.Unmarshal(output, &outputs); err != nil {
+		return nil, errors.Wrap(err, "Could not decode NX-API outputs")
+	}
+	return outputs, nil
+}
+
+// lines returns the lines of the output. The body of `cli_show_ascii` is the text output,
+// whereas the body of `cli_show` is returned as a single line of JSON.
+func (output *nxapiOutput) lines() []string {
+	body := bytes.TrimSpace(output.Body)
+	if len(body) == 0 {
+		return nil
+	}
+	if body[0] != '"' {
+		return []string{string(body)}
+	}
+
+	var text string
+	if err := json.Unmarshal(body, &text); err != nil {
+		return nil
+	}
+	text = strings.TrimRight(strings.Replace(text, "\r", "", -1), "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// IsConnected implements the Connection interface's IsConnected function.
+// NX-API is stateless, the connection is up until it is terminated.
+func (conn *NXAPIConnection) IsConnected() bool {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	return !conn.closed
+}
+
+// IsAuthenticated implements the Connection interface's IsAuthenticated function.
+// The credentials are sent along with every request, rejected credentials are reported by the commands.
+func (conn *NXAPIConnection) IsAuthenticated() bool {
+	return true
+}
+
+// Terminate implements the Connection interface's Terminate function.
+func (conn *NXAPIConnection) Terminate() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if !conn.closed {
+		openConnections.WithLabelValues(config.TransportNXAPI).Dec()
+	}
+	conn.closed = true
+	conn.client.CloseIdleConnections()
+}
@@ -0,0 +1,197 @@
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+// Responses recorded from a Nexus 9000 running NX-OS 9.3(5), keyed by request type and input.
+var nxapiResponses = map[string]string{
+	"cli_show_ascii show version": `{
+  "ins_api": {
+    "type": "cli_show_ascii",
+    "version": "1.0",
+    "sid": "eoc",
+    "outputs": {
+      "output": {
+        "input": "show version",
+        "msg": "Success",
+        "code": "200",
+        "body": "Cisco Nexus Operating System (NX-OS) Software\nSoftware\n  BIOS: version 07.67\n  NXOS: version 9.3(5)\nHardware\n  cisco Nexus9000 C93180YC-EX chassis\n  Processor Board ID FDO21231ABC\n\nKernel uptime is 123 day(s), 4 hour(s), 5 minute(s), 6 second(s)\n"
+      }
+    }
+  }
+}`,
+	"cli_show show system resources": `{
+  "ins_api": {
+    "type": "cli_show",
+    "version": "1.0",
+    "sid": "eoc",
+    "outputs": {
+      "output": {
+        "input": "show system resources",
+        "msg": "Success",
+        "code": "200",
+        "body": {
+          "load_avg_1min": "0.34",
+          "memory_usage_total": "24632700",
+          "memory_usage_used": "7071164"
+        }
+      }
+    }
+  }
+}`,
+	"cli_show_ascii show clock ;show hostname": `{
+  "ins_api": {
+    "type": "cli_show_ascii",
+    "version": "1.0",
+    "sid": "eoc",
+    "outputs": {
+      "output": [
+        {"input": "show clock", "msg": "Success", "code": "200", "body": "12:34:56.789 UTC Mon Oct 12 2026\nTime source is NTP\n"},
+        {"input": "show hostname", "msg": "Success", "code": "200", "body": "nexus-1 \n"}
+      ]
+    }
+  }
+}`,
+	"cli_show_ascii show bogus": `{
+  "ins_api": {
+    "type": "cli_show_ascii",
+    "version": "1.0",
+    "sid": "eoc",
+    "outputs": {
+      "output": {
+        "input": "show bogus",
+        "clierror": "% Invalid command at '^' marker.\n",
+        "msg": "Input CLI command error",
+        "code": "400"
+      }
+    }
+  }
+}`,
+}
+
+func newNXAPITestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if r.URL.Path != "/ins" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if !ok || username != "monitoring" || password != testPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		request := nxapiRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Could not decode NX-API request: %v", err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		response, found := nxapiResponses[request.InsAPI.Type+" "+request.InsAPI.Input]
+		if !found {
+			t.Errorf("Unexpected NX-API request of type '%s': %s", request.InsAPI.Type, request.InsAPI.Input)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func nxapiDevice(t *testing.T, server *httptest.Server, password string) *config.DeviceGroupConfig {
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, _ := strconv.Atoi(serverURL.Port())
+	return &config.DeviceGroupConfig{
+		Transport:      config.TransportNXAPI,
+		NXAPI:          config.NXAPIConfig{Scheme: "https", Port: port, InsecureSkipVerify: true},
+		ConnectTimeout: 5,
+		CommandTimeout: 5,
+		AuthConfig: config.AuthConfig{
+			Username: "monitoring",
+			Password: config.NewSecret(password),
+		},
+	}
+}
+
+func TestNXAPIConnection(t *testing.T) {
+	server := newNXAPITestServer(t)
+	connection, err := NewConnectionManager().GetConnection("127.0.0.1", nxapiDevice(t, server, testPassword))
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer connection.Terminate()
+
+	info := connection.Info()
+	expectedInfo := DeviceInfo{
+		OSVersion: config.NXOS,
+		Version:   "9.3(5)",
+		Platform:  "Nexus9000 C93180YC-EX",
+		Serial:    "FDO21231ABC",
+		Uptime:    123*24*time.Hour + 4*time.Hour + 5*time.Minute + 6*time.Second,
+	}
+	info.DeviceInfo.IdentifiedAt = time.Time{}
+	if info.DeviceInfo != expectedInfo {
+		t.Errorf("Expected device info %+v, got %+v", expectedInfo, info.DeviceInfo)
+	}
+	if info.PrivilegeLevel != -1 {
+		t.Errorf("Expected unknown privilege level, got %d", info.PrivilegeLevel)
+	}
+
+	tests := []struct {
+		command        string
+		expectedLines  []string
+		expectedErrors int
+	}{
+		{"show clock\nshow hostname", []string{"12:34:56.789 UTC Mon Oct 12 2026", "Time source is NTP", "nexus-1 "}, 0},
+		{"show system resources | json", []string{`{
+          "load_avg_1min": "0.34",
+          "memory_usage_total": "24632700",
+          "memory_usage_used": "7071164"
+        }`}, 0},
+		{"show bogus", []string{}, 1},
+		{"", []string{}, 0},
+	}
+	for _, test := range tests {
+		sshCtx := NewSSHCommandContext(test.command)
+		go connection.RunCommand(context.Background(), sshCtx)
+		lines, errs := collectOutput(sshCtx)
+		if len(errs) != test.expectedErrors {
+			t.Errorf("%q: Expected %d errors, got %v", test.command, test.expectedErrors, errs)
+		}
+		if len(lines) != len(test.expectedLines) {
+			t.Errorf("%q: Expected lines %q, got %q", test.command, test.expectedLines, lines)
+			continue
+		}
+		for i := range lines {
+			if lines[i] != test.expectedLines[i] {
+				t.Errorf("%q: Expected line %q, got %q", test.command, test.expectedLines[i], lines[i])
+			}
+		}
+	}
+}
+
+func TestNXAPIConnectionUnauthorized(t *testing.T) {
+	server := newNXAPITestServer(t)
+	_, err := NewConnectionManager().GetConnection("127.0.0.1", nxapiDevice(t, server, "wrong"))
+	if err == nil {
+		t.Fatalf("Expected connection to fail")
+	}
+	if reason := FailureReason(err); reason != ReasonAuth {
+		t.Errorf("Expected failure reason '%s', got '%s': %v", ReasonAuth, reason, err)
+	}
+}
@@ -0,0 +1,127 @@
+package connector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/log"
+)
+
+// ReasonPrivilege is returned by FailureReason if the prompt could not be detected or entering privileged EXEC mode failed.
+const ReasonPrivilege = "privilege"
+
+// PrivilegeLevelEnabled is the privilege level of privileged EXEC mode, entered by `enable`.
+const PrivilegeLevelEnabled = 15
+
+var (
+	passwordPromptRegexp = regexp.MustCompile(`(?i)password: ?$`)
+	privilegeLevelRegexp = regexp.MustCompile(`[Pp]rivilege level(?: is|:)\s*(\d+)`)
+)
+
+// withTimeout runs f, which reads from the CLI without a deadline, and gives up once the timeout is reached.
+// If the timeout is reached, the connection has to be terminated, as f is still reading.
+func (conn *SSHConnection) withTimeout(timeout time.Duration, f func() error) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- f()
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("Timeout reached waiting for a prompt on %s", conn.Target)
+	}
+}
+
+// Enable waits for the initial prompt of the remote device. If it is unprivileged (`>`) and an enable password is configured,
+// privileged EXEC mode is entered by sending `enable`.
+// It returns whether `enable` was sent.
+func (conn *SSHConnection) Enable() (bool, error) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+
+	timeout := time.Duration(conn.Device().CommandTimeout) * time.Second
+	var prompt string
+	err := conn.withTimeout(timeout, func() (err error) {
+		prompt, err = conn.cli.learnPrompt()
+		return err
+	})
+	if err != nil {
+		return false, errors.Wrapf(err, "Could not detect the prompt")
+	}
+	conn.PrivilegeLevel = privilegeLevelForPrompt(prompt)
+	conn.SecurityContext = conn.cli.loginContext
+	if prompt == "#" {
+		return false, nil
+	}
+
+	password, configured, err := conn.Device().GetEnablePassword()
+	if err != nil {
+		return false, errors.Wrapf(err, "Could not read the enable secret")
+	}
+	if !configured {
+		log.Debugf("'%s' is in unprivileged EXEC mode and no enable password is configured", conn.Target)
+		return false, nil
+	}
+
+	var index int
+	err = conn.withTimeout(timeout, func() (err error) {
+		io.WriteString(conn.cli.writer, "enable\n")
+		index, prompt, err = conn.cli.waitForPrompt(passwordPromptRegexp)
+		if err != nil || index != 0 {
+			return err
+		}
+		io.WriteString(conn.cli.writer, password+"\n")
+		index, prompt, err = conn.cli.waitForPrompt(passwordPromptRegexp)
+		return err
+	})
+	if err != nil {
+		return true, errors.Wrapf(err, "No response to 'enable'")
+	}
+	if index == 0 || prompt != "#" {
+		return true, errors.New("The enable password was rejected")
+	}
+
+	conn.PrivilegeLevel = PrivilegeLevelEnabled
+	return true, nil
+}
+
+// IdentifyPrivilegeLevel determines the privilege level of the session by running `show privilege`.
+// If the output can not be parsed, the privilege level derived from the prompt is kept.
+func (conn *SSHConnection) IdentifyPrivilegeLevel() (int, error) {
+	sshCtx := NewSSHCommandContext("show privilege")
+	sshCtx.Timeout = 2
+	go conn.RunCommand(context.Background(), sshCtx)
+
+	var lastErr error = nil
+	level := -1
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			if level >= 0 {
+				conn.PrivilegeLevel = level
+			}
+			return conn.PrivilegeLevel, lastErr
+		case line := <-sshCtx.Output:
+			if match := privilegeLevelRegexp.FindStringSubmatch(line); match != nil && level < 0 {
+				level, _ = strconv.Atoi(match[1])
+			}
+		case lastErr = <-sshCtx.Errors:
+			continue
+		}
+	}
+}
+
+func privilegeLevelForPrompt(prompt string) int {
+	if prompt == "#" {
+		return PrivilegeLevelEnabled
+	}
+	return 1
+}
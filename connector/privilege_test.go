@@ -0,0 +1,83 @@
+package connector
+
+import (
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func connectToCiscoShell(t *testing.T, shell *ciscoShell, device *config.DeviceGroupConfig) (*SSHConnection, error) {
+	server := newTestServer(t, passwordServerConfig(testPassword), func(channel ssh.Channel) { shell.serve(channel) })
+	t.Cleanup(server.Close)
+
+	device.Port = server.Port()
+	device.ConnectTimeout = 5
+	device.CommandTimeout = 5
+	device.AuthConfig = config.AuthConfig{
+		Username:    "monitoring",
+		Password:    config.NewSecret(testPassword),
+		AuthMethods: []string{config.AuthPassword},
+	}
+	device.HostKey.Mode = config.HostKeyInsecure
+
+	return NewConnectionManager().establishConnection("127.0.0.1", device)
+}
+
+func TestEnable(t *testing.T) {
+	shell := &ciscoShell{
+		hostname:       "router",
+		enablePassword: "enable-secret",
+		outputs:        map[string]string{"show version": "Cisco IOS Software, C2960 Software\r\n"},
+	}
+	conn, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{EnablePassword: config.NewSecret("enable-secret")})
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer conn.Terminate()
+
+	if conn.PrivilegeLevel != PrivilegeLevelEnabled {
+		t.Errorf("Expected privilege level %d, got %d", PrivilegeLevelEnabled, conn.PrivilegeLevel)
+	}
+	if conn.DeviceInfo.OSVersion != config.IOS {
+		t.Errorf("Expected OS version to be identified after enable, got %s", conn.DeviceInfo.OSVersion)
+	}
+}
+
+func TestEnableWrongPassword(t *testing.T) {
+	shell := &ciscoShell{hostname: "router", enablePassword: "enable-secret"}
+	_, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{EnablePassword: config.NewSecret("wrong")})
+	if err == nil {
+		t.Fatalf("Expected connection with a wrong enable password to fail")
+	}
+	if reason := FailureReason(err); reason != ReasonPrivilege {
+		t.Errorf("Expected failure reason '%s', got '%s' (%v)", ReasonPrivilege, reason, err)
+	}
+}
+
+func TestEnableNotConfigured(t *testing.T) {
+	shell := &ciscoShell{hostname: "router", enablePassword: "enable-secret"}
+	conn, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{})
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer conn.Terminate()
+
+	if conn.PrivilegeLevel != 1 {
+		t.Errorf("Expected privilege level 1, got %d", conn.PrivilegeLevel)
+	}
+}
+
+func TestEnableAlreadyPrivileged(t *testing.T) {
+	shell := &ciscoShell{hostname: "router", privileged: true}
+	conn, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{EnablePassword: config.NewSecret("unused")})
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer conn.Terminate()
+
+	if conn.PrivilegeLevel != PrivilegeLevelEnabled {
+		t.Errorf("Expected privilege level %d, got %d", PrivilegeLevelEnabled, conn.PrivilegeLevel)
+	}
+}
@@ -0,0 +1,220 @@
+package connector
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/log"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ReasonProxyJump is returned by FailureReason if a jump host is not used due to a recent connection failure.
+const ReasonProxyJump = "proxy_jump"
+
+// tunnel is an SSH connection to a jump host. Tunnels are shared by all devices reached through the same chain of jump hosts
+// and closed once the last connection using them terminates.
+type tunnel struct {
+	key string
+	// name is the chain of jump hosts as `user@host,…`, which is logged instead of the key.
+	name   string
+	client *ssh.Client
+	parent *tunnel
+	refs   int
+	closed chan struct{}
+}
+
+// tunnelKey identifies the tunnel through the chain of jump hosts. Device groups only share a tunnel if they use the same
+// credentials and host key verification for every jump host, as the tunnel was authenticated and verified with them.
+// The key contains the credentials, it is only used to look up tunnels and must never be logged, see tunnelName.
+func tunnelKey(chain []*config.JumpHostConfig) string {
+	hops := make([]string, len(chain))
+	for i, jumpHost := range chain {
+		hops[i] = strings.Join([]string{
+			jumpHost.Username,
+			jumpHost.Address(),
+			jumpHost.KeyFile,
+			jumpHost.KeyPassphrase.Value(),
+			jumpHost.CertificateFile,
+			jumpHost.AgentSocket,
+			jumpHost.Password.Value(),
+			jumpHost.PasswordFile,
+			strings.Join(jumpHost.AuthMethods, ","),
+			jumpHost.HostKey.Mode,
+			jumpHost.HostKey.KnownHostsFile,
+			strings.Join(jumpHost.HostKey.Fingerprints, ","),
+		}, "\x00")
+	}
+	return strings.Join(hops, "\x01")
+}
+
+// tunnelName returns the chain of jump hosts as `user@host,…` for logs and errors.
+func tunnelName(chain []*config.JumpHostConfig) string {
+	hops := make([]string, len(chain))
+	for i, jumpHost := range chain {
+		hops[i] = jumpHost.Username + "@" + jumpHost.Address()
+	}
+	return strings.Join(hops, ",")
+}
+
+// isAlive returns whether the SSH connection to the jump host is still up. A nil tunnel (direct connection) is always alive.
+func (t *tunnel) isAlive() bool {
+	if t == nil {
+		return true
+	}
+	select {
+	case <-t.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+// dial opens a TCP connection to address through the tunnel, or directly if the tunnel is nil.
+func (t *tunnel) dial(address string, timeout time.Duration) (net.Conn, error) {
+	if t == nil {
+		return net.DialTimeout("tcp", address, timeout)
+	}
+
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	result := make(chan dialResult, 1)
+	go func() {
+		conn, err := t.client.Dial("tcp", address)
+		result <- dialResult{conn, err}
+	}()
+
+	select {
+	case r := <-result:
+		return r.conn, r.err
+	case <-time.After(timeout):
+		go func() {
+			if r := <-result; r.conn != nil {
+				r.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("Timeout dialing '%s' through jump host", address)
+	}
+}
+
+// acquireTunnel returns a tunnel through the given chain of jump hosts, establishing it if there is none yet.
+// Tunnels which failed to connect are not retried before the reconnect interval passed.
+// Jump hosts are dialed without holding tunnelsMutex. Concurrent acquires of a tunnel being dialed wait for its outcome.
+// Every acquired tunnel must be released using releaseTunnel.
+func (connMan *SSHConnectionManager) acquireTunnel(target string, chain []*config.JumpHostConfig, timeout time.Duration) (*tunnel, error) {
+	key, name := tunnelKey(chain), tunnelName(chain)
+	connMan.tunnelsMutex.Lock()
+	for {
+		if t, found := connMan.tunnels[key]; found {
+			if t.isAlive() {
+				t.refs++
+				connMan.tunnelsMutex.Unlock()
+				return t, nil
+			}
+			delete(connMan.tunnels, key)
+		}
+		dialing, found := connMan.tunnelDials[key]
+		if !found {
+			break
+		}
+		connMan.tunnelsMutex.Unlock()
+		<-dialing
+		connMan.tunnelsMutex.Lock()
+	}
+
+	if failedAt, found := connMan.tunnelFailures[key]; found && time.Since(failedAt) < connMan.reconnectInterval {
+		connMan.tunnelsMutex.Unlock()
+		return nil, newConnectError(target, ReasonProxyJump, fmt.Errorf("Jump host '%s' failed %s ago, not reconnecting before %s passed", name, time.Since(failedAt).Round(time.Second), connMan.reconnectInterval))
+	}
+	dialing := make(chan struct{})
+	connMan.tunnelDials[key] = dialing
+	connMan.tunnelsMutex.Unlock()
+	defer func() {
+		connMan.tunnelsMutex.Lock()
+		delete(connMan.tunnelDials, key)
+		connMan.tunnelsMutex.Unlock()
+		close(dialing)
+	}()
+
+	var parent *tunnel
+	if len(chain) > 1 {
+		var err error
+		parent, err = connMan.acquireTunnel(target, chain[:len(chain)-1], timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	jumpHost := chain[len(chain)-1]
+	client, err := connMan.dialJumpHost(target, parent, jumpHost, timeout)
+
+	connMan.tunnelsMutex.Lock()
+	defer connMan.tunnelsMutex.Unlock()
+	if err != nil {
+		connMan.tunnelFailures[key] = time.Now()
+		connMan.releaseTunnelLocked(parent)
+		return nil, err
+	}
+	delete(connMan.tunnelFailures, key)
+
+	t := &tunnel{
+		key:    key,
+		name:   name,
+		client: client,
+		parent: parent,
+		refs:   1,
+		closed: make(chan struct{}),
+	}
+	go func() {
+		client.Wait()
+		log.Infof("Connection to jump host '%s' closed", name)
+		close(t.closed)
+	}()
+	connMan.tunnels[key] = t
+	log.Infof("Established a tunnel through jump host '%s'", name)
+	return t, nil
+}
+
+func (connMan *SSHConnectionManager) dialJumpHost(target string, parent *tunnel, jumpHost *config.JumpHostConfig, timeout time.Duration) (*ssh.Client, error) {
+	address := jumpHost.Address()
+	transportConnection, err := parent.dial(address, timeout)
+	if err != nil {
+		return nil, newConnectError(target, ReasonDial, errors.Wrapf(err, "Could not connect to jump host '%s'", address))
+	}
+
+	client, err := connMan.handshake(target, transportConnection, address, &jumpHost.AuthConfig, &jumpHost.HostKey, timeout)
+	if err != nil {
+		transportConnection.Close()
+		return nil, errors.Wrapf(err, "Jump host '%s'", address)
+	}
+	return client, nil
+}
+
+// releaseTunnel releases a tunnel acquired using acquireTunnel. Releasing a nil tunnel is a no op.
+func (connMan *SSHConnectionManager) releaseTunnel(t *tunnel) {
+	connMan.tunnelsMutex.Lock()
+	defer connMan.tunnelsMutex.Unlock()
+	connMan.releaseTunnelLocked(t)
+}
+
+func (connMan *SSHConnectionManager) releaseTunnelLocked(t *tunnel) {
+	if t == nil {
+		return
+	}
+	t.refs--
+	if t.refs > 0 {
+		return
+	}
+	t.client.Close()
+	if connMan.tunnels[t.key] == t {
+		delete(connMan.tunnels, t.key)
+	}
+	connMan.releaseTunnelLocked(t.parent)
+}
@@ -0,0 +1,197 @@
+package connector
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func passwordServerConfig(password string) *ssh.ServerConfig {
+	return &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, p []byte) (*ssh.Permissions, error) {
+			if string(p) == password {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wrong password")
+		},
+	}
+}
+
+func jumpHostDevice(bastion *testServer, target *testServer, bastionPassword string) *config.DeviceGroupConfig {
+	return &config.DeviceGroupConfig{
+		Port:           target.Port(),
+		ConnectTimeout: 5,
+		AuthConfig: config.AuthConfig{
+			Username:    "monitoring",
+			Password:    config.NewSecret(testPassword),
+			AuthMethods: []string{config.AuthPassword},
+		},
+		HostKey: config.HostKeyConfig{Mode: config.HostKeyInsecure},
+		ProxyJump: []*config.JumpHostConfig{
+			{
+				Host: "127.0.0.1",
+				Port: bastion.Port(),
+				AuthConfig: config.AuthConfig{
+					Username:    "jump",
+					Password:    config.NewSecret(bastionPassword),
+					AuthMethods: []string{config.AuthPassword},
+				},
+				HostKey: config.HostKeyConfig{
+					Mode:         config.HostKeyStrict,
+					Fingerprints: []string{ssh.FingerprintSHA256(bastion.hostKey.PublicKey())},
+				},
+			},
+		},
+	}
+}
+
+func TestProxyJumpSharesTunnel(t *testing.T) {
+	bastion := newTestServer(t, passwordServerConfig("jump"), nil)
+	bastion.forwarding = true
+	defer bastion.Close()
+	target := newTestServer(t, passwordServerConfig(testPassword), nil)
+	defer target.Close()
+
+	connMan := NewConnectionManager()
+	device := jumpHostDevice(bastion, target, "jump")
+
+	client1, _, tunnel1, err := connMan.makeSSHClient("127.0.0.1", device)
+	if err != nil {
+		t.Fatalf("Expected connection through jump host to succeed: %v", err)
+	}
+	client2, _, tunnel2, err := connMan.makeSSHClient("127.0.0.1", device)
+	if err != nil {
+		t.Fatalf("Expected second connection through jump host to succeed: %v", err)
+	}
+
+	if tunnel1 != tunnel2 {
+		t.Errorf("Expected both connections to share the tunnel")
+	}
+	if connections := atomic.LoadInt32(&bastion.connections); connections != 1 {
+		t.Errorf("Expected a single connection to the jump host, got %d", connections)
+	}
+	if connections := atomic.LoadInt32(&target.connections); connections != 2 {
+		t.Errorf("Expected two connections to the target, got %d", connections)
+	}
+
+	client1.Close()
+	connMan.releaseTunnel(tunnel1)
+	if !tunnel1.isAlive() {
+		t.Errorf("Expected tunnel to stay open while it is in use")
+	}
+	client2.Close()
+	connMan.releaseTunnel(tunnel2)
+	<-tunnel2.closed
+	if len(connMan.tunnels) != 0 {
+		t.Errorf("Expected unused tunnel to be removed")
+	}
+}
+
+func TestProxyJumpReconnectInterval(t *testing.T) {
+	bastion := newTestServer(t, passwordServerConfig("jump"), nil)
+	bastion.forwarding = true
+	defer bastion.Close()
+	target := newTestServer(t, passwordServerConfig(testPassword), nil)
+	defer target.Close()
+
+	connMan := NewConnectionManager()
+	device := jumpHostDevice(bastion, target, "wrong")
+
+	_, _, _, err := connMan.makeSSHClient("127.0.0.1", device)
+	if reason := FailureReason(err); reason != ReasonAuth {
+		t.Fatalf("Expected jump host authentication to fail with reason '%s', got '%s' (%v)", ReasonAuth, reason, err)
+	}
+
+	_, _, _, err = connMan.makeSSHClient("127.0.0.1", device)
+	if reason := FailureReason(err); reason != ReasonProxyJump {
+		t.Errorf("Expected jump host not to be retried before the reconnect interval, got '%s' (%v)", reason, err)
+	}
+	if message := err.Error(); !strings.Contains(message, "'jump@127.0.0.1:") || strings.Contains(message, "wrong") {
+		t.Errorf("Expected the error to name the jump host chain without its settings, got %q", message)
+	}
+	if connections := atomic.LoadInt32(&bastion.connections); connections != 0 {
+		t.Errorf("Expected no successful connection to the jump host, got %d", connections)
+	}
+}
+
+func TestProxyJumpSeparateCredentials(t *testing.T) {
+	bastion := newTestServer(t, passwordServerConfig("jump"), nil)
+	bastion.forwarding = true
+	defer bastion.Close()
+	target := newTestServer(t, passwordServerConfig(testPassword), nil)
+	defer target.Close()
+
+	connMan := NewConnectionManager()
+	client, _, tunnel, err := connMan.makeSSHClient("127.0.0.1", jumpHostDevice(bastion, target, "jump"))
+	if err != nil {
+		t.Fatalf("Expected connection through jump host to succeed: %v", err)
+	}
+	defer func() {
+		client.Close()
+		connMan.releaseTunnel(tunnel)
+	}()
+
+	// Another device group using the same jump host with other credentials must not use the authenticated tunnel.
+	_, _, _, err = connMan.makeSSHClient("127.0.0.1", jumpHostDevice(bastion, target, "wrong"))
+	if reason := FailureReason(err); reason != ReasonAuth {
+		t.Errorf("Expected jump host authentication to fail with reason '%s', got '%s' (%v)", ReasonAuth, reason, err)
+	}
+}
+
+func TestProxyJumpDialsWithoutLock(t *testing.T) {
+	// The stalled jump host accepts connections, but never answers the SSH handshake.
+	stalled, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stalled.Close()
+	go func() {
+		for {
+			conn, err := stalled.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+
+	jumpHost := func(listener net.Listener) []*config.JumpHostConfig {
+		return []*config.JumpHostConfig{{
+			Host:       "127.0.0.1",
+			Port:       listener.Addr().(*net.TCPAddr).Port,
+			AuthConfig: config.AuthConfig{Username: "jump", Password: config.NewSecret("jump"), AuthMethods: []string{config.AuthPassword}},
+			HostKey:    config.HostKeyConfig{Mode: config.HostKeyInsecure},
+		}}
+	}
+
+	connMan := NewConnectionManager()
+	stalledDone := make(chan error, 1)
+	go func() {
+		_, err := connMan.acquireTunnel("a", jumpHost(stalled), 2*time.Second)
+		stalledDone <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	startTime := time.Now()
+	if _, err := connMan.acquireTunnel("b", jumpHost(closed), 2*time.Second); FailureReason(err) != ReasonDial {
+		t.Errorf("Expected dialing the closed jump host to fail with reason '%s', got %v", ReasonDial, err)
+	}
+	if elapsed := time.Since(startTime); elapsed > time.Second {
+		t.Errorf("Expected other jump hosts not to wait for the stalled one, took %s", elapsed)
+	}
+	if err := <-stalledDone; err == nil {
+		t.Errorf("Expected the stalled jump host to time out")
+	}
+}
@@ -0,0 +1,260 @@
+package connector
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer is an in-process SSH server used to test the connector against.
+// Interactive shells are handled by the shell function, exec requests by the exec function if it is set.
+type testServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	hostKey  ssh.Signer
+	shell    func(channel ssh.Channel)
+	exec     func(command string) (string, uint32)
+	// forwarding enables direct-tcpip channels, which makes the server usable as jump host.
+	forwarding  bool
+	connections int32
+	wg          sync.WaitGroup
+}
+
+func newTestServer(t *testing.T, serverConfig *ssh.ServerConfig, shell func(channel ssh.Channel)) *testServer {
+	return newExecTestServer(t, serverConfig, shell, nil)
+}
+
+// newExecTestServer returns a testServer answering exec requests with the output and exit status returned by exec.
+func newExecTestServer(t *testing.T, serverConfig *ssh.ServerConfig, shell func(channel ssh.Channel), exec func(command string) (string, uint32)) *testServer {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not generate host key: %v", err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Could not create host key signer: %v", err)
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+
+	server := &testServer{
+		listener: listener,
+		config:   serverConfig,
+		hostKey:  hostKey,
+		shell:    shell,
+		exec:     exec,
+	}
+	go server.serve()
+	return server
+}
+
+// Port returns the TCP port the server is listening on.
+func (s *testServer) Port() int {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+func (s *testServer) Close() {
+	s.listener.Close()
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handleConn(conn)
+	}
+}
+
+func (s *testServer) handleConn(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	atomic.AddInt32(&s.connections, 1)
+	go ssh.DiscardRequests(reqs)
+
+	for newChannel := range chans {
+		if newChannel.ChannelType() == "direct-tcpip" && s.forwarding {
+			go s.handleForward(newChannel)
+			continue
+		}
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go s.handleSession(channel, requests)
+	}
+}
+
+func (s *testServer) handleSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			req.Reply(true, nil)
+		case "shell":
+			if s.shell == nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			go func() {
+				s.shell(channel)
+				channel.Close()
+			}()
+		case "exec":
+			var payload struct{ Command string }
+			if s.exec == nil || ssh.Unmarshal(req.Payload, &payload) != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			go func() {
+				output, status := s.exec(payload.Command)
+				io.WriteString(channel, output)
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				channel.Close()
+			}()
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func (s *testServer) handleForward(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	go func() {
+		io.Copy(conn, channel)
+		conn.Close()
+	}()
+	io.Copy(channel, conn)
+	channel.Close()
+}
+
+// ciscoShell emulates the CLI of an IOS device. Like real devices, it echoes the command lines it receives.
+// Commands are answered using the outputs map, `show privilege` is answered by the shell itself.
+type ciscoShell struct {
+	hostname       string
+	privileged     bool
+	enablePassword string
+	outputs        map[string]string
+	// delays postpones the output of commands.
+	delays map[string]time.Duration
+	// running and maxRunning count the commands run at the same time in exec channels.
+	running    int32
+	maxRunning int32
+}
+
+// exec answers a command run in an exec channel.
+func (c *ciscoShell) exec(command string) (string, uint32) {
+	running := atomic.AddInt32(&c.running, 1)
+	defer atomic.AddInt32(&c.running, -1)
+	for {
+		max := atomic.LoadInt32(&c.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt32(&c.maxRunning, max, running) {
+			break
+		}
+	}
+
+	time.Sleep(c.delays[command])
+	output, found := c.outputs[command]
+	if !found {
+		return "% Invalid input detected at '^' marker.\r\n", 1
+	}
+	return output, 0
+}
+
+func (c *ciscoShell) prompt() string {
+	if c.privileged {
+		return c.hostname + "#"
+	}
+	return c.hostname + ">"
+}
+
+func (c *ciscoShell) serve(channel io.ReadWriter) {
+	io.WriteString(channel, "\r\nUnauthorized access is prohibited!\r\n\r\n"+c.prompt())
+
+	waitingForPassword := false
+	scanner := bufio.NewScanner(channel)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if waitingForPassword {
+			waitingForPassword = false
+			io.WriteString(channel, "\r\n")
+			if line == c.enablePassword {
+				c.privileged = true
+			} else {
+				io.WriteString(channel, "% Access denied\r\n\r\n")
+			}
+			io.WriteString(channel, c.prompt())
+			continue
+		}
+
+		io.WriteString(channel, line+"\r\n")
+		if line == "enable" && !c.privileged {
+			if c.enablePassword == "" {
+				io.WriteString(channel, "% No password set\r\n"+c.prompt())
+				continue
+			}
+			waitingForPassword = true
+			io.WriteString(channel, "Password: ")
+			continue
+		}
+
+		if line == "show privilege" {
+			level := 1
+			if c.privileged {
+				level = PrivilegeLevelEnabled
+			}
+			io.WriteString(channel, fmt.Sprintf("Current privilege level is %d\r\n", level))
+		} else {
+			time.Sleep(c.delays[line])
+			io.WriteString(channel, c.outputs[line])
+		}
+		io.WriteString(channel, c.prompt())
+	}
+}
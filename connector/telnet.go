@@ -0,0 +1,214 @@
+package connector
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// Telnet commands and options, see RFC 854, RFC 857, RFC 858, RFC 1073 and RFC 1091.
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptionEcho            = 1
+	telnetOptionSuppressGoAhead = 3
+	telnetOptionTerminalType    = 24
+	telnetOptionWindowSize      = 31
+	telnetTerminalTypeIs        = 0
+	telnetTerminalTypeSend      = 1
+	telnetTerminalType          = "vt100"
+	telnetWindowWidth           = 2000
+	telnetWindowHeight          = 0
+)
+
+var (
+	usernamePromptRegexp = regexp.MustCompile(`(?i)(?:username|login): ?$`)
+	loginFailedRegexp    = regexp.MustCompile(`(?i)% ?(?:login invalid|authentication failed|bad passwords|access denied)`)
+)
+
+// telnetConn removes the Telnet commands from the data received on a TCP connection and negotiates the options they request.
+// Like the SSH session, it asks for a terminal without paginator: `vt100` with a window height of 0.
+type telnetConn struct {
+	conn net.Conn
+	// pending holds an incomplete command received at the end of the last read.
+	pending []byte
+	// buffered holds data which did not fit into the buffer passed to the last read.
+	buffered []byte
+	// carriageReturn is set if the last data byte was a carriage return.
+	carriageReturn bool
+}
+
+func newTelnetConn(conn net.Conn) *telnetConn {
+	return &telnetConn{conn: conn}
+}
+
+// Read implements the io.Reader interface's Read function. It returns the received data without Telnet commands.
+func (t *telnetConn) Read(buf []byte) (int, error) {
+	if len(t.buffered) > 0 {
+		n := copy(buf, t.buffered)
+		t.buffered = t.buffered[n:]
+		return n, nil
+	}
+	for {
+		n, err := t.conn.Read(buf)
+		if n > 0 {
+			data := append(t.pending, buf[:n]...)
+			t.pending = nil
+			data, replies := t.process(data)
+			if len(replies) > 0 {
+				if _, err := t.conn.Write(replies); err != nil {
+					return 0, err
+				}
+			}
+			if len(data) > 0 {
+				n := copy(buf, data)
+				t.buffered = data[n:]
+				return n, nil
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// process splits data into the data to return and the replies to the commands it contains.
+// An incomplete command at the end of data is kept for the next read.
+func (t *telnetConn) process(data []byte) ([]byte, []byte) {
+	out := make([]byte, 0, len(data))
+	replies := make([]byte, 0)
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		if b != telnetIAC {
+			// A carriage return is followed by NUL if no line feed follows.
+			if b != 0 || !t.carriageReturn {
+				out = append(out, b)
+			}
+			t.carriageReturn = b == '\r'
+			continue
+		}
+		if i+1 >= len(data) {
+			t.pending = data[i:]
+			break
+		}
+
+		command := data[i+1]
+		switch command {
+		case telnetIAC:
+			out = append(out, telnetIAC)
+			t.carriageReturn = false
+			i++
+		case telnetDO, telnetDONT, telnetWILL, telnetWONT:
+			if i+2 >= len(data) {
+				t.pending = data[i:]
+				return out, replies
+			}
+			replies = append(replies, negotiate(command, data[i+2])...)
+			i += 2
+		case telnetSB:
+			end := bytes.Index(data[i:], []byte{telnetIAC, telnetSE})
+			if end < 0 {
+				t.pending = data[i:]
+				return out, replies
+			}
+			sub := data[i+2 : i+end]
+			if len(sub) == 2 && sub[0] == telnetOptionTerminalType && sub[1] == telnetTerminalTypeSend {
+				replies = append(replies, telnetIAC, telnetSB, telnetOptionTerminalType, telnetTerminalTypeIs)
+				replies = append(replies, telnetTerminalType...)
+				replies = append(replies, telnetIAC, telnetSE)
+			}
+			i += end + 1
+		default:
+			// Other commands like NOP or GA carry no data.
+			i++
+		}
+	}
+	return out, replies
+}
+
+// negotiate returns the reply to an option negotiation. The remote device may echo and suppress go ahead,
+// we announce the terminal type and window size. Everything else is refused.
+// Refusals are not answered, which prevents negotiation loops.
+func negotiate(command byte, option byte) []byte {
+	switch command {
+	case telnetWILL:
+		if option == telnetOptionEcho || option == telnetOptionSuppressGoAhead {
+			return []byte{telnetIAC, telnetDO, option}
+		}
+		return []byte{telnetIAC, telnetDONT, option}
+	case telnetDO:
+		switch option {
+		case telnetOptionSuppressGoAhead, telnetOptionTerminalType:
+			return []byte{telnetIAC, telnetWILL, option}
+		case telnetOptionWindowSize:
+			return []byte{telnetIAC, telnetWILL, option,
+				telnetIAC, telnetSB, option, telnetWindowWidth >> 8, telnetWindowWidth & 0xff, telnetWindowHeight >> 8, telnetWindowHeight & 0xff, telnetIAC, telnetSE}
+		}
+		return []byte{telnetIAC, telnetWONT, option}
+	}
+	return nil
+}
+
+// Write implements the io.Writer interface's Write function. Line feeds are sent as CR LF, IAC is escaped.
+func (t *telnetConn) Write(data []byte) (int, error) {
+	escaped := make([]byte, 0, len(data))
+	for _, b := range data {
+		switch b {
+		case '\n':
+			escaped = append(escaped, '\r', '\n')
+		case telnetIAC:
+			escaped = append(escaped, telnetIAC, telnetIAC)
+		default:
+			escaped = append(escaped, b)
+		}
+	}
+	if _, err := t.conn.Write(escaped); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// errLoginFailed is returned by login if the remote device rejected the credentials.
+var errLoginFailed = errors.New("The remote device rejected the credentials")
+
+// login answers the `Username:` and `Password:` prompts of a Telnet login. Devices only asking for a password,
+// e.g. a line password, get the password only. It returns once the CLI prompt appears, which is left for learnPrompt.
+func (s *cliSession) login(username string, password string) error {
+	buf := make([]byte, 4096)
+	usernameSent, passwordSent := false, false
+	for {
+		received := s.received()
+		switch {
+		case loginFailedRegexp.MatchString(received):
+			return errLoginFailed
+		case usernamePromptRegexp.MatchString(received):
+			if usernameSent {
+				return errLoginFailed
+			}
+			usernameSent = true
+			s.pending = ""
+			io.WriteString(s.writer, username+"\n")
+		case passwordPromptRegexp.MatchString(received):
+			if passwordSent {
+				return errLoginFailed
+			}
+			passwordSent = true
+			s.pending = ""
+			io.WriteString(s.writer, password+"\n")
+		case initialPromptRegexp.MatchString(received):
+			return nil
+		}
+		if err := s.read(buf); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package connector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+// telnetStandIn is a local TCP server speaking Telnet like a Cisco device: it negotiates the options, asks for username
+// and password and then serves a ciscoShell. The commands received from the client are recorded.
+type telnetStandIn struct {
+	listener net.Listener
+	shell    *ciscoShell
+	// passwordOnly skips the username prompt, like a line password does.
+	passwordOnly bool
+	mu           sync.Mutex
+	commands     []byte
+}
+
+func newTelnetStandIn(t *testing.T, shell *ciscoShell, passwordOnly bool) *telnetStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	s := &telnetStandIn{listener: listener, shell: shell, passwordOnly: passwordOnly}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *telnetStandIn) device() *config.DeviceGroupConfig {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return &config.DeviceGroupConfig{
+		Transport:      config.TransportTelnet,
+		Port:           p,
+		ConnectTimeout: 5,
+		CommandTimeout: 5,
+		AuthConfig: config.AuthConfig{
+			Username: "monitoring",
+			Password: config.NewSecret(testPassword),
+		},
+	}
+}
+
+func (s *telnetStandIn) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.Write([]byte{
+		telnetIAC, telnetWILL, telnetOptionEcho,
+		telnetIAC, telnetWILL, telnetOptionSuppressGoAhead,
+		telnetIAC, telnetDO, telnetOptionTerminalType,
+		telnetIAC, telnetDO, telnetOptionWindowSize,
+		telnetIAC, telnetDO, 39, // NEW-ENVIRON is refused
+		telnetIAC, telnetSB, telnetOptionTerminalType, telnetTerminalTypeSend, telnetIAC, telnetSE,
+	})
+	// The IAC in the banner is escaped.
+	conn.Write([]byte("\r\n\xff\xff\r\nUser Access Verification\r\n\r\n"))
+
+	client := &recordingReader{conn: conn, standIn: s}
+	reader := bufio.NewReader(client)
+	for attempt := 0; attempt < 3; attempt++ {
+		username := "monitoring"
+		if !s.passwordOnly {
+			io.WriteString(conn, "Username: ")
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			username = strings.TrimRight(line, "\r\n")
+		}
+		io.WriteString(conn, "Password: ")
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if username == "monitoring" && strings.TrimRight(line, "\r\n") == testPassword {
+			io.WriteString(conn, "\r\n")
+			s.shell.serve(struct {
+				io.Reader
+				io.Writer
+			}{reader, conn})
+			return
+		}
+		io.WriteString(conn, "\r\n% Login invalid\r\n\r\n")
+	}
+}
+
+// recordedCommands returns the Telnet commands received so far.
+func (s *telnetStandIn) recordedCommands() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]byte{}, s.commands...)
+}
+
+// recordingReader removes the Telnet commands sent by the client and records them.
+type recordingReader struct {
+	conn    net.Conn
+	standIn *telnetStandIn
+	command []byte
+}
+
+func (r *recordingReader) Read(buf []byte) (int, error) {
+	raw := make([]byte, len(buf))
+	for {
+		n, err := r.conn.Read(raw)
+		out := 0
+		for _, b := range raw[:n] {
+			if len(r.command) == 0 && b != telnetIAC {
+				buf[out] = b
+				out++
+				continue
+			}
+			r.command = append(r.command, b)
+			// Commands are either IAC <command> <option> or subnegotiations ending with IAC SE.
+			if (len(r.command) == 3 && r.command[1] != telnetSB) || bytes.HasSuffix(r.command, []byte{telnetIAC, telnetSE}) {
+				r.standIn.mu.Lock()
+				r.standIn.commands = append(r.standIn.commands, r.command...)
+				r.standIn.mu.Unlock()
+				r.command = nil
+			}
+		}
+		if out > 0 || err != nil {
+			return out, err
+		}
+	}
+}
+
+func TestTelnetConnection(t *testing.T) {
+	for _, passwordOnly := range []bool{false, true} {
+		shell := &ciscoShell{
+			hostname: "switch",
+			outputs: map[string]string{
+				"show version": "Cisco IOS Software, C2950 Software (C2950-I6Q4L2-M), Version 12.1(22)EA14, RELEASE SOFTWARE (fc1)\r\n" +
+					"cisco WS-C2950T-24 (RC32300) processor (revision R0) with 19959K bytes of memory.\r\n" +
+					"Processor board ID FOC0815Z1AB\r\n",
+				"show clock": "*12:34:56.789 UTC Sun Oct 18 2026\r\n",
+			},
+		}
+		standIn := newTelnetStandIn(t, shell, passwordOnly)
+		connection, err := NewConnectionManager().GetConnection("127.0.0.1", standIn.device())
+		if err != nil {
+			t.Fatalf("Expected connection to succeed: %v", err)
+		}
+		conn := connection.(*SSHConnection)
+
+		info := conn.Info()
+		if info.DeviceInfo.OSVersion != config.IOS || info.DeviceInfo.Version != "12.1(22)EA14" || info.DeviceInfo.Platform != "WS-C2950T-24" {
+			t.Errorf("Unexpected device info %+v", info.DeviceInfo)
+		}
+		if info.PrivilegeLevel != 1 {
+			t.Errorf("Expected privilege level 1, got %d", info.PrivilegeLevel)
+		}
+
+		sshCtx := NewSSHCommandContext("show clock")
+		go conn.RunCommand(context.Background(), sshCtx)
+		lines, errs := collectOutput(sshCtx)
+		if len(errs) != 0 || len(lines) != 1 || lines[0] != "*12:34:56.789 UTC Sun Oct 18 2026" {
+			t.Errorf("Unexpected output %q: %v", lines, errs)
+		}
+
+		commands := standIn.recordedCommands()
+		for _, expected := range [][]byte{
+			{telnetIAC, telnetDO, telnetOptionEcho},
+			{telnetIAC, telnetWILL, telnetOptionWindowSize, telnetIAC, telnetSB, telnetOptionWindowSize, 0x07, 0xd0, 0, 0, telnetIAC, telnetSE},
+			append(append([]byte{telnetIAC, telnetSB, telnetOptionTerminalType, telnetTerminalTypeIs}, "vt100"...), telnetIAC, telnetSE),
+			{telnetIAC, telnetWONT, 39},
+		} {
+			if !bytes.Contains(commands, expected) {
+				t.Errorf("Expected the client to send %v, got %v", expected, commands)
+			}
+		}
+
+		conn.Terminate()
+		if conn.IsConnected() {
+			t.Errorf("Expected terminated connection to be disconnected")
+		}
+	}
+}
+
+func TestTelnetConnectionLoginFailed(t *testing.T) {
+	standIn := newTelnetStandIn(t, &ciscoShell{hostname: "switch"}, false)
+	device := standIn.device()
+	device.Password = config.NewSecret("wrong")
+	_, err := NewConnectionManager().GetConnection("127.0.0.1", device)
+	if reason := FailureReason(err); reason != ReasonAuth {
+		t.Errorf("Expected failure reason '%s', got '%s': %v", ReasonAuth, reason, err)
+	}
+}
+
+func TestTelnetConnSplitCommands(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	telnet := newTelnetConn(client)
+
+	replies := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 3)
+		io.ReadFull(server, buf)
+		replies <- buf
+	}()
+	go func() {
+		// Each part ends in the middle of a command or a CR NUL sequence.
+		for _, part := range [][]byte{{'a', telnetIAC}, {telnetWILL}, {telnetOptionEcho, 'b', '\r'}, {0, 'c', telnetIAC}, {telnetIAC, '\n'}} {
+			server.Write(part)
+		}
+	}()
+
+	received := make([]byte, 0)
+	buf := make([]byte, 16)
+	for len(received) < 6 {
+		n, err := telnet.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		received = append(received, buf[:n]...)
+	}
+	if reply := <-replies; !bytes.Equal(reply, []byte{telnetIAC, telnetDO, telnetOptionEcho}) {
+		t.Errorf("Unexpected reply %v", reply)
+	}
+	if expected := []byte("ab\rc\xff\n"); !bytes.Equal(received, expected) {
+		t.Errorf("Expected %q, got %q", expected, received)
+	}
+}
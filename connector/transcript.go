@@ -0,0 +1,115 @@
+package connector
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+type transcriptKey struct{}
+
+// Transcript records the commands run on the remote device and their raw output, see WithTranscript.
+type Transcript struct {
+	mu      sync.Mutex
+	entries []*TranscriptEntry
+}
+
+// TranscriptEntry is a command run on the remote device, or a NETCONF request, and its raw output.
+type TranscriptEntry struct {
+	Command string   `json:"command"`
+	Output  []string `json:"output"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// WithTranscript returns a context recording the commands run with it into transcript.
+func WithTranscript(ctx context.Context, transcript *Transcript) context.Context {
+	return context.WithValue(ctx, transcriptKey{}, transcript)
+}
+
+// Entries returns a copy of the recorded commands in the order they were run.
+func (t *Transcript) Entries() []TranscriptEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entries := make([]TranscriptEntry, len(t.entries))
+	for i, entry := range t.entries {
+		entries[i] = TranscriptEntry{
+			Command: entry.Command,
+			Output:  append([]string{}, entry.Output...),
+			Errors:  append([]string{}, entry.Errors...),
+		}
+	}
+	return entries
+}
+
+func (t *Transcript) add(command string) *TranscriptEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := &TranscriptEntry{Command: command, Output: make([]string, 0)}
+	t.entries = append(t.entries, entry)
+	return entry
+}
+
+func (t *Transcript) addOutput(entry *TranscriptEntry, line string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry.Output = append(entry.Output, line)
+}
+
+func (t *Transcript) addError(entry *TranscriptEntry, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry.Errors = append(entry.Errors, err.Error())
+}
+
+// recordNETCONF records a NETCONF request and its reply if ctx carries a Transcript.
+func recordNETCONF(ctx context.Context, filter string, reply []byte, err error) {
+	transcript, ok := ctx.Value(transcriptKey{}).(*Transcript)
+	if !ok {
+		return
+	}
+	entry := transcript.add("get " + filter)
+	for _, line := range strings.Split(string(reply), "\n") {
+		transcript.addOutput(entry, line)
+	}
+	if err != nil {
+		transcript.addError(entry, err)
+	}
+}
+
+// record returns the command context to run the command of sshCtx with. If ctx carries a Transcript,
+// the command and its output are recorded on their way to sshCtx. Otherwise sshCtx is returned.
+func record(ctx context.Context, sshCtx *SSHCommandContext) *SSHCommandContext {
+	transcript, ok := ctx.Value(transcriptKey{}).(*Transcript)
+	if !ok || strings.TrimSpace(sshCtx.Command) == "" {
+		return sshCtx
+	}
+
+	entry := transcript.add(sshCtx.Command)
+	recorded := NewSSHCommandContext(sshCtx.Command)
+	recorded.Template = sshCtx.Template
+	recorded.Timeout = sshCtx.Timeout
+	go func() {
+		for {
+			select {
+			case line := <-recorded.Output:
+				transcript.addOutput(entry, line)
+				select {
+				case sshCtx.Output <- line:
+				case <-ctx.Done():
+				}
+			case err := <-recorded.Errors:
+				transcript.addError(entry, err)
+				select {
+				case sshCtx.Errors <- err:
+				case <-ctx.Done():
+				}
+			case <-recorded.Done:
+				sshCtx.Done <- struct{}{}
+				return
+			}
+		}
+	}()
+	return recorded
+}
@@ -0,0 +1,47 @@
+package connector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+func TestTranscript(t *testing.T) {
+	shell := &ciscoShell{
+		hostname:   "router",
+		privileged: true,
+		outputs:    map[string]string{"show users": "line one\r\nline two\r\n"},
+	}
+	conn, err := connectToCiscoShell(t, shell, &config.DeviceGroupConfig{})
+	if err != nil {
+		t.Fatalf("Expected connection to succeed: %v", err)
+	}
+	defer conn.Terminate()
+
+	transcript := &Transcript{}
+	ctx := WithTranscript(context.Background(), transcript)
+	sshCtx := NewSSHCommandContext("show users")
+	go conn.RunCommand(ctx, sshCtx)
+	lines, errs := collectOutput(sshCtx)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+
+	entries := transcript.Entries()
+	if len(entries) != 1 || entries[0].Command != "show users" {
+		t.Fatalf("Expected the command to be recorded, got %+v", entries)
+	}
+	if !reflect.DeepEqual(entries[0].Output, lines) || len(lines) == 0 {
+		t.Errorf("Expected the recorded output %q to match the output %q", entries[0].Output, lines)
+	}
+
+	// Commands run without a transcript are not recorded.
+	sshCtx = NewSSHCommandContext("show users")
+	go conn.RunCommand(context.Background(), sshCtx)
+	collectOutput(sshCtx)
+	if len(transcript.Entries()) != 1 {
+		t.Errorf("Expected only one recorded command, got %+v", transcript.Entries())
+	}
+}
@@ -0,0 +1,76 @@
+package connector
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+// Connection is the transport commands are run on the remote device with.
+// It is implemented by SSHConnection, running commands in an interactive SSH session, NXAPIConnection and NETCONFConnection.
+type Connection interface {
+	// RunCommand runs the command on the remote device. Lines of its output are written to the Output chan of sshCtx,
+	// errors to the Errors chan. Once the command finished or ctx is done, Done is signaled.
+	// If ctx carries a Transcript, see WithTranscript, the command and its output are recorded.
+	RunCommand(ctx context.Context, sshCtx *SSHCommandContext)
+	// Info returns what is known about the remote device.
+	Info() *ConnectionInfo
+	// IsConnected returns whether commands can still be run.
+	IsConnected() bool
+	// IsAuthenticated returns whether the credentials are still accepted by the remote device.
+	IsAuthenticated() bool
+	// Terminate closes the connection.
+	Terminate()
+}
+
+// ConnectionInfo holds what is known about the remote device behind a Connection.
+type ConnectionInfo struct {
+	Target string
+	// device is replaced when the configuration is reloaded, see Device.
+	device     *config.DeviceGroupConfig
+	DeviceInfo DeviceInfo
+	// SecurityContext is the security context of an ASA in multiple context mode the session logged into,
+	// empty for the system context and other devices.
+	SecurityContext string
+	// PrivilegeLevel is the privilege level of the session, -1 if it is unknown.
+	PrivilegeLevel int
+	// Established is the time the connection was established.
+	Established time.Time
+	// jsonUnsupported is set once the device did not answer a command with JSON, see JSONUnsupported.
+	jsonUnsupported int32
+}
+
+// deviceMu protects the device group configuration of all connections, which is replaced on reload while commands run.
+var deviceMu sync.RWMutex
+
+// Device returns the device group configuration the connection uses.
+func (info *ConnectionInfo) Device() *config.DeviceGroupConfig {
+	deviceMu.RLock()
+	defer deviceMu.RUnlock()
+	return info.device
+}
+
+func (info *ConnectionInfo) setDevice(device *config.DeviceGroupConfig) {
+	deviceMu.Lock()
+	defer deviceMu.Unlock()
+	info.device = device
+}
+
+// JSONUnsupported returns whether the device did not answer a command with JSON before, so collectors can
+// parse the text output right away.
+func (info *ConnectionInfo) JSONUnsupported() bool {
+	return atomic.LoadInt32(&info.jsonUnsupported) != 0
+}
+
+// SetJSONUnsupported records that the device does not answer commands with JSON.
+func (info *ConnectionInfo) SetJSONUnsupported() {
+	atomic.StoreInt32(&info.jsonUnsupported, 1)
+}
+
+// Info implements the Connection interface's Info function.
+func (info *ConnectionInfo) Info() *ConnectionInfo {
+	return info
+}
@@ -1,11 +1,13 @@
 package cpu
 
 import (
+	"context"
 	"regexp"
 
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
 	"gitlab.com/wobcom/cisco-exporter/util"
 
 	"github.com/pkg/errors"
@@ -14,12 +16,15 @@ import (
 
 const prefix string = "cisco_cpu_"
 
+var errNoMetric = errors.New("No cpu metric was extracted")
+
 var (
-	cpuUsageDesc       *prometheus.Desc
-	cpuFiveSecondsDesc *prometheus.Desc
-	cpuOneMinuteDesc   *prometheus.Desc
-	cpuFiveMinutesDesc *prometheus.Desc
-	cpuInterruptsDesc  *prometheus.Desc
+	cpuUsageDesc          *prometheus.Desc
+	cpuFiveSecondsDesc    *prometheus.Desc
+	cpuOneMinuteDesc      *prometheus.Desc
+	cpuFiveMinutesDesc    *prometheus.Desc
+	cpuFifteenMinutesDesc *prometheus.Desc
+	cpuInterruptsDesc     *prometheus.Desc
 )
 
 // Collector gathers metrics for the remote device's cpu usage.
@@ -43,6 +48,7 @@ func init() {
 	cpuFiveSecondsDesc = prometheus.NewDesc(prefix+"five_seconds_percent", "CPU utilization for five seconds", l, nil)
 	cpuOneMinuteDesc = prometheus.NewDesc(prefix+"one_minute_percent", "CPU utilization for one minute", l, nil)
 	cpuFiveMinutesDesc = prometheus.NewDesc(prefix+"five_minutes_percent", "CPU utilization for five minutes", l, nil)
+	cpuFifteenMinutesDesc = prometheus.NewDesc(prefix+"fifteen_minutes_percent", "CPU utilization for fifteen minutes", l, nil)
 	cpuInterruptsDesc = prometheus.NewDesc(prefix+"interrupt_percent", "Interrupt percentage", l, nil)
 }
 
@@ -52,17 +58,37 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- cpuFiveSecondsDesc
 	ch <- cpuOneMinuteDesc
 	ch <- cpuFiveMinutesDesc
+	ch <- cpuFifteenMinutesDesc
 	ch <- cpuInterruptsDesc
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	if netconf, ok := collectCtx.Connection.(*connector.NETCONFConnection); ok {
+		if err := c.collectNETCONF(ctx, collectCtx, result, netconf); err != nil {
+			result.AddError(errors.Wrap(err, "Error scraping cpu usage"))
+		}
+		return result
+	}
+
+	if collectCtx.Connection.Info().DeviceInfo.OSVersion == config.NXOS {
+		err := c.collectNXOSJSON(ctx, collectCtx, result)
+		if errors.Cause(err) != nxos.ErrJSONUnsupported {
+			if err != nil {
+				result.AddError(errors.Wrap(err, "Error scraping cpu usage"))
+			}
+			return result
+		}
+	}
 
-	sshCtx := connector.NewSSHCommandContext("show processes cpu")
-	go ctx.Connection.RunCommand(sshCtx)
+	command := "show processes cpu"
+	if collectCtx.Connection.Info().DeviceInfo.OSVersion == config.ASA {
+		command = "show cpu usage"
+	}
+	sshCtx := connector.NewSSHCommandContext(command)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	matchesCount := 0
 
@@ -70,44 +96,101 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 		select {
 		case <-sshCtx.Done:
 			if matchesCount == 0 {
-				ctx.Errors <- errors.New("No cpu metric was extracted")
+				result.AddError(errNoMetric)
 			}
-			return
+			return result
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping cpu usage: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping cpu usage: %v", err))
 		case line := <-sshCtx.Output:
-			var result bool
-			if ctx.Connection.Device.OSVersion == config.NXOS {
-				result = c.parseNXOS(ctx, line)
-			} else {
-				result = c.parse(ctx, line)
+			var matched bool
+			switch collectCtx.Connection.Info().DeviceInfo.OSVersion {
+			case config.NXOS:
+				matched = c.parseNXOS(collectCtx, result, line)
+			case config.IOSXR:
+				matched = c.parseXR(collectCtx, result, line)
+			case config.ASA:
+				matched = c.parseASA(collectCtx, result, line)
+			default:
+				matched = c.parse(collectCtx, result, line)
 			}
-			if result {
+			if matched {
 				matchesCount++
 			}
 		}
 	}
 }
 
-func (c *Collector) parseNXOS(ctx *collector.CollectContext, line string) bool {
+// Utilization is the CPU utilization in percent parsed from a line of the output.
+// Values the line does not contain are nil.
+type Utilization struct {
+	User           *float64 `json:"user,omitempty"`
+	Kernel         *float64 `json:"kernel,omitempty"`
+	Idle           *float64 `json:"idle,omitempty"`
+	FiveSeconds    *float64 `json:"five_seconds,omitempty"`
+	Interrupts     *float64 `json:"interrupts,omitempty"`
+	OneMinute      *float64 `json:"one_minute,omitempty"`
+	FiveMinutes    *float64 `json:"five_minutes,omitempty"`
+	FifteenMinutes *float64 `json:"fifteen_minutes,omitempty"`
+}
+
+func percent(s string) *float64 {
+	value := util.Str2float64(s)
+	return &value
+}
+
+func (c *Collector) parseNXOS(collectCtx *collector.CollectContext, result *collector.Result, line string) bool {
 	cpuUsageRegexp := regexp.MustCompile(`CPU util\s+:\s+(\d+.\d+)% user,\s+(\d+.\d+)% kernel,\s+(\d+.\d+)% idle`)
 	if matches := cpuUsageRegexp.FindStringSubmatch(line); matches != nil {
-		ctx.Metrics <- prometheus.MustNewConstMetric(cpuUsageDesc, prometheus.GaugeValue, util.Str2float64(matches[1]), append(ctx.LabelValues, "user")...)
-		ctx.Metrics <- prometheus.MustNewConstMetric(cpuUsageDesc, prometheus.GaugeValue, util.Str2float64(matches[2]), append(ctx.LabelValues, "kernel")...)
-		ctx.Metrics <- prometheus.MustNewConstMetric(cpuUsageDesc, prometheus.GaugeValue, util.Str2float64(matches[3]), append(ctx.LabelValues, "idle")...)
+		generateMetrics(collectCtx, result, &Utilization{User: percent(matches[1]), Kernel: percent(matches[2]), Idle: percent(matches[3])})
 		return true
 	}
 	return false
 }
 
-func (c *Collector) parse(ctx *collector.CollectContext, line string) bool {
+func (c *Collector) parse(collectCtx *collector.CollectContext, result *collector.Result, line string) bool {
 	cpuUsageRegexp := regexp.MustCompile(`^\s*CPU utilization for five seconds: (\d+)%\/(\d+)%; one minute: (\d+)%; five minutes: (\d+)%.*$`)
 	if matches := cpuUsageRegexp.FindStringSubmatch(line); matches != nil {
-		ctx.Metrics <- prometheus.MustNewConstMetric(cpuFiveSecondsDesc, prometheus.GaugeValue, util.Str2float64(matches[1]), ctx.LabelValues...)
-		ctx.Metrics <- prometheus.MustNewConstMetric(cpuInterruptsDesc, prometheus.GaugeValue, util.Str2float64(matches[2]), ctx.LabelValues...)
-		ctx.Metrics <- prometheus.MustNewConstMetric(cpuOneMinuteDesc, prometheus.GaugeValue, util.Str2float64(matches[3]), ctx.LabelValues...)
-		ctx.Metrics <- prometheus.MustNewConstMetric(cpuFiveMinutesDesc, prometheus.GaugeValue, util.Str2float64(matches[4]), ctx.LabelValues...)
+		generateMetrics(collectCtx, result, &Utilization{FiveSeconds: percent(matches[1]), Interrupts: percent(matches[2]), OneMinute: percent(matches[3]), FiveMinutes: percent(matches[4])})
 		return true
 	}
 	return false
 }
+
+func (c *Collector) parseXR(collectCtx *collector.CollectContext, result *collector.Result, line string) bool {
+	cpuUsageRegexp := regexp.MustCompile(`^\s*CPU utilization for one minute: (\d+)%; five minutes: (\d+)%; fifteen minutes: (\d+)%`)
+	if matches := cpuUsageRegexp.FindStringSubmatch(line); matches != nil {
+		generateMetrics(collectCtx, result, &Utilization{OneMinute: percent(matches[1]), FiveMinutes: percent(matches[2]), FifteenMinutes: percent(matches[3])})
+		return true
+	}
+	return false
+}
+
+func (c *Collector) parseASA(collectCtx *collector.CollectContext, result *collector.Result, line string) bool {
+	cpuUsageRegexp := regexp.MustCompile(`^\s*CPU utilization for 5 seconds = (\d+)%; 1 minute: (\d+)%; 5 minutes: (\d+)%`)
+	if matches := cpuUsageRegexp.FindStringSubmatch(line); matches != nil {
+		generateMetrics(collectCtx, result, &Utilization{FiveSeconds: percent(matches[1]), OneMinute: percent(matches[2]), FiveMinutes: percent(matches[3])})
+		return true
+	}
+	return false
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, utilization *Utilization) {
+	collectCtx.Record(utilization)
+	l := collectCtx.LabelValues
+	for state, value := range map[string]*float64{"user": utilization.User, "kernel": utilization.Kernel, "idle": utilization.Idle} {
+		if value != nil {
+			result.AddMetric(prometheus.MustNewConstMetric(cpuUsageDesc, prometheus.GaugeValue, *value, append(l, state)...))
+		}
+	}
+	for desc, value := range map[*prometheus.Desc]*float64{
+		cpuFiveSecondsDesc:    utilization.FiveSeconds,
+		cpuInterruptsDesc:     utilization.Interrupts,
+		cpuOneMinuteDesc:      utilization.OneMinute,
+		cpuFiveMinutesDesc:    utilization.FiveMinutes,
+		cpuFifteenMinutesDesc: utilization.FifteenMinutes,
+	} {
+		if value != nil {
+			result.AddMetric(prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, *value, l...))
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package cpu
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const iosOutput = `CPU utilization for five seconds: 7%/2%; one minute: 6%; five minutes: 5%
+ PID Runtime(ms)     Invoked      uSecs   5Sec   1Min   5Min TTY Process
+   1           0           6          0  0.00%  0.00%  0.00%   0 Chunk Manager`
+
+const nxosOutput = `PID    Runtime(ms)  Invoked   uSecs  1Sec    Process
+-----  -----------  --------  -----  ------  -----------
+    1       134180    170718    785   0.00%  init
+CPU util  :    3.25% user,    2.75% kernel,   94.00% idle`
+
+const xrOutput = `CPU utilization for one minute: 3%; five minutes: 4%; fifteen minutes: 5%
+
+PID    1Min    5Min    15Min Process
+1        0%      0%       0% init
+1544     0%      0%       0% bash`
+
+const asaOutput = `CPU utilization for 5 seconds = 4%; 1 minute: 3%; 5 minutes: 2%`
+
+func performTest(input string, parse func(*Collector, *collector.CollectContext, *collector.Result, string) bool, expected map[string]float64, t *testing.T) {
+	c := &Collector{}
+	collectCtx := &collector.CollectContext{LabelValues: []string{"test.test"}}
+	result := collector.NewResult()
+	matched := 0
+	for _, line := range strings.Split(input, "\n") {
+		if parse(c, collectCtx, result, line) {
+			matched++
+		}
+	}
+	if matched != 1 {
+		t.Errorf("Expected exactly one matching line, got %d", matched)
+	}
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), expected, t)
+}
+
+func TestParseIOS(t *testing.T) {
+	performTest(iosOutput, (*Collector).parse, map[string]float64{
+		"cisco_cpu_five_seconds_percent{target=test.test}": 7,
+		"cisco_cpu_interrupt_percent{target=test.test}":    2,
+		"cisco_cpu_one_minute_percent{target=test.test}":   6,
+		"cisco_cpu_five_minutes_percent{target=test.test}": 5,
+	}, t)
+}
+
+func TestParseNXOS(t *testing.T) {
+	performTest(nxosOutput, (*Collector).parseNXOS, map[string]float64{
+		"cisco_cpu_usage_percent{state=user,target=test.test}":   3.25,
+		"cisco_cpu_usage_percent{state=kernel,target=test.test}": 2.75,
+		"cisco_cpu_usage_percent{state=idle,target=test.test}":   94,
+	}, t)
+}
+
+func TestParseXR(t *testing.T) {
+	performTest(xrOutput, (*Collector).parseXR, map[string]float64{
+		"cisco_cpu_one_minute_percent{target=test.test}":      3,
+		"cisco_cpu_five_minutes_percent{target=test.test}":    4,
+		"cisco_cpu_fifteen_minutes_percent{target=test.test}": 5,
+	}, t)
+}
+
+func TestParseASA(t *testing.T) {
+	performTest(asaOutput, (*Collector).parseASA, map[string]float64{
+		"cisco_cpu_five_seconds_percent{target=test.test}": 4,
+		"cisco_cpu_one_minute_percent{target=test.test}":   3,
+		"cisco_cpu_five_minutes_percent{target=test.test}": 2,
+	}, t)
+}
+
+const nxosJSONOutput = `{
+  "TABLE_process_cpu": {
+    "ROW_process_cpu": [
+      {"pid": "1", "runtime": "134180", "invoked": "170718", "usecs": "785", "onesec": "0.00", "process": "init"}
+    ]
+  },
+  "user_percent": "3.25",
+  "kernel_percent": "2.75",
+  "idle_percent": "94.00"
+}`
+
+func TestParseNXOSJSON(t *testing.T) {
+	c := &Collector{}
+	collectCtx := &collector.CollectContext{LabelValues: []string{"test.test"}}
+	result := collector.NewResult()
+	data := &nxosProcessesCPU{}
+	if err := nxos.Decode(nxosJSONOutput, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.parseNXOSJSON(collectCtx, result, data); err != nil {
+		t.Fatal(err)
+	}
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_cpu_usage_percent{state=user,target=test.test}":   3.25,
+		"cisco_cpu_usage_percent{state=kernel,target=test.test}": 2.75,
+		"cisco_cpu_usage_percent{state=idle,target=test.test}":   94,
+	}, t)
+}
@@ -0,0 +1,47 @@
+package cpu
+
+import (
+	"context"
+	"encoding/xml"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const netconfFilter = `<cpu-usage xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-process-cpu-oper"><cpu-utilization>` +
+	`<five-seconds/><five-seconds-intr/><one-minute/><five-minutes/>` +
+	`</cpu-utilization></cpu-usage>`
+
+// netconfCPUUsage is the relevant part of Cisco-IOS-XE-process-cpu-oper.
+type netconfCPUUsage struct {
+	Utilization *struct {
+		FiveSeconds     float64 `xml:"five-seconds"`
+		FiveSecondsIntr float64 `xml:"five-seconds-intr"`
+		OneMinute       float64 `xml:"one-minute"`
+		FiveMinutes     float64 `xml:"five-minutes"`
+	} `xml:"cpu-usage>cpu-utilization"`
+}
+
+func (c *Collector) collectNETCONF(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, conn *connector.NETCONFConnection) error {
+	reply, err := conn.Get(ctx, netconfFilter)
+	if err != nil {
+		return err
+	}
+	data := &netconfCPUUsage{}
+	if err := xml.Unmarshal(reply, data); err != nil {
+		return errors.Wrap(err, "Could not decode cpu-usage")
+	}
+	collectCtx.Record(data)
+	if data.Utilization == nil {
+		return errNoMetric
+	}
+
+	result.AddMetric(prometheus.MustNewConstMetric(cpuFiveSecondsDesc, prometheus.GaugeValue, data.Utilization.FiveSeconds, collectCtx.LabelValues...))
+	result.AddMetric(prometheus.MustNewConstMetric(cpuInterruptsDesc, prometheus.GaugeValue, data.Utilization.FiveSecondsIntr, collectCtx.LabelValues...))
+	result.AddMetric(prometheus.MustNewConstMetric(cpuOneMinuteDesc, prometheus.GaugeValue, data.Utilization.OneMinute, collectCtx.LabelValues...))
+	result.AddMetric(prometheus.MustNewConstMetric(cpuFiveMinutesDesc, prometheus.GaugeValue, data.Utilization.FiveMinutes, collectCtx.LabelValues...))
+	return nil
+}
@@ -0,0 +1,37 @@
+package cpu
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/connector/netconftest"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const netconfOutput = `<cpu-usage xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-process-cpu-oper">
+  <cpu-utilization>
+    <five-seconds>7</five-seconds>
+    <five-seconds-intr>2</five-seconds-intr>
+    <one-minute>6</one-minute>
+    <five-minutes>5</five-minutes>
+  </cpu-utilization>
+</cpu-usage>`
+
+func TestCollectNETCONF(t *testing.T) {
+	server := netconftest.NewServer(t, map[string]string{"cpu-usage": netconfOutput})
+	conn, err := connector.NewConnectionManager().GetConnection("127.0.0.1", server.Device())
+	if err != nil {
+		t.Fatalf("Could not connect to the NETCONF server: %v", err)
+	}
+	defer conn.Terminate()
+
+	result := NewCollector().Collect(context.Background(), &collector.CollectContext{Connection: conn, LabelValues: []string{"test.test"}})
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_cpu_five_seconds_percent{target=test.test}": 7,
+		"cisco_cpu_interrupt_percent{target=test.test}":    2,
+		"cisco_cpu_one_minute_percent{target=test.test}":   6,
+		"cisco_cpu_five_minutes_percent{target=test.test}": 5,
+	}, t)
+}
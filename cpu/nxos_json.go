@@ -0,0 +1,46 @@
+package cpu
+
+import (
+	"context"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// nxosProcessesCPU is the relevant part of `show processes cpu | json`.
+type nxosProcessesCPU struct {
+	UserPercent   *nxos.Number `json:"user_percent"`
+	KernelPercent *nxos.Number `json:"kernel_percent"`
+	IdlePercent   *nxos.Number `json:"idle_percent"`
+}
+
+func (c *Collector) collectNXOSJSON(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) error {
+	data := &nxosProcessesCPU{}
+	if err := nxos.RunJSON(ctx, collectCtx.Connection, "show processes cpu", data); err != nil {
+		return err
+	}
+	collectCtx.Record(data)
+	return c.parseNXOSJSON(collectCtx, result, data)
+}
+
+func (c *Collector) parseNXOSJSON(collectCtx *collector.CollectContext, result *collector.Result, data *nxosProcessesCPU) error {
+	states := map[string]*nxos.Number{
+		"user":   data.UserPercent,
+		"kernel": data.KernelPercent,
+		"idle":   data.IdlePercent,
+	}
+	found := false
+	for state, value := range states {
+		if !value.Valid() {
+			continue
+		}
+		result.AddMetric(prometheus.MustNewConstMetric(cpuUsageDesc, prometheus.GaugeValue, value.Value(), append(collectCtx.LabelValues, state)...))
+		found = true
+	}
+	if !found {
+		return errNoMetric
+	}
+	return nil
+}
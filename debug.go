@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/web"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/prometheus/common/log"
+)
+
+// debugResponse is the outcome of running a collector for the debug endpoint.
+type debugResponse struct {
+	Target    string                      `json:"target"`
+	Collector string                      `json:"collector"`
+	Commands  []connector.TranscriptEntry `json:"commands"`
+	Parsed    []debugValue                `json:"parsed"`
+	Metrics   string                      `json:"metrics"`
+	Errors    []string                    `json:"errors"`
+}
+
+// debugValue is a value parsed by the collector and its Go type, e.g. *bgp.Neighbor.
+type debugValue struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// debugMetrics holds the metrics of a collector run, so that they can be rendered in the text format.
+type debugMetrics []prometheus.Metric
+
+// Describe sends no descriptors, the metrics are checked when gathered.
+func (m debugMetrics) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (m debugMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range m {
+		ch <- metric
+	}
+}
+
+// handleDebugCommandRequest runs a collector against a target and returns the commands it ran with their raw output,
+// the values parsed from it and the resulting metrics. Only the commands of the collectors can be run this way.
+// Requests need to be authenticated using the web configuration file.
+func handleDebugCommandRequest(w http.ResponseWriter, request *http.Request) {
+	if !web.Authenticated(request) {
+		http.Error(w, "The debug endpoint requires basic auth or a client certificate configured in the web configuration file", http.StatusForbidden)
+		return
+	}
+
+	target := request.URL.Query().Get("target")
+	collectorName := request.URL.Query().Get("collector")
+	if target == "" || collectorName == "" {
+		http.Error(w, "Both target and collector are required", http.StatusBadRequest)
+		return
+	}
+	deviceGroup := getConfiguration().GetDeviceGroup(target)
+	if deviceGroup == nil {
+		http.Error(w, "Target not configured", http.StatusNotFound)
+		return
+	}
+	query := url.Values{"collect[]": []string{collectorName}, "module": []string{request.URL.Query().Get("module")}}
+	selection, err := parseCollectorSelection(query, getConfiguration())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ciscoCollector := newCiscoCollector(request.Context(), []string{target}, connectionManager, nil, selection)
+	if len(ciscoCollector.collectorsForDevice[target]) == 0 {
+		http.Error(w, fmt.Sprintf("Collector '%s' is not enabled for target '%s'", collectorName, target), http.StatusBadRequest)
+		return
+	}
+	specificCollector := ciscoCollector.collectorsForDevice[target][0]
+
+	transcript := &connector.Transcript{}
+	ctx, cancel := context.WithTimeout(request.Context(), *scrapeTimeout)
+	defer cancel()
+	ctx = connector.WithTranscript(ctx, transcript)
+
+	response := &debugResponse{
+		Target:    target,
+		Collector: collectorName,
+		Parsed:    make([]debugValue, 0),
+		Errors:    make([]string, 0),
+	}
+	collectContext, err := ciscoCollector.createCollectContext(target, deviceGroup)
+	if err != nil {
+		response.Errors = append(response.Errors, err.Error())
+	} else {
+		collectContext.Debug = &collector.Debug{}
+		result := runCollector(ctx, specificCollector, collectContext)
+		for _, err := range result.Errors {
+			response.Errors = append(response.Errors, err.Error())
+		}
+		for _, value := range collectContext.Debug.Parsed() {
+			response.Parsed = append(response.Parsed, newDebugValue(value))
+		}
+		metrics, err := renderMetrics(result.Metrics)
+		if err != nil {
+			response.Errors = append(response.Errors, err.Error())
+		}
+		response.Metrics = metrics
+	}
+	response.Commands = transcript.Entries()
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(response); err != nil {
+		log.Errorf("Could not encode the debug response for %s: %v", target, err)
+	}
+}
+
+// newDebugValue returns the value to list in the response. Values which cannot be encoded as JSON,
+// e.g. because of NaN fields, are formatted as text instead.
+func newDebugValue(value interface{}) debugValue {
+	v := debugValue{Type: fmt.Sprintf("%T", value), Value: value}
+	if _, err := json.Marshal(value); err != nil {
+		v.Value = fmt.Sprintf("%+v", value)
+	}
+	return v
+}
+
+// renderMetrics returns the metrics in the Prometheus text format.
+func renderMetrics(metrics []prometheus.Metric) (string, error) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(debugMetrics(metrics))
+	families, err := registry.Gather()
+	buf := &bytes.Buffer{}
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(buf, family); err != nil {
+			return buf.String(), err
+		}
+	}
+	return buf.String(), err
+}
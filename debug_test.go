@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugCommandRequestRequiresAuthentication(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	handleDebugCommandRequest(recorder, httptest.NewRequest("GET", "/debug/command?target=127.0.0.1&collector=cpu", nil))
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 without authentication, got %d", recorder.Code)
+	}
+}
+
+func TestDebugCommandRequest(t *testing.T) {
+	device := newTestDevice(t, map[string]string{"show processes cpu": testShowCPU})
+	loadTestConfiguration(t, `
+devices:
+  127.0.0.1:`+device.groupConfig()+`
+    enabled_collectors: [cpu]
+`)
+
+	request := httptest.NewRequest("GET", "/debug/command?target=127.0.0.1&collector=cpu", nil)
+	// A verified client certificate authenticates the request.
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+	recorder := httptest.NewRecorder()
+	handleDebugCommandRequest(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	response := &debugResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), response); err != nil {
+		t.Fatalf("Could not decode the response: %v", err)
+	}
+	if len(response.Errors) != 0 {
+		t.Errorf("Expected no errors, got %v", response.Errors)
+	}
+
+	found := false
+	for _, entry := range response.Commands {
+		if entry.Command == "show processes cpu" {
+			found = strings.Contains(strings.Join(entry.Output, "\n"), "one minute: 4%")
+		}
+	}
+	if !found {
+		t.Errorf("Expected the transcript to list the command with its output, got %+v", response.Commands)
+	}
+
+	if len(response.Parsed) != 1 || response.Parsed[0].Type != "*cpu.Utilization" {
+		t.Fatalf("Expected the parsed utilization, got %+v", response.Parsed)
+	}
+	if value, _ := response.Parsed[0].Value.(map[string]interface{}); value["one_minute"] != 4.0 {
+		t.Errorf("Expected a one minute utilization of 4, got %v", response.Parsed[0].Value)
+	}
+
+	if !strings.Contains(response.Metrics, `cisco_cpu_one_minute_percent{target="127.0.0.1"} 4`) {
+		t.Errorf("Expected the metrics of the collector, got %s", response.Metrics)
+	}
+}
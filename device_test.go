@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	testPassword    = "test-password"
+	testShowVersion = "Cisco IOS Software, C2960 Software (C2960-LANBASEK9-M), Version 12.2(55)SE12, RELEASE SOFTWARE (fc2)\r\n"
+	testShowCPU     = "CPU utilization for five seconds: 5%/1%; one minute: 4%; five minutes: 3%\r\n"
+)
+
+// testDevice is an in-process SSH server emulating the CLI of an IOS device in privileged EXEC mode.
+// Commands are answered using the outputs map and counted.
+type testDevice struct {
+	listener net.Listener
+	mu       sync.Mutex
+	outputs  map[string]string
+	commands map[string]int
+}
+
+func newTestDevice(t *testing.T, outputs map[string]string) *testDevice {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Could not generate host key: %v", err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(privateKey)
+	if err != nil {
+		t.Fatalf("Could not create host key signer: %v", err)
+	}
+	serverConfig := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != testPassword {
+				return nil, fmt.Errorf("Wrong password")
+			}
+			return nil, nil
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	d := &testDevice{listener: listener, outputs: map[string]string{"show version": testShowVersion}, commands: make(map[string]int)}
+	for command, output := range outputs {
+		d.outputs[command] = output
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go d.handle(conn, serverConfig)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return d
+}
+
+// groupConfig returns the options of a device group connecting to the device.
+func (d *testDevice) groupConfig() string {
+	return fmt.Sprintf(`
+    port: %d
+    username: monitoring
+    password: %s
+    auth_methods: [%s]
+    host_key:
+      mode: %s
+    connect_timeout: 5
+    command_timeout: 5`, d.listener.Addr().(*net.TCPAddr).Port, testPassword, config.AuthPassword, config.HostKeyInsecure)
+}
+
+// setOutput changes the output of a command.
+func (d *testDevice) setOutput(command string, output string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.outputs[command] = output
+}
+
+// count returns how often the command was run.
+func (d *testDevice) count(command string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.commands[command]
+}
+
+func (d *testDevice) handle(conn net.Conn, serverConfig *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, serverConfig)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				req.Reply(req.Type == "pty-req" || req.Type == "shell", nil)
+				if req.Type == "shell" {
+					go func() {
+						d.serve(channel)
+						channel.Close()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+func (d *testDevice) serve(channel io.ReadWriter) {
+	io.WriteString(channel, "\r\nrouter#")
+	scanner := bufio.NewScanner(channel)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		io.WriteString(channel, line+"\r\n")
+
+		d.mu.Lock()
+		d.commands[line]++
+		output, found := d.outputs[line]
+		d.mu.Unlock()
+		switch {
+		case line == "show privilege":
+			output = fmt.Sprintf("Current privilege level is %d\r\n", connector.PrivilegeLevelEnabled)
+		case line != "" && !strings.HasPrefix(line, "terminal ") && !found:
+			output = "% Invalid input detected at '^' marker.\r\n"
+		}
+		io.WriteString(channel, output+"router#")
+	}
+}
+
+// loadTestConfiguration loads content and makes it the configuration in use, with a new connection manager.
+func loadTestConfiguration(t *testing.T, content string) *config.Config {
+	c, err := config.Load(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+	configurationMutex.Lock()
+	configuration = c
+	configurationMutex.Unlock()
+	manager := connector.NewConnectionManager()
+	connectionManager = manager
+	t.Cleanup(func() {
+		for _, target := range c.GetStaticDevices() {
+			manager.Close(target)
+		}
+	})
+	return c
+}
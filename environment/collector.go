@@ -1,11 +1,15 @@
 package environment
 
 import (
+	"context"
 	"fmt"
 
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+
+	"github.com/pkg/errors"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -60,6 +64,9 @@ var (
 	temperatureHighAlarmThresholdDesc    *prometheus.Desc
 	temperatureHighShutdownThresholdDesc *prometheus.Desc
 	alarmContactAssertedDesc             *prometheus.Desc
+
+	// IOS-XR
+	fanSpeedRPMDesc *prometheus.Desc
 )
 
 // Collector gathers environmental metrics fro the remote device by running `show environment`.
@@ -125,6 +132,8 @@ func init() {
 	temperatureHighAlarmThresholdDesc = prometheus.NewDesc(prefix+"temperature_high_alarm_threshold_celsius", "High alarm threshold in degrees celsius", []string{"target", "sensor"}, nil)
 	temperatureHighShutdownThresholdDesc = prometheus.NewDesc(prefix+"temperature_high_shutdown_threshold_celsius", "High shutdown threshold in degrees celsius", []string{"target", "sensor"}, nil)
 	alarmContactAssertedDesc = prometheus.NewDesc(prefix+"alarm_contacted_asserted_info", "1 if the alarm contact is asserted", []string{"target", "contact"}, nil)
+
+	fanSpeedRPMDesc = prometheus.NewDesc(prefix+"fan_speed_rpm", "Fan speed in rotations per minute", []string{"target", "slot", "fan"}, nil)
 }
 
 // Describe implements the collector.Collector interface's Describe function
@@ -176,27 +185,48 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- temperatureHighAlarmThresholdDesc
 	ch <- temperatureHighShutdownThresholdDesc
 	ch <- alarmContactAssertedDesc
+
+	ch <- fanSpeedRPMDesc
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	if netconf, ok := collectCtx.Connection.(*connector.NETCONFConnection); ok {
+		if err := c.collectNETCONF(ctx, collectCtx, result, netconf); err != nil {
+			result.AddError(errors.Wrap(err, "Error scraping environment"))
+		}
+		return result
+	}
 
-	parser, err := getParserForOSversion(ctx.Connection.Device.OSVersion)
+	parser, err := getParserForOSversion(collectCtx.Connection.Info().DeviceInfo.OSVersion)
 	if err != nil {
-		ctx.Errors <- fmt.Errorf("Could not get an environment parser for OS Version '%s': %v", ctx.Connection.Device.OSVersion.String(), err)
-		return
+		result.AddError(fmt.Errorf("Could not get an environment parser for OS Version '%s': %v", collectCtx.Connection.Info().DeviceInfo.OSVersion.String(), err))
+		return result
+	}
+
+	if collectCtx.Connection.Info().DeviceInfo.OSVersion == config.NXOS {
+		err := c.collectNXOSJSON(ctx, collectCtx, result)
+		if errors.Cause(err) != nxos.ErrJSONUnsupported {
+			if err != nil {
+				result.AddError(errors.Wrap(err, "Error scraping environment"))
+			}
+			return result
+		}
 	}
 
 	command := "show environment"
-	if ctx.Connection.Device.OSVersion == config.IOS {
+	switch collectCtx.Connection.Info().DeviceInfo.OSVersion {
+	case config.IOS:
 		command = "show env"
+	case config.IOSXR:
+		command = "show environment all"
 	}
 
 	sshCtx := connector.NewSSHCommandContext(command)
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
-	parser.parse(sshCtx, ctx.LabelValues, ctx.Errors, ctx.Metrics)
+	parser.parse(sshCtx, collectCtx, result)
+	return result
 }
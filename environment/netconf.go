@@ -0,0 +1,69 @@
+package environment
+
+import (
+	"context"
+	"encoding/xml"
+	"regexp"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const netconfFilter = `<environment-sensors xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-environment-oper"><environment-sensor>` +
+	`<name/><location/><state/><current-reading/><sensor-units/>` +
+	`</environment-sensor></environment-sensors>`
+
+var netconfFanSpeedRegexp = regexp.MustCompile(`fan speed (\d+)%`)
+
+// netconfEnvironment is the relevant part of Cisco-IOS-XE-environment-oper, which lists the sensors like `show environment`.
+type netconfEnvironment struct {
+	Sensors []struct {
+		Name     string  `xml:"name"`
+		Location string  `xml:"location"`
+		State    string  `xml:"state"`
+		Reading  float64 `xml:"current-reading"`
+		Units    string  `xml:"sensor-units"`
+	} `xml:"environment-sensors>environment-sensor"`
+}
+
+func (c *Collector) collectNETCONF(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, conn *connector.NETCONFConnection) error {
+	reply, err := conn.Get(ctx, netconfFilter)
+	if err != nil {
+		return err
+	}
+	data := &netconfEnvironment{}
+	if err := xml.Unmarshal(reply, data); err != nil {
+		return errors.Wrap(err, "Could not decode environment-sensors")
+	}
+	collectCtx.Record(data)
+	if len(data.Sensors) == 0 {
+		return errors.New("No environment sensor was found")
+	}
+
+	// Slot and sensor are lower case like the labels parsed from `show environment`.
+	for _, sensor := range data.Sensors {
+		labels := append(collectCtx.LabelValues, strings.ToLower(sensor.Location), strings.ToLower(sensor.Name))
+		switch strings.Replace(strings.ToLower(sensor.Units), "-", " ", -1) {
+		case "amperes":
+			result.AddMetric(prometheus.MustNewConstMetric(currentReadingDesc, prometheus.GaugeValue, sensor.Reading, labels...))
+		case "milli amperes":
+			result.AddMetric(prometheus.MustNewConstMetric(currentReadingDesc, prometheus.GaugeValue, sensor.Reading/1000.0, labels...))
+		case "volts dc", "volts ac", "volts":
+			result.AddMetric(prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, sensor.Reading, labels...))
+		case "milli volts":
+			result.AddMetric(prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, sensor.Reading/1000.0, labels...))
+		case "celsius":
+			result.AddMetric(prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, sensor.Reading, labels...))
+		}
+
+		if matches := netconfFanSpeedRegexp.FindStringSubmatch(strings.ToLower(sensor.State)); matches != nil {
+			result.AddMetric(prometheus.MustNewConstMetric(fanSpeedDesc, prometheus.GaugeValue, util.Str2float64(matches[1]), labels...))
+		}
+	}
+	return nil
+}
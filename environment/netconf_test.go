@@ -0,0 +1,53 @@
+package environment
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/connector/netconftest"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+// netconfOutput holds the sensors of iosXe1 as returned by Cisco-IOS-XE-environment-oper.
+const netconfOutput = `<environment-sensors xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-environment-oper">
+  <environment-sensor><name>PEM Iout</name><location>P0</location><state>Normal</state><current-reading>6</current-reading><sensor-units>Amperes</sensor-units></environment-sensor>
+  <environment-sensor><name>PEM Vout</name><location>P0</location><state>Normal</state><current-reading>12</current-reading><sensor-units>Volts DC</sensor-units></environment-sensor>
+  <environment-sensor><name>PEM Vin</name><location>P0</location><state>Normal</state><current-reading>53</current-reading><sensor-units>Volts AC</sensor-units></environment-sensor>
+  <environment-sensor><name>Temp: PEM</name><location>P0</location><state>Normal</state><current-reading>28</current-reading><sensor-units>Celsius</sensor-units></environment-sensor>
+  <environment-sensor><name>Temp: FC</name><location>P0</location><state>Fan Speed 65%</state><current-reading>20</current-reading><sensor-units>Celsius</sensor-units></environment-sensor>
+  <environment-sensor><name>VCP 1: VH</name><location>R0</location><state>Normal</state><current-reading>11945</current-reading><sensor-units>milli-volts</sensor-units></environment-sensor>
+  <environment-sensor><name>Temp: YODA Die</name><location>R0</location><state>Normal</state><current-reading>40</current-reading><sensor-units>Celsius</sensor-units></environment-sensor>
+</environment-sensors>`
+
+func TestCollectNETCONF(t *testing.T) {
+	server := netconftest.NewServer(t, map[string]string{"environment-sensors": netconfOutput})
+	conn, err := connector.NewConnectionManager().GetConnection("127.0.0.1", server.Device())
+	if err != nil {
+		t.Fatalf("Could not connect to the NETCONF server: %v", err)
+	}
+	defer conn.Terminate()
+
+	// The metrics match those parsed from `show environment`.
+	expected := make(map[string]float64)
+	for _, name := range []string{
+		"current_amps{sensor=pem iout,slot=p0,target=test.test}",
+		"voltage_reading_volts{sensor=pem vout,slot=p0,target=test.test}",
+		"voltage_reading_volts{sensor=pem vin,slot=p0,target=test.test}",
+		"temperature_current_celsius{module=p0,sensor=temp: pem,target=test.test}",
+		"temperature_current_celsius{module=p0,sensor=temp: fc,target=test.test}",
+		"fan_speed_percentage{sensor=temp: fc,slot=p0,taget=test.test}",
+		"voltage_reading_volts{sensor=vcp 1: vh,slot=r0,target=test.test}",
+		"temperature_current_celsius{module=r0,sensor=temp: yoda die,target=test.test}",
+	} {
+		expected[prefix+name] = expectedIosXe1Metrics[prefix+name]
+	}
+
+	result := NewCollector().Collect(context.Background(), &collector.CollectContext{Connection: conn, LabelValues: []string{"test.test"}})
+	got := util.PrepareResultForTesting(result, t)
+	util.CompareMetrics(got, expected, t)
+	if len(got) != len(expected) {
+		t.Errorf("Expected %d metrics, got %d", len(expected), len(got))
+	}
+}
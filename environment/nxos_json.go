@@ -0,0 +1,177 @@
+package environment
+
+import (
+	"context"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// nxosEnvironment is the relevant part of `show environment | json`.
+type nxosEnvironment struct {
+	FanDetails struct {
+		Fans struct {
+			Rows nxos.Rows `json:"ROW_faninfo"`
+		} `json:"TABLE_faninfo"`
+	} `json:"fandetails"`
+	PowerSupply struct {
+		VoltageLevel  *nxos.Number `json:"voltage_level"`
+		PowerSupplies struct {
+			Rows nxos.Rows `json:"ROW_psinfo"`
+		} `json:"TABLE_psinfo"`
+		Modules struct {
+			Rows nxos.Rows `json:"ROW_mod_pow_info"`
+		} `json:"TABLE_mod_pow_info"`
+		Summary struct {
+			RedundancyMode            string       `json:"ps_redun_mode"`
+			RedundancyOperationalMode string       `json:"ps_redun_op_mode"`
+			TotalCapacity             *nxos.Number `json:"tot_pow_capacity"`
+			TotalInput                *nxos.Number `json:"tot_pow_input_actual_draw"`
+			TotalOutput               *nxos.Number `json:"tot_pow_out_actual_draw"`
+			Available                 *nxos.Number `json:"available_pow"`
+		} `json:"power_summary"`
+	} `json:"powersup"`
+	Temperatures struct {
+		Rows nxos.Rows `json:"ROW_tempinfo"`
+	} `json:"TABLE_tempinfo"`
+}
+
+type nxosFan struct {
+	Name   nxos.String `json:"fanname"`
+	Model  nxos.String `json:"fanmodel"`
+	Hw     nxos.String `json:"fanhwver"`
+	Status string      `json:"fanstatus"`
+}
+
+// nxosPowerSupply is a row of TABLE_psinfo. Depending on the platform either input type, power and current
+// or actual output, actual input and capacity are present.
+type nxosPowerSupply struct {
+	Number      nxos.String  `json:"psnum"`
+	Model       nxos.String  `json:"psmodel"`
+	InputType   nxos.String  `json:"input_type"`
+	Watts       *nxos.Number `json:"watts"`
+	Amps        *nxos.Number `json:"amps"`
+	ActualOut   *nxos.Number `json:"actual_out"`
+	ActualInput *nxos.Number `json:"actual_input"`
+	Capacity    *nxos.Number `json:"tot_capa"`
+	Status      string       `json:"ps_status"`
+}
+
+type nxosModulePower struct {
+	Module         nxos.String  `json:"modnum"`
+	Model          nxos.String  `json:"mod_model"`
+	WattsRequested *nxos.Number `json:"watts_requested"`
+	AmpsRequested  *nxos.Number `json:"amps_requested"`
+	WattsAllocated *nxos.Number `json:"watts_alloced"`
+	AmpsAllocated  *nxos.Number `json:"amps_alloced"`
+	Status         string       `json:"modstatus"`
+}
+
+type nxosTemperature struct {
+	Module      nxos.String  `json:"tempmod"`
+	Sensor      nxos.String  `json:"sensor"`
+	MajorThresh *nxos.Number `json:"majthres"`
+	MinorThresh *nxos.Number `json:"minthres"`
+	Current     *nxos.Number `json:"curtemp"`
+}
+
+func (c *Collector) collectNXOSJSON(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) error {
+	data := &nxosEnvironment{}
+	if err := nxos.RunJSON(ctx, collectCtx.Connection, "show environment", data); err != nil {
+		return err
+	}
+	collectCtx.Record(data)
+	return parseNXOSJSON(data, collectCtx.LabelValues, result)
+}
+
+func parseNXOSJSON(data *nxosEnvironment, labelValues []string, result *collector.Result) error {
+	addGauge := func(desc *prometheus.Desc, value *nxos.Number, labels ...string) {
+		if value.Valid() {
+			result.AddMetric(prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value.Value(), labels...))
+		}
+	}
+	isOk := func(status string) float64 {
+		if strings.ToLower(status) == "ok" {
+			return 1
+		}
+		return 0
+	}
+	isRedundant := func(mode string) float64 {
+		mode = strings.ToLower(mode)
+		if mode == "redundant" || mode == "ps-redundant" {
+			return 1
+		}
+		return 0
+	}
+
+	var fans []nxosFan
+	if err := data.FanDetails.Fans.Rows.Decode(&fans); err != nil {
+		return err
+	}
+	for _, fan := range fans {
+		labels := append(labelValues, string(fan.Name), string(fan.Model), string(fan.Hw))
+		result.AddMetric(prometheus.MustNewConstMetric(fanOperationalInfoDesc, prometheus.GaugeValue, isOk(fan.Status), labels...))
+	}
+
+	powerSupply := &data.PowerSupply
+	addGauge(powerSupplyVoltageDesc, powerSupply.VoltageLevel, labelValues...)
+	var powerSupplies []nxosPowerSupply
+	if err := powerSupply.PowerSupplies.Rows.Decode(&powerSupplies); err != nil {
+		return err
+	}
+	for _, ps := range powerSupplies {
+		if ps.Watts.Valid() {
+			labels := append(labelValues, string(ps.Number), string(ps.Model), string(ps.InputType))
+			addGauge(powerSupplyPowerDesc, ps.Watts, labels...)
+			addGauge(powerSupplyCurrentDesc, ps.Amps, labels...)
+			result.AddMetric(prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, isOk(ps.Status), labels...))
+			continue
+		}
+
+		labels := append(labelValues, string(ps.Number), string(ps.Model))
+		addGauge(powerSupplyActualOutputDesc, ps.ActualOut, labels...)
+		addGauge(powerSupplyActualInputDesc, ps.ActualInput, labels...)
+		addGauge(powerSupplyCapacityDesc, ps.Capacity, labels...)
+		result.AddMetric(prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, isOk(ps.Status), append(labels, "")...))
+	}
+
+	var modules []nxosModulePower
+	if err := powerSupply.Modules.Rows.Decode(&modules); err != nil {
+		return err
+	}
+	for _, module := range modules {
+		labels := append(labelValues, string(module.Module), string(module.Model))
+		addGauge(powerSupplyRequestedPower, module.WattsRequested, labels...)
+		addGauge(powerSupplyRequestedCurrent, module.AmpsRequested, labels...)
+		addGauge(powerSupplyAllocatedPower, module.WattsAllocated, labels...)
+		addGauge(powerSupplyAllocatedCurrent, module.AmpsAllocated, labels...)
+		result.AddMetric(prometheus.MustNewConstMetric(powerSupplyStatusInfo, prometheus.GaugeValue, 1, append(labels, strings.ToLower(module.Status))...))
+	}
+
+	summary := &powerSupply.Summary
+	if summary.RedundancyMode != "" {
+		result.AddMetric(prometheus.MustNewConstMetric(powerSupplyRedundancyConfiguredDesc, prometheus.GaugeValue, isRedundant(summary.RedundancyMode), labelValues...))
+	}
+	if summary.RedundancyOperationalMode != "" {
+		result.AddMetric(prometheus.MustNewConstMetric(powerSupplyRedundancyOperationalDesc, prometheus.GaugeValue, isRedundant(summary.RedundancyOperationalMode), labelValues...))
+	}
+	addGauge(powerSupplyTotalCapacityDesc, summary.TotalCapacity, labelValues...)
+	addGauge(powerSupplyTotalPowerInputDesc, summary.TotalInput, labelValues...)
+	addGauge(powerSupplyTotalPowerOutputDesc, summary.TotalOutput, labelValues...)
+	addGauge(powerSupplyTotalPowerAvailableDesc, summary.Available, labelValues...)
+
+	var temperatures []nxosTemperature
+	if err := data.Temperatures.Rows.Decode(&temperatures); err != nil {
+		return err
+	}
+	for _, temperature := range temperatures {
+		labels := append(labelValues, string(temperature.Module), string(temperature.Sensor))
+		addGauge(temperatureMajorThreshDesc, temperature.MajorThresh, labels...)
+		addGauge(temperatureMinorThreshDesc, temperature.MinorThresh, labels...)
+		addGauge(temperatureCurrentDesc, temperature.Current, labels...)
+	}
+	return nil
+}
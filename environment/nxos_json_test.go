@@ -0,0 +1,71 @@
+package environment
+
+import (
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const nxosJSON = `{
+  "fandetails": {
+    "TABLE_faninfo": {
+      "ROW_faninfo": [
+        {"fanname": "Fan1(sys_fan1)", "fanmodel": "NXA-FAN-30CFM-B", "fanhwver": "--", "fandir": "front-to-back", "fanstatus": "Ok"},
+        {"fanname": "Fan2(sys_fan2)", "fanmodel": "NXA-FAN-30CFM-B", "fanhwver": "--", "fandir": "front-to-back", "fanstatus": "Ok"},
+        {"fanname": "Fan3(sys_fan3)", "fanmodel": "NXA-FAN-30CFM-B", "fanhwver": "--", "fandir": "front-to-back", "fanstatus": "Ok"},
+        {"fanname": "Fan4(sys_fan4)", "fanmodel": "NXA-FAN-30CFM-B", "fanhwver": "--", "fandir": "front-to-back", "fanstatus": "Ok"},
+        {"fanname": "Fan_in_PS1", "fanmodel": "--", "fanhwver": "--", "fandir": "front-to-back", "fanstatus": "Ok"},
+        {"fanname": "Fan_in_PS2", "fanmodel": "--", "fanhwver": "--", "fandir": "front-to-back", "fanstatus": "Ok"}
+      ]
+    },
+    "fan_filter_status": "NotSupported"
+  },
+  "powersup": {
+    "voltage_level": 12,
+    "TABLE_psinfo": {
+      "ROW_psinfo": [
+        {"psnum": 1, "psmodel": "NXA-PAC-650W-PI", "actual_out": "80 W", "actual_input": "92 W", "tot_capa": "650 W", "ps_status": "Ok"},
+        {"psnum": 2, "psmodel": "NXA-PAC-650W-PI", "actual_out": "75 W", "actual_input": "89 W", "tot_capa": "650 W", "ps_status": "Ok"}
+      ]
+    },
+    "power_summary": {
+      "ps_redun_mode": "PS-Redundant",
+      "ps_redun_op_mode": "PS-Redundant",
+      "tot_pow_capacity": "650.00 W",
+      "tot_pow_input_actual_draw": "181.00 W",
+      "tot_pow_out_actual_draw": "155.00 W",
+      "tot_pow_alloc_budgeted": "N/A",
+      "available_pow": "N/A"
+    }
+  },
+  "TABLE_tempinfo": {
+    "ROW_tempinfo": [
+      {"tempmod": "1", "sensor": "FRONT", "majthres": "70", "minthres": "42", "curtemp": "26", "alarmstatus": "Ok"},
+      {"tempmod": "1", "sensor": "BACK", "majthres": "80", "minthres": "70", "curtemp": "32", "alarmstatus": "Ok"},
+      {"tempmod": "1", "sensor": "CPU", "majthres": "90", "minthres": "80", "curtemp": "45", "alarmstatus": "Ok"},
+      {"tempmod": "1", "sensor": "Sugarbowl", "majthres": "100", "minthres": "90", "curtemp": "51", "alarmstatus": "Ok"}
+    ]
+  }
+}`
+
+func TestParseNxosJSON(t *testing.T) {
+	data := &nxosEnvironment{}
+	if err := nxos.Decode(nxosJSON, data); err != nil {
+		t.Fatal(err)
+	}
+	result := collector.NewResult()
+	if err := parseNXOSJSON(data, []string{"test.test"}, result); err != nil {
+		t.Fatal(err)
+	}
+
+	// The JSON output yields the same metrics as the text output plus the total capacity.
+	expected := map[string]float64{
+		prefix + "powersupply_capacity_total_watts{target=test.test}": 650,
+	}
+	for key, value := range expectedNxos2Metrics {
+		expected[key] = value
+	}
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), expected, t)
+}
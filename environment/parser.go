@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 	"gitlab.com/wobcom/cisco-exporter/util"
@@ -13,16 +14,35 @@ import (
 )
 
 type parser interface {
-	parse(sshCtx *connector.SSHCommandContext, labelValues []string, errors chan error, metrics chan<- prometheus.Metric)
+	parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result)
+}
+
+// Match is a line of the output the values of a sensor, power supply or alarm were extracted from.
+type Match struct {
+	Line   string   `json:"line"`
+	Values []string `json:"values"`
+}
+
+// match returns the submatches of re in line and records them if the collector runs for debugging.
+func match(collectCtx *collector.CollectContext, re *regexp.Regexp, line string) []string {
+	matches := re.FindStringSubmatch(line)
+	if matches != nil {
+		collectCtx.Record(&Match{Line: line, Values: matches[1:]})
+	}
+	return matches
 }
 
 type nxosEnvironmentParser struct{}
 type iosEnvironmentParser struct{}
 type iosXeEnvironmentParser struct{}
+type iosXrEnvironmentParser struct{}
+type asaEnvironmentParser struct{}
 
 func newNxosEnvironmentParser() *nxosEnvironmentParser   { return &nxosEnvironmentParser{} }
 func newIosEnvironmentParser() *iosEnvironmentParser     { return &iosEnvironmentParser{} }
 func newIosXeEnvironmentParser() *iosXeEnvironmentParser { return &iosXeEnvironmentParser{} }
+func newIosXrEnvironmentParser() *iosXrEnvironmentParser { return &iosXrEnvironmentParser{} }
+func newAsaEnvironmentParser() *asaEnvironmentParser     { return &asaEnvironmentParser{} }
 
 func getParserForOSversion(osVersion config.OSVersion) (parser, error) {
 	switch osVersion {
@@ -32,6 +52,10 @@ func getParserForOSversion(osVersion config.OSVersion) (parser, error) {
 		return newIosEnvironmentParser(), nil
 	case config.IOSXE:
 		return newIosXeEnvironmentParser(), nil
+	case config.IOSXR:
+		return newIosXrEnvironmentParser(), nil
+	case config.ASA:
+		return newAsaEnvironmentParser(), nil
 	default:
 		return nil, fmt.Errorf("Unsupported operating system version %v", osVersion)
 	}
@@ -48,7 +72,8 @@ const (
 	nxosParserStatePSModule
 )
 
-func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, labelValues []string, errors chan error, metrics chan<- prometheus.Metric) {
+func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result) {
+	labelValues := collectCtx.LabelValues
 	fanRegex := regexp.MustCompile(`fan\s+model\s+hw\s+(direction)?\s+status`)
 	temperatureRegex := regexp.MustCompile(`module\s+sensor\s+majorthresh\s+minorthres\s+curtemp\s+status`)
 	temperatereValuesRegex := regexp.MustCompile(`^(\d+)\s+(.*?)(\d{2,})\s+(\d{2,})\s+(\d{2,})\s+(\S+)`)
@@ -74,7 +99,7 @@ func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, label
 		case <-sshCtx.Done:
 			return
 		case err := <-sshCtx.Errors:
-			errors <- fmt.Errorf("Error scraping environment: %v", err)
+			result.AddError(fmt.Errorf("Error scraping environment: %v", err))
 		case line := <-sshCtx.Output:
 			if len(line) <= 1 {
 				parserState = nxosParserStateUnknown
@@ -96,39 +121,39 @@ func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, label
 			}
 
 			if parserState == nxosParserStateUnknown {
-				if matches := powerSupplyVoltageRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+				if matches := match(collectCtx, powerSupplyVoltageRegex, strings.ToLower(line)); matches != nil {
 					voltage := util.Str2float64(matches[1])
-					metrics <- prometheus.MustNewConstMetric(powerSupplyVoltageDesc, prometheus.GaugeValue, voltage, labelValues...)
+					result.AddMetric(prometheus.MustNewConstMetric(powerSupplyVoltageDesc, prometheus.GaugeValue, voltage, labelValues...))
 				}
-				if matches := powerSupplyRedundancyModeOperationalRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+				if matches := match(collectCtx, powerSupplyRedundancyModeOperationalRegex, strings.ToLower(line)); matches != nil {
 					redundancyState := 0.0
 					if matches[1] == "redundant" || matches[1] == "ps-redundant" {
 						redundancyState = 1
 					}
-					metrics <- prometheus.MustNewConstMetric(powerSupplyRedundancyOperationalDesc, prometheus.GaugeValue, redundancyState, labelValues...)
+					result.AddMetric(prometheus.MustNewConstMetric(powerSupplyRedundancyOperationalDesc, prometheus.GaugeValue, redundancyState, labelValues...))
 				}
-				if matches := powerSupplyRedundancyModeConfiguredRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+				if matches := match(collectCtx, powerSupplyRedundancyModeConfiguredRegex, strings.ToLower(line)); matches != nil {
 					redundancyState := 0.0
 					if matches[2] == "redundant" || matches[2] == "ps-redundant" {
 						redundancyState = 1
 					}
-					metrics <- prometheus.MustNewConstMetric(powerSupplyRedundancyConfiguredDesc, prometheus.GaugeValue, redundancyState, labelValues...)
+					result.AddMetric(prometheus.MustNewConstMetric(powerSupplyRedundancyConfiguredDesc, prometheus.GaugeValue, redundancyState, labelValues...))
 				}
-				if matches := totalPowerCapacityRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+				if matches := match(collectCtx, totalPowerCapacityRegex, strings.ToLower(line)); matches != nil {
 					totalPowerCapacity := util.Str2float64(matches[1])
-					metrics <- prometheus.MustNewConstMetric(powerSupplyTotalCapacityDesc, prometheus.GaugeValue, totalPowerCapacity, labelValues...)
+					result.AddMetric(prometheus.MustNewConstMetric(powerSupplyTotalCapacityDesc, prometheus.GaugeValue, totalPowerCapacity, labelValues...))
 				}
-				if matches := totalPowerInputRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+				if matches := match(collectCtx, totalPowerInputRegex, strings.ToLower(line)); matches != nil {
 					totalPowerInput := util.Str2float64(matches[1])
-					metrics <- prometheus.MustNewConstMetric(powerSupplyTotalPowerInputDesc, prometheus.GaugeValue, totalPowerInput, labelValues...)
+					result.AddMetric(prometheus.MustNewConstMetric(powerSupplyTotalPowerInputDesc, prometheus.GaugeValue, totalPowerInput, labelValues...))
 				}
-				if matches := totalPowerOutputRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+				if matches := match(collectCtx, totalPowerOutputRegex, strings.ToLower(line)); matches != nil {
 					totalPowerOutput := util.Str2float64(matches[1])
-					metrics <- prometheus.MustNewConstMetric(powerSupplyTotalPowerOutputDesc, prometheus.GaugeValue, totalPowerOutput, labelValues...)
+					result.AddMetric(prometheus.MustNewConstMetric(powerSupplyTotalPowerOutputDesc, prometheus.GaugeValue, totalPowerOutput, labelValues...))
 				}
-				if matches := totalPowerAvailableRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+				if matches := match(collectCtx, totalPowerAvailableRegex, strings.ToLower(line)); matches != nil {
 					totalPowerAvailable := util.Str2float64(matches[1])
-					metrics <- prometheus.MustNewConstMetric(powerSupplyTotalPowerAvailableDesc, prometheus.GaugeValue, totalPowerAvailable, labelValues...)
+					result.AddMetric(prometheus.MustNewConstMetric(powerSupplyTotalPowerAvailableDesc, prometheus.GaugeValue, totalPowerAvailable, labelValues...))
 				}
 			}
 
@@ -152,12 +177,12 @@ func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, label
 				}
 
 				if fanName != "Fan" && fanModel != "Model" && fanHw != "Hw" {
-					metrics <- prometheus.MustNewConstMetric(fanOperationalInfoDesc, prometheus.GaugeValue, fanOperational, append(labelValues, []string{fanName, fanModel, fanHw}...)...)
+					result.AddMetric(prometheus.MustNewConstMetric(fanOperationalInfoDesc, prometheus.GaugeValue, fanOperational, append(labelValues, []string{fanName, fanModel, fanHw}...)...))
 				}
 			}
 
 			if parserState == nxosParserStateTemp {
-				values := temperatereValuesRegex.FindStringSubmatch(line)
+				values := match(collectCtx, temperatereValuesRegex, line)
 				if values == nil {
 					continue
 				}
@@ -168,13 +193,13 @@ func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, label
 				currentTemp := util.Str2float64(values[5])
 
 				labels := append(labelValues, []string{temperatureModule, temperatureSensor}...)
-				metrics <- prometheus.MustNewConstMetric(temperatureMajorThreshDesc, prometheus.GaugeValue, majorThresh, labels...)
-				metrics <- prometheus.MustNewConstMetric(temperatureMinorThreshDesc, prometheus.GaugeValue, minorThresh, labels...)
-				metrics <- prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, currentTemp, labels...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureMajorThreshDesc, prometheus.GaugeValue, majorThresh, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureMinorThreshDesc, prometheus.GaugeValue, minorThresh, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, currentTemp, labels...))
 			}
 
 			if parserState == nxosParserStatePS {
-				values := powerSupplyValuesRegex.FindStringSubmatch(line)
+				values := match(collectCtx, powerSupplyValuesRegex, line)
 				if values == nil {
 					continue
 				}
@@ -190,13 +215,13 @@ func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, label
 				}
 
 				labels := append(labelValues, []string{ps, model, inputType}...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyPowerDesc, prometheus.GaugeValue, power, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyCurrentDesc, prometheus.GaugeValue, current, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, operational, labels...)
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyPowerDesc, prometheus.GaugeValue, power, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyCurrentDesc, prometheus.GaugeValue, current, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, operational, labels...))
 			}
 
 			if parserState == nxosParserStatePSModule {
-				values := powerSupplyModuleValuesRegex.FindStringSubmatch(line)
+				values := match(collectCtx, powerSupplyModuleValuesRegex, line)
 				if values == nil {
 					continue
 				}
@@ -210,15 +235,15 @@ func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, label
 				status := strings.ToLower(strings.TrimSpace(values[7]))
 
 				labels := append(labelValues, []string{module, model}...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyRequestedPower, prometheus.GaugeValue, reqPow, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyRequestedCurrent, prometheus.GaugeValue, reqCur, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyAllocatedPower, prometheus.GaugeValue, allocPow, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyAllocatedCurrent, prometheus.GaugeValue, allocCur, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyStatusInfo, prometheus.GaugeValue, 1.0, append(labels, status)...)
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyRequestedPower, prometheus.GaugeValue, reqPow, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyRequestedCurrent, prometheus.GaugeValue, reqCur, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyAllocatedPower, prometheus.GaugeValue, allocPow, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyAllocatedCurrent, prometheus.GaugeValue, allocCur, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyStatusInfo, prometheus.GaugeValue, 1.0, append(labels, status)...))
 			}
 
 			if parserState == nxosParserStatePS2 {
-				values := powerSupplyModuleValuesRegex2.FindStringSubmatch(line)
+				values := match(collectCtx, powerSupplyModuleValuesRegex2, line)
 				if values == nil {
 					continue
 				}
@@ -234,16 +259,17 @@ func (p *nxosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, label
 				}
 
 				labels := append(labelValues, supply, model)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyActualOutputDesc, prometheus.GaugeValue, actualOutput, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyActualInputDesc, prometheus.GaugeValue, actualInput, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyCapacityDesc, prometheus.GaugeValue, capacity, labels...)
-				metrics <- prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, status, append(labels, "")...)
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyActualOutputDesc, prometheus.GaugeValue, actualOutput, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyActualInputDesc, prometheus.GaugeValue, actualInput, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyCapacityDesc, prometheus.GaugeValue, capacity, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, status, append(labels, "")...))
 			}
 		}
 	}
 }
 
-func (p *iosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, labelValues []string, errors chan error, metrics chan<- prometheus.Metric) {
+func (p *iosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result) {
+	labelValues := collectCtx.LabelValues
 	fanStatusRegex := regexp.MustCompile(`fan\s+in(.*?)\s+is\s+(\S+)`)
 	systemTemperatureStatusRegex := regexp.MustCompile(`system temperature is (.*)`)
 	temperatureValueRegex := regexp.MustCompile(`(.*) temperature value: (.*) degree`)
@@ -261,84 +287,85 @@ func (p *iosEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, labelV
 		case <-sshCtx.Done:
 			return
 		case err := <-sshCtx.Errors:
-			errors <- fmt.Errorf("Error scraping environment: %v", err)
+			result.AddError(fmt.Errorf("Error scraping environment: %v", err))
 		case line := <-sshCtx.Output:
-			if matches := fanStatusRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, fanStatusRegex, strings.ToLower(line)); matches != nil {
 				fan := matches[1]
 				fanOperational := 0.0
 				if matches[2] == "ok" {
 					fanOperational = 1
 				}
-				metrics <- prometheus.MustNewConstMetric(fanOperationalInfoDesc, prometheus.GaugeValue, fanOperational, append(labelValues, fan, "", "")...)
+				result.AddMetric(prometheus.MustNewConstMetric(fanOperationalInfoDesc, prometheus.GaugeValue, fanOperational, append(labelValues, fan, "", "")...))
 			}
-			if matches := systemTemperatureStatusRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, systemTemperatureStatusRegex, strings.ToLower(line)); matches != nil {
 				status := matches[1]
-				metrics <- prometheus.MustNewConstMetric(systemTemperatureStatusInfoDesc, prometheus.GaugeValue, 1.0, append(labelValues, status)...)
+				result.AddMetric(prometheus.MustNewConstMetric(systemTemperatureStatusInfoDesc, prometheus.GaugeValue, 1.0, append(labelValues, status)...))
 			}
-			if matches := temperatureValueRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, temperatureValueRegex, strings.ToLower(line)); matches != nil {
 				sensor := matches[1]
 				value := util.Str2float64(matches[2])
-				metrics <- prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, value, append(labelValues, "", sensor)...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, value, append(labelValues, "", sensor)...))
 			}
-			if matches := systemTemperatureLowAlertThresholdRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, systemTemperatureLowAlertThresholdRegex, strings.ToLower(line)); matches != nil {
 				sensor := "system"
 				value := util.Str2float64(matches[1])
-				metrics <- prometheus.MustNewConstMetric(temperatureLowAlarmThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureLowAlarmThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...))
 				continue
 			}
-			if matches := systemTemperatureLowShutdownThresholdRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, systemTemperatureLowShutdownThresholdRegex, strings.ToLower(line)); matches != nil {
 				sensor := "system"
 				value := util.Str2float64(matches[1])
-				metrics <- prometheus.MustNewConstMetric(temperatureLowShutdownThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureLowShutdownThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...))
 				continue
 			}
-			if matches := systemTemperatureHighAlertThresholdRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, systemTemperatureHighAlertThresholdRegex, strings.ToLower(line)); matches != nil {
 				sensor := "system"
 				value := util.Str2float64(matches[1])
-				metrics <- prometheus.MustNewConstMetric(temperatureHighAlarmThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureHighAlarmThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...))
 				continue
 			}
-			if matches := systemTemperatureHighShutdownThresholdRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, systemTemperatureHighShutdownThresholdRegex, strings.ToLower(line)); matches != nil {
 				sensor := "system"
 				value := util.Str2float64(matches[1])
-				metrics <- prometheus.MustNewConstMetric(temperatureHighShutdownThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureHighShutdownThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...))
 				continue
 			}
-			if matches := temperatureAlertThresholdRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, temperatureAlertThresholdRegex, strings.ToLower(line)); matches != nil {
 				sensor := matches[1]
 				value := util.Str2float64(matches[2])
-				metrics <- prometheus.MustNewConstMetric(temperatureHighAlarmThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureHighAlarmThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...))
 				continue
 			}
-			if matches := temperatureShutdownThresholdRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, temperatureShutdownThresholdRegex, strings.ToLower(line)); matches != nil {
 				sensor := matches[1]
 				value := util.Str2float64(matches[2])
-				metrics <- prometheus.MustNewConstMetric(temperatureHighShutdownThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...)
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureHighShutdownThresholdDesc, prometheus.GaugeValue, value, append(labelValues, sensor)...))
 				continue
 			}
-			if matches := powerSupplyStatusRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, powerSupplyStatusRegex, strings.ToLower(line)); matches != nil {
 				powerSupply := matches[1]
 				status := 0.0
 				if matches[2] == "dc ok" {
 					status = 1
 				}
-				metrics <- prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, status, append(labelValues, powerSupply, "", "")...)
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, status, append(labelValues, powerSupply, "", "")...))
 				continue
 			}
-			if matches := alarmContactStatusRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, alarmContactStatusRegex, strings.ToLower(line)); matches != nil {
 				contact := matches[1]
 				asserted := 1.0
 				if matches[2] == "not asserted" {
 					asserted = 0
 				}
-				metrics <- prometheus.MustNewConstMetric(alarmContactAssertedDesc, prometheus.GaugeValue, asserted, append(labelValues, contact)...)
+				result.AddMetric(prometheus.MustNewConstMetric(alarmContactAssertedDesc, prometheus.GaugeValue, asserted, append(labelValues, contact)...))
 				continue
 			}
 		}
 	}
 }
 
-func (p *iosXeEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, labelValues []string, errors chan error, metrics chan<- prometheus.Metric) {
+func (p *iosXeEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result) {
+	labelValues := collectCtx.LabelValues
 	criticalAlarmsRegex := regexp.MustCompile(`critical alarms.*?(\d+)`)
 	majorAlarmsRegex := regexp.MustCompile(`major alarms.*?(\d+)`)
 	minorAlarmsRegex := regexp.MustCompile(`minor alarms.*?(\d+)`)
@@ -350,21 +377,21 @@ func (p *iosXeEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, labe
 		case <-sshCtx.Done:
 			return
 		case err := <-sshCtx.Errors:
-			errors <- fmt.Errorf("Error scraping environment: %v", err)
+			result.AddError(fmt.Errorf("Error scraping environment: %v", err))
 		case line := <-sshCtx.Output:
-			if matches := criticalAlarmsRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, criticalAlarmsRegex, strings.ToLower(line)); matches != nil {
 				criticalAlarms := util.Str2float64(matches[1])
-				metrics <- prometheus.MustNewConstMetric(criticalAlarmsDesc, prometheus.GaugeValue, criticalAlarms, labelValues...)
+				result.AddMetric(prometheus.MustNewConstMetric(criticalAlarmsDesc, prometheus.GaugeValue, criticalAlarms, labelValues...))
 			}
-			if matches := majorAlarmsRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, majorAlarmsRegex, strings.ToLower(line)); matches != nil {
 				majorAlarms := util.Str2float64(matches[1])
-				metrics <- prometheus.MustNewConstMetric(majorAlarmsDesc, prometheus.GaugeValue, majorAlarms, labelValues...)
+				result.AddMetric(prometheus.MustNewConstMetric(majorAlarmsDesc, prometheus.GaugeValue, majorAlarms, labelValues...))
 			}
-			if matches := minorAlarmsRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, minorAlarmsRegex, strings.ToLower(line)); matches != nil {
 				minorAlarms := util.Str2float64(matches[1])
-				metrics <- prometheus.MustNewConstMetric(minorAlarmsDesc, prometheus.GaugeValue, minorAlarms, labelValues...)
+				result.AddMetric(prometheus.MustNewConstMetric(minorAlarmsDesc, prometheus.GaugeValue, minorAlarms, labelValues...))
 			}
-			if matches := valuesRegex.FindStringSubmatch(strings.ToLower(line)); matches != nil {
+			if matches := match(collectCtx, valuesRegex, strings.ToLower(line)); matches != nil {
 				slot := matches[1]
 				sensor := matches[2]
 				state := matches[3]
@@ -374,22 +401,22 @@ func (p *iosXeEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, labe
 				labels := append(labelValues, slot, sensor)
 				switch unit {
 				case "a":
-					metrics <- prometheus.MustNewConstMetric(currentReadingDesc, prometheus.GaugeValue, value, labels...)
+					result.AddMetric(prometheus.MustNewConstMetric(currentReadingDesc, prometheus.GaugeValue, value, labels...))
 				case "v ac":
 					fallthrough
 				case "v dc":
 					fallthrough
 				case "v":
-					metrics <- prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, value, labels...)
+					result.AddMetric(prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, value, labels...))
 				case "mv":
-					metrics <- prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, value/1000.0, labels...)
+					result.AddMetric(prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, value/1000.0, labels...))
 				case "celsius":
-					metrics <- prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, value, labels...)
+					result.AddMetric(prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, value, labels...))
 				}
 
 				if matches := fanSpeedRegex.FindStringSubmatch(state); matches != nil {
 					fanSpeed := util.Str2float64(matches[1])
-					metrics <- prometheus.MustNewConstMetric(fanSpeedDesc, prometheus.GaugeValue, fanSpeed, labels...)
+					result.AddMetric(prometheus.MustNewConstMetric(fanSpeedDesc, prometheus.GaugeValue, fanSpeed, labels...))
 				}
 
 				if unit == "celsius" {
@@ -398,17 +425,175 @@ func (p *iosXeEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, labe
 						majorThreshold := util.Str2float64(matches[7])
 						criticalThreshold := util.Str2float64(matches[8])
 
-						metrics <- prometheus.MustNewConstMetric(temperatureMinorThreshDesc, prometheus.GaugeValue, minorThreshold, labels...)
-						metrics <- prometheus.MustNewConstMetric(temperatureMajorThreshDesc, prometheus.GaugeValue, majorThreshold, labels...)
-						metrics <- prometheus.MustNewConstMetric(temperatureCriticalThreshDesc, prometheus.GaugeValue, criticalThreshold, labels...)
+						result.AddMetric(prometheus.MustNewConstMetric(temperatureMinorThreshDesc, prometheus.GaugeValue, minorThreshold, labels...))
+						result.AddMetric(prometheus.MustNewConstMetric(temperatureMajorThreshDesc, prometheus.GaugeValue, majorThreshold, labels...))
+						result.AddMetric(prometheus.MustNewConstMetric(temperatureCriticalThreshDesc, prometheus.GaugeValue, criticalThreshold, labels...))
 					}
 
 					if matches[9] != "" {
 						shutdownThreshold := util.Str2float64(matches[9])
-						metrics <- prometheus.MustNewConstMetric(temperatureShutdownThreshDesc, prometheus.GaugeValue, shutdownThreshold, labels...)
+						result.AddMetric(prometheus.MustNewConstMetric(temperatureShutdownThreshDesc, prometheus.GaugeValue, shutdownThreshold, labels...))
+					}
+				}
+			}
+		}
+	}
+}
+
+type iosXrParserState int
+
+const (
+	iosXrParserStateUnknown iosXrParserState = iota
+	iosXrParserStateTemp
+	iosXrParserStateVoltage
+	iosXrParserStatePower
+	iosXrParserStateFan
+)
+
+func (p *iosXrEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result) {
+	labelValues := collectCtx.LabelValues
+	temperatureRegex := regexp.MustCompile(`^temperature:`)
+	voltageRegex := regexp.MustCompile(`^voltage:`)
+	powerRegex := regexp.MustCompile(`^power\b`)
+	fanRegex := regexp.MustCompile(`^fan speed \(rpm\):`)
+	locationRegex := regexp.MustCompile(`^(\d+/\S+)\s*$`)
+	temperatureValuesRegex := regexp.MustCompile(`^\s+(\S+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s+(-?\d+)\s*$`)
+	voltageValuesRegex := regexp.MustCompile(`^\s+(\S+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*$`)
+	powerValuesRegex := regexp.MustCompile(`^(\d+/\S+)\s+(\S+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\d+\.\d+)\s+(\S+)`)
+	fanNamesRegex := regexp.MustCompile(`^\s+(FAN\d+(?:\s+FAN\d+)*)\s*$`)
+	fanValuesRegex := regexp.MustCompile(`^\s+(\d+(?:\s+\d+)*)\s*$`)
+	seperator := regexp.MustCompile(`\s+`)
+
+	parserState := iosXrParserStateUnknown
+	location := ""
+	var fanNames []string
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			return
+		case err := <-sshCtx.Errors:
+			result.AddError(fmt.Errorf("Error scraping environment: %v", err))
+		case line := <-sshCtx.Output:
+			lowerLine := strings.ToLower(line)
+			switch {
+			case temperatureRegex.MatchString(lowerLine):
+				parserState = iosXrParserStateTemp
+				continue
+			case voltageRegex.MatchString(lowerLine):
+				parserState = iosXrParserStateVoltage
+				continue
+			case powerRegex.MatchString(lowerLine):
+				parserState = iosXrParserStatePower
+				continue
+			case fanRegex.MatchString(lowerLine):
+				parserState = iosXrParserStateFan
+				continue
+			}
+			if matches := match(collectCtx, locationRegex, line); matches != nil {
+				location = matches[1]
+				continue
+			}
+
+			switch parserState {
+			case iosXrParserStateTemp:
+				values := match(collectCtx, temperatureValuesRegex, line)
+				if values == nil {
+					continue
+				}
+				labels := append(labelValues, location, values[1])
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, util.Str2float64(values[2]), labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureMinorThreshDesc, prometheus.GaugeValue, util.Str2float64(values[6]), labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureMajorThreshDesc, prometheus.GaugeValue, util.Str2float64(values[7]), labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureCriticalThreshDesc, prometheus.GaugeValue, util.Str2float64(values[8]), labels...))
+			case iosXrParserStateVoltage:
+				values := match(collectCtx, voltageValuesRegex, line)
+				if values == nil {
+					continue
+				}
+				labels := append(labelValues, location, values[1])
+				result.AddMetric(prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, util.Str2float64(values[2])/1000.0, labels...))
+			case iosXrParserStatePower:
+				values := match(collectCtx, powerValuesRegex, line)
+				if values == nil {
+					continue
+				}
+				operational := 0.0
+				if strings.ToLower(values[7]) == "ok" {
+					operational = 1
+				}
+				labels := append(labelValues, values[1], values[2], "")
+				outputPower := util.Str2float64(values[5]) * util.Str2float64(values[6])
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyPowerDesc, prometheus.GaugeValue, outputPower, labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyCurrentDesc, prometheus.GaugeValue, util.Str2float64(values[4]), labels...))
+				result.AddMetric(prometheus.MustNewConstMetric(powerSupplyOperationalInfoDesc, prometheus.GaugeValue, operational, labels...))
+			case iosXrParserStateFan:
+				if matches := match(collectCtx, fanNamesRegex, line); matches != nil {
+					fanNames = seperator.Split(matches[1], -1)
+					continue
+				}
+				values := match(collectCtx, fanValuesRegex, line)
+				if values == nil {
+					continue
+				}
+				for i, speed := range seperator.Split(values[1], -1) {
+					if i >= len(fanNames) {
+						break
 					}
+					result.AddMetric(prometheus.MustNewConstMetric(fanSpeedRPMDesc, prometheus.GaugeValue, util.Str2float64(speed), append(labelValues, location, fanNames[i])...))
 				}
 			}
 		}
 	}
 }
+
+// parse parses the readings of `show environment` on an ASA. They are grouped in sections like `Temperature` and subsections
+// like `Processors`, which are exported together as module / slot label, e.g. `Temperature/Processors`.
+func (p *asaEnvironmentParser) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result) {
+	labelValues := collectCtx.LabelValues
+	sectionRegex := regexp.MustCompile(`^(\S.*):\s*$`)
+	subsectionRegex := regexp.MustCompile(`^\s+(\S.*):\s*$`)
+	valueRegex := regexp.MustCompile(`^\s+(.+?): (-?\d+(?:\.\d+)?) (C|RPM|V|mV) - `)
+
+	section := ""
+	subsection := ""
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			return
+		case err := <-sshCtx.Errors:
+			result.AddError(fmt.Errorf("Error scraping environment: %v", err))
+		case line := <-sshCtx.Output:
+			if matches := match(collectCtx, sectionRegex, line); matches != nil {
+				section = matches[1]
+				subsection = ""
+				continue
+			}
+			if matches := match(collectCtx, subsectionRegex, line); matches != nil {
+				subsection = matches[1]
+				continue
+			}
+			matches := match(collectCtx, valueRegex, line)
+			if matches == nil {
+				continue
+			}
+			module := section
+			if subsection != "" {
+				module += "/" + subsection
+			}
+			labels := append(labelValues, module, matches[1])
+			value := util.Str2float64(matches[2])
+			switch matches[3] {
+			case "C":
+				result.AddMetric(prometheus.MustNewConstMetric(temperatureCurrentDesc, prometheus.GaugeValue, value, labels...))
+			case "RPM":
+				result.AddMetric(prometheus.MustNewConstMetric(fanSpeedRPMDesc, prometheus.GaugeValue, value, labels...))
+			case "V":
+				result.AddMetric(prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, value, labels...))
+			case "mV":
+				result.AddMetric(prometheus.MustNewConstMetric(voltageReadingDesc, prometheus.GaugeValue, value/1000.0, labels...))
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package environment
 import (
     "github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/util"
 	"testing"
@@ -10,19 +11,17 @@ import (
 
 func performTest(input string, expectedResult map[string]float64, p parser, t *testing.T) {
 	ctx := util.PrepareOutputForTesting(input)
-	errChan := make(chan error, 100)
-	metricsChan := make(chan prometheus.Metric, 1000)
-	p.parse(&ctx, []string{"test.test"}, errChan, metricsChan)
-	close(errChan)
-	close(metricsChan)
-	for {
-		err, more := <-errChan
-		if !more {
-			break
-		}
+	result := collector.NewResult()
+	p.parse(&ctx, &collector.CollectContext{LabelValues: []string{"test.test"}}, result)
+	for _, err := range result.Errors {
 		t.Errorf("Got error from parser: %v", err)
 	}
 
+	metricsChan := make(chan prometheus.Metric, len(result.Metrics))
+	for _, metric := range result.Metrics {
+		metricsChan <- metric
+	}
+	close(metricsChan)
 	gotMetrics := util.PrepareMetricsForTesting(metricsChan, t)
 	util.CompareMetrics(gotMetrics, expectedResult, t)
 }
@@ -63,6 +62,18 @@ func TestParseIos2(t *testing.T) {
 	performTest(ios2, expectedIos2Metrics, parser, t)
 }
 
+func TestParseIosXr1(t *testing.T) {
+	parser, err := getParserForOSversion(config.IOSXR)
+	if err != nil {
+		t.Errorf("Could not get parser for IOS XR: %v", err)
+	}
+	performTest(iosXr1, expectedIosXr1Metrics, parser, t)
+}
+
+func TestParseAsa1(t *testing.T) {
+	performTest(asa1, expectedAsa1Metrics, newAsaEnvironmentParser(), t)
+}
+
 func TestGetParserForInvalidOSversion(t *testing.T) {
 	_, err := getParserForOSversion(config.INVALID)
 	if err == nil {
@@ -85,10 +96,9 @@ func TestSshCtxErrorHandling(t *testing.T) {
             t.Errorf("Could not get parser for OS version %s", version.String())
         }
         ctx := util.PrepareErrorForTesting(errors.New("example"))
-        metricsChan := make(chan prometheus.Metric, 100)
-        errChan := make(chan error, 100)
-        parser.parse(&ctx, []string{"test.test"}, errChan, metricsChan)
-        if len(errChan) != 1 {
+        result := collector.NewResult()
+        parser.parse(&ctx, &collector.CollectContext{LabelValues: []string{"test.test"}}, result)
+        if len(result.Errors) != 1 {
             t.Errorf("Expected exactly one error")
         }
     }
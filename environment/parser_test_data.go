@@ -523,4 +523,107 @@ ALARM CONTACT 4 is not asserted
 		prefix + "temperature_low_alarm_threshold_celsius{sensor=system,target=test.test}":             0,
 		prefix + "temperature_low_shutdown_threshold_celsius{sensor=system,target=test.test}":          -20,
 	}
+
+	iosXr1 = `
+Temperature:
+---------------------------------------------------------------------------------------------------
+Location  TEMPERATURE                 Value   Crit Major Minor Minor Major  Crit
+          Sensor                     (deg C)  (Lo) (Lo)  (Lo)  (Hi)  (Hi)   (Hi)
+---------------------------------------------------------------------------------------------------
+0/RSP0/CPU0
+          Inlet0                       29      -10    -5     0    55    65    70
+          Hotspot0                     38      -10    -5     0    85    95   105
+0/0/CPU0
+          Inlet0                       31      -10    -5     0    55    65    70
+
+Voltage:
+----------------------------------------------------------------------------
+Location  VOLTAGE                     Value   Crit Minor Minor  Crit
+          Sensor                      (mV)    (Lo) (Lo)  (Hi)   (Hi)
+----------------------------------------------------------------------------
+0/RSP0/CPU0
+          0.75VTT                      751     684   702   798   816
+          3.3V                        3295    3003  3036  3564  3597
+
+Power Supply:
+================================================================================
+Module      Type           ---Input----      ---Output---     Status
+                           Volts  Amps       Volts  Amps
+================================================================================
+0/PS0/M0/SP  PWR-3KW-AC-V2   235.2    4.9      54.6    19.3      OK
+0/PS0/M1/SP  PWR-3KW-AC-V2   0.0      0.0      0.0     0.0       FAILED
+
+Total of Power Modules:    3000W/63.2A
+Total Power Input:         1152W/4.9A
+Total Power Output:        1053W/19.3A
+
+Fan speed (rpm):
+                  FAN0    FAN1    FAN2    FAN3
+0/FT0/SP
+                  6720    6660    6720    6600
+0/FT1/SP
+                  6780    6720    6660    6720
+`
+	expectedIosXr1Metrics = map[string]float64{
+		prefix + "temperature_current_celsius{module=0/RSP0/CPU0,sensor=Inlet0,target=test.test}":            29,
+		prefix + "temperature_minor_threshold_celsius{module=0/RSP0/CPU0,sensor=Inlet0,target=test.test}":    55,
+		prefix + "temperature_major_threshold_celsius{module=0/RSP0/CPU0,sensor=Inlet0,target=test.test}":    65,
+		prefix + "temperature_critical_threshold_celsius{module=0/RSP0/CPU0,sensor=Inlet0,target=test.test}": 70,
+		prefix + "temperature_current_celsius{module=0/RSP0/CPU0,sensor=Hotspot0,target=test.test}":          38,
+		prefix + "temperature_current_celsius{module=0/0/CPU0,sensor=Inlet0,target=test.test}":               31,
+		prefix + "voltage_reading_volts{sensor=0.75VTT,slot=0/RSP0/CPU0,target=test.test}":                   0.751,
+		prefix + "voltage_reading_volts{sensor=3.3V,slot=0/RSP0/CPU0,target=test.test}":                      3.295,
+		prefix + "powersupply_power_watts{input_type=,model=PWR-3KW-AC-V2,ps=0/PS0/M0/SP,target=test.test}":  54.6 * 19.3,
+		prefix + "powersupply_current_amps{input_type=,model=PWR-3KW-AC-V2,ps=0/PS0/M0/SP,target=test.test}": 4.9,
+		prefix + "powersupply_operational_info{input_type=,model=PWR-3KW-AC-V2,ps=0/PS0/M0/SP,target=test.test}": 1,
+		prefix + "powersupply_operational_info{input_type=,model=PWR-3KW-AC-V2,ps=0/PS0/M1/SP,target=test.test}": 0,
+		prefix + "fan_speed_rpm{fan=FAN0,slot=0/FT0/SP,target=test.test}":                                    6720,
+		prefix + "fan_speed_rpm{fan=FAN3,slot=0/FT0/SP,target=test.test}":                                    6600,
+		prefix + "fan_speed_rpm{fan=FAN1,slot=0/FT1/SP,target=test.test}":                                    6720,
+	}
+
+	asa1 = `
+Cooling Fans:
+-----------------------------------
+  Power Supplies:
+  --------------------------------
+  Left Slot (PS0): 7200 RPM - OK (Power Supply Fan)
+  Right Slot (PS1): N/A - Not Present
+
+  Chassis:
+  --------------------------------
+  Fan 1: 5120 RPM - OK (Chassis Fan)
+
+Power Supplies:
+-----------------------------------
+  Power Supply Unit Redundancy: N/A
+
+  Temperature:
+  --------------------------------
+  Left Slot (PS0): 29 C - OK  (Power Supply Temperature)
+
+Temperature:
+-----------------------------------
+  Processors:
+  --------------------------------
+  Processor 1: 45.0 C - OK (CPU1 Core Temperature)
+
+  Chassis:
+  --------------------------------
+  Ambient 1: 27.0 C - OK (Chassis Front Temperature)
+
+Voltage:
+-----------------------------------
+  Channel 1: 3.300 V - (3.3V)
+  Channel 2: 1500 mV - (1.5V)
+`
+	expectedAsa1Metrics = map[string]float64{
+		prefix + "fan_speed_rpm{fan=Left Slot (PS0),slot=Cooling Fans/Power Supplies,target=test.test}": 7200,
+		prefix + "fan_speed_rpm{fan=Fan 1,slot=Cooling Fans/Chassis,target=test.test}":                  5120,
+		prefix + "temperature_current_celsius{module=Power Supplies/Temperature,sensor=Left Slot (PS0),target=test.test}": 29,
+		prefix + "temperature_current_celsius{module=Temperature/Processors,sensor=Processor 1,target=test.test}":         45,
+		prefix + "temperature_current_celsius{module=Temperature/Chassis,sensor=Ambient 1,target=test.test}":              27,
+		prefix + "voltage_reading_volts{sensor=Channel 1,slot=Voltage,target=test.test}":                                 3.3,
+		prefix + "voltage_reading_volts{sensor=Channel 2,slot=Voltage,target=test.test}":                                 1.5,
+	}
 )
@@ -0,0 +1,164 @@
+package failover
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const prefix string = "cisco_asa_failover_"
+
+var (
+	enabledDesc         *prometheus.Desc
+	linkUpDesc          *prometheus.Desc
+	stateDesc           *prometheus.Desc
+	activeTimeDesc      *prometheus.Desc
+	interfaceNormalDesc *prometheus.Desc
+)
+
+// Collector gathers the failover state of an ASA by running `show failover`.
+type Collector struct {
+}
+
+// NewCollector returns a new failover.Collector instance.
+func NewCollector() collector.Collector {
+	return &Collector{}
+}
+
+// Name implements the collector.Collector interface's Name function
+func (*Collector) Name() string {
+	return "failover"
+}
+
+func init() {
+	l := []string{"target"}
+	enabledDesc = prometheus.NewDesc(prefix+"enabled", "1 if failover is enabled", l, nil)
+	linkUpDesc = prometheus.NewDesc(prefix+"link_up", "1 if the failover or stateful link is up", append(l, "link", "interface"), nil)
+	stateDesc = prometheus.NewDesc(prefix+"state_info", "Failover unit and state of this and the other host exported as labels", append(l, "host", "unit", "state"), nil)
+	activeTimeDesc = prometheus.NewDesc(prefix+"active_seconds_total", "Time the host has been active", append(l, "host"), nil)
+	interfaceNormalDesc = prometheus.NewDesc(prefix+"interface_normal", "1 if the monitored interface is in state Normal", append(l, "host", "interface", "state"), nil)
+}
+
+// Describe implements the collector.Collector interface's Describe function
+func (*Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- enabledDesc
+	ch <- linkUpDesc
+	ch <- stateDesc
+	ch <- activeTimeDesc
+	ch <- interfaceNormalDesc
+}
+
+// Collect implements the collector.Collector interface's Collect function.
+// The failover state is global, so it is gathered in the current (system) context.
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	sshCtx := connector.NewSSHCommandContext("show failover")
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
+	c.parse(sshCtx, collectCtx, result)
+	return result
+}
+
+// State is the failover state parsed from `show failover`.
+type State struct {
+	Enabled bool
+	Links   []*Link
+	Hosts   []*Host
+}
+
+// Link is the failover LAN or the stateful link.
+type Link struct {
+	Link      string
+	Interface string
+	Up        bool
+}
+
+// Host is this or the other host of a failover pair.
+type Host struct {
+	Host       string
+	Unit       string
+	State      string
+	ActiveTime float64
+	Interfaces []*MonitoredInterface
+}
+
+// MonitoredInterface is an interface monitored by a host.
+type MonitoredInterface struct {
+	Name  string
+	State string
+}
+
+func (c *Collector) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result) {
+	enabledRegexp := regexp.MustCompile(`^Failover (On|Off)\s*$`)
+	lanLinkRegexp := regexp.MustCompile(`^Failover LAN Interface: (\S+) \S+ \((\S+)\)`)
+	statefulLinkRegexp := regexp.MustCompile(`^\s+Link : (\S+) \S+ \((\S+)\)`)
+	hostRegexp := regexp.MustCompile(`^\s+(This|Other) host: (\S+) - (.+?)\s*$`)
+	activeTimeRegexp := regexp.MustCompile(`^\s+Active time: (\d+) \(sec\)`)
+	interfaceRegexp := regexp.MustCompile(`^\s+Interface (\S+) \(.*\): (.+?)(?: \(.*\))?\s*$`)
+
+	matched := false
+	state := &State{}
+	var host *Host
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			if !matched {
+				result.AddError(errors.New("No failover state was extracted"))
+				return
+			}
+			generateMetrics(collectCtx, result, state)
+			return
+		case err := <-sshCtx.Errors:
+			result.AddError(errors.Wrapf(err, "Error scraping failover state: %v", err))
+		case line := <-sshCtx.Output:
+			if matches := enabledRegexp.FindStringSubmatch(line); matches != nil {
+				matched = true
+				state.Enabled = matches[1] == "On"
+			} else if matches := lanLinkRegexp.FindStringSubmatch(line); matches != nil {
+				state.Links = append(state.Links, &Link{Link: "lan", Interface: matches[1], Up: matches[2] == "up"})
+			} else if matches := statefulLinkRegexp.FindStringSubmatch(line); matches != nil {
+				state.Links = append(state.Links, &Link{Link: "stateful", Interface: matches[1], Up: matches[2] == "up"})
+			} else if matches := hostRegexp.FindStringSubmatch(line); matches != nil {
+				host = &Host{Host: strings.ToLower(matches[1]), Unit: matches[2], State: matches[3]}
+				state.Hosts = append(state.Hosts, host)
+			} else if host == nil {
+				continue
+			} else if matches := activeTimeRegexp.FindStringSubmatch(line); matches != nil {
+				host.ActiveTime = util.Str2float64(matches[1])
+			} else if matches := interfaceRegexp.FindStringSubmatch(line); matches != nil {
+				host.Interfaces = append(host.Interfaces, &MonitoredInterface{Name: matches[1], State: matches[2]})
+			}
+		}
+	}
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, state *State) {
+	collectCtx.Record(state)
+	l := collectCtx.LabelValues
+	result.AddMetric(prometheus.MustNewConstMetric(enabledDesc, prometheus.GaugeValue, boolToFloat(state.Enabled), l...))
+	for _, link := range state.Links {
+		result.AddMetric(prometheus.MustNewConstMetric(linkUpDesc, prometheus.GaugeValue, boolToFloat(link.Up), append(l, link.Link, link.Interface)...))
+	}
+	for _, host := range state.Hosts {
+		result.AddMetric(prometheus.MustNewConstMetric(stateDesc, prometheus.GaugeValue, 1, append(l, host.Host, host.Unit, host.State)...))
+		result.AddMetric(prometheus.MustNewConstMetric(activeTimeDesc, prometheus.CounterValue, host.ActiveTime, append(l, host.Host)...))
+		for _, iface := range host.Interfaces {
+			result.AddMetric(prometheus.MustNewConstMetric(interfaceNormalDesc, prometheus.GaugeValue, boolToFloat(iface.State == "Normal"), append(l, host.Host, iface.Name, iface.State)...))
+		}
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package failover
+
+import (
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const showFailover = `Failover On
+Failover unit Primary
+Failover LAN Interface: folink GigabitEthernet1/8 (up)
+Reconnect timeout 0:00:00
+Unit Poll frequency 1 seconds, holdtime 15 seconds
+Interface Poll frequency 5 seconds, holdtime 25 seconds
+Interface Policy 1
+Monitored Interfaces 2 of 1292 maximum
+MAC Address Move Notification Interval not set
+Version: Ours 9.12(4)24, Mate 9.12(4)24
+Serial Number: Ours JAD123456AB, Mate JAD654321BA
+Last Failover at: 10:00:00 UTC Sep 12 2026
+        This host: Primary - Active
+                Active time: 3024000 (sec)
+                slot 0: ASA5516 hw/sw rev (1.0/9.12(4)24) status (Up Sys)
+                  Interface outside (192.0.2.10): Normal (Monitored)
+                  Interface inside (10.0.0.1): Normal (Monitored)
+        Other host: Secondary - Standby Ready
+                Active time: 120 (sec)
+                slot 0: ASA5516 hw/sw rev (1.0/9.12(4)24) status (Up Sys)
+                  Interface outside (192.0.2.11): Normal (Monitored)
+                  Interface inside (10.0.0.2): No Link (Monitored)
+
+Stateful Failover Logical Update Statistics
+        Link : stateful GigabitEthernet1/7 (up)
+        Stateful Obj    xmit       xerr       rcv        rerr
+        General         123456     0          65432      0`
+
+func TestParse(t *testing.T) {
+	c := &Collector{}
+	ctx := util.PrepareOutputForTesting(showFailover)
+	result := collector.NewResult()
+	c.parse(&ctx, &collector.CollectContext{LabelValues: []string{"test.test"}}, result)
+
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_asa_failover_enabled{target=test.test}":                                                    1,
+		"cisco_asa_failover_link_up{interface=folink,link=lan,target=test.test}":                          1,
+		"cisco_asa_failover_link_up{interface=stateful,link=stateful,target=test.test}":                   1,
+		"cisco_asa_failover_state_info{host=this,state=Active,target=test.test,unit=Primary}":             1,
+		"cisco_asa_failover_state_info{host=other,state=Standby Ready,target=test.test,unit=Secondary}":   1,
+		"cisco_asa_failover_active_seconds_total{host=this,target=test.test}":                             3024000,
+		"cisco_asa_failover_active_seconds_total{host=other,target=test.test}":                            120,
+		"cisco_asa_failover_interface_normal{host=this,interface=outside,state=Normal,target=test.test}":  1,
+		"cisco_asa_failover_interface_normal{host=other,interface=inside,state=No Link,target=test.test}": 0,
+	}, t)
+}
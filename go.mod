@@ -3,11 +3,15 @@ module gitlab.com/wobcom/cisco-exporter
 go 1.14
 
 require (
-	github.com/gobwas/glob v0.2.3 // indirect
+	github.com/gobwas/glob v0.2.3
+	github.com/golang/protobuf v1.4.3
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.7.1
 	github.com/prometheus/client_model v0.2.0
 	github.com/prometheus/common v0.10.0
 	golang.org/x/crypto v0.0.0-20200709230013-948cd5f35899
-	gopkg.in/yaml.v2 v2.3.0
+	google.golang.org/grpc v1.40.0
+	google.golang.org/protobuf v1.25.0
+	gopkg.in/yaml.v2 v2.3.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -1,8 +1,13 @@
 package interfaces
 
 import (
+	"context"
+	"strings"
+
 	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
 
 	"github.com/pkg/errors"
 
@@ -64,24 +69,43 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
-
-	if len(ctx.Connection.Device.Interfaces) > 0 {
-		for _, interfaceName := range ctx.Connection.Device.Interfaces {
-			c.collect(ctx, interfaceName)
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	// A single request retrieves all configured interfaces.
+	if netconf, ok := collectCtx.Connection.(*connector.NETCONFConnection); ok {
+		if err := c.collectNETCONF(ctx, collectCtx, result, netconf); err != nil {
+			result.AddError(errors.Wrap(err, "Error scraping interfaces"))
+		}
+		return result
+	}
+
+	if len(collectCtx.Interfaces()) > 0 {
+		for _, interfaceName := range collectCtx.Interfaces() {
+			c.collect(ctx, collectCtx, result, interfaceName)
 		}
 	} else {
-		c.collect(ctx, "")
+		c.collect(ctx, collectCtx, result, "")
 	}
-	return
+	return result
 }
 
-func (c *Collector) collect(ctx *collector.CollectContext, interfaceName string) {
+func (c *Collector) collect(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, interfaceName string) {
+	if collectCtx.Connection.Info().DeviceInfo.OSVersion == config.NXOS {
+		err := c.collectNXOSJSON(ctx, collectCtx, result, interfaceName)
+		if errors.Cause(err) != nxos.ErrJSONUnsupported {
+			if err != nil {
+				result.AddError(errors.Wrap(err, "Error scraping interfaces"))
+			}
+			return
+		}
+	}
+
 	sshCtx := connector.NewSSHCommandContext("show interface " + interfaceName)
-	go ctx.Connection.RunCommand(sshCtx)
+	if interfaceName != "" {
+		sshCtx.Template = "show interface <name>"
+	}
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 	interfaces := make(chan *Interface)
 	interfacesParsingDone := make(chan struct{})
 	interfacesCount := 0
@@ -90,23 +114,46 @@ func (c *Collector) collect(ctx *collector.CollectContext, interfaceName string)
 		select {
 		case iface := <-interfaces:
 			interfacesCount++
-			generateMetrics(ctx, iface)
+			generateMetrics(collectCtx, result, iface)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping interfaces: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping interfaces: %v", err))
 		case <-interfacesParsingDone:
 			if interfacesCount == 0 {
-				ctx.Errors <- errors.New("No interface metric was scraped")
+				result.AddError(errors.New("No interface metric was scraped"))
 			}
 			return
 		}
 	}
 }
 
-func generateMetrics(ctx *collector.CollectContext, iface *Interface) {
+func (c *Collector) collectNXOSJSON(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, interfaceName string) error {
+	data := &NXOSInterfaces{}
+	template := ""
+	if interfaceName != "" {
+		template = "show interface <name>"
+	}
+	if err := nxos.RunJSONTemplate(ctx, collectCtx.Connection, strings.TrimSpace("show interface "+interfaceName), template, data); err != nil {
+		return err
+	}
+	ifaces, err := data.Interfaces()
+	if err != nil {
+		return err
+	}
+	if len(ifaces) == 0 {
+		return errors.New("No interface metric was scraped")
+	}
+	for _, iface := range ifaces {
+		generateMetrics(collectCtx, result, iface)
+	}
+	return nil
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, iface *Interface) {
+	collectCtx.Record(iface)
 	if iface.Description == "" {
 		iface.Description = "<no description>"
 	}
-	l := append(ctx.LabelValues, iface.Name, iface.Description, iface.MacAddress, iface.Speed)
+	l := append(collectCtx.LabelValues, iface.Name, iface.Description, iface.MacAddress, iface.Speed)
 
 	errorStatus := 0
 	if iface.AdminStatus != iface.OperStatus {
@@ -120,14 +167,14 @@ func generateMetrics(ctx *collector.CollectContext, iface *Interface) {
 	if iface.OperStatus == "up" {
 		operStatus = 1
 	}
-	ctx.Metrics <- prometheus.MustNewConstMetric(receiveBytesDesc, prometheus.GaugeValue, iface.InputBytes, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(receiveErrorsDesc, prometheus.GaugeValue, iface.InputErrors, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(receiveDropsDesc, prometheus.GaugeValue, iface.InputDrops, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(transmitBytesDesc, prometheus.GaugeValue, iface.OutputBytes, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(transmitErrorsDesc, prometheus.GaugeValue, iface.OutputErrors, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(transmitDropsDesc, prometheus.GaugeValue, iface.OutputDrops, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(adminStatusDesc, prometheus.GaugeValue, float64(adminStatus), l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(operStatusDesc, prometheus.GaugeValue, float64(operStatus), l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(errorStatusDesc, prometheus.GaugeValue, float64(errorStatus), l...)
+	result.AddMetric(prometheus.MustNewConstMetric(receiveBytesDesc, prometheus.GaugeValue, iface.InputBytes, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(receiveErrorsDesc, prometheus.GaugeValue, iface.InputErrors, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(receiveDropsDesc, prometheus.GaugeValue, iface.InputDrops, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(transmitBytesDesc, prometheus.GaugeValue, iface.OutputBytes, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(transmitErrorsDesc, prometheus.GaugeValue, iface.OutputErrors, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(transmitDropsDesc, prometheus.GaugeValue, iface.OutputDrops, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(adminStatusDesc, prometheus.GaugeValue, float64(adminStatus), l...))
+	result.AddMetric(prometheus.MustNewConstMetric(operStatusDesc, prometheus.GaugeValue, float64(operStatus), l...))
+	result.AddMetric(prometheus.MustNewConstMetric(errorStatusDesc, prometheus.GaugeValue, float64(errorStatus), l...))
 
 }
@@ -0,0 +1,123 @@
+package interfaces
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"github.com/pkg/errors"
+)
+
+const netconfNamespace = "http://cisco.com/ns/yang/Cisco-IOS-XE-interfaces-oper"
+
+// netconfLeaves selects the leaves of an interface in Cisco-IOS-XE-interfaces-oper which are exported.
+const netconfLeaves = `<description/><admin-status/><oper-status/><phys-address/><speed/>` +
+	`<statistics><in-octets/><in-discards/><in-errors/><out-octets/><out-discards/><out-errors/></statistics>`
+
+// netconfInterfaces is the relevant part of Cisco-IOS-XE-interfaces-oper.
+type netconfInterfaces struct {
+	Entries []struct {
+		Name        string `xml:"name"`
+		Description string `xml:"description"`
+		AdminStatus string `xml:"admin-status"`
+		OperStatus  string `xml:"oper-status"`
+		PhysAddress string `xml:"phys-address"`
+		Speed       uint64 `xml:"speed"`
+		Statistics  struct {
+			InOctets    float64 `xml:"in-octets"`
+			InDiscards  float64 `xml:"in-discards"`
+			InErrors    float64 `xml:"in-errors"`
+			OutOctets   float64 `xml:"out-octets"`
+			OutDiscards float64 `xml:"out-discards"`
+			OutErrors   float64 `xml:"out-errors"`
+		} `xml:"statistics"`
+	} `xml:"interfaces>interface"`
+}
+
+// netconfFilter selects the given interfaces, all interfaces if none are given.
+func netconfFilter(interfaceNames []string) string {
+	if len(interfaceNames) == 0 {
+		return `<interfaces xmlns="` + netconfNamespace + `"><interface><name/>` + netconfLeaves + `</interface></interfaces>`
+	}
+	filter := `<interfaces xmlns="` + netconfNamespace + `">`
+	for _, name := range interfaceNames {
+		escaped := &bytes.Buffer{}
+		xml.EscapeText(escaped, []byte(name))
+		filter += `<interface><name>` + escaped.String() + `</name>` + netconfLeaves + `</interface>`
+	}
+	return filter + `</interfaces>`
+}
+
+func (c *Collector) collectNETCONF(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, conn *connector.NETCONFConnection) error {
+	reply, err := conn.Get(ctx, netconfFilter(collectCtx.Interfaces()))
+	if err != nil {
+		return err
+	}
+	data := &netconfInterfaces{}
+	if err := xml.Unmarshal(reply, data); err != nil {
+		return errors.Wrap(err, "Could not decode interfaces")
+	}
+	ifaces := data.interfaces()
+	if len(ifaces) == 0 {
+		return errors.New("No interface metric was scraped")
+	}
+	for _, iface := range ifaces {
+		generateMetrics(collectCtx, result, iface)
+	}
+	return nil
+}
+
+// interfaces converts the interfaces to the representation of the CLI output,
+// e.g. `if-state-up` to `up` and the MAC address to dotted notation.
+func (data *netconfInterfaces) interfaces() []*Interface {
+	ifaces := make([]*Interface, 0, len(data.Entries))
+	for _, i := range data.Entries {
+		iface := &Interface{
+			Name:         i.Name,
+			Description:  i.Description,
+			MacAddress:   netconfMacAddress(i.PhysAddress),
+			AdminStatus:  "down",
+			OperStatus:   "down",
+			InputBytes:   i.Statistics.InOctets,
+			InputDrops:   i.Statistics.InDiscards,
+			InputErrors:  i.Statistics.InErrors,
+			OutputBytes:  i.Statistics.OutOctets,
+			OutputDrops:  i.Statistics.OutDiscards,
+			OutputErrors: i.Statistics.OutErrors,
+			Speed:        netconfSpeed(i.Speed),
+		}
+		if i.AdminStatus == "if-state-up" {
+			iface.AdminStatus = "up"
+		}
+		if i.OperStatus == "if-oper-state-ready" {
+			iface.OperStatus = "up"
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces
+}
+
+// netconfMacAddress converts a MAC address like `00:a3:8e:12:34:56` to `00a3.8e12.3456`.
+func netconfMacAddress(address string) string {
+	hex := strings.Replace(address, ":", "", -1)
+	if len(hex) != 12 {
+		return address
+	}
+	return hex[0:4] + "." + hex[4:8] + "." + hex[8:12]
+}
+
+// netconfSpeed converts the speed in bits per second to the format of the CLI output, e.g. `1000 Mb/s`.
+func netconfSpeed(speed uint64) string {
+	switch {
+	case speed == 0:
+		return ""
+	case speed < 1000000:
+		return fmt.Sprintf("%d Kb/s", speed/1000)
+	}
+	return fmt.Sprintf("%d Mb/s", speed/1000000)
+}
@@ -0,0 +1,84 @@
+package interfaces_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/connector/netconftest"
+	"gitlab.com/wobcom/cisco-exporter/interfaces"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const netconfOutput = `<interfaces xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-interfaces-oper">
+  <interface>
+    <name>GigabitEthernet0/0/0</name>
+    <description>uplink</description>
+    <admin-status>if-state-up</admin-status>
+    <oper-status>if-oper-state-ready</oper-status>
+    <phys-address>00:a3:8e:12:34:56</phys-address>
+    <speed>1000000000</speed>
+    <statistics>
+      <in-octets>86193128</in-octets>
+      <in-discards>5</in-discards>
+      <in-errors>2</in-errors>
+      <out-octets>57319402</out-octets>
+      <out-discards>7</out-discards>
+      <out-errors>1</out-errors>
+    </statistics>
+  </interface>
+  <interface>
+    <name>GigabitEthernet0/0/1</name>
+    <admin-status>if-state-up</admin-status>
+    <oper-status>if-oper-state-no-pass</oper-status>
+    <phys-address>00:a3:8e:12:34:57</phys-address>
+    <speed>10000000000</speed>
+    <statistics>
+      <in-octets>0</in-octets>
+      <in-discards>0</in-discards>
+      <in-errors>0</in-errors>
+      <out-octets>0</out-octets>
+      <out-discards>0</out-discards>
+      <out-errors>0</out-errors>
+    </statistics>
+  </interface>
+</interfaces>`
+
+func TestCollectNETCONF(t *testing.T) {
+	server := netconftest.NewServer(t, map[string]string{"interfaces": netconfOutput})
+	device := server.Device()
+	device.Interfaces = []string{"GigabitEthernet0/0/0", "GigabitEthernet0/0/1"}
+	conn, err := connector.NewConnectionManager().GetConnection("127.0.0.1", device)
+	if err != nil {
+		t.Fatalf("Could not connect to the NETCONF server: %v", err)
+	}
+	defer conn.Terminate()
+
+	result := interfaces.NewCollector().Collect(context.Background(), &collector.CollectContext{Connection: conn, LabelValues: []string{"test.test"}})
+	up := "description=uplink,mac=00a3.8e12.3456,name=GigabitEthernet0/0/0,speed=1000 Mb/s,target=test.test"
+	down := "description=<no description>,mac=00a3.8e12.3457,name=GigabitEthernet0/0/1,speed=10000 Mb/s,target=test.test"
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_interface_receive_bytes{" + up + "}":          86193128,
+		"cisco_interface_receive_drops_total{" + up + "}":    5,
+		"cisco_interface_receive_errors_total{" + up + "}":   2,
+		"cisco_interface_transmit_bytes{" + up + "}":         57319402,
+		"cisco_interface_transmit_drops_total{" + up + "}":   7,
+		"cisco_interface_transmit_errors_total{" + up + "}":  1,
+		"cisco_interface_admin_up_info{" + up + "}":          1,
+		"cisco_interface_up_info{" + up + "}":                1,
+		"cisco_interface_error_status_info{" + up + "}":      0,
+		"cisco_interface_admin_up_info{" + down + "}":        1,
+		"cisco_interface_up_info{" + down + "}":              0,
+		"cisco_interface_error_status_info{" + down + "}":    1,
+		"cisco_interface_receive_errors_total{" + down + "}": 0,
+	}, t)
+
+	// The configured interfaces are selected by the filter of a single request.
+	filters := server.Filters()
+	last := filters[len(filters)-1]
+	if strings.Count(last, "<interface>") != 2 || !strings.Contains(last, "<name>GigabitEthernet0/0/1</name>") {
+		t.Errorf("Unexpected filter %s", last)
+	}
+}
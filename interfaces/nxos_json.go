@@ -0,0 +1,70 @@
+package interfaces
+
+import (
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+)
+
+// NXOSInterfaces is the relevant part of `show interface | json` on NX-OS devices.
+type NXOSInterfaces struct {
+	Table struct {
+		Rows nxos.Rows `json:"ROW_interface"`
+	} `json:"TABLE_interface"`
+}
+
+type nxosInterface struct {
+	Name         string       `json:"interface"`
+	State        string       `json:"state"`
+	AdminState   string       `json:"admin_state"`
+	MacAddress   string       `json:"eth_hw_addr"`
+	Description  string       `json:"desc"`
+	Speed        string       `json:"eth_speed"`
+	InputBytes   *nxos.Number `json:"eth_inbytes"`
+	OutputBytes  *nxos.Number `json:"eth_outbytes"`
+	InputErrors  *nxos.Number `json:"eth_inerr"`
+	OutputErrors *nxos.Number `json:"eth_outerr"`
+	InputDrops   *nxos.Number `json:"eth_indiscard"`
+	OutputDrops  *nxos.Number `json:"eth_outdiscard"`
+
+	// VLAN interfaces use different keys
+	SVIAdminState  string `json:"svi_admin_state"`
+	SVILineProto   string `json:"svi_line_proto"`
+	SVIMacAddress  string `json:"svi_mac"`
+	SVIDescription string `json:"svi_desc"`
+}
+
+// Interfaces returns the interfaces contained in the JSON output.
+func (n *NXOSInterfaces) Interfaces() ([]*Interface, error) {
+	var rows []nxosInterface
+	if err := n.Table.Rows.Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	ifaces := make([]*Interface, 0, len(rows))
+	for _, row := range rows {
+		iface := &Interface{
+			Name:        row.Name,
+			MacAddress:  firstNonEmpty(row.MacAddress, row.SVIMacAddress),
+			Description: firstNonEmpty(row.Description, row.SVIDescription),
+			AdminStatus: firstNonEmpty(row.AdminState, row.SVIAdminState),
+			OperStatus:  firstNonEmpty(row.State, row.SVILineProto),
+			Speed:       row.Speed,
+		}
+		iface.InputBytes = row.InputBytes.Value()
+		iface.OutputBytes = row.OutputBytes.Value()
+		iface.InputErrors = row.InputErrors.Value()
+		iface.OutputErrors = row.OutputErrors.Value()
+		iface.InputDrops = row.InputDrops.Value()
+		iface.OutputDrops = row.OutputDrops.Value()
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
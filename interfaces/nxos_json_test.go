@@ -0,0 +1,100 @@
+package interfaces_test
+
+import (
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/interfaces"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+)
+
+const nxosJSON = `{
+  "TABLE_interface": {
+    "ROW_interface": [
+      {
+        "interface": "mgmt0",
+        "state": "up",
+        "admin_state": "up",
+        "eth_hw_addr": "008a.9628.2b3f",
+        "eth_speed": "1000 Mb/s",
+        "eth_inbytes": "1512306",
+        "eth_outbytes": "283012",
+        "eth_inerr": "0",
+        "eth_outerr": "0"
+      },
+      {
+        "interface": "Ethernet1/1",
+        "state": "up",
+        "admin_state": "up",
+        "eth_hw_addr": "008a.9628.2b40",
+        "desc": "uplink",
+        "eth_speed": "10 Gb/s",
+        "eth_bw": 10000000,
+        "eth_inbytes": 86193128,
+        "eth_outbytes": 57319402,
+        "eth_inerr": 2,
+        "eth_outerr": 1,
+        "eth_indiscard": 5,
+        "eth_outdiscard": 7
+      },
+      {
+        "interface": "Vlan100",
+        "svi_admin_state": "up",
+        "svi_line_proto": "down",
+        "svi_mac": "008a.9628.2b47",
+        "svi_desc": "servers"
+      }
+    ]
+  }
+}`
+
+func TestParseNXOSJSON(t *testing.T) {
+	expected := []interfaces.Interface{
+		{
+			Name:        "mgmt0",
+			MacAddress:  "008a.9628.2b3f",
+			AdminStatus: "up",
+			OperStatus:  "up",
+			InputBytes:  1512306,
+			OutputBytes: 283012,
+			Speed:       "1000 Mb/s",
+		},
+		{
+			Name:         "Ethernet1/1",
+			MacAddress:   "008a.9628.2b40",
+			Description:  "uplink",
+			AdminStatus:  "up",
+			OperStatus:   "up",
+			InputErrors:  2,
+			OutputErrors: 1,
+			InputDrops:   5,
+			OutputDrops:  7,
+			InputBytes:   86193128,
+			OutputBytes:  57319402,
+			Speed:        "10 Gb/s",
+		},
+		{
+			Name:        "Vlan100",
+			MacAddress:  "008a.9628.2b47",
+			Description: "servers",
+			AdminStatus: "up",
+			OperStatus:  "down",
+		},
+	}
+
+	data := &interfaces.NXOSInterfaces{}
+	if err := nxos.Decode(nxosJSON, data); err != nil {
+		t.Fatal(err)
+	}
+	ifaces, err := data.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ifaces) != len(expected) {
+		t.Fatalf("Got %d interfaces, expected %d", len(ifaces), len(expected))
+	}
+	for i, iface := range ifaces {
+		if *iface != expected[i] {
+			t.Errorf("Got an unexpected interface, expected %v, got %v", expected[i], *iface)
+		}
+	}
+}
@@ -12,21 +12,28 @@ func Parse(sshCtx *connector.SSHCommandContext, interfaces chan *Interface, done
 		done <- struct{}{}
 	}()
 
-	newIfRegexp := regexp.MustCompile(`(?:^!?(?: |admin|show|.+#).*$|^$)`)
+	newIfRegexp := regexp.MustCompile(`(?:^!?(?:\s|admin|show|.+#).*$|^$)`)
 	macRegexp := regexp.MustCompile(`^\s+Hardware(?: is|:) .+, address(?: is|:) (.*) \(.*\)$`)
 	deviceNameRegexp := regexp.MustCompile(`^([a-zA-Z0-9\/\.-]+) is.*$`)
+	deviceNameASARegexp := regexp.MustCompile(`^Interface ([a-zA-Z0-9\/\.-]+) "[^"]*", is.*$`)
+	macASARegexp := regexp.MustCompile(`^\s+MAC address ([0-9a-f.]+),`)
+	speedASARegexp := regexp.MustCompile(`^\s+.*-Duplex(?:\(.*\))?, .*Speed\((\d+) (\w)bps\)`)
+	trafficStatisticsASARegexp := regexp.MustCompile(`^\s+Traffic Statistics for`)
+	droppedASARegexp := regexp.MustCompile(`^\s+(\d+) packets dropped`)
 	adminStatusRegexp := regexp.MustCompile(`^.+ is (administratively)?\s*(up|down).*, line protocol is.*$`)
 	adminStatusNXOSRegexp := regexp.MustCompile(`^\S+ is (up|down)(?:\s|,)?(\(Administratively down\))?.*$`)
 	adminStatusNXOSRegexp1 := regexp.MustCompile(`^admin state is (up|down)`)
 	descRegexp := regexp.MustCompile(`^\s+Description: (.*)$`)
 	dropsRegexp := regexp.MustCompile(`^\s+Input queue: \d+\/\d+\/(\d+)\/\d+ .+ Total output drops: (\d+)$`)
-	inputBytesRegexp := regexp.MustCompile(`^\s+\d+ (?:packets input,|input packets)\s+(\d+) bytes.*$`)
-	outputBytesRegexp := regexp.MustCompile(`^\s+\d+ (?:packets output,|output packets)\s+(\d+) bytes.*$`)
+	inputBytesRegexp := regexp.MustCompile(`^\s+\d+ (?:packets input,|input packets)\s+(\d+) bytes(?:, (\d+) total input drops)?.*$`)
+	outputBytesRegexp := regexp.MustCompile(`^\s+\d+ (?:packets output,|output packets)\s+(\d+) bytes(?:, (\d+) total output drops)?.*$`)
 	inputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) input error(?:s,)? .*$`)
 	outputErrorsRegexp := regexp.MustCompile(`^\s+(\d+) output error(?:s,)? .*$`)
-	speedRegexp := regexp.MustCompile(`^\s+(.*)-duplex,\s(\d+) ((\wb)/s).*$`)
+	speedRegexp := regexp.MustCompile(`^\s+(.*)-duplex,\s(\d+) ?((\wb)/s).*$`)
 
 	current := &Interface{}
+	// ASAs repeat the counters for traffic passing the named interface in the traffic statistics.
+	trafficStatistics := false
 
 	for {
 		select {
@@ -40,8 +47,13 @@ func Parse(sshCtx *connector.SSHCommandContext, interfaces chan *Interface, done
 				if current.Name != "" {
 					interfaces <- current
 				}
+				trafficStatistics = false
 				matches := deviceNameRegexp.FindStringSubmatch(line)
 				if matches == nil {
+					matches = deviceNameASARegexp.FindStringSubmatch(line)
+				}
+				if matches == nil {
+					current = &Interface{}
 					continue
 				}
 				current = &Interface{
@@ -52,7 +64,16 @@ func Parse(sshCtx *connector.SSHCommandContext, interfaces chan *Interface, done
 				continue
 			}
 
-			if matches := adminStatusRegexp.FindStringSubmatch(line); matches != nil {
+			if trafficStatistics {
+				if matches := droppedASARegexp.FindStringSubmatch(line); matches != nil {
+					current.InputDrops = util.Str2float64(matches[1])
+				}
+				continue
+			}
+
+			if trafficStatisticsASARegexp.MatchString(line) {
+				trafficStatistics = true
+			} else if matches := adminStatusRegexp.FindStringSubmatch(line); matches != nil {
 				if matches[1] == "" {
 					current.AdminStatus = "up"
 				} else {
@@ -72,19 +93,29 @@ func Parse(sshCtx *connector.SSHCommandContext, interfaces chan *Interface, done
 				current.Description = matches[1]
 			} else if matches := macRegexp.FindStringSubmatch(line); matches != nil {
 				current.MacAddress = matches[1]
+			} else if matches := macASARegexp.FindStringSubmatch(line); matches != nil {
+				current.MacAddress = matches[1]
 			} else if matches := dropsRegexp.FindStringSubmatch(line); matches != nil {
 				current.InputDrops = util.Str2float64(matches[1])
 				current.OutputDrops = util.Str2float64(matches[2])
 			} else if matches := inputBytesRegexp.FindStringSubmatch(line); matches != nil {
 				current.InputBytes = util.Str2float64(matches[1])
+				if matches[2] != "" {
+					current.InputDrops = util.Str2float64(matches[2])
+				}
 			} else if matches := outputBytesRegexp.FindStringSubmatch(line); matches != nil {
 				current.OutputBytes = util.Str2float64(matches[1])
+				if matches[2] != "" {
+					current.OutputDrops = util.Str2float64(matches[2])
+				}
 			} else if matches := inputErrorsRegexp.FindStringSubmatch(line); matches != nil {
 				current.InputErrors = util.Str2float64(matches[1])
 			} else if matches := outputErrorsRegexp.FindStringSubmatch(line); matches != nil {
 				current.OutputErrors = util.Str2float64(matches[1])
 			} else if matches := speedRegexp.FindStringSubmatch(line); matches != nil {
 				current.Speed = matches[2] + " " + matches[3]
+			} else if matches := speedASARegexp.FindStringSubmatch(line); matches != nil {
+				current.Speed = matches[1] + " " + matches[2] + "b/s"
 			}
 		}
 	}
@@ -6,8 +6,86 @@ import (
 
 	"gitlab.com/wobcom/cisco-exporter/connector"
 	"gitlab.com/wobcom/cisco-exporter/interfaces"
+	"gitlab.com/wobcom/cisco-exporter/util"
 )
 
+const asaInput = "Interface GigabitEthernet1/1 \"outside\", is up, line protocol is up\n" +
+	"  Hardware is Accelerator rev01, BW 1000 Mbps, DLY 10 usec\n" +
+	"\tAuto-Duplex(Full-duplex), Auto-Speed(1000 Mbps)\n" +
+	"\tInput flow control is unsupported, output flow control is off\n" +
+	"\tDescription: uplink\n" +
+	"\tMAC address 00a3.8e12.3456, MTU 1500\n" +
+	"\tIP address 192.0.2.10, subnet mask 255.255.255.0\n" +
+	"\t1234567 packets input, 987654321 bytes, 0 no buffer\n" +
+	"\tReceived 1234 broadcasts, 0 runts, 0 giants\n" +
+	"\t3 input errors, 0 CRC, 0 frame, 0 overrun, 0 ignored, 0 abort\n" +
+	"\t0 pause input, 0 resume input\n" +
+	"\t0 L2 decode drops\n" +
+	"\t7654321 packets output, 123456789 bytes, 0 underruns\n" +
+	"\t0 pause output, 0 resume output\n" +
+	"\t1 output errors, 0 collisions, 0 interface resets\n" +
+	"\tinput queue (blocks free curr/low): hardware (2035/1933)\n" +
+	"\toutput queue (blocks free curr/low): hardware (2047/1990)\n" +
+	"  Traffic Statistics for \"outside\":\n" +
+	"\t1234000 packets input, 987000000 bytes\n" +
+	"\t7654000 packets output, 123000000 bytes\n" +
+	"\t5 packets dropped\n" +
+	"      1 minute input rate 12 pkts/sec,  3456 bytes/sec\n" +
+	"Interface GigabitEthernet1/2 \"\", is administratively down, line protocol is down\n" +
+	"  Hardware is Accelerator rev01, BW 1000 Mbps, DLY 10 usec\n" +
+	"\tAuto-Duplex, Auto-Speed\n" +
+	"\tMAC address 00a3.8e12.3457, MTU not set\n" +
+	"\tIP address unassigned\n" +
+	"\t0 packets input, 0 bytes, 0 no buffer\n" +
+	"\t0 input errors, 0 CRC, 0 frame, 0 overrun, 0 ignored, 0 abort\n" +
+	"\t0 packets output, 0 bytes, 0 underruns\n" +
+	"\t0 output errors, 0 collisions, 0 interface resets\n"
+
+const xrInput = `TenGigE0/0/0/0 is up, line protocol is up 
+  Interface state transitions: 1
+  Hardware is TenGigE, address is 008a.9628.2b40 (bia 008a.9628.2b40)
+  Layer 1 Transport Mode is LAN
+  Description: uplink core-2
+  Internet address is 192.0.2.1/31
+  MTU 9216 bytes, BW 10000000 Kbit (Max: 10000000 Kbit)
+     reliability 255/255, txload 0/255, rxload 0/255
+  Encapsulation ARPA,
+  Full-duplex, 10000Mb/s, LR, link type is force-up
+  output flow control is off, input flow control is off
+  Carrier delay (up) is 10 msec
+  loopback not set,
+  Last link flapped 12w3d
+  ARP type ARPA, ARP timeout 04:00:00
+  Last input 00:00:00, output 00:00:00
+  Last clearing of "show interface" counters never
+  5 minute input rate 123000 bits/sec, 45 packets/sec
+  5 minute output rate 456000 bits/sec, 67 packets/sec
+     123456789 packets input, 98765432100 bytes, 12 total input drops
+     0 drops for unrecognized upper-level protocol
+     Received 3 broadcast packets, 1234 multicast packets
+              0 runts, 0 giants, 0 throttles, 0 parity
+     5 input errors, 0 CRC, 0 frame, 0 overrun, 0 ignored, 0 abort
+     987654321 packets output, 12345678900 bytes, 7 total output drops
+     Output 4 broadcast packets, 1234 multicast packets
+     2 output errors, 0 underruns, 0 applique, 0 resets
+     0 output buffer failures, 0 output buffers swapped out
+     1 carrier transitions
+
+TenGigE0/0/0/1 is administratively down, line protocol is administratively down 
+  Interface state transitions: 0
+  Hardware is TenGigE, address is 008a.9628.2b41 (bia 008a.9628.2b41)
+  Layer 1 Transport Mode is LAN
+  MTU 1514 bytes, BW 10000000 Kbit (Max: 10000000 Kbit)
+     reliability 255/255, txload 0/255, rxload 0/255
+  Encapsulation ARPA,
+  Full-duplex, 10000Mb/s, link type is force-up
+  Last clearing of "show interface" counters never
+     0 packets input, 0 bytes, 0 total input drops
+     0 input errors, 0 CRC, 0 frame, 0 overrun, 0 ignored, 0 abort
+     0 packets output, 0 bytes, 0 total output drops
+     0 output errors, 0 underruns, 0 applique, 0 resets
+`
+
 func inputContext() connector.SSHCommandContext {
 	const input = `
 Ethernet101/1/1 is up
@@ -87,10 +165,70 @@ func TestParse(t *testing.T) {
 	}
 
 	ctx := inputContext()
+	performTest(&ctx, ifaces, t)
+}
+
+func TestParseASA(t *testing.T) {
+	ifaces := []interfaces.Interface{
+		interfaces.Interface{
+			Name:         "GigabitEthernet1/1",
+			MacAddress:   "00a3.8e12.3456",
+			Description:  "uplink",
+			AdminStatus:  "up",
+			OperStatus:   "up",
+			InputErrors:  3,
+			OutputErrors: 1,
+			InputDrops:   5,
+			InputBytes:   987654321,
+			OutputBytes:  123456789,
+			Speed:        "1000 Mb/s",
+		},
+		interfaces.Interface{
+			Name:        "GigabitEthernet1/2",
+			MacAddress:  "00a3.8e12.3457",
+			AdminStatus: "down",
+			OperStatus:  "down",
+		},
+	}
+
+	ctx := util.PrepareOutputForTesting(asaInput)
+	performTest(&ctx, ifaces, t)
+}
+
+func TestParseXR(t *testing.T) {
+	ifaces := []interfaces.Interface{
+		interfaces.Interface{
+			Name:         "TenGigE0/0/0/0",
+			MacAddress:   "008a.9628.2b40",
+			Description:  "uplink core-2",
+			AdminStatus:  "up",
+			OperStatus:   "up",
+			InputErrors:  5,
+			OutputErrors: 2,
+			InputDrops:   12,
+			OutputDrops:  7,
+			InputBytes:   98765432100,
+			OutputBytes:  12345678900,
+			Speed:        "10000 Mb/s",
+		},
+		interfaces.Interface{
+			Name:        "TenGigE0/0/0/1",
+			MacAddress:  "008a.9628.2b41",
+			AdminStatus: "down",
+			OperStatus:  "down",
+			Speed:       "10000 Mb/s",
+		},
+	}
+
+	ctx := util.PrepareOutputForTesting(xrInput)
+	performTest(&ctx, ifaces, t)
+}
+
+func performTest(ctx *connector.SSHCommandContext, ifaces []interfaces.Interface, t *testing.T) {
 	interfacesChan := make(chan *interfaces.Interface)
 	done := make(chan struct{})
 
-	go interfaces.Parse(&ctx, interfacesChan, done)
+	go interfaces.Parse(ctx, interfacesChan, done)
 
 	at := 0
 	for {
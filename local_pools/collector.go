@@ -1,6 +1,7 @@
 package local_pools
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 
@@ -46,17 +47,16 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
-	collectPools(ctx)
+	collectPools(ctx, collectCtx, result)
+	return result
 }
 
-func collectPools(ctx *collector.CollectContext) {
+func collectPools(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) {
 	sshCtx := connector.NewSSHCommandContext("show ip local pool")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	poolsChan := make(chan *PoolGroup)
 	poolParsingDone := make(chan struct{})
@@ -66,22 +66,23 @@ func collectPools(ctx *collector.CollectContext) {
 	for {
 		select {
 		case pool := <-poolsChan:
-			generatePoolMetrics(ctx, pool)
+			generatePoolMetrics(collectCtx, result, pool)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping local_pools statistics: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping local_pools statistics: %v", err))
 		case <-poolParsingDone:
 			return
 		}
 	}
 }
 
-func generatePoolMetrics(ctx *collector.CollectContext, poolGroup *PoolGroup) {
-	l := append(ctx.LabelValues, poolGroup.Name)
+func generatePoolMetrics(collectCtx *collector.CollectContext, result *collector.Result, poolGroup *PoolGroup) {
+	collectCtx.Record(poolGroup)
+	l := append(collectCtx.LabelValues, poolGroup.Name)
 	for _, pool := range poolGroup.Pools {
 		m := append(l, pool.StartIP, pool.EndIP)
-		ctx.Metrics <- prometheus.MustNewConstMetric(addressesTotalDesc, prometheus.GaugeValue, pool.AddressesTotal, m...)
-		ctx.Metrics <- prometheus.MustNewConstMetric(addressesAvailDesc, prometheus.GaugeValue, pool.AddressesAvail, m...)
-		ctx.Metrics <- prometheus.MustNewConstMetric(addressesAssignedDesc, prometheus.GaugeValue, pool.AddressesAssigned, m...)
+		result.AddMetric(prometheus.MustNewConstMetric(addressesTotalDesc, prometheus.GaugeValue, pool.AddressesTotal, m...))
+		result.AddMetric(prometheus.MustNewConstMetric(addressesAvailDesc, prometheus.GaugeValue, pool.AddressesAvail, m...))
+		result.AddMetric(prometheus.MustNewConstMetric(addressesAssignedDesc, prometheus.GaugeValue, pool.AddressesAssigned, m...))
 	}
 
 }
@@ -11,6 +11,8 @@ import (
 
 	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/telemetry"
+	"gitlab.com/wobcom/cisco-exporter/web"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/log"
@@ -25,13 +27,26 @@ var (
 	showVersion          = flag.Bool("version", false, "Print version and exit")
 	listenAddress        = flag.String("web.listen-address", "[::]:9457", "Address to listen on")
 	metricsPath          = flag.String("web.telemetry-path", "/metrics", "Path under which to expose metrics")
+	webEnableLifecycle   = flag.Bool("web.enable-lifecycle", false, "Enable reloading the configuration using POST requests to /-/reload")
+	webConfigFile        = flag.String("web.config.file", "", "Web configuration file to enable TLS or basic auth, compatible with the Prometheus exporter-toolkit")
 	configFile           = flag.String("config.file", "cisco-exporter.yml", "Configuration file")
+	configCheck          = flag.Bool("config.check", false, "Check the configuration file and exit")
 	sshReconnectInterval = flag.Duration("ssh.reconnect-interval", 30*time.Second, "Duration to wait before reconnecting to a device after connection got lost")
 	sshKeepAliveInterval = flag.Duration("ssh.keep-alive-interval", 10*time.Second, "Duration to wait between keep alive messages")
 	sshKeepAliveTimeout  = flag.Duration("ssh.keep-alive-timeout", 15*time.Second, "Duration to wait for keep alive message response")
 	scrapeTimeout        = flag.Duration("scrape.timeout", 50*time.Second, "Duration after which to abort a scrape")
+	pollingDynamicExpiry = flag.Duration("polling.dynamic-expiry", time.Hour, "Duration after which dynamic targets which were not scraped are no longer polled and their connection is closed, never if 0")
+	telemetryAddress     = flag.String("telemetry.listen-address", "", "Address to receive model-driven telemetry dial-out (gRPC) on, disabled if empty")
+	telemetryStaleAfter  = flag.Duration("telemetry.stale-after", 5*time.Minute, "Duration after which received telemetry values are dropped if they were not updated")
+	telemetryTLSCert     = flag.String("telemetry.tls-cert-file", "", "Certificate to use for telemetry dial-out, plain text gRPC is used if empty")
+	telemetryTLSKey      = flag.String("telemetry.tls-key-file", "", "Private key of the certificate to use for telemetry dial-out")
+	telemetryClientCA    = flag.String("telemetry.tls-client-ca-file", "", "CA certificates devices have to present a client certificate of for telemetry dial-out, not verified if empty")
 	configuration        *config.Config
 	connectionManager    *connector.SSHConnectionManager
+	poller               *Poller
+	telemetryStore       *telemetry.Store
+	// exporterRegistry holds the metrics about the exporter itself, which are served unless a target is scraped.
+	exporterRegistry = prometheus.NewRegistry()
 )
 
 func main() {
@@ -42,6 +57,21 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *configCheck {
+		if _, err := loadConfiguration(); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if *webConfigFile != "" {
+			if _, err := web.LoadConfig(*webConfigFile); err != nil {
+				fmt.Fprintf(os.Stderr, "%v\n", err)
+				os.Exit(1)
+			}
+		}
+		fmt.Printf("Configuration file '%s' is valid\n", *configFile)
+		os.Exit(0)
+	}
+
 	err := initialize()
 	if err != nil {
 		log.Fatalf("Failed to initialize cisco-exporter: %v", err)
@@ -59,7 +89,8 @@ func printVersion() {
 }
 
 func initialize() error {
-	err := loadConfiguration()
+	var err error
+	configuration, err = loadConfiguration()
 	if err != nil {
 		return err
 	}
@@ -69,60 +100,92 @@ func initialize() error {
 		connector.WithKeepAliveInterval(*sshKeepAliveInterval),
 		connector.WithKeepAliveTimeout(*sshKeepAliveTimeout))
 
+	poller = NewPoller(connectionManager, *pollingDynamicExpiry)
+	poller.startStaticDevices(configuration)
+
+	if *telemetryAddress != "" {
+		if err := startTelemetryReceiver(); err != nil {
+			return err
+		}
+	}
+
+	exporterRegistry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		configReloadSuccess,
+		configReloadSeconds)
+	exporterRegistry.MustRegister(connector.Collectors()...)
+
+	configReloadSuccess.Set(1)
+	configReloadSeconds.SetToCurrentTime()
+	go reloadOnSIGHUP()
+
 	return nil
 }
 
-func loadConfiguration() error {
+func loadConfiguration() (*config.Config, error) {
 	log.Infof("Loading configuration from '%s'\n", *configFile)
 	yamlFile, err := ioutil.ReadFile(*configFile)
 	if err != nil {
-		return errors.Wrapf(err, "Failed to load the configuration file '%s'", *configFile)
+		return nil, errors.Wrapf(err, "Failed to load the configuration file '%s'", *configFile)
 	}
-	configuration, err = config.Load(bytes.NewReader(yamlFile))
+	c, err := config.Load(bytes.NewReader(yamlFile))
 	if err != nil {
-		return errors.Wrap(err, "Failed to parse the configuration file")
+		return nil, errors.Wrap(err, "Failed to parse the configuration file")
 	}
-	log.Infof("Loaded %d static device(s) from configuration", len(configuration.GetStaticDevices()))
-	return nil
+	err = c.Validate(collectorNames())
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid configuration file")
+	}
+	log.Infof("Loaded %d static device(s) from configuration", len(c.GetStaticDevices()))
+	return c, nil
 }
 
 func startServer() {
 	log.Infof("Starting cisco-exporter (version: %s)\n", version)
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(`<html>
-            <head><title>cisco-exporter (Version ` + version + `)</title></head>
-            <body>
-            <h1>cisco-exporter</h1>
-            <p><a href="` + *metricsPath + `">Metrics</a></p>
-            </body>
-            </html>`))
-	})
+	http.HandleFunc("/", handleStatusRequest)
+	http.HandleFunc("/api/v1/targets", handleTargetsRequest)
 	http.HandleFunc(*metricsPath, handleMetricsRequest)
+	http.HandleFunc("/-/reload", handleReloadRequest)
+	http.HandleFunc("/debug/command", handleDebugCommandRequest)
 
 	log.Infof("Listening on %s", *listenAddress)
-	log.Fatal(http.ListenAndServe(*listenAddress, nil))
+	log.Fatal(web.ListenAndServe(&http.Server{Addr: *listenAddress}, *webConfigFile))
 }
 
 func handleMetricsRequest(w http.ResponseWriter, request *http.Request) {
 	registry := prometheus.NewRegistry()
+	gatherers := prometheus.Gatherers{registry}
 
 	var collector *CiscoCollector
+	selection, err := parseCollectorSelection(request.URL.Query(), getConfiguration())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	if target := request.URL.Query().Get("target"); target != "" {
-		deviceGroup := configuration.GetDeviceGroup(target)
+		deviceGroup := getConfiguration().GetDeviceGroup(target)
 		if deviceGroup == nil {
 			http.Error(w, "Target not configured", 404)
 			return
 		}
 
-		collector = newCiscoCollector([]string{target}, connectionManager)
+		collector = newCiscoCollector(request.Context(), []string{target}, connectionManager, poller, selection)
+		if telemetryStore != nil {
+			registry.MustRegister(telemetryStore.Collector(target))
+		}
 	} else {
-		devices := configuration.GetStaticDevices()
-		collector = newCiscoCollector(devices, connectionManager)
+		devices := getConfiguration().GetStaticDevices()
+		collector = newCiscoCollector(request.Context(), devices, connectionManager, poller, selection)
+		gatherers = append(gatherers, exporterRegistry)
+		if telemetryStore != nil {
+			registry.MustRegister(telemetryStore.Collector())
+		}
 	}
 	registry.MustRegister(collector)
 
-	promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+	promhttp.HandlerFor(gatherers, promhttp.HandlerOpts{
 		ErrorLog:      log.NewErrorLogger(),
 		ErrorHandling: promhttp.ContinueOnError}).ServeHTTP(w, request)
 }
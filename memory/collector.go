@@ -1,12 +1,14 @@
 package memory
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/config"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
 	"gitlab.com/wobcom/cisco-exporter/util"
 
 	"github.com/pkg/errors"
@@ -16,6 +18,8 @@ import (
 
 const prefix string = "cisco_memory_"
 
+var errNoMetric = errors.New("No memory metric was extracted")
+
 var (
 	totalMemoryMetricDesc   *prometheus.Desc
 	usedMemoryMetricDesc    *prometheus.Desc
@@ -55,76 +59,175 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
-	sshCtx := connector.NewSSHCommandContext(c.getMemoryCommand(ctx))
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
-	go ctx.Connection.RunCommand(sshCtx)
+	if netconf, ok := collectCtx.Connection.(*connector.NETCONFConnection); ok {
+		if err := c.collectNETCONF(ctx, collectCtx, result, netconf); err != nil {
+			result.AddError(errors.Wrap(err, "Error scraping memory"))
+		}
+		return result
+	}
+
+	if collectCtx.Connection.Info().DeviceInfo.OSVersion == config.NXOS {
+		err := c.collectNXOSJSON(ctx, collectCtx, result)
+		if errors.Cause(err) != nxos.ErrJSONUnsupported {
+			if err != nil {
+				result.AddError(errors.Wrap(err, "Error scraping memory"))
+			}
+			return result
+		}
+	}
+
+	sshCtx := connector.NewSSHCommandContext(c.getMemoryCommand(collectCtx))
+
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	matchesCount := 0
+	node := "system"
 
 	for {
 		select {
 		case <-sshCtx.Done:
 			if matchesCount == 0 {
-				ctx.Errors <- errors.New("No memory metric was extracted")
+				result.AddError(errNoMetric)
 			}
-			return
+			return result
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping memory: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping memory: %v", err))
 		case line := <-sshCtx.Output:
-			var result bool
-			if ctx.Connection.Device.OSVersion == config.NXOS {
-				result = c.parseNXOS(ctx, line)
-			} else {
-				result = c.parse(ctx, line)
+			var matched bool
+			switch collectCtx.Connection.Info().DeviceInfo.OSVersion {
+			case config.NXOS:
+				matched = c.parseNXOS(collectCtx, result, line)
+			case config.IOSXR:
+				matched = c.parseXR(collectCtx, result, line, &node)
+			case config.ASA:
+				matched = c.parseASA(collectCtx, result, line)
+			default:
+				matched = c.parse(collectCtx, result, line)
 			}
-			if result {
+			if matched {
 				matchesCount++
 			}
 		}
 	}
 }
 
-func (c *Collector) parse(ctx *collector.CollectContext, line string) bool {
+// Usage is the memory usage in bytes of a subsystem parsed from a line of the output.
+// Values the line does not contain are nil.
+type Usage struct {
+	Subsystem string   `json:"subsystem"`
+	Total     *float64 `json:"total,omitempty"`
+	Used      *float64 `json:"used,omitempty"`
+	Lowest    *float64 `json:"lowest,omitempty"`
+	Largest   *float64 `json:"largest,omitempty"`
+}
+
+func byteCount(value float64) *float64 {
+	return &value
+}
+
+func (c *Collector) parse(collectCtx *collector.CollectContext, result *collector.Result, line string) bool {
 	memoryRegex := regexp.MustCompile(`^\s*(\S+)\s+[a-zA-Z0-9]+\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)`)
 	matches := memoryRegex.FindStringSubmatch(line)
 	if len(matches) == 0 {
 		return false
 	}
 
-	subsystem := matches[1]
 	total, _ := strconv.ParseFloat(matches[2], 32)
 	used, _ := strconv.ParseFloat(matches[3], 32)
 	lowest, _ := strconv.ParseFloat(matches[4], 32)
 	largest, _ := strconv.ParseFloat(matches[5], 32)
-	labels := append(ctx.LabelValues, subsystem)
-
-	ctx.Metrics <- prometheus.MustNewConstMetric(totalMemoryMetricDesc, prometheus.GaugeValue, total, labels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(usedMemoryMetricDesc, prometheus.GaugeValue, used, labels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(lowestMemoryMetricDesc, prometheus.GaugeValue, lowest, labels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(largestMemoryMetricDesc, prometheus.GaugeValue, largest, labels...)
+	generateMetrics(collectCtx, result, &Usage{Subsystem: matches[1], Total: byteCount(total), Used: byteCount(used), Lowest: byteCount(lowest), Largest: byteCount(largest)})
 	return true
 }
 
-func (c *Collector) parseNXOS(ctx *collector.CollectContext, line string) bool {
+func (c *Collector) parseNXOS(collectCtx *collector.CollectContext, result *collector.Result, line string) bool {
 	memoryRegex := regexp.MustCompile(`Memory usage:\s+(\d+)K total,\s+(\d+)K used`)
 	matches := memoryRegex.FindStringSubmatch(line)
 	if len(matches) == 0 {
 		return false
 	}
 
-	labels := append(ctx.LabelValues, "system")
-	ctx.Metrics <- prometheus.MustNewConstMetric(totalMemoryMetricDesc, prometheus.GaugeValue, util.Str2float64(matches[1])*1024, labels...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(usedMemoryMetricDesc, prometheus.GaugeValue, util.Str2float64(matches[2])*1024, labels...)
+	generateMetrics(collectCtx, result, &Usage{Subsystem: "system", Total: byteCount(util.Str2float64(matches[1]) * 1024), Used: byteCount(util.Str2float64(matches[2]) * 1024)})
 	return true
 }
 
-func (c *Collector) getMemoryCommand(ctx *collector.CollectContext) string {
-	if ctx.Connection.Device.OSVersion == config.NXOS {
+// parseXR parses the output of `show memory summary`, which lists the memory of every node (route processor or line card).
+// node holds the node the following lines belong to and is used as the subsystem label.
+func (c *Collector) parseXR(collectCtx *collector.CollectContext, result *collector.Result, line string, node *string) bool {
+	nodeRegex := regexp.MustCompile(`^\s*node:\s+(\S+)`)
+	if matches := nodeRegex.FindStringSubmatch(line); matches != nil {
+		*node = matches[1]
+		return false
+	}
+
+	memoryRegex := regexp.MustCompile(`^\s*Physical Memory:\s+(\d+(?:\.\d+)?)([KMG]?) total \((\d+(?:\.\d+)?)([KMG]?) available\)`)
+	matches := memoryRegex.FindStringSubmatch(line)
+	if len(matches) == 0 {
+		return false
+	}
+
+	total := util.Str2float64(matches[1]) * xrUnitMultiplier(matches[2])
+	available := util.Str2float64(matches[3]) * xrUnitMultiplier(matches[4])
+	generateMetrics(collectCtx, result, &Usage{Subsystem: *node, Total: byteCount(total), Used: byteCount(total - available)})
+	return true
+}
+
+// parseASA parses the summary at the end of `show memory`.
+func (c *Collector) parseASA(collectCtx *collector.CollectContext, result *collector.Result, line string) bool {
+	memoryRegex := regexp.MustCompile(`^(Used|Total) memory:\s+(\d+) bytes`)
+	matches := memoryRegex.FindStringSubmatch(line)
+	if len(matches) == 0 {
+		return false
+	}
+
+	usage := &Usage{Subsystem: "system"}
+	if matches[1] == "Total" {
+		usage.Total = byteCount(util.Str2float64(matches[2]))
+	} else {
+		usage.Used = byteCount(util.Str2float64(matches[2]))
+	}
+	generateMetrics(collectCtx, result, usage)
+	return true
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, usage *Usage) {
+	collectCtx.Record(usage)
+	labels := append(collectCtx.LabelValues, usage.Subsystem)
+	for desc, value := range map[*prometheus.Desc]*float64{
+		totalMemoryMetricDesc:   usage.Total,
+		usedMemoryMetricDesc:    usage.Used,
+		lowestMemoryMetricDesc:  usage.Lowest,
+		largestMemoryMetricDesc: usage.Largest,
+	} {
+		if value != nil {
+			result.AddMetric(prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, *value, labels...))
+		}
+	}
+}
+
+func xrUnitMultiplier(unit string) float64 {
+	switch unit {
+	case "K":
+		return 1024
+	case "M":
+		return 1024 * 1024
+	case "G":
+		return 1024 * 1024 * 1024
+	}
+	return 1
+}
+
+func (c *Collector) getMemoryCommand(collectCtx *collector.CollectContext) string {
+	switch collectCtx.Connection.Info().DeviceInfo.OSVersion {
+	case config.NXOS:
 		return "show system resources"
+	case config.IOSXR:
+		return "show memory summary"
+	case config.ASA:
+		return "show memory"
 	}
 	return "show memory statistics"
 }
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const nxosOutput = `Load average:   1 minute: 0.34   5 minutes: 0.39   15 minutes: 0.41
+Memory usage:   24632700K total,   7071164K used,   17561536K free`
+
+const xrOutput = `
+node:      node0_RSP0_CPU0
+------------------------------------------------------------------
+
+Physical Memory: 12288M total (8402M available)
+ Application Memory : 11914M (8402M available)
+ Image: 4M (bootram: 0M)
+ Reserved: 224M, IOMem: 0, flashfsys: 0
+ Total shared window: 163M
+
+node:      node0_0_CPU0
+------------------------------------------------------------------
+
+Physical Memory: 4096M total (2048M available)
+ Application Memory : 3967M (2048M available)
+ Image: 48M (bootram: 48M)
+ Reserved: 128M, IOMem: 0, flashfsys: 0
+ Total shared window: 57M`
+
+func TestParseNXOS(t *testing.T) {
+	c := &Collector{}
+	collectCtx := &collector.CollectContext{LabelValues: []string{"test.test"}}
+	result := collector.NewResult()
+	for _, line := range strings.Split(nxosOutput, "\n") {
+		c.parseNXOS(collectCtx, result, line)
+	}
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_memory_total_bytes{subsystem=system,target=test.test}": 24632700 * 1024,
+		"cisco_memory_used_bytes{subsystem=system,target=test.test}":  7071164 * 1024,
+	}, t)
+}
+
+func TestParseXR(t *testing.T) {
+	c := &Collector{}
+	collectCtx := &collector.CollectContext{LabelValues: []string{"test.test"}}
+	result := collector.NewResult()
+	node := "system"
+	matched := 0
+	for _, line := range strings.Split(xrOutput, "\n") {
+		if c.parseXR(collectCtx, result, line, &node) {
+			matched++
+		}
+	}
+	if matched != 2 {
+		t.Errorf("Expected 2 nodes to be parsed, got %d", matched)
+	}
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_memory_total_bytes{subsystem=node0_RSP0_CPU0,target=test.test}": 12288 * 1024 * 1024,
+		"cisco_memory_used_bytes{subsystem=node0_RSP0_CPU0,target=test.test}":  (12288 - 8402) * 1024 * 1024,
+		"cisco_memory_total_bytes{subsystem=node0_0_CPU0,target=test.test}":    4096 * 1024 * 1024,
+		"cisco_memory_used_bytes{subsystem=node0_0_CPU0,target=test.test}":     2048 * 1024 * 1024,
+	}, t)
+}
+
+func TestParseASA(t *testing.T) {
+	c := &Collector{}
+	collectCtx := &collector.CollectContext{LabelValues: []string{"test.test"}}
+	result := collector.NewResult()
+	output := `Free memory:        6353387520 bytes (74%)
+Used memory:        2236370944 bytes (26%)
+-------------     ------------------
+Total memory:       8589758464 bytes (100%)`
+	for _, line := range strings.Split(output, "\n") {
+		c.parseASA(collectCtx, result, line)
+	}
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_memory_total_bytes{subsystem=system,target=test.test}": 8589758464,
+		"cisco_memory_used_bytes{subsystem=system,target=test.test}":  2236370944,
+	}, t)
+}
+
+const nxosJSONOutput = `{
+  "load_avg_1min": "0.34",
+  "load_avg_5min": "0.39",
+  "load_avg_15min": "0.41",
+  "memory_usage_total": "24632700",
+  "memory_usage_used": "7071164",
+  "memory_usage_free": "17561536",
+  "current_memory_status": "OK"
+}`
+
+func TestParseNXOSJSON(t *testing.T) {
+	c := &Collector{}
+	collectCtx := &collector.CollectContext{LabelValues: []string{"test.test"}}
+	result := collector.NewResult()
+	data := &nxosSystemResources{}
+	if err := nxos.Decode(nxosJSONOutput, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.parseNXOSJSON(collectCtx, result, data); err != nil {
+		t.Fatal(err)
+	}
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_memory_total_bytes{subsystem=system,target=test.test}": 24632700 * 1024,
+		"cisco_memory_used_bytes{subsystem=system,target=test.test}":  7071164 * 1024,
+	}, t)
+}
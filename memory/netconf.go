@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"encoding/xml"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const netconfFilter = `<memory-statistics xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-memory-oper"><memory-statistic>` +
+	`<name/><total-memory/><used-memory/><lowest-usage/>` +
+	`</memory-statistic></memory-statistics>`
+
+// netconfMemoryStatistics is the relevant part of Cisco-IOS-XE-memory-oper, which lists the memory pools like `show memory statistics`.
+type netconfMemoryStatistics struct {
+	Pools []struct {
+		Name        string  `xml:"name"`
+		Total       float64 `xml:"total-memory"`
+		Used        float64 `xml:"used-memory"`
+		LowestUsage float64 `xml:"lowest-usage"`
+	} `xml:"memory-statistics>memory-statistic"`
+}
+
+func (c *Collector) collectNETCONF(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, conn *connector.NETCONFConnection) error {
+	reply, err := conn.Get(ctx, netconfFilter)
+	if err != nil {
+		return err
+	}
+	data := &netconfMemoryStatistics{}
+	if err := xml.Unmarshal(reply, data); err != nil {
+		return errors.Wrap(err, "Could not decode memory-statistics")
+	}
+	collectCtx.Record(data)
+	if len(data.Pools) == 0 {
+		return errNoMetric
+	}
+
+	for _, pool := range data.Pools {
+		labels := append(collectCtx.LabelValues, pool.Name)
+		result.AddMetric(prometheus.MustNewConstMetric(totalMemoryMetricDesc, prometheus.GaugeValue, pool.Total, labels...))
+		result.AddMetric(prometheus.MustNewConstMetric(usedMemoryMetricDesc, prometheus.GaugeValue, pool.Used, labels...))
+		result.AddMetric(prometheus.MustNewConstMetric(lowestMemoryMetricDesc, prometheus.GaugeValue, pool.LowestUsage, labels...))
+	}
+	return nil
+}
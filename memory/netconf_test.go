@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/connector/netconftest"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const netconfOutput = `<memory-statistics xmlns="http://cisco.com/ns/yang/Cisco-IOS-XE-memory-oper">
+  <memory-statistic>
+    <name>Processor</name>
+    <total-memory>2028030944</total-memory>
+    <used-memory>314387176</used-memory>
+    <free-memory>1713643768</free-memory>
+    <lowest-usage>1711219768</lowest-usage>
+    <highest-usage>1294040976</highest-usage>
+  </memory-statistic>
+  <memory-statistic>
+    <name>lsmpi_io</name>
+    <total-memory>6295128</total-memory>
+    <used-memory>6294304</used-memory>
+    <free-memory>824</free-memory>
+    <lowest-usage>824</lowest-usage>
+    <highest-usage>412</highest-usage>
+  </memory-statistic>
+</memory-statistics>`
+
+func TestCollectNETCONF(t *testing.T) {
+	server := netconftest.NewServer(t, map[string]string{"memory-statistics": netconfOutput})
+	conn, err := connector.NewConnectionManager().GetConnection("127.0.0.1", server.Device())
+	if err != nil {
+		t.Fatalf("Could not connect to the NETCONF server: %v", err)
+	}
+	defer conn.Terminate()
+
+	result := NewCollector().Collect(context.Background(), &collector.CollectContext{Connection: conn, LabelValues: []string{"test.test"}})
+	util.CompareMetrics(util.PrepareResultForTesting(result, t), map[string]float64{
+		"cisco_memory_total_bytes{subsystem=Processor,target=test.test}":  2028030944,
+		"cisco_memory_used_bytes{subsystem=Processor,target=test.test}":   314387176,
+		"cisco_memory_lowest_bytes{subsystem=Processor,target=test.test}": 1711219768,
+		"cisco_memory_total_bytes{subsystem=lsmpi_io,target=test.test}":   6295128,
+		"cisco_memory_used_bytes{subsystem=lsmpi_io,target=test.test}":    6294304,
+		"cisco_memory_lowest_bytes{subsystem=lsmpi_io,target=test.test}":  824,
+	}, t)
+}
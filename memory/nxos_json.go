@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// nxosSystemResources is the relevant part of `show system resources | json`. Values are in KB.
+type nxosSystemResources struct {
+	MemoryUsageTotal *nxos.Number `json:"memory_usage_total"`
+	MemoryUsageUsed  *nxos.Number `json:"memory_usage_used"`
+}
+
+func (c *Collector) collectNXOSJSON(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) error {
+	data := &nxosSystemResources{}
+	if err := nxos.RunJSON(ctx, collectCtx.Connection, "show system resources", data); err != nil {
+		return err
+	}
+	collectCtx.Record(data)
+	return c.parseNXOSJSON(collectCtx, result, data)
+}
+
+func (c *Collector) parseNXOSJSON(collectCtx *collector.CollectContext, result *collector.Result, data *nxosSystemResources) error {
+	if !data.MemoryUsageTotal.Valid() || !data.MemoryUsageUsed.Valid() {
+		return errNoMetric
+	}
+
+	labels := append(collectCtx.LabelValues, "system")
+	result.AddMetric(prometheus.MustNewConstMetric(totalMemoryMetricDesc, prometheus.GaugeValue, data.MemoryUsageTotal.Value()*1024, labels...))
+	result.AddMetric(prometheus.MustNewConstMetric(usedMemoryMetricDesc, prometheus.GaugeValue, data.MemoryUsageUsed.Value()*1024, labels...))
+	return nil
+}
@@ -1,6 +1,7 @@
 package mpls
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 	"strconv"
@@ -46,17 +47,17 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
-	c.collectForwardingTable(ctx)
-	c.collectMemory(ctx)
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	c.collectForwardingTable(ctx, collectCtx, result)
+	c.collectMemory(ctx, collectCtx, result)
+	return result
 }
 
-func (c *Collector) collectForwardingTable(ctx *collector.CollectContext) {
+func (c *Collector) collectForwardingTable(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) {
 	sshCtx := connector.NewSSHCommandContext("show mpls forwarding-table")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	labelStatistics := make(chan *LabelStatistic)
 	labelStatisticsParsingDone := make(chan struct{})
@@ -65,19 +66,20 @@ func (c *Collector) collectForwardingTable(ctx *collector.CollectContext) {
 	for {
 		select {
 		case labelStatistic := <-labelStatistics:
-			l := append(ctx.LabelValues, labelStatistic.LocalLabel, labelStatistic.OutgoingLabel, labelStatistic.PrefixOrTunnelID, labelStatistic.OutgoingInterface, labelStatistic.NextHop)
-			ctx.Metrics <- prometheus.MustNewConstMetric(bytesLabelSwitchedDesc, prometheus.GaugeValue, labelStatistic.BytesLabelSwitched, l...)
+			collectCtx.Record(labelStatistic)
+			l := append(collectCtx.LabelValues, labelStatistic.LocalLabel, labelStatistic.OutgoingLabel, labelStatistic.PrefixOrTunnelID, labelStatistic.OutgoingInterface, labelStatistic.NextHop)
+			result.AddMetric(prometheus.MustNewConstMetric(bytesLabelSwitchedDesc, prometheus.GaugeValue, labelStatistic.BytesLabelSwitched, l...))
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping mpls metrics: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping mpls metrics: %v", err))
 		case <-labelStatisticsParsingDone:
 			return
 		}
 	}
 }
 
-func (c *Collector) collectMemory(ctx *collector.CollectContext) {
+func (c *Collector) collectMemory(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) {
 	sshCtx := connector.NewSSHCommandContext("show mpls memory")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	allocatorNames := make(map[string]int)
 	memoryStatistics := make(chan *MemoryStatistic)
@@ -87,6 +89,7 @@ func (c *Collector) collectMemory(ctx *collector.CollectContext) {
 	for {
 		select {
 		case memoryStatistic := <-memoryStatistics:
+			collectCtx.Record(memoryStatistic)
 			count, found := allocatorNames[memoryStatistic.AllocatorName]
 			if !found {
 				allocatorNames[memoryStatistic.AllocatorName] = 0
@@ -95,11 +98,11 @@ func (c *Collector) collectMemory(ctx *collector.CollectContext) {
 			if count > 0 {
 				memoryStatistic.AllocatorName += strconv.Itoa(count)
 			}
-			l := append(ctx.LabelValues, memoryStatistic.AllocatorName)
-			ctx.Metrics <- prometheus.MustNewConstMetric(memoryCountDesc, prometheus.GaugeValue, memoryStatistic.InUse, append(l, "in_use")...)
-			ctx.Metrics <- prometheus.MustNewConstMetric(memoryCountDesc, prometheus.GaugeValue, memoryStatistic.Allocated, append(l, "allocated")...)
+			l := append(collectCtx.LabelValues, memoryStatistic.AllocatorName)
+			result.AddMetric(prometheus.MustNewConstMetric(memoryCountDesc, prometheus.GaugeValue, memoryStatistic.InUse, append(l, "in_use")...))
+			result.AddMetric(prometheus.MustNewConstMetric(memoryCountDesc, prometheus.GaugeValue, memoryStatistic.Allocated, append(l, "allocated")...))
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping mpls metrics: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping mpls metrics: %v", err))
 		case <-memoryStatisticsParsingDone:
 			return
 		}
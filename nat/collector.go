@@ -1,6 +1,7 @@
 package nat
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 
@@ -142,19 +143,18 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
-	for _, pool := range collectStats(ctx) {
-		collectPool(ctx, pool)
+	for _, pool := range collectStats(ctx, collectCtx, result) {
+		collectPool(ctx, collectCtx, result, pool)
 	}
+	return result
 }
 
-func collectStats(ctx *collector.CollectContext) []*Pool {
+func collectStats(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) []*Pool {
 	sshCtx := connector.NewSSHCommandContext("show ip nat statistics")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	pools := make([]*Pool, 0)
 	poolsChan := make(chan *Pool)
@@ -165,19 +165,20 @@ func collectStats(ctx *collector.CollectContext) []*Pool {
 	for {
 		select {
 		case stat := <-statisticsChan:
-			generateStatisticsMetrics(ctx, stat)
+			generateStatisticsMetrics(collectCtx, result, stat)
 			return pools
 		case pool := <-poolsChan:
 			pools = append(pools, pool)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping NAT statistics: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping NAT statistics: %v", err))
 		}
 	}
 }
 
-func collectPool(ctx *collector.CollectContext, pool *Pool) {
+func collectPool(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, pool *Pool) {
 	sshCtx := connector.NewSSHCommandContext("show ip nat pool name " + pool.Name)
-	go ctx.Connection.RunCommand(sshCtx)
+	sshCtx.Template = "show ip nat pool name <pool>"
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	poolsChan := make(chan *Pool)
 
@@ -186,55 +187,57 @@ func collectPool(ctx *collector.CollectContext, pool *Pool) {
 	for {
 		select {
 		case pool := <-poolsChan:
-			generatePoolMetrics(ctx, pool)
+			generatePoolMetrics(collectCtx, result, pool)
 			return
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping pool statistics: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping pool statistics: %v", err))
 		}
 	}
 }
 
-func generateStatisticsMetrics(ctx *collector.CollectContext, stat *Statistics) {
-	l := ctx.LabelValues
-	ctx.Metrics <- prometheus.MustNewConstMetric(activeTranslationsDesc, prometheus.GaugeValue, stat.ActiveTranslations, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(activeStaticTranslationsDesc, prometheus.GaugeValue, stat.ActiveStaticTranslations, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(activeDynamicTranslationsDesc, prometheus.GaugeValue, stat.ActiveDynamicTranslations, l...)
+func generateStatisticsMetrics(collectCtx *collector.CollectContext, result *collector.Result, stat *Statistics) {
+	collectCtx.Record(stat)
+	l := collectCtx.LabelValues
+	result.AddMetric(prometheus.MustNewConstMetric(activeTranslationsDesc, prometheus.GaugeValue, stat.ActiveTranslations, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(activeStaticTranslationsDesc, prometheus.GaugeValue, stat.ActiveStaticTranslations, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(activeDynamicTranslationsDesc, prometheus.GaugeValue, stat.ActiveDynamicTranslations, l...))
 	for _, interfaceName := range stat.OutsideInterfaces {
-		ctx.Metrics <- prometheus.MustNewConstMetric(outsideInterfacesDesc, prometheus.GaugeValue, 1, append(l, interfaceName)...)
+		result.AddMetric(prometheus.MustNewConstMetric(outsideInterfacesDesc, prometheus.GaugeValue, 1, append(l, interfaceName)...))
 	}
 	for _, interfaceName := range stat.InsideInterfaces {
-		ctx.Metrics <- prometheus.MustNewConstMetric(insideInterfacesDesc, prometheus.GaugeValue, 1, append(l, interfaceName)...)
+		result.AddMetric(prometheus.MustNewConstMetric(insideInterfacesDesc, prometheus.GaugeValue, 1, append(l, interfaceName)...))
 	}
-	ctx.Metrics <- prometheus.MustNewConstMetric(hitsDesc, prometheus.GaugeValue, stat.Hits, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(missesDesc, prometheus.GaugeValue, stat.Misses, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(expiredTranslationsDesc, prometheus.GaugeValue, stat.ExpiredTranslations, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(inToOutDropsDesc, prometheus.GaugeValue, stat.InToOutDrops, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(outToInDropsDesc, prometheus.GaugeValue, stat.OutToInDrops, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(limitMaxAllowedDesc, prometheus.GaugeValue, stat.LimitMaxAllowed, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(limitUsedDesc, prometheus.GaugeValue, stat.LimitUsed, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(limitMissedDesc, prometheus.GaugeValue, stat.LimitMissed, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(poolStatsDropDesc, prometheus.GaugeValue, stat.PoolStatsDrop, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(mappingStatsDropDesc, prometheus.GaugeValue, stat.MappingStatsDrop, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(portBlockAllocFailDesc, prometheus.GaugeValue, stat.PortBlockAllocFail, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(ipAliasAddFailDesc, prometheus.GaugeValue, stat.IPAliasAddFail, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(limitEntryAddFailDesc, prometheus.GaugeValue, stat.LimitEntryAddFail, l...)
+	result.AddMetric(prometheus.MustNewConstMetric(hitsDesc, prometheus.GaugeValue, stat.Hits, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(missesDesc, prometheus.GaugeValue, stat.Misses, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(expiredTranslationsDesc, prometheus.GaugeValue, stat.ExpiredTranslations, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(inToOutDropsDesc, prometheus.GaugeValue, stat.InToOutDrops, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(outToInDropsDesc, prometheus.GaugeValue, stat.OutToInDrops, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(limitMaxAllowedDesc, prometheus.GaugeValue, stat.LimitMaxAllowed, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(limitUsedDesc, prometheus.GaugeValue, stat.LimitUsed, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(limitMissedDesc, prometheus.GaugeValue, stat.LimitMissed, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(poolStatsDropDesc, prometheus.GaugeValue, stat.PoolStatsDrop, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(mappingStatsDropDesc, prometheus.GaugeValue, stat.MappingStatsDrop, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(portBlockAllocFailDesc, prometheus.GaugeValue, stat.PortBlockAllocFail, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(ipAliasAddFailDesc, prometheus.GaugeValue, stat.IPAliasAddFail, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(limitEntryAddFailDesc, prometheus.GaugeValue, stat.LimitEntryAddFail, l...))
 }
 
-func generatePoolMetrics(ctx *collector.CollectContext, pool *Pool) {
-	l := append(ctx.LabelValues, pool.ID, pool.Name)
-	ctx.Metrics <- prometheus.MustNewConstMetric(refcountDesc, prometheus.GaugeValue, pool.Refcount, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(netmaskDesc, prometheus.GaugeValue, 1, append(l, pool.Netmask)...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(startIPDesc, prometheus.GaugeValue, 1, append(l, pool.StartIP)...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(endIPDesc, prometheus.GaugeValue, 1, append(l, pool.EndIP)...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(addressesTotalDesc, prometheus.GaugeValue, pool.AddressesTotal, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(addressesAvailDesc, prometheus.GaugeValue, pool.AddressesAvail, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(addressesAssignedDesc, prometheus.GaugeValue, pool.AddressesAssigned, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(udpLowPortAvailDesc, prometheus.GaugeValue, pool.UDPLowPortsAvail, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(udpLowPortAssignedDesc, prometheus.GaugeValue, pool.UDPLowPortsAssigned, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(tcpLowPortAvailDesc, prometheus.GaugeValue, pool.TCPLowPortsAvail, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(tcpLowPortAssignedDesc, prometheus.GaugeValue, pool.TCPLowPortsAssigned, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(udpHighPortAvailDesc, prometheus.GaugeValue, pool.UDPHighPortsAvail, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(udpHighPortAssignedDesc, prometheus.GaugeValue, pool.UDPHighPortsAssigned, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(tcpHighPortAvailDesc, prometheus.GaugeValue, pool.TCPHighPortsAvail, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(tcpHighPortAssignedDesc, prometheus.GaugeValue, pool.TCPHighPortsAssigned, l...)
+func generatePoolMetrics(collectCtx *collector.CollectContext, result *collector.Result, pool *Pool) {
+	collectCtx.Record(pool)
+	l := append(collectCtx.LabelValues, pool.ID, pool.Name)
+	result.AddMetric(prometheus.MustNewConstMetric(refcountDesc, prometheus.GaugeValue, pool.Refcount, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(netmaskDesc, prometheus.GaugeValue, 1, append(l, pool.Netmask)...))
+	result.AddMetric(prometheus.MustNewConstMetric(startIPDesc, prometheus.GaugeValue, 1, append(l, pool.StartIP)...))
+	result.AddMetric(prometheus.MustNewConstMetric(endIPDesc, prometheus.GaugeValue, 1, append(l, pool.EndIP)...))
+	result.AddMetric(prometheus.MustNewConstMetric(addressesTotalDesc, prometheus.GaugeValue, pool.AddressesTotal, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(addressesAvailDesc, prometheus.GaugeValue, pool.AddressesAvail, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(addressesAssignedDesc, prometheus.GaugeValue, pool.AddressesAssigned, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(udpLowPortAvailDesc, prometheus.GaugeValue, pool.UDPLowPortsAvail, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(udpLowPortAssignedDesc, prometheus.GaugeValue, pool.UDPLowPortsAssigned, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(tcpLowPortAvailDesc, prometheus.GaugeValue, pool.TCPLowPortsAvail, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(tcpLowPortAssignedDesc, prometheus.GaugeValue, pool.TCPLowPortsAssigned, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(udpHighPortAvailDesc, prometheus.GaugeValue, pool.UDPHighPortsAvail, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(udpHighPortAssignedDesc, prometheus.GaugeValue, pool.UDPHighPortsAssigned, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(tcpHighPortAvailDesc, prometheus.GaugeValue, pool.TCPHighPortsAvail, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(tcpHighPortAssignedDesc, prometheus.GaugeValue, pool.TCPHighPortsAssigned, l...))
 }
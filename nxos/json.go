@@ -0,0 +1,151 @@
+package nxos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"github.com/pkg/errors"
+
+	"github.com/prometheus/common/log"
+)
+
+// ErrJSONUnsupported is returned if the device does not answer with JSON, e.g. on releases without `| json`.
+var ErrJSONUnsupported = errors.New("JSON output is not supported")
+
+// RunJSON runs `command | json` on the remote device and decodes its output into v.
+// Collectors fall back to parsing the text output if ErrJSONUnsupported is returned.
+// Once a device did not answer with JSON, ErrJSONUnsupported is returned without running the command again on its connection.
+func RunJSON(ctx context.Context, conn connector.Connection, command string, v interface{}) error {
+	return RunJSONTemplate(ctx, conn, command, "", v)
+}
+
+// RunJSONTemplate is like RunJSON for commands with arguments naming an entity, template labels the command metrics.
+func RunJSONTemplate(ctx context.Context, conn connector.Connection, command string, template string, v interface{}) error {
+	if conn.Info().JSONUnsupported() {
+		return ErrJSONUnsupported
+	}
+	sshCtx := connector.NewSSHCommandContext(command + " | json")
+	if template != "" {
+		sshCtx.Template = template + " | json"
+	}
+	go conn.RunCommand(ctx, sshCtx)
+
+	var output strings.Builder
+	var lastErr error
+	for {
+		select {
+		case <-sshCtx.Done:
+			if lastErr != nil {
+				return lastErr
+			}
+			err := Decode(output.String(), v)
+			if err == ErrJSONUnsupported {
+				conn.Info().SetJSONUnsupported()
+				log.Debugf("'%s' does not support '%s', falling back to parsing text", conn.Info().Target, sshCtx.Command)
+			}
+			return err
+		case line := <-sshCtx.Output:
+			output.WriteString(line)
+			output.WriteString("\n")
+		case lastErr = <-sshCtx.Errors:
+			continue
+		}
+	}
+}
+
+// Decode decodes the JSON output of a command into v.
+// Empty output, which NX-OS returns for empty tables, leaves v untouched.
+func Decode(output string, v interface{}) error {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil
+	}
+	if !strings.HasPrefix(output, "{") {
+		return ErrJSONUnsupported
+	}
+	if err := json.Unmarshal([]byte(output), v); err != nil {
+		return errors.Wrap(err, "Could not decode JSON output")
+	}
+	return nil
+}
+
+// Rows holds the rows of a table like `TABLE_interface.ROW_interface`.
+// NX-OS encodes a table with a single row as object instead of an array.
+type Rows []json.RawMessage
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (r *Rows) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		*r = Rows{json.RawMessage(data)}
+		return nil
+	}
+	var rows []json.RawMessage
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return err
+	}
+	*r = rows
+	return nil
+}
+
+// Decode decodes the rows into the slice v points to.
+func (r Rows) Decode(v interface{}) error {
+	if len(r) == 0 {
+		return nil
+	}
+	data, err := json.Marshal([]json.RawMessage(r))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Number is a number NX-OS encodes either as JSON number or as string, which might be followed by a unit like `650.00 W`.
+// Values which are no number, like `N/A`, are decoded as NaN and reported as invalid by Valid.
+type Number float64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *Number) UnmarshalJSON(data []byte) error {
+	*n = Number(math.NaN())
+	fields := strings.Fields(strings.Trim(string(data), `"`))
+	if len(fields) == 0 {
+		return nil
+	}
+	if value, err := strconv.ParseFloat(fields[0], 64); err == nil {
+		*n = Number(value)
+	}
+	return nil
+}
+
+// Valid returns true if n is present and holds a number.
+func (n *Number) Valid() bool {
+	return n != nil && !math.IsNaN(float64(*n))
+}
+
+// Value returns n as float64 or 0 if n is not valid.
+func (n *Number) Value() float64 {
+	if !n.Valid() {
+		return 0
+	}
+	return float64(*n)
+}
+
+// String is a label NX-OS encodes either as JSON string or as number, like the number of a module.
+type String string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *String) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err == nil {
+		*s = String(strings.TrimSpace(str))
+		return nil
+	}
+	*s = String(strings.TrimSpace(string(data)))
+	return nil
+}
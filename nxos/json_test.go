@@ -0,0 +1,92 @@
+package nxos
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/connector"
+)
+
+type testRow struct {
+	Name  String  `json:"name"`
+	Value *Number `json:"value"`
+}
+
+type testTable struct {
+	Table struct {
+		Rows Rows `json:"ROW_test"`
+	} `json:"TABLE_test"`
+}
+
+func TestDecodeRows(t *testing.T) {
+	tests := []struct {
+		name     string
+		output   string
+		expected map[String]float64
+	}{
+		{"array", `{"TABLE_test": {"ROW_test": [{"name": "a", "value": 1}, {"name": "b", "value": "2.5"}]}}`, map[String]float64{"a": 1, "b": 2.5}},
+		{"single row", `{"TABLE_test": {"ROW_test": {"name": 1, "value": "650.00 W"}}}`, map[String]float64{"1": 650}},
+		{"no number", `{"TABLE_test": {"ROW_test": [{"name": "a", "value": "N/A"}, {"name": "b"}]}}`, map[String]float64{}},
+		{"empty", ``, map[String]float64{}},
+	}
+
+	for _, test := range tests {
+		table := testTable{}
+		if err := Decode(test.output, &table); err != nil {
+			t.Errorf("%s: Unexpected error: %v", test.name, err)
+			continue
+		}
+		var rows []testRow
+		if err := table.Table.Rows.Decode(&rows); err != nil {
+			t.Errorf("%s: Unexpected error decoding rows: %v", test.name, err)
+		}
+		got := map[String]float64{}
+		for _, row := range rows {
+			if row.Value.Valid() {
+				got[row.Name] = row.Value.Value()
+			}
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("%s: Expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestDecodeUnsupported(t *testing.T) {
+	err := Decode("% Invalid command at '^' marker.", &testTable{})
+	if err != ErrJSONUnsupported {
+		t.Errorf("Expected ErrJSONUnsupported, got %v", err)
+	}
+}
+
+// textConnection answers every command with the output of a release without `| json` and counts the commands run.
+type textConnection struct {
+	connector.ConnectionInfo
+	commands int
+}
+
+func (c *textConnection) RunCommand(ctx context.Context, sshCtx *connector.SSHCommandContext) {
+	c.commands++
+	sshCtx.Output <- "% Invalid command at '^' marker."
+	sshCtx.Done <- struct{}{}
+}
+
+func (c *textConnection) IsConnected() bool     { return true }
+func (c *textConnection) IsAuthenticated() bool { return true }
+func (c *textConnection) Terminate()            {}
+
+func TestRunJSONUnsupportedIsCached(t *testing.T) {
+	conn := &textConnection{ConnectionInfo: connector.ConnectionInfo{Target: "switch"}}
+	for i := 0; i < 3; i++ {
+		if err := RunJSON(context.Background(), conn, "show processes cpu", &testTable{}); err != ErrJSONUnsupported {
+			t.Errorf("Expected ErrJSONUnsupported, got %v", err)
+		}
+	}
+	if conn.commands != 1 {
+		t.Errorf("Expected a single command to be run, got %d", conn.commands)
+	}
+	if !conn.Info().JSONUnsupported() {
+		t.Errorf("Expected the connection to record that JSON is unsupported")
+	}
+}
@@ -1,6 +1,7 @@
 package opticsios
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 
@@ -52,13 +53,11 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
 	sshCtx := connector.NewSSHCommandContext("show interfaces transceiver detail")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	transceivers := make(chan *Transceiver)
 	transceiversParsingDone := make(chan struct{})
@@ -67,30 +66,31 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 	for {
 		select {
 		case transceiver := <-transceivers:
-			generateMetrics(ctx, transceiver)
+			generateMetrics(collectCtx, result, transceiver)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping transceivers: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping transceivers: %v", err))
 		case <-transceiversParsingDone:
-			return
+			return result
 		}
 	}
 }
 
-func generateMetrics(ctx *collector.CollectContext, transceiver *Transceiver) {
-	l := append(ctx.LabelValues, transceiver.Name)
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, transceiver *Transceiver) {
+	collectCtx.Record(transceiver)
+	l := append(collectCtx.LabelValues, transceiver.Name)
 	for readingType, value := range transceiver.Temperature {
-		ctx.Metrics <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.Voltage {
-		ctx.Metrics <- prometheus.MustNewConstMetric(voltageDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(voltageDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.Current {
-		ctx.Metrics <- prometheus.MustNewConstMetric(currentDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(currentDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.TransmitPower {
-		ctx.Metrics <- prometheus.MustNewConstMetric(transmitPowerDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(transmitPowerDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.ReceivePower {
-		ctx.Metrics <- prometheus.MustNewConstMetric(receivePowerDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(receivePowerDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 }
@@ -1,8 +1,10 @@
 package opticsnxos
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/nxos"
 
 	"github.com/pkg/errors"
 
@@ -56,13 +58,19 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	err := c.collectJSON(ctx, collectCtx, result)
+	if errors.Cause(err) != nxos.ErrJSONUnsupported {
+		if err != nil {
+			result.AddError(errors.Wrap(err, "Error scraping transceivers"))
+		}
+		return result
+	}
 
 	sshCtx := connector.NewSSHCommandContext("show interface transceiver detail")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	transceivers := make(chan *NXOSTransceiver)
 	transceiversParsingDone := make(chan struct{})
@@ -71,31 +79,47 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 	for {
 		select {
 		case transceiver := <-transceivers:
-			generateMetrics(ctx, transceiver)
+			generateMetrics(collectCtx, result, transceiver)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping transceivers: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping transceivers: %v", err))
 		case <-transceiversParsingDone:
-			return
+			return result
 		}
 	}
 }
 
-func generateMetrics(ctx *collector.CollectContext, transceiver *NXOSTransceiver) {
-	l := append(ctx.LabelValues, transceiver.Name, transceiver.Lane)
+func (c *Collector) collectJSON(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) error {
+	data := &nxosTransceivers{}
+	if err := nxos.RunJSON(ctx, collectCtx.Connection, "show interface transceiver detail", data); err != nil {
+		return err
+	}
+	transceivers, err := data.transceivers()
+	if err != nil {
+		return err
+	}
+	for _, transceiver := range transceivers {
+		generateMetrics(collectCtx, result, transceiver)
+	}
+	return nil
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, transceiver *NXOSTransceiver) {
+	collectCtx.Record(transceiver)
+	l := append(collectCtx.LabelValues, transceiver.Name, transceiver.Lane)
 	for readingType, value := range transceiver.Temperature {
-		ctx.Metrics <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.Voltage {
-		ctx.Metrics <- prometheus.MustNewConstMetric(voltageDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(voltageDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.Current {
-		ctx.Metrics <- prometheus.MustNewConstMetric(currentDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(currentDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.TransmitPower {
-		ctx.Metrics <- prometheus.MustNewConstMetric(transmitPowerDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(transmitPowerDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
 	for readingType, value := range transceiver.ReceivePower {
-		ctx.Metrics <- prometheus.MustNewConstMetric(receivePowerDesc, prometheus.GaugeValue, value, append(l, readingType)...)
+		result.AddMetric(prometheus.MustNewConstMetric(receivePowerDesc, prometheus.GaugeValue, value, append(l, readingType)...))
 	}
-	ctx.Metrics <- prometheus.MustNewConstMetric(faultcountDesc, prometheus.GaugeValue, transceiver.Faultcount, l...)
+	result.AddMetric(prometheus.MustNewConstMetric(faultcountDesc, prometheus.GaugeValue, transceiver.Faultcount, l...))
 }
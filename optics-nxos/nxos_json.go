@@ -0,0 +1,102 @@
+package opticsnxos
+
+import (
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+)
+
+// nxosTransceivers is the relevant part of `show interface transceiver detail | json`.
+type nxosTransceivers struct {
+	Table struct {
+		Rows nxos.Rows `json:"ROW_interface"`
+	} `json:"TABLE_interface"`
+}
+
+type nxosTransceiverInterface struct {
+	Name  string `json:"interface"`
+	Lanes struct {
+		Rows nxos.Rows `json:"ROW_lane"`
+	} `json:"TABLE_lane"`
+}
+
+// nxosLane holds the diagnostics of a lane. Single lane transceivers omit the lane number.
+type nxosLane struct {
+	Number nxos.String `json:"lane_number"`
+
+	Temperature          *nxos.Number `json:"temperature"`
+	TemperatureHighAlarm *nxos.Number `json:"temp_alrm_hi"`
+	TemperatureLowAlarm  *nxos.Number `json:"temp_alrm_lo"`
+	TemperatureHighWarn  *nxos.Number `json:"temp_warn_hi"`
+	TemperatureLowWarn   *nxos.Number `json:"temp_warn_lo"`
+
+	Voltage          *nxos.Number `json:"voltage"`
+	VoltageHighAlarm *nxos.Number `json:"volt_alrm_hi"`
+	VoltageLowAlarm  *nxos.Number `json:"volt_alrm_lo"`
+	VoltageHighWarn  *nxos.Number `json:"volt_warn_hi"`
+	VoltageLowWarn   *nxos.Number `json:"volt_warn_lo"`
+
+	Current          *nxos.Number `json:"current"`
+	CurrentHighAlarm *nxos.Number `json:"current_alrm_hi"`
+	CurrentLowAlarm  *nxos.Number `json:"current_alrm_lo"`
+	CurrentHighWarn  *nxos.Number `json:"current_warn_hi"`
+	CurrentLowWarn   *nxos.Number `json:"current_warn_lo"`
+
+	TxPower          *nxos.Number `json:"tx_pwr"`
+	TxPowerHighAlarm *nxos.Number `json:"tx_pwr_alrm_hi"`
+	TxPowerLowAlarm  *nxos.Number `json:"tx_pwr_alrm_lo"`
+	TxPowerHighWarn  *nxos.Number `json:"tx_pwr_warn_hi"`
+	TxPowerLowWarn   *nxos.Number `json:"tx_pwr_warn_lo"`
+
+	RxPower          *nxos.Number `json:"rx_pwr"`
+	RxPowerHighAlarm *nxos.Number `json:"rx_pwr_alrm_hi"`
+	RxPowerLowAlarm  *nxos.Number `json:"rx_pwr_alrm_lo"`
+	RxPowerHighWarn  *nxos.Number `json:"rx_pwr_warn_hi"`
+	RxPowerLowWarn   *nxos.Number `json:"rx_pwr_warn_lo"`
+
+	TransmitFaults *nxos.Number `json:"xmit_faults"`
+}
+
+func (t *nxosTransceivers) transceivers() ([]*NXOSTransceiver, error) {
+	var interfaces []nxosTransceiverInterface
+	if err := t.Table.Rows.Decode(&interfaces); err != nil {
+		return nil, err
+	}
+
+	transceivers := make([]*NXOSTransceiver, 0)
+	for _, iface := range interfaces {
+		var lanes []nxosLane
+		if err := iface.Lanes.Rows.Decode(&lanes); err != nil {
+			return nil, err
+		}
+		for _, lane := range lanes {
+			transceiver := NewTransceiver()
+			transceiver.Name = iface.Name
+			transceiver.Lane = string(lane.Number)
+			if transceiver.Lane == "" {
+				transceiver.Lane = "0"
+			}
+			setReadings(transceiver.Temperature, lane.Temperature, lane.TemperatureHighAlarm, lane.TemperatureLowAlarm, lane.TemperatureHighWarn, lane.TemperatureLowWarn)
+			setReadings(transceiver.Voltage, lane.Voltage, lane.VoltageHighAlarm, lane.VoltageLowAlarm, lane.VoltageHighWarn, lane.VoltageLowWarn)
+			setReadings(transceiver.Current, lane.Current, lane.CurrentHighAlarm, lane.CurrentLowAlarm, lane.CurrentHighWarn, lane.CurrentLowWarn)
+			setReadings(transceiver.TransmitPower, lane.TxPower, lane.TxPowerHighAlarm, lane.TxPowerLowAlarm, lane.TxPowerHighWarn, lane.TxPowerLowWarn)
+			setReadings(transceiver.ReceivePower, lane.RxPower, lane.RxPowerHighAlarm, lane.RxPowerLowAlarm, lane.RxPowerHighWarn, lane.RxPowerLowWarn)
+			transceiver.Faultcount = lane.TransmitFaults.Value()
+			transceivers = append(transceivers, transceiver)
+		}
+	}
+	return transceivers, nil
+}
+
+func setReadings(readings map[string]float64, current, highAlarm, lowAlarm, highWarn, lowWarn *nxos.Number) {
+	values := map[string]*nxos.Number{
+		"current":    current,
+		"high_alarm": highAlarm,
+		"low_alarm":  lowAlarm,
+		"high_warn":  highWarn,
+		"low_warn":   lowWarn,
+	}
+	for readingType, value := range values {
+		if value.Valid() {
+			readings[readingType] = value.Value()
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package opticsnxos
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/nxos"
+)
+
+const nxosJSON = `{
+  "TABLE_interface": {
+    "ROW_interface": [
+      {
+        "interface": "Ethernet1/1",
+        "sfp": "present",
+        "type": "10Gbase-LR",
+        "TABLE_lane": {
+          "ROW_lane": {
+            "temperature": "31.48",
+            "temp_alrm_hi": "75.00",
+            "temp_alrm_lo": "-5.00",
+            "temp_warn_hi": "70.00",
+            "temp_warn_lo": "0.00",
+            "voltage": "3.29",
+            "volt_alrm_hi": "3.63",
+            "volt_alrm_lo": "2.97",
+            "volt_warn_hi": "3.46",
+            "volt_warn_lo": "3.13",
+            "current": "35.84",
+            "current_alrm_hi": "70.00",
+            "current_alrm_lo": "4.00",
+            "current_warn_hi": "68.00",
+            "current_warn_lo": "5.00",
+            "tx_pwr": "-2.21",
+            "tx_pwr_alrm_hi": "3.49",
+            "tx_pwr_alrm_lo": "-12.19",
+            "tx_pwr_warn_hi": "0.49",
+            "tx_pwr_warn_lo": "-8.20",
+            "rx_pwr": "-3.98",
+            "rx_pwr_alrm_hi": "3.49",
+            "rx_pwr_alrm_lo": "-18.38",
+            "rx_pwr_warn_hi": "0.49",
+            "rx_pwr_warn_lo": "-14.40",
+            "xmit_faults": "0"
+          }
+        }
+      },
+      {
+        "interface": "Ethernet1/2",
+        "sfp": "not present"
+      },
+      {
+        "interface": "Ethernet1/49",
+        "sfp": "present",
+        "type": "QSFP-40G-SR4",
+        "TABLE_lane": {
+          "ROW_lane": [
+            {"lane_number": "1", "temperature": "28.11", "rx_pwr": "-1.02", "xmit_faults": "2"},
+            {"lane_number": "2", "temperature": "28.11", "rx_pwr": "N/A", "xmit_faults": "0"}
+          ]
+        }
+      }
+    ]
+  }
+}`
+
+func TestParseNXOSJSON(t *testing.T) {
+	first := NewTransceiver()
+	first.Name = "Ethernet1/1"
+	first.Lane = "0"
+	first.Temperature = map[string]float64{"current": 31.48, "high_alarm": 75, "low_alarm": -5, "high_warn": 70, "low_warn": 0}
+	first.Voltage = map[string]float64{"current": 3.29, "high_alarm": 3.63, "low_alarm": 2.97, "high_warn": 3.46, "low_warn": 3.13}
+	first.Current = map[string]float64{"current": 35.84, "high_alarm": 70, "low_alarm": 4, "high_warn": 68, "low_warn": 5}
+	first.TransmitPower = map[string]float64{"current": -2.21, "high_alarm": 3.49, "low_alarm": -12.19, "high_warn": 0.49, "low_warn": -8.2}
+	first.ReceivePower = map[string]float64{"current": -3.98, "high_alarm": 3.49, "low_alarm": -18.38, "high_warn": 0.49, "low_warn": -14.4}
+
+	second := NewTransceiver()
+	second.Name = "Ethernet1/49"
+	second.Lane = "1"
+	second.Temperature["current"] = 28.11
+	second.ReceivePower["current"] = -1.02
+	second.Faultcount = 2
+
+	third := NewTransceiver()
+	third.Name = "Ethernet1/49"
+	third.Lane = "2"
+	third.Temperature["current"] = 28.11
+
+	data := &nxosTransceivers{}
+	if err := nxos.Decode(nxosJSON, data); err != nil {
+		t.Fatal(err)
+	}
+	transceivers, err := data.transceivers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*NXOSTransceiver{first, second, third}
+	if !reflect.DeepEqual(transceivers, expected) {
+		t.Errorf("Got unexpected transceivers, expected %+v, got %+v", expected, transceivers)
+	}
+}
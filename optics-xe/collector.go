@@ -1,6 +1,7 @@
 package opticsxe
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 
@@ -52,37 +53,37 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
-	inventory := c.getInventory(ctx)
+	inventory := c.getInventory(ctx, collectCtx, result)
 	transceivers := make(chan *XETransceiver)
 	transceiversParsingDone := make(chan struct{})
 
 	for _, transceiver := range inventory {
 		sshCtx := connector.NewSSHCommandContext("show hw-module subslot " + transceiver.Slot + "/" + transceiver.Subslot + " transceiver " + transceiver.Port + " status")
-		go ctx.Connection.RunCommand(sshCtx)
+		sshCtx.Template = "show hw-module subslot <slot>/<subslot> transceiver <port> status"
+		go collectCtx.Connection.RunCommand(ctx, sshCtx)
 		go c.parse(sshCtx, transceivers, transceiversParsingDone)
 
 	TransceiversLoop:
 		for {
 			select {
 			case transceiver := <-transceivers:
-				generateMetrics(ctx, transceiver)
+				generateMetrics(collectCtx, result, transceiver)
 			case err := <-sshCtx.Errors:
-				ctx.Errors <- errors.Wrapf(err, "Error collecting transceiver metrics: %v", err)
+				result.AddError(errors.Wrapf(err, "Error collecting transceiver metrics: %v", err))
 			case <-transceiversParsingDone:
 				break TransceiversLoop
 			}
 		}
 	}
+	return result
 }
 
-func (c *Collector) getInventory(ctx *collector.CollectContext) []*XETransceiver {
+func (c *Collector) getInventory(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) []*XETransceiver {
 	sshCtx := connector.NewSSHCommandContext("show inventory raw")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	inventory := make([]*XETransceiver, 0)
 	inventoryChan := make(chan *XETransceiver)
@@ -94,22 +95,23 @@ func (c *Collector) getInventory(ctx *collector.CollectContext) []*XETransceiver
 		case item := <-inventoryChan:
 			inventory = append(inventory, item)
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error retrieving inventory (transceivers): %v", err)
+			result.AddError(errors.Wrapf(err, "Error retrieving inventory (transceivers): %v", err))
 		case <-inventoryParsingDone:
 			return inventory
 		}
 	}
 }
 
-func generateMetrics(ctx *collector.CollectContext, transceiver *XETransceiver) {
-	l := append(ctx.LabelValues, transceiver.Slot, transceiver.Subslot, transceiver.Port)
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, transceiver *XETransceiver) {
+	collectCtx.Record(transceiver)
+	l := append(collectCtx.LabelValues, transceiver.Slot, transceiver.Subslot, transceiver.Port)
 	value := 0.0
 	if transceiver.Enabled {
 		value = 1
 	}
-	ctx.Metrics <- prometheus.MustNewConstMetric(enabledDesc, prometheus.GaugeValue, value, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, transceiver.Temperature, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(biasCurrentDesc, prometheus.GaugeValue, transceiver.BiasCurrent/(1000*1000), l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(transmitPowerDesc, prometheus.GaugeValue, transceiver.TransmitPower, l...)
-	ctx.Metrics <- prometheus.MustNewConstMetric(receivePowerDesc, prometheus.GaugeValue, transceiver.ReceivePower, l...)
+	result.AddMetric(prometheus.MustNewConstMetric(enabledDesc, prometheus.GaugeValue, value, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, transceiver.Temperature, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(biasCurrentDesc, prometheus.GaugeValue, transceiver.BiasCurrent/(1000*1000), l...))
+	result.AddMetric(prometheus.MustNewConstMetric(transmitPowerDesc, prometheus.GaugeValue, transceiver.TransmitPower, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(receivePowerDesc, prometheus.GaugeValue, transceiver.ReceivePower, l...))
 }
@@ -0,0 +1,121 @@
+package opticsxr
+
+import (
+	"context"
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"github.com/pkg/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const prefix string = "cisco_optics_xr_"
+
+var (
+	enabledDesc       *prometheus.Desc
+	temperatureDesc   *prometheus.Desc
+	voltageDesc       *prometheus.Desc
+	biasCurrentDesc   *prometheus.Desc
+	transmitPowerDesc *prometheus.Desc
+	receivePowerDesc  *prometheus.Desc
+)
+
+// Collector gathers transceiver metrics on Cisco devices running IOS XR.
+type Collector struct {
+}
+
+// NewCollector returns a new opticsxr.Collector instance.
+func NewCollector() collector.Collector {
+	return &Collector{}
+}
+
+// Name implements the collector.Collector interface's Name function
+func (*Collector) Name() string {
+	return "optics-xr"
+}
+
+func init() {
+	l := []string{"target", "controller"}
+	enabledDesc = prometheus.NewDesc(prefix+"enabled_info", "Whether the laser of the transceiver is on", l, nil)
+	temperatureDesc = prometheus.NewDesc(prefix+"temperature_celsius", "Temperature in Celsius", l, nil)
+	voltageDesc = prometheus.NewDesc(prefix+"voltage_volts", "Voltage in Volts", l, nil)
+	biasCurrentDesc = prometheus.NewDesc(prefix+"bias_current_amps", "Bias current in Amps", l, nil)
+	transmitPowerDesc = prometheus.NewDesc(prefix+"tx_power_dbm", "Transmit power in dBm", l, nil)
+	receivePowerDesc = prometheus.NewDesc(prefix+"rx_power_dbm", "Receive power in dBm", l, nil)
+}
+
+// Describe implements the collector.Collector interface's Describe function
+func (*Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- enabledDesc
+	ch <- temperatureDesc
+	ch <- voltageDesc
+	ch <- biasCurrentDesc
+	ch <- transmitPowerDesc
+	ch <- receivePowerDesc
+}
+
+// Collect implements the collector.Collector interface's Collect function
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	transceivers := make(chan *XRTransceiver)
+	transceiversParsingDone := make(chan struct{})
+
+	for _, controller := range c.getControllers(ctx, collectCtx, result) {
+		sshCtx := connector.NewSSHCommandContext("show controllers optics " + controller)
+		sshCtx.Template = "show controllers optics <controller>"
+		go collectCtx.Connection.RunCommand(ctx, sshCtx)
+		go c.parse(sshCtx, controller, transceivers, transceiversParsingDone)
+
+	TransceiversLoop:
+		for {
+			select {
+			case transceiver := <-transceivers:
+				generateMetrics(collectCtx, result, transceiver)
+			case err := <-sshCtx.Errors:
+				result.AddError(errors.Wrapf(err, "Error collecting transceiver metrics: %v", err))
+			case <-transceiversParsingDone:
+				break TransceiversLoop
+			}
+		}
+	}
+	return result
+}
+
+// getControllers returns the optics controllers (e.g. `0/0/0/1`) of all ethernet interfaces.
+func (c *Collector) getControllers(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result) []string {
+	sshCtx := connector.NewSSHCommandContext("show interfaces brief")
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
+
+	controllers := make([]string, 0)
+	controllersChan := make(chan string)
+	controllersParsingDone := make(chan struct{})
+	go c.parseControllers(sshCtx, controllersChan, controllersParsingDone)
+
+	for {
+		select {
+		case controller := <-controllersChan:
+			controllers = append(controllers, controller)
+		case err := <-sshCtx.Errors:
+			result.AddError(errors.Wrapf(err, "Error retrieving optics controllers: %v", err))
+		case <-controllersParsingDone:
+			return controllers
+		}
+	}
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, transceiver *XRTransceiver) {
+	collectCtx.Record(transceiver)
+	l := append(collectCtx.LabelValues, transceiver.Controller)
+	value := 0.0
+	if transceiver.Enabled {
+		value = 1
+	}
+	result.AddMetric(prometheus.MustNewConstMetric(enabledDesc, prometheus.GaugeValue, value, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(temperatureDesc, prometheus.GaugeValue, transceiver.Temperature, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(voltageDesc, prometheus.GaugeValue, transceiver.Voltage, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(biasCurrentDesc, prometheus.GaugeValue, transceiver.BiasCurrent/1000, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(transmitPowerDesc, prometheus.GaugeValue, transceiver.TransmitPower, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(receivePowerDesc, prometheus.GaugeValue, transceiver.ReceivePower, l...))
+}
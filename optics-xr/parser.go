@@ -0,0 +1,72 @@
+package opticsxr
+
+import (
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+	"regexp"
+)
+
+// parseControllers parses the output of `show interfaces brief` and returns the optics controller of every ethernet interface once.
+// Breakout interfaces (e.g. `Te0/0/0/1/2`) share the controller of their port.
+func (c *Collector) parseControllers(sshCtx *connector.SSHCommandContext, controllers chan string, done chan struct{}) {
+	defer func() {
+		done <- struct{}{}
+	}()
+
+	interfaceRegexp := regexp.MustCompile(`^\s*(?:Gi|Te|TF|Fo|FH|Hu|TH|FiH)(\d+/\d+/\d+/\d+)(?:/\d+)?\s`)
+	seen := make(map[string]bool)
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			return
+		case line := <-sshCtx.Output:
+			if matches := interfaceRegexp.FindStringSubmatch(line); matches != nil && !seen[matches[1]] {
+				seen[matches[1]] = true
+				controllers <- matches[1]
+			}
+		}
+	}
+}
+
+// parse parses the output of `show controllers optics <controller>`.
+// Only the aggregate values are read, the values of individual lanes are ignored.
+func (c *Collector) parse(sshCtx *connector.SSHCommandContext, controller string, transceivers chan *XRTransceiver, done chan struct{}) {
+	defer func() {
+		done <- struct{}{}
+	}()
+	laserStateRegexp := regexp.MustCompile(`^\s+Laser State:\s+(\S+)`)
+	temperatureRegexp := regexp.MustCompile(`^\s+Temperature\s+=\s+(\-?\d+\.?\d*)`)
+	voltageRegexp := regexp.MustCompile(`^\s+Voltage\s+=\s+(\-?\d+\.?\d*)`)
+	currentRegexp := regexp.MustCompile(`^\s+Laser Bias Current\s+=\s+(\-?\d+\.?\d*)`)
+	txPowerRegexp := regexp.MustCompile(`^\s+Actual TX Power\s+=\s+(\-?\d+\.?\d*) dBm`)
+	rxPowerRegexp := regexp.MustCompile(`^\s+RX Power\s+=\s+(\-?\d+\.?\d*) dBm`)
+
+	current := &XRTransceiver{Controller: controller}
+	found := false
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			if found {
+				transceivers <- current
+			}
+			return
+		case line := <-sshCtx.Output:
+			if matches := laserStateRegexp.FindStringSubmatch(line); matches != nil {
+				current.Enabled = matches[1] == "On"
+				found = true
+			} else if matches := temperatureRegexp.FindStringSubmatch(line); matches != nil {
+				current.Temperature = util.Str2float64(matches[1])
+			} else if matches := voltageRegexp.FindStringSubmatch(line); matches != nil {
+				current.Voltage = util.Str2float64(matches[1])
+			} else if matches := currentRegexp.FindStringSubmatch(line); matches != nil {
+				current.BiasCurrent = util.Str2float64(matches[1])
+			} else if matches := txPowerRegexp.FindStringSubmatch(line); matches != nil {
+				current.TransmitPower = util.Str2float64(matches[1])
+			} else if matches := rxPowerRegexp.FindStringSubmatch(line); matches != nil {
+				current.ReceivePower = util.Str2float64(matches[1])
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package opticsxr
+
+import (
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const interfacesBrief = `
+               Intf       Intf        LineP              Encap  MTU        BW
+               Name       State       State               Type (byte)    (Kbps)
+--------------------------------------------------------------------------------
+                Lo0          up          up           Loopback  1500          0
+               Nu0          up          up               Null  1500          0
+          Te0/0/0/0          up          up               ARPA  9216   10000000
+          Te0/0/0/1  admin-down  admin-down               ARPA  1514   10000000
+        Hu0/1/0/0/1          up          up               ARPA  9216   25000000
+        Hu0/1/0/0/2          up          up               ARPA  9216   25000000
+          BE1               up          up               ARPA  9216   20000000
+     Mg0/RSP0/CPU0/0          up          up               ARPA  1514    1000000`
+
+const controllerOptics = `
+ Controller State: Up
+
+ Transport Admin State: In Service
+
+ Laser State: On
+
+ LED State: Green
+
+ Optics Status
+
+         Optics Type:  SFP+ 10G LR
+         Wavelength = 1310.00 nm
+
+         Alarm Status:
+         -------------
+         Detected Alarms: None
+
+         Laser Bias Current = 34.5 mA
+         Actual TX Power = -1.95 dBm
+         RX Power = -4.27 dBm
+
+         Performance Monitoring: Disable
+
+         THRESHOLD VALUES
+         ----------------
+
+         Parameter                 High Alarm  Low Alarm  High Warning  Low Warning
+         ------------------------  ----------  ---------  ------------  -----------
+         Rx Power Threshold(dBm)          1.5      -14.4           0.5        -14.0
+         Tx Power Threshold(dBm)          3.5       -9.5           0.5         -8.2
+         LBC Threshold(mA)              70.00       0.00         68.00         0.00
+         Temperature Threshold(celsius)  75.00      -5.00         70.00         0.00
+         Voltage Threshold(volt)          3.63       2.97          3.46         3.13
+
+         Temperature = 32.25 Celsius
+         Voltage = 3.30 V`
+
+func TestParseControllers(t *testing.T) {
+	c := &Collector{}
+	ctx := util.PrepareOutputForTesting(interfacesBrief)
+	controllersChan := make(chan string)
+	done := make(chan struct{})
+	go c.parseControllers(&ctx, controllersChan, done)
+
+	expected := []string{"0/0/0/0", "0/0/0/1", "0/1/0/0"}
+	got := make([]string, 0)
+	for {
+		select {
+		case controller := <-controllersChan:
+			got = append(got, controller)
+			continue
+		case <-done:
+		}
+		break
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected controllers %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected controllers %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	c := &Collector{}
+	ctx := util.PrepareOutputForTesting(controllerOptics)
+	transceivers := make(chan *XRTransceiver)
+	done := make(chan struct{})
+	go c.parse(&ctx, "0/0/0/0", transceivers, done)
+
+	expected := XRTransceiver{
+		Controller:    "0/0/0/0",
+		Enabled:       true,
+		Temperature:   32.25,
+		Voltage:       3.3,
+		BiasCurrent:   34.5,
+		TransmitPower: -1.95,
+		ReceivePower:  -4.27,
+	}
+	count := 0
+	for {
+		select {
+		case transceiver := <-transceivers:
+			count++
+			if *transceiver != expected {
+				t.Errorf("Expected %+v, got %+v", expected, *transceiver)
+			}
+			continue
+		case <-done:
+		}
+		break
+	}
+	if count != 1 {
+		t.Errorf("Expected exactly one transceiver, got %d", count)
+	}
+}
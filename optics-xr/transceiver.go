@@ -0,0 +1,12 @@
+package opticsxr
+
+// XRTransceiver represent a transceiver inserted into a cisco device running IOS XR.
+type XRTransceiver struct {
+	Controller    string
+	Enabled       bool
+	Temperature   float64
+	Voltage       float64
+	BiasCurrent   float64
+	TransmitPower float64
+	ReceivePower  float64
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/config"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+// snapshot holds the outcome of the last poll of a collector and the metrics of the last successful one.
+type snapshot struct {
+	metrics     []prometheus.Metric
+	lastSuccess time.Time
+	errors      float64
+	duration    float64
+}
+
+// polledDevice holds the snapshots of all collectors of a target polled in the background.
+type polledDevice struct {
+	mu          sync.RWMutex
+	ctx         context.Context
+	cancel      context.CancelFunc
+	deviceGroup *config.DeviceGroupConfig
+	// static and lastScrape are protected by the Poller's mutex. Dynamic devices are no longer polled
+	// once they were not scraped for the Poller's dynamicExpiry.
+	static     bool
+	lastScrape time.Time
+	// polled is set once the first poll finished, the state is unknown before.
+	polled    bool
+	state     deviceState
+	snapshots map[string]*snapshot
+}
+
+// Poller runs the collectors of targets in the background, each on its own interval.
+// Scrapes are answered with the metrics of the last successful poll.
+type Poller struct {
+	ctx               context.Context
+	cancel            context.CancelFunc
+	connectionManager *connector.SSHConnectionManager
+	mu                sync.Mutex
+	devices           map[string]*polledDevice
+	// dynamicExpiry is how long dynamic targets are polled after their last scrape, forever if 0.
+	dynamicExpiry time.Duration
+}
+
+// NewPoller returns a Poller using the given connection manager.
+// Dynamic targets are no longer polled and their connection is closed once they were not scraped for dynamicExpiry.
+func NewPoller(connectionManager *connector.SSHConnectionManager, dynamicExpiry time.Duration) *Poller {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Poller{
+		ctx:               ctx,
+		cancel:            cancel,
+		connectionManager: connectionManager,
+		devices:           make(map[string]*polledDevice),
+		dynamicExpiry:     dynamicExpiry,
+	}
+	if dynamicExpiry > 0 {
+		go p.expireDynamicDevices()
+	}
+	return p
+}
+
+// Start starts polling the collectors of target in the background, if it is not polled yet, and counts as a scrape of it.
+// It returns the polled device, which stays usable even if a reload stops polling it meanwhile.
+func (p *Poller) Start(target string, deviceGroup *config.DeviceGroupConfig, static bool) *polledDevice {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if device, found := p.devices[target]; found {
+		device.static = device.static || static
+		device.lastScrape = time.Now()
+		return device
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	device := &polledDevice{
+		ctx:         ctx,
+		cancel:      cancel,
+		deviceGroup: deviceGroup,
+		static:      static,
+		lastScrape:  time.Now(),
+		state:       *newDeviceState(),
+		snapshots:   make(map[string]*snapshot),
+	}
+	p.devices[target] = device
+
+	ciscoCollector := newCiscoCollector(ctx, []string{target}, p.connectionManager, nil, nil)
+	for _, specificCollector := range ciscoCollector.collectorsForDevice[target] {
+		interval := deviceGroup.Polling.IntervalFor(specificCollector.Name())
+		log.Infof("Polling collector %s on device %s every %s", specificCollector.Name(), target, interval)
+		go p.poll(ciscoCollector, target, device, specificCollector, interval)
+	}
+	return device
+}
+
+// Reload applies a reloaded configuration.
+// Targets whose configuration changed are polled from scratch, targets no longer configured for polling are no longer polled.
+func (p *Poller) Reload(configuration *config.Config) {
+	static := make(map[string]bool)
+	for _, target := range configuration.GetStaticDevices() {
+		static[target] = true
+	}
+
+	p.mu.Lock()
+	for target, device := range p.devices {
+		deviceGroup := configuration.GetDeviceGroup(target)
+		if deviceGroup != nil && deviceGroup.Polling.Enabled() && deviceGroup.Equal(device.deviceGroup) {
+			device.static = static[target]
+			device.mu.Lock()
+			device.deviceGroup = deviceGroup
+			device.mu.Unlock()
+			continue
+		}
+		log.Infof("Configuration of '%s' changed, restarting polling", target)
+		device.cancel()
+		delete(p.devices, target)
+	}
+	p.mu.Unlock()
+
+	p.startStaticDevices(configuration)
+}
+
+// startStaticDevices starts polling all static devices of the configuration which have polling enabled.
+func (p *Poller) startStaticDevices(configuration *config.Config) {
+	for _, target := range configuration.GetStaticDevices() {
+		deviceGroup := configuration.GetDeviceGroup(target)
+		if deviceGroup.Polling.Enabled() {
+			p.Start(target, deviceGroup, true)
+		}
+	}
+}
+
+// expireDynamicDevices periodically stops polling dynamic targets which were not scraped for dynamicExpiry.
+func (p *Poller) expireDynamicDevices() {
+	interval := p.dynamicExpiry / 2
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+			p.expire()
+		}
+	}
+}
+
+// expire stops polling dynamic targets which were not scraped for dynamicExpiry and closes their connections.
+func (p *Poller) expire() {
+	expired := make([]string, 0)
+	p.mu.Lock()
+	for target, device := range p.devices {
+		if device.static || time.Since(device.lastScrape) < p.dynamicExpiry {
+			continue
+		}
+		device.cancel()
+		delete(p.devices, target)
+		expired = append(expired, target)
+	}
+	p.mu.Unlock()
+
+	for _, target := range expired {
+		log.Infof("Dynamic target '%s' was not scraped for %s, stopping polling", target, p.dynamicExpiry)
+		p.connectionManager.Close(target)
+	}
+}
+
+// Stop stops polling all targets.
+func (p *Poller) Stop() {
+	p.cancel()
+}
+
+func (p *Poller) poll(ciscoCollector *CiscoCollector, target string, device *polledDevice, specificCollector collector.Collector, interval time.Duration) {
+	for {
+		timeout := interval
+		if *scrapeTimeout < timeout {
+			timeout = *scrapeTimeout
+		}
+		ctx, cancel := context.WithTimeout(device.ctx, timeout)
+		device.mu.RLock()
+		deviceGroup := device.deviceGroup
+		device.mu.RUnlock()
+		state := newDeviceState()
+		run := ciscoCollector.runCollectorWithRetries(ctx, target, deviceGroup, specificCollector, state)
+		cancel()
+
+		device.mu.Lock()
+		// The collectors of a device are polled independently, each of them only updates what it observed.
+		if device.state.merge(state) {
+			device.polled = true
+		}
+		current, found := device.snapshots[specificCollector.Name()]
+		if !found {
+			current = &snapshot{}
+			device.snapshots[specificCollector.Name()] = current
+		}
+		current.errors = run.errors
+		current.duration = run.duration
+		if run.success {
+			current.metrics = run.metrics
+			current.lastSuccess = time.Now()
+		}
+		device.mu.Unlock()
+
+		select {
+		case <-device.ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// collectForDevice sends the metrics of the last successful poll of each selected collector of target to the channel.
+// The collectors are polled using the options of the device group, the options of a selected module do not apply.
+func (p *Poller) collectForDevice(target string, deviceGroup *config.DeviceGroupConfig, selection *collectorSelection, ch chan<- prometheus.Metric, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	startTime := time.Now()
+	device := p.Start(target, deviceGroup, false)
+	device.mu.RLock()
+	defer device.mu.RUnlock()
+
+	for name, current := range device.snapshots {
+		if !selection.allows(name) {
+			continue
+		}
+		for _, metric := range current.metrics {
+			ch <- metric
+		}
+
+		labels := []string{target, name}
+		ch <- prometheus.MustNewConstMetric(errorsDesc, prometheus.GaugeValue, current.errors, labels...)
+		ch <- prometheus.MustNewConstMetric(scrapeCollectorDurationDesc, prometheus.GaugeValue, current.duration, labels...)
+		if !current.lastSuccess.IsZero() {
+			ch <- prometheus.MustNewConstMetric(lastSuccessDesc, prometheus.GaugeValue, float64(current.lastSuccess.UnixNano())/1e9, labels...)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, time.Since(startTime).Seconds(), target)
+	if device.polled {
+		device.state.collect(ch, target)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const pollerConfig = `
+devices:
+  127.0.0.1:%s
+    enabled_collectors: [cpu]
+    polling:
+      interval: %s
+`
+
+// waitFor waits until condition is met, failing the test after five seconds.
+func waitFor(t *testing.T, description string, condition func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timeout waiting until %s", description)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startTestPoller makes a new Poller the one in use and starts polling the static devices of the configuration.
+func startTestPoller(t *testing.T, device *testDevice, interval string) *Poller {
+	c := loadTestConfiguration(t, strings.Replace(strings.Replace(pollerConfig, "%s", device.groupConfig(), 1), "%s", interval, 1))
+	poller = NewPoller(connectionManager, 0)
+	t.Cleanup(poller.Stop)
+	poller.startStaticDevices(c)
+	return poller
+}
+
+// polledMetrics returns the metrics a scrape of target is answered with by the poller.
+func polledMetrics(t *testing.T, p *Poller, target string) map[string]float64 {
+	ch := make(chan prometheus.Metric, 100)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	p.collectForDevice(target, getConfiguration().GetDeviceGroup(target), nil, ch, wg)
+	close(ch)
+	return util.PrepareMetricsForTesting(ch, t)
+}
+
+func TestPollerServesLastSnapshot(t *testing.T) {
+	device := newTestDevice(t, map[string]string{"show processes cpu": testShowCPU})
+	p := startTestPoller(t, device, "1h")
+	waitFor(t, "the first poll finished", func() bool {
+		return polledMetrics(t, p, "127.0.0.1")["cisco_up{target=127.0.0.1}"] == 1
+	})
+
+	for i := 0; i < 3; i++ {
+		recorder := httptest.NewRecorder()
+		handleMetricsRequest(recorder, httptest.NewRequest("GET", "/metrics?target=127.0.0.1", nil))
+		if body := recorder.Body.String(); !strings.Contains(body, `cisco_cpu_one_minute_percent{target="127.0.0.1"} 4`) {
+			t.Errorf("Expected the polled CPU utilization to be served, got %s", body)
+		}
+	}
+	if count := device.count("show processes cpu"); count != 1 {
+		t.Errorf("Expected scrapes to be answered without contacting the device, the command ran %d times", count)
+	}
+}
+
+func TestPollerLastSuccess(t *testing.T) {
+	device := newTestDevice(t, map[string]string{"show processes cpu": testShowCPU})
+	p := startTestPoller(t, device, "200ms")
+	lastSuccess := "cisco_collector_last_success_timestamp_seconds{collector=cpu,target=127.0.0.1}"
+	errors := "cisco_collector_errors{collector=cpu,target=127.0.0.1}"
+
+	var first float64
+	waitFor(t, "the first poll succeeded", func() bool {
+		first = polledMetrics(t, p, "127.0.0.1")[lastSuccess]
+		return first > 0
+	})
+
+	device.setOutput("show processes cpu", "")
+	waitFor(t, "a poll failed", func() bool {
+		return polledMetrics(t, p, "127.0.0.1")[errors] > 0
+	})
+	got := polledMetrics(t, p, "127.0.0.1")
+	if got[lastSuccess] != first {
+		t.Errorf("Expected the last success to stay at %v after a failed poll, got %v", first, got[lastSuccess])
+	}
+	if got["cisco_cpu_one_minute_percent{target=127.0.0.1}"] != 4 {
+		t.Errorf("Expected the metrics of the last successful poll to be kept, got %v", got)
+	}
+
+	device.setOutput("show processes cpu", testShowCPU)
+	waitFor(t, "a poll succeeded again", func() bool {
+		return polledMetrics(t, p, "127.0.0.1")[lastSuccess] > first
+	})
+}
+
+func TestPollerExpiresDynamicTargets(t *testing.T) {
+	device := newTestDevice(t, map[string]string{"show processes cpu": testShowCPU})
+	c := loadTestConfiguration(t, `
+devices:
+  127.0.0.1:`+device.groupConfig()+`
+    enabled_collectors: [cpu]
+    polling:
+      interval: 1h
+  "localhost":`+device.groupConfig()+`
+    enabled_collectors: [cpu]
+    polling:
+      interval: 1h
+`)
+	p := NewPoller(connectionManager, time.Hour)
+	defer p.Stop()
+	static := p.Start("127.0.0.1", c.GetDeviceGroup("127.0.0.1"), true)
+	dynamic := p.Start("localhost", c.GetDeviceGroup("localhost"), false)
+	waitFor(t, "the dynamic target is connected", func() bool {
+		return connectionManager.ConnectionState("localhost").Connected
+	})
+
+	p.expire()
+	if p.devices["localhost"] != dynamic {
+		t.Fatalf("Expected the recently scraped dynamic target to be polled")
+	}
+
+	p.mu.Lock()
+	static.lastScrape = time.Now().Add(-2 * time.Hour)
+	dynamic.lastScrape = time.Now().Add(-2 * time.Hour)
+	p.mu.Unlock()
+	p.expire()
+	if _, found := p.devices["localhost"]; found {
+		t.Errorf("Expected the dynamic target to expire")
+	}
+	if dynamic.ctx.Err() == nil {
+		t.Errorf("Expected polling of the dynamic target to be stopped")
+	}
+	if connectionManager.ConnectionState("localhost").Connected {
+		t.Errorf("Expected the connection to the dynamic target to be closed")
+	}
+	if p.devices["127.0.0.1"] != static || static.ctx.Err() != nil {
+		t.Errorf("Expected the static target not to expire")
+	}
+}
+
+func TestPollerReload(t *testing.T) {
+	device := newTestDevice(t, map[string]string{"show processes cpu": testShowCPU})
+	groups := map[string]string{
+		"127.0.0.1":   "interval: 1h",
+		"127.0.0.2":   "interval: 1h",
+		"127.0.0.3":   "interval: 1h",
+		"*.localhost": "interval: 1h",
+	}
+	render := func() string {
+		content := "devices:\n"
+		for name, polling := range groups {
+			content += "  \"" + name + "\":" + device.groupConfig() + "\n    enabled_collectors: [cpu]\n    polling:\n      " + polling + "\n"
+		}
+		return content
+	}
+	c := loadTestConfiguration(t, render())
+	p := NewPoller(connectionManager, 0)
+	defer p.Stop()
+	p.startStaticDevices(c)
+	dynamic := p.Start("router.localhost", c.GetDeviceGroup("router.localhost"), false)
+	unchanged, changed, disabled := p.devices["127.0.0.1"], p.devices["127.0.0.2"], p.devices["127.0.0.3"]
+
+	groups["127.0.0.2"] = "interval: 2h"
+	groups["127.0.0.3"] = "interval: 0s"
+	c = loadTestConfiguration(t, render())
+	p.Reload(c)
+
+	if p.devices["127.0.0.1"] != unchanged || unchanged.ctx.Err() != nil {
+		t.Errorf("Expected the unchanged target to keep being polled")
+	}
+	if p.devices["router.localhost"] != dynamic || dynamic.static {
+		t.Errorf("Expected the unchanged dynamic target to keep being polled as dynamic target")
+	}
+	if restarted := p.devices["127.0.0.2"]; restarted == nil || restarted == changed || changed.ctx.Err() == nil {
+		t.Errorf("Expected the changed target to be polled from scratch")
+	} else if interval := restarted.deviceGroup.Polling.Interval; interval != 2*time.Hour {
+		t.Errorf("Expected the changed target to be polled every 2h, got %s", interval)
+	}
+	if _, found := p.devices["127.0.0.3"]; found || disabled.ctx.Err() == nil {
+		t.Errorf("Expected the target no longer configured for polling to be dropped")
+	}
+}
@@ -1,6 +1,7 @@
 package pppoe
 
 import (
+	"context"
 	"regexp"
 
 	"gitlab.com/wobcom/cisco-exporter/collector"
@@ -44,14 +45,20 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- pppoeStatisticsDesc
 }
 
+// Counter is a PPPoE event or statistic counted in total and since the counters were cleared.
+type Counter struct {
+	Section      string
+	Name         string
+	Total        float64
+	SinceCleared float64
+}
+
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
 	sshCtx := connector.NewSSHCommandContext("show pppoe statistics")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	eventsRegexp := regexp.MustCompile(`PPPoE Events`)
 	statisticsRegexp := regexp.MustCompile(`PPPoE Statistics`)
@@ -64,11 +71,11 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 		select {
 		case <-sshCtx.Done:
 			if matchesCount == 0 {
-				ctx.Errors <- errors.New("No cpu metric was extracted")
+				result.AddError(errors.New("No cpu metric was extracted"))
 			}
-			return
+			return result
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping cpu usage: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping cpu usage: %v", err))
 		case line := <-sshCtx.Output:
 			if eventsRegexp.MatchString(line) {
 				state = 1
@@ -82,15 +89,20 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 					matchesCount++
 				}
 
-				labelsTotal := append(ctx.LabelValues, "total", matches[1])
-				labelsSinceCleared := append(ctx.LabelValues, "since_cleared", matches[1])
+				counter := &Counter{Name: matches[1], Total: util.Str2float64(matches[2]), SinceCleared: util.Str2float64(matches[3])}
+				labelsTotal := append(collectCtx.LabelValues, "total", counter.Name)
+				labelsSinceCleared := append(collectCtx.LabelValues, "since_cleared", counter.Name)
 
 				if state == 1 {
-					ctx.Metrics <- prometheus.MustNewConstMetric(pppoeEventsDesc, prometheus.GaugeValue, util.Str2float64(matches[2]), labelsTotal...)
-					ctx.Metrics <- prometheus.MustNewConstMetric(pppoeEventsDesc, prometheus.GaugeValue, util.Str2float64(matches[3]), labelsSinceCleared...)
+					counter.Section = "events"
+					collectCtx.Record(counter)
+					result.AddMetric(prometheus.MustNewConstMetric(pppoeEventsDesc, prometheus.GaugeValue, counter.Total, labelsTotal...))
+					result.AddMetric(prometheus.MustNewConstMetric(pppoeEventsDesc, prometheus.GaugeValue, counter.SinceCleared, labelsSinceCleared...))
 				} else if state == 2 {
-					ctx.Metrics <- prometheus.MustNewConstMetric(pppoeStatisticsDesc, prometheus.GaugeValue, util.Str2float64(matches[2]), labelsTotal...)
-					ctx.Metrics <- prometheus.MustNewConstMetric(pppoeStatisticsDesc, prometheus.GaugeValue, util.Str2float64(matches[3]), labelsSinceCleared...)
+					counter.Section = "statistics"
+					collectCtx.Record(counter)
+					result.AddMetric(prometheus.MustNewConstMetric(pppoeStatisticsDesc, prometheus.GaugeValue, counter.Total, labelsTotal...))
+					result.AddMetric(prometheus.MustNewConstMetric(pppoeStatisticsDesc, prometheus.GaugeValue, counter.SinceCleared, labelsSinceCleared...))
 				}
 			}
 		}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+)
+
+var (
+	configurationMutex sync.RWMutex
+	// reloadMutex serializes reloads triggered by SIGHUP and /-/reload.
+	reloadMutex sync.Mutex
+
+	configReloadSuccess = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "exporter_config_last_reload_successful",
+		Help: "Whether the last configuration reload attempt was successful",
+	})
+	configReloadSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: prefix + "exporter_config_last_reload_success_timestamp_seconds",
+		Help: "Timestamp of the last successful configuration reload",
+	})
+)
+
+// getConfiguration returns the configuration currently in use.
+func getConfiguration() *config.Config {
+	configurationMutex.RLock()
+	defer configurationMutex.RUnlock()
+	return configuration
+}
+
+// reloadConfiguration loads the configuration file and replaces the configuration in use, if it is valid.
+// Connections and background polling are only restarted for devices whose configuration changed.
+func reloadConfiguration() error {
+	reloadMutex.Lock()
+	defer reloadMutex.Unlock()
+
+	c, err := loadConfiguration()
+	if err != nil {
+		configReloadSuccess.Set(0)
+		return err
+	}
+
+	configurationMutex.Lock()
+	configuration = c
+	configurationMutex.Unlock()
+
+	connectionManager.UpdateDevices(c)
+	poller.Reload(c)
+
+	configReloadSuccess.Set(1)
+	configReloadSeconds.SetToCurrentTime()
+	return nil
+}
+
+func reloadOnSIGHUP() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		log.Infof("Received SIGHUP, reloading configuration")
+		if err := reloadConfiguration(); err != nil {
+			log.Errorf("Failed to reload configuration: %v", err)
+		}
+	}
+}
+
+// handleReloadRequest reloads the configuration on POST requests if -web.enable-lifecycle is set.
+func handleReloadRequest(w http.ResponseWriter, request *http.Request) {
+	if !*webEnableLifecycle {
+		http.Error(w, "Lifecycle API is not enabled", http.StatusForbidden)
+		return
+	}
+	if request.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST requests are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := reloadConfiguration(); err != nil {
+		log.Errorf("Failed to reload configuration: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// writeConfigFile writes content to a temporary file and makes it the configuration file to reload.
+func writeConfigFile(t *testing.T, content string) {
+	dir, err := ioutil.TempDir("", "cisco-exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "cisco-exporter.yml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	previous := *configFile
+	*configFile = path
+	t.Cleanup(func() {
+		*configFile = previous
+		os.RemoveAll(dir)
+	})
+}
+
+// startReloadTest loads content as the configuration in use and starts a poller for reloads to update.
+func startReloadTest(t *testing.T, content string) {
+	loadTestConfiguration(t, content)
+	poller = NewPoller(connectionManager, 0)
+	t.Cleanup(poller.Stop)
+}
+
+func TestReloadInvalidConfiguration(t *testing.T) {
+	startReloadTest(t, statusConfig)
+	previous := getConfiguration()
+	writeConfigFile(t, "devices:\n  router.example.com:\n    enabled_collectors: [unknown]\n")
+
+	if err := reloadConfiguration(); err == nil {
+		t.Fatalf("Expected the invalid configuration to be rejected")
+	}
+	if getConfiguration() != previous {
+		t.Errorf("Expected the previous configuration to stay in use")
+	}
+	if success := testutil.ToFloat64(configReloadSuccess); success != 0 {
+		t.Errorf("Expected the reload to be reported as failed, got %v", success)
+	}
+}
+
+func TestReloadConfiguration(t *testing.T) {
+	device := newTestDevice(t, nil)
+	content := `
+devices:
+  127.0.0.1:` + device.groupConfig() + `
+    enabled_collectors: [cpu]
+  localhost:` + device.groupConfig() + `
+    enabled_collectors: [cpu]
+  127.0.0.2:` + device.groupConfig() + `
+    enabled_collectors: [cpu]
+`
+	startReloadTest(t, content)
+	for _, target := range []string{"127.0.0.1", "localhost"} {
+		if _, err := connectionManager.GetConnection(target, getConfiguration().GetDeviceGroup(target)); err != nil {
+			t.Fatalf("Could not connect to %s: %v", target, err)
+		}
+	}
+
+	// localhost changes its connection parameters, 127.0.0.2 is removed.
+	writeConfigFile(t, `
+devices:
+  127.0.0.1:`+device.groupConfig()+`
+    enabled_collectors: [cpu, memory]
+  localhost:`+strings.Replace(device.groupConfig(), "connect_timeout: 5", "connect_timeout: 6", 1)+`
+    enabled_collectors: [cpu]
+`)
+	if err := reloadConfiguration(); err != nil {
+		t.Fatalf("Expected the configuration to be reloaded, got %v", err)
+	}
+	if success := testutil.ToFloat64(configReloadSuccess); success != 1 {
+		t.Errorf("Expected the reload to be reported as successful, got %v", success)
+	}
+
+	c := getConfiguration()
+	if collectors := c.GetDeviceGroup("127.0.0.1").EnabledCollectors; len(collectors) != 2 {
+		t.Errorf("Expected the reloaded collectors to be in use, got %v", collectors)
+	}
+	if c.GetDeviceGroup("127.0.0.2") != nil {
+		t.Errorf("Expected the removed device to be no longer configured")
+	}
+	if !connectionManager.ConnectionState("127.0.0.1").Connected {
+		t.Errorf("Expected the connection to the unchanged device to be kept")
+	}
+	if connectionManager.ConnectionState("localhost").Connected {
+		t.Errorf("Expected the connection to the changed device to be terminated")
+	}
+}
+
+func TestReloadRequest(t *testing.T) {
+	startReloadTest(t, statusConfig)
+	writeConfigFile(t, statusConfig)
+	previous := *webEnableLifecycle
+	defer func() { *webEnableLifecycle = previous }()
+
+	tests := []struct {
+		lifecycle bool
+		method    string
+		expected  int
+	}{
+		{false, http.MethodPost, http.StatusForbidden},
+		{false, http.MethodGet, http.StatusForbidden},
+		{true, http.MethodGet, http.StatusMethodNotAllowed},
+		{true, http.MethodPost, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		*webEnableLifecycle = test.lifecycle
+		recorder := httptest.NewRecorder()
+		handleReloadRequest(recorder, httptest.NewRequest(test.method, "/-/reload", nil))
+		if recorder.Code != test.expected {
+			t.Errorf("%s with lifecycle %v: Expected status %d, got %d", test.method, test.lifecycle, test.expected, recorder.Code)
+		}
+		if test.expected == http.StatusMethodNotAllowed && recorder.Header().Get("Allow") != http.MethodPost {
+			t.Errorf("Expected the allowed method to be listed, got %q", recorder.Header().Get("Allow"))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package resources
+
+import (
+	"context"
+	"regexp"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const prefix string = "cisco_asa_resource_"
+
+var (
+	currentDesc *prometheus.Desc
+	peakDesc    *prometheus.Desc
+	limitDesc   *prometheus.Desc
+	deniedDesc  *prometheus.Desc
+)
+
+// Collector gathers the resource usage of an ASA by running `show resource usage`.
+type Collector struct {
+}
+
+// NewCollector returns a new resources.Collector instance.
+func NewCollector() collector.Collector {
+	return &Collector{}
+}
+
+// Name implements the collector.Collector interface's Name function
+func (*Collector) Name() string {
+	return "resources"
+}
+
+func init() {
+	l := []string{"target", "context", "resource"}
+	currentDesc = prometheus.NewDesc(prefix+"current", "Current usage of the resource", l, nil)
+	peakDesc = prometheus.NewDesc(prefix+"peak", "Highest usage of the resource since the last reboot or clear", l, nil)
+	limitDesc = prometheus.NewDesc(prefix+"limit", "Limit of the resource, missing if unlimited", l, nil)
+	deniedDesc = prometheus.NewDesc(prefix+"denied_total", "Number of times the resource was denied due to the limit", l, nil)
+}
+
+// Describe implements the collector.Collector interface's Describe function
+func (*Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- currentDesc
+	ch <- peakDesc
+	ch <- limitDesc
+	ch <- deniedDesc
+}
+
+// Collect implements the collector.Collector interface's Collect function.
+// In the system context of an ASA in multiple context mode, the usage of every security context is listed.
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	sshCtx := connector.NewSSHCommandContext("show resource usage")
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
+	c.parse(sshCtx, collectCtx, result)
+	return result
+}
+
+// Usage is the usage of a resource by a security context. Limit is nil if the resource is unlimited.
+type Usage struct {
+	Context  string
+	Resource string
+	Current  float64
+	Peak     float64
+	Limit    *float64
+	Denied   float64
+}
+
+func (c *Collector) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, result *collector.Result) {
+	resourceRegexp := regexp.MustCompile(`^(\S.*?)\s+(\d+)\s+(\d+)\s+(\d+|unlimited|N/A)\s+(\d+)\s+(\S+)\s*$`)
+
+	matched := false
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			if !matched {
+				result.AddError(errors.New("No resource usage was extracted"))
+			}
+			return
+		case err := <-sshCtx.Errors:
+			result.AddError(errors.Wrapf(err, "Error scraping resource usage: %v", err))
+		case line := <-sshCtx.Output:
+			matches := resourceRegexp.FindStringSubmatch(line)
+			if matches == nil {
+				continue
+			}
+			matched = true
+			usage := &Usage{
+				Context:  matches[6],
+				Resource: matches[1],
+				Current:  util.Str2float64(matches[2]),
+				Peak:     util.Str2float64(matches[3]),
+				Denied:   util.Str2float64(matches[5]),
+			}
+			if matches[4] != "unlimited" && matches[4] != "N/A" {
+				limit := util.Str2float64(matches[4])
+				usage.Limit = &limit
+			}
+			generateMetrics(collectCtx, result, usage)
+		}
+	}
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, usage *Usage) {
+	collectCtx.Record(usage)
+	l := append(collectCtx.LabelValues, usage.Context, usage.Resource)
+	result.AddMetric(prometheus.MustNewConstMetric(currentDesc, prometheus.GaugeValue, usage.Current, l...))
+	result.AddMetric(prometheus.MustNewConstMetric(peakDesc, prometheus.GaugeValue, usage.Peak, l...))
+	if usage.Limit != nil {
+		result.AddMetric(prometheus.MustNewConstMetric(limitDesc, prometheus.GaugeValue, *usage.Limit, l...))
+	}
+	result.AddMetric(prometheus.MustNewConstMetric(deniedDesc, prometheus.CounterValue, usage.Denied, l...))
+}
@@ -0,0 +1,34 @@
+package resources
+
+import (
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const showResourceUsage = `Resource              Current         Peak      Limit        Denied Context
+SysLog [rate]               1           18 unlimited             0 admin
+Conns                     123          456 unlimited             0 admin
+Xlates                      0            4 unlimited             0 admin
+Other VPN Sessions          2            5         250           3 customer-a
+Conns [rate]               17           80        1000          12 customer-a`
+
+func TestParse(t *testing.T) {
+	c := &Collector{}
+	ctx := util.PrepareOutputForTesting(showResourceUsage)
+	result := collector.NewResult()
+	c.parse(&ctx, &collector.CollectContext{LabelValues: []string{"test.test"}}, result)
+
+	got := util.PrepareResultForTesting(result, t)
+	util.CompareMetrics(got, map[string]float64{
+		"cisco_asa_resource_current{context=admin,resource=Conns,target=test.test}":                  123,
+		"cisco_asa_resource_peak{context=admin,resource=Conns,target=test.test}":                     456,
+		"cisco_asa_resource_current{context=admin,resource=SysLog [rate],target=test.test}":          1,
+		"cisco_asa_resource_limit{context=customer-a,resource=Other VPN Sessions,target=test.test}":  250,
+		"cisco_asa_resource_denied_total{context=customer-a,resource=Conns [rate],target=test.test}": 12,
+	}, t)
+	if _, found := got["cisco_asa_resource_limit{context=admin,resource=Conns,target=test.test}"]; found {
+		t.Errorf("Expected no limit for unlimited resources")
+	}
+}
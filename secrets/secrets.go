@@ -0,0 +1,127 @@
+// Package secrets resolves secrets referenced in the configuration file, like passwords read from files or printed by helper commands.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Provider resolves a reference to a secret. The meaning of the reference depends on the provider, e.g. a path or a command.
+type Provider interface {
+	Resolve(reference string) (string, error)
+}
+
+// ProviderFunc allows using a function as Provider.
+type ProviderFunc func(reference string) (string, error)
+
+// Resolve calls f(reference).
+func (f ProviderFunc) Resolve(reference string) (string, error) {
+	return f(reference)
+}
+
+var (
+	providersMutex sync.RWMutex
+	providers      = map[string]Provider{
+		"env":  ProviderFunc(resolveEnv),
+		"exec": &ExecProvider{Timeout: 10 * time.Second},
+		"file": ProviderFunc(resolveFile),
+	}
+)
+
+// Register makes a provider available under the given name. A provider already registered under that name is replaced.
+func Register(name string, provider Provider) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+	providers[name] = provider
+}
+
+func getProvider(name string) (Provider, bool) {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+	provider, found := providers[name]
+	return provider, found
+}
+
+// resolveEnv returns the value of the environment variable reference.
+func resolveEnv(reference string) (string, error) {
+	value, found := os.LookupEnv(reference)
+	if !found {
+		return "", fmt.Errorf("environment variable '%s' is not set", reference)
+	}
+	return value, nil
+}
+
+// resolveFile returns the content of the file at reference without trailing newlines.
+func resolveFile(reference string) (string, error) {
+	content, err := ioutil.ReadFile(reference)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// ExecProvider runs the reference as shell command and returns what it prints to stdout without trailing newlines.
+type ExecProvider struct {
+	Timeout time.Duration
+}
+
+// Resolve runs the command.
+func (p *ExecProvider) Resolve(reference string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", reference)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", errors.Wrapf(err, "'%s' failed: %s", reference, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", errors.Wrapf(err, "'%s' failed", reference)
+	}
+	return strings.TrimRight(string(output), "\r\n"), nil
+}
+
+// Cache resolves secrets, resolving each reference only once.
+// A new Cache is used for every configuration loaded, so that secrets are refreshed on reload.
+type Cache struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewCache returns an empty Cache.
+func NewCache() *Cache {
+	return &Cache{
+		secrets: make(map[string]string),
+	}
+}
+
+// Resolve resolves reference using the named provider, if it has not been resolved by this cache before.
+// Errors never contain the secret.
+func (c *Cache) Resolve(provider, reference string) (string, error) {
+	key := provider + "\x00" + reference
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if secret, found := c.secrets[key]; found {
+		return secret, nil
+	}
+
+	p, found := getProvider(provider)
+	if !found {
+		return "", fmt.Errorf("unknown secret provider '%s'", provider)
+	}
+	secret, err := p.Resolve(reference)
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not resolve secret using provider '%s'", provider)
+	}
+	c.secrets[key] = secret
+	return secret, nil
+}
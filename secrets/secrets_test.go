@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	secretFile := filepath.Join(dir, "secret")
+	if err := ioutil.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("SECRETS_TEST_PASSWORD", "from-env")
+	defer os.Unsetenv("SECRETS_TEST_PASSWORD")
+
+	tests := []struct {
+		provider  string
+		reference string
+		expected  string
+	}{
+		{"file", secretFile, "from-file"},
+		{"env", "SECRETS_TEST_PASSWORD", "from-env"},
+		{"exec", "echo from-exec", "from-exec"},
+	}
+
+	cache := NewCache()
+	for _, test := range tests {
+		secret, err := cache.Resolve(test.provider, test.reference)
+		if err != nil {
+			t.Errorf("%s: %v", test.provider, err)
+			continue
+		}
+		if secret != test.expected {
+			t.Errorf("%s: got '%s', want '%s'", test.provider, secret, test.expected)
+		}
+	}
+
+	for _, test := range []struct{ provider, reference string }{
+		{"file", filepath.Join(dir, "missing")},
+		{"env", "SECRETS_TEST_UNSET"},
+		{"exec", "exit 1"},
+		{"vault", "secret/monitoring"},
+	} {
+		if _, err := cache.Resolve(test.provider, test.reference); err == nil {
+			t.Errorf("%s: expected an error resolving '%s'", test.provider, test.reference)
+		}
+	}
+}
+
+func TestCacheResolvesOnce(t *testing.T) {
+	calls := 0
+	Register("counting", ProviderFunc(func(reference string) (string, error) {
+		calls++
+		return reference, nil
+	}))
+
+	cache := NewCache()
+	for i := 0; i < 3; i++ {
+		if secret, _ := cache.Resolve("counting", "secret"); secret != "secret" {
+			t.Fatalf("Unexpected secret '%s'", secret)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Provider was called %d times, want 1", calls)
+	}
+
+	NewCache().Resolve("counting", "secret")
+	if calls != 2 {
+		t.Errorf("A new cache must resolve the secret again")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+// collectorSelection restricts the collectors run for a scrape to the collectors of a module and the ones listed in collect[].
+// Only collectors enabled for the device group are run either way.
+type collectorSelection struct {
+	module  *config.ModuleConfig
+	collect map[string]bool
+}
+
+// parseCollectorSelection reads the module and collect[] query parameters.
+// It returns nil if neither is set, so that all enabled collectors are run.
+func parseCollectorSelection(query url.Values, configuration *config.Config) (*collectorSelection, error) {
+	moduleName := query.Get("module")
+	names := query["collect[]"]
+	if moduleName == "" && len(names) == 0 {
+		return nil, nil
+	}
+
+	selection := &collectorSelection{}
+	if moduleName != "" {
+		selection.module = configuration.GetModule(moduleName)
+		if selection.module == nil {
+			return nil, fmt.Errorf("Unknown module '%s'", moduleName)
+		}
+	}
+	if len(names) > 0 {
+		known := make(map[string]bool)
+		for _, name := range collectorNames() {
+			known[name] = true
+		}
+		selection.collect = make(map[string]bool, len(names))
+		for _, name := range names {
+			if !known[name] {
+				return nil, fmt.Errorf("Unknown collector '%s'", name)
+			}
+			selection.collect[name] = true
+		}
+	}
+	return selection, nil
+}
+
+// allows returns whether the collector is selected. A nil selection allows all collectors.
+func (s *collectorSelection) allows(name string) bool {
+	if s == nil {
+		return true
+	}
+	if s.collect != nil && !s.collect[name] {
+		return false
+	}
+	if s.module == nil {
+		return true
+	}
+	for _, collector := range s.module.Collectors {
+		if collector == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getModule returns the selected module, nil if none is selected.
+func (s *collectorSelection) getModule() *config.ModuleConfig {
+	if s == nil {
+		return nil
+	}
+	return s.module
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+)
+
+const selectionConfig = `
+devices:
+  router.example.com:
+    username: monitoring
+    password: secret
+    enabled_collectors: [cpu, memory, environment, interfaces]
+modules:
+  hardware:
+    collectors: [environment, cpu]
+`
+
+func TestCollectorSelection(t *testing.T) {
+	c, err := config.Load(strings.NewReader(selectionConfig))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		err      string
+		allowed  []string
+		excluded []string
+	}{
+		{name: "none", query: "", allowed: []string{"cpu", "memory", "environment", "interfaces"}},
+		{name: "include", query: "collect[]=cpu&collect[]=memory", allowed: []string{"cpu", "memory"}, excluded: []string{"environment", "interfaces"}},
+		{name: "unknown collector", query: "collect[]=cpu&collect[]=unknown", err: "Unknown collector 'unknown'"},
+		{name: "module", query: "module=hardware", allowed: []string{"environment", "cpu"}, excluded: []string{"memory", "interfaces"}},
+		{name: "unknown module", query: "module=unknown", err: "Unknown module 'unknown'"},
+		{name: "module and collectors", query: "module=hardware&collect[]=cpu&collect[]=memory", allowed: []string{"cpu"}, excluded: []string{"environment", "memory", "interfaces"}},
+	}
+
+	for _, test := range tests {
+		query, err := url.ParseQuery(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		selection, err := parseCollectorSelection(query, c)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("%s: Expected error %q, got %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Unexpected error %v", test.name, err)
+			continue
+		}
+		for _, name := range test.allowed {
+			if !selection.allows(name) {
+				t.Errorf("%s: Expected %s to be allowed", test.name, name)
+			}
+		}
+		for _, name := range test.excluded {
+			if selection.allows(name) {
+				t.Errorf("%s: Expected %s to be excluded", test.name, name)
+			}
+		}
+	}
+}
+
+func TestCollectorSelectionModule(t *testing.T) {
+	c, err := config.Load(strings.NewReader(selectionConfig))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+
+	var none *collectorSelection
+	if none.getModule() != nil {
+		t.Errorf("Expected no module without a selection")
+	}
+	selection, err := parseCollectorSelection(url.Values{"module": []string{"hardware"}}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if module := selection.getModule(); module == nil || module.Name != "hardware" {
+		t.Errorf("Expected the hardware module to be selected, got %+v", module)
+	}
+}
+
+func TestCollectorSelectionOfDevice(t *testing.T) {
+	c := loadTestConfiguration(t, selectionConfig)
+	// vlans is not enabled for the device group, so it is not run even if selected.
+	selection, err := parseCollectorSelection(url.Values{"collect[]": []string{"cpu", "vlans"}}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciscoCollector := newCiscoCollector(context.Background(), []string{"router.example.com"}, connectionManager, nil, selection)
+	names := []string{}
+	for _, collector := range ciscoCollector.collectorsForDevice["router.example.com"] {
+		names = append(names, collector.Name())
+	}
+	if len(names) != 1 || names[0] != "cpu" {
+		t.Errorf("Expected only cpu to be run, got %v", names)
+	}
+}
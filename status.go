@@ -0,0 +1,293 @@
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+
+	"github.com/prometheus/common/log"
+	"gopkg.in/yaml.v3"
+)
+
+// dynamicTargetRetention is how long dynamic targets are listed on the status page after their last scrape.
+const dynamicTargetRetention = time.Hour
+
+var targetStatuses = newStatusTracker()
+
+// collectorRecord is the outcome of the last run of a collector against a target.
+type collectorRecord struct {
+	lastRun     time.Time
+	duration    float64
+	lastError   error
+	lastSuccess time.Time
+}
+
+// statusTracker records the last run of every collector by target, for the status page.
+type statusTracker struct {
+	mu      sync.Mutex
+	targets map[string]map[string]*collectorRecord
+	lastRun map[string]time.Time
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{
+		targets: make(map[string]map[string]*collectorRecord),
+		lastRun: make(map[string]time.Time),
+	}
+}
+
+// record records the outcome of a collector run against the target.
+func (s *statusTracker) record(target string, collectorName string, run *collectorRun) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.lastRun[target] = now
+	collectors, found := s.targets[target]
+	if !found {
+		collectors = make(map[string]*collectorRecord)
+		s.targets[target] = collectors
+	}
+	record, found := collectors[collectorName]
+	if !found {
+		record = &collectorRecord{}
+		collectors[collectorName] = record
+	}
+	record.lastRun = now
+	record.duration = run.duration
+	record.lastError = run.lastError
+	if run.success {
+		record.lastSuccess = now
+	}
+}
+
+// recentTargets returns the targets run within dynamicTargetRetention, sorted by name.
+func (s *statusTracker) recentTargets() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	targets := make([]string, 0)
+	for target, lastRun := range s.lastRun {
+		if time.Since(lastRun) > dynamicTargetRetention {
+			delete(s.lastRun, target)
+			delete(s.targets, target)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// collector returns a copy of the last run of the collector against the target, nil if it did not run yet.
+func (s *statusTracker) collector(target string, collectorName string) *collectorRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, found := s.targets[target][collectorName]
+	if !found {
+		return nil
+	}
+	copied := *record
+	return &copied
+}
+
+// lastScrape returns the time a collector last ran against the target, the zero time if none did.
+func (s *statusTracker) lastScrape(target string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRun[target]
+}
+
+// targetStatus is the state of a target as listed on the status page and by /api/v1/targets.
+type targetStatus struct {
+	Target            string                 `json:"target"`
+	Static            bool                   `json:"static"`
+	DeviceGroup       string                 `json:"device_group"`
+	DeviceGroupConfig map[string]interface{} `json:"device_group_config"`
+	OSVersion         string                 `json:"os_version"`
+	Version           string                 `json:"version,omitempty"`
+	Platform          string                 `json:"platform,omitempty"`
+	Connection        connectionStatus       `json:"connection"`
+	LastScrape        *time.Time             `json:"last_scrape,omitempty"`
+	Collectors        []collectorStatus      `json:"collectors"`
+}
+
+// connectionStatus is the state of the connection to a target.
+type connectionStatus struct {
+	// State is connected, disconnected, failed if the last connection attempt failed, or unknown if none was made yet.
+	State          string     `json:"state"`
+	Transport      string     `json:"transport"`
+	Established    *time.Time `json:"established,omitempty"`
+	UptimeSeconds  float64    `json:"uptime_seconds,omitempty"`
+	PrivilegeLevel *int       `json:"privilege_level,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// collectorStatus is the outcome of the last run of a collector against a target.
+type collectorStatus struct {
+	Name            string     `json:"name"`
+	LastRun         *time.Time `json:"last_run,omitempty"`
+	DurationSeconds float64    `json:"duration_seconds"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastSuccess     *time.Time `json:"last_success,omitempty"`
+}
+
+// targetStatusList returns the state of all static targets and the dynamic targets scraped recently.
+func targetStatusList(configuration *config.Config) []targetStatus {
+	static := configuration.GetStaticDevices()
+	targets := append([]string{}, static...)
+	isStatic := make(map[string]bool, len(static))
+	for _, target := range static {
+		isStatic[target] = true
+	}
+	for _, target := range targetStatuses.recentTargets() {
+		if !isStatic[target] {
+			targets = append(targets, target)
+		}
+	}
+
+	statuses := make([]targetStatus, 0, len(targets))
+	for _, target := range targets {
+		deviceGroup := configuration.GetDeviceGroup(target)
+		if deviceGroup == nil {
+			continue
+		}
+		statuses = append(statuses, newTargetStatus(target, isStatic[target], deviceGroup))
+	}
+	return statuses
+}
+
+func newTargetStatus(target string, static bool, deviceGroup *config.DeviceGroupConfig) targetStatus {
+	status := targetStatus{
+		Target:      target,
+		Static:      static,
+		DeviceGroup: deviceGroup.Name,
+		OSVersion:   "unknown",
+		Connection:  connectionStatus{State: "unknown", Transport: deviceGroup.Transport},
+		Collectors:  make([]collectorStatus, 0, len(deviceGroup.EnabledCollectors)),
+	}
+	options, err := deviceGroup.Redacted()
+	if err != nil {
+		log.Errorf("Could not render the configuration of '%s': %v", deviceGroup.Name, err)
+	}
+	status.DeviceGroupConfig = options
+	if deviceGroup.OSVersion != config.INVALID {
+		status.OSVersion = deviceGroup.OSVersion.String()
+	}
+
+	state := connectionManager.ConnectionState(target)
+	if state.Info != nil {
+		status.Connection.State = "disconnected"
+		if state.Connected {
+			status.Connection.State = "connected"
+			established := state.Info.Established
+			status.Connection.Established = &established
+			status.Connection.UptimeSeconds = time.Since(established).Seconds()
+		}
+		if state.Info.PrivilegeLevel >= 0 {
+			privilegeLevel := state.Info.PrivilegeLevel
+			status.Connection.PrivilegeLevel = &privilegeLevel
+		}
+		if state.Info.DeviceInfo.OSVersion != config.INVALID {
+			status.OSVersion = state.Info.DeviceInfo.OSVersion.String()
+		}
+		status.Version = state.Info.DeviceInfo.Version
+		status.Platform = state.Info.DeviceInfo.Platform
+	}
+	if state.LastError != nil {
+		status.Connection.State = "failed"
+		status.Connection.LastError = state.LastError.Error()
+	}
+	if lastScrape := targetStatuses.lastScrape(target); !lastScrape.IsZero() {
+		status.LastScrape = &lastScrape
+	}
+
+	for _, name := range deviceGroup.EnabledCollectors {
+		collector := collectorStatus{Name: name}
+		if record := targetStatuses.collector(target, name); record != nil {
+			collector.LastRun = &record.lastRun
+			collector.DurationSeconds = record.duration
+			if record.lastError != nil {
+				collector.LastError = record.lastError.Error()
+			}
+			if !record.lastSuccess.IsZero() {
+				collector.LastSuccess = &record.lastSuccess
+			}
+		}
+		status.Collectors = append(status.Collectors, collector)
+	}
+	return status
+}
+
+// handleTargetsRequest lists the state of all targets as JSON.
+func handleTargetsRequest(w http.ResponseWriter, request *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		Status string         `json:"status"`
+		Data   []targetStatus `json:"data"`
+	}{"success", targetStatusList(getConfiguration())})
+}
+
+var statusTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"since": func(t *time.Time) string {
+		if t == nil {
+			return "never"
+		}
+		return time.Since(*t).Truncate(time.Second).String() + " ago"
+	},
+	"yaml": func(options map[string]interface{}) string {
+		content, _ := yaml.Marshal(options)
+		return string(content)
+	},
+}).Parse(`<html>
+<head>
+<title>cisco-exporter (Version {{.Version}})</title>
+<style>
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 2px 6px; text-align: left; vertical-align: top; }
+.connected { color: green; } .failed, .error { color: red; }
+</style>
+</head>
+<body>
+<h1>cisco-exporter</h1>
+<p><a href="{{.MetricsPath}}">Metrics</a> - <a href="/api/v1/targets">Targets as JSON</a></p>
+{{range .Targets}}
+<h2>{{.Target}}</h2>
+<table>
+<tr><th>Device group</th><td>{{.DeviceGroup}}{{if not .Static}} (dynamic){{end}} <details><summary>Configuration</summary><pre>{{yaml .DeviceGroupConfig}}</pre></details></td></tr>
+<tr><th>OS</th><td>{{.OSVersion}} {{.Version}} {{.Platform}}</td></tr>
+<tr><th>Connection</th><td class="{{.Connection.State}}">{{.Connection.State}} ({{.Connection.Transport}}){{if .Connection.Established}}, established {{since .Connection.Established}}{{end}}{{if .Connection.LastError}}: {{.Connection.LastError}}{{end}}</td></tr>
+<tr><th>Last scrape</th><td>{{since .LastScrape}}</td></tr>
+</table>
+<table>
+<tr><th>Collector</th><th>Last run</th><th>Duration</th><th>Last success</th><th>Last error</th></tr>
+{{range .Collectors}}<tr><td>{{.Name}}</td><td>{{since .LastRun}}</td><td>{{printf "%.3fs" .DurationSeconds}}</td><td>{{since .LastSuccess}}</td><td class="error">{{.LastError}}</td></tr>
+{{end}}</table>
+{{else}}
+<p>No targets configured or scraped yet.</p>
+{{end}}
+</body>
+</html>
+`))
+
+// handleStatusRequest renders the status page listing the state of all targets.
+func handleStatusRequest(w http.ResponseWriter, request *http.Request) {
+	if request.URL.Path != "/" {
+		http.NotFound(w, request)
+		return
+	}
+	err := statusTemplate.Execute(w, struct {
+		Version     string
+		MetricsPath string
+		Targets     []targetStatus
+	}{version, *metricsPath, targetStatusList(getConfiguration())})
+	if err != nil {
+		log.Errorf("Could not render the status page: %v", err)
+	}
+}
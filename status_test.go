@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/config"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+)
+
+const statusConfig = `
+devices:
+  router.example.com:
+    username: monitoring
+    password: static-password
+    enable_password: enable-password
+    enabled_collectors: [cpu]
+  "*.dynamic.example.com":
+    username: monitoring
+    password: dynamic-password
+    enabled_collectors: [cpu, memory]
+`
+
+func TestTargetsRequest(t *testing.T) {
+	c, err := config.Load(strings.NewReader(statusConfig))
+	if err != nil {
+		t.Fatalf("Could not load configuration: %v", err)
+	}
+	configurationMutex.Lock()
+	configuration = c
+	configurationMutex.Unlock()
+	connectionManager = connector.NewConnectionManager()
+	// Pollers of other tests might still record their last runs.
+	targetStatuses.mu.Lock()
+	targetStatuses.targets = make(map[string]map[string]*collectorRecord)
+	targetStatuses.lastRun = make(map[string]time.Time)
+	targetStatuses.mu.Unlock()
+	targetStatuses.record("switch.dynamic.example.com", "cpu", &collectorRun{duration: 0.5, success: true})
+
+	server := httptest.NewServer(http.HandlerFunc(handleTargetsRequest))
+	defer server.Close()
+	response, err := http.Get(server.URL + "/api/v1/targets")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Expected Content-Type application/json, got %s", contentType)
+	}
+
+	content, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"static-password", "enable-password", "dynamic-password"} {
+		if strings.Contains(string(content), secret) {
+			t.Errorf("Expected %s to be redacted, got %s", secret, content)
+		}
+	}
+
+	body := struct {
+		Status string         `json:"status"`
+		Data   []targetStatus `json:"data"`
+	}{}
+	if err := json.Unmarshal(content, &body); err != nil {
+		t.Fatalf("Could not decode the response: %v", err)
+	}
+	if body.Status != "success" {
+		t.Errorf("Expected status success, got %s", body.Status)
+	}
+	if len(body.Data) != 2 {
+		t.Fatalf("Expected the static and the dynamic target, got %+v", body.Data)
+	}
+
+	static, dynamic := body.Data[0], body.Data[1]
+	if static.Target != "router.example.com" || !static.Static || static.DeviceGroup != "router.example.com" {
+		t.Errorf("Unexpected static target %+v", static)
+	}
+	if static.LastScrape != nil || static.Collectors[0].LastRun != nil {
+		t.Errorf("Expected the static target not to be scraped yet, got %+v", static)
+	}
+	if dynamic.Target != "switch.dynamic.example.com" || dynamic.Static || dynamic.DeviceGroup != "*.dynamic.example.com" {
+		t.Errorf("Unexpected dynamic target %+v", dynamic)
+	}
+	if dynamic.LastScrape == nil || len(dynamic.Collectors) != 2 || dynamic.Collectors[0].LastSuccess == nil {
+		t.Errorf("Expected the scrape of the dynamic target to be listed, got %+v", dynamic)
+	}
+
+	for _, status := range body.Data {
+		if status.DeviceGroupConfig["password"] != "<secret>" {
+			t.Errorf("Expected the password of %s to be listed redacted, got %v", status.Target, status.DeviceGroupConfig["password"])
+		}
+	}
+	if static.DeviceGroupConfig["enable_password"] != "<secret>" {
+		t.Errorf("Expected the enable password to be listed redacted, got %v", static.DeviceGroupConfig["enable_password"])
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+
+	"gitlab.com/wobcom/cisco-exporter/telemetry"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// startTelemetryReceiver listens for model-driven telemetry dial-out and keeps the received values in telemetryStore.
+func startTelemetryReceiver() error {
+	options := make([]grpc.ServerOption, 0)
+	if *telemetryTLSCert != "" {
+		tlsConfig, err := telemetryTLSConfig()
+		if err != nil {
+			return err
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	} else if *telemetryClientCA != "" {
+		return errors.New("-telemetry.tls-client-ca-file requires -telemetry.tls-cert-file and -telemetry.tls-key-file")
+	}
+
+	listener, err := net.Listen("tcp", *telemetryAddress)
+	if err != nil {
+		return errors.Wrapf(err, "Could not listen for telemetry on %s", *telemetryAddress)
+	}
+
+	telemetryStore = telemetry.NewStore(*telemetryStaleAfter)
+	server := grpc.NewServer(options...)
+	telemetry.NewReceiver(telemetryStore, telemetryTarget).Register(server)
+
+	log.Infof("Receiving telemetry on %s", *telemetryAddress)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			log.Errorf("Telemetry receiver stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// telemetryTLSConfig returns the TLS configuration of the telemetry listener. If a client CA is configured,
+// devices have to present a certificate signed by it.
+func telemetryTLSConfig() (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(*telemetryTLSCert, *telemetryTLSKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not load the telemetry TLS certificate")
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{certificate}}
+	if *telemetryClientCA != "" {
+		ca, err := ioutil.ReadFile(*telemetryClientCA)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not read the telemetry client CA")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("No certificates found in the telemetry client CA '%s'", *telemetryClientCA)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
+
+// telemetryTarget returns the target label of telemetry sent by a device. Devices are matched against the configuration
+// by the node id they send, e.g. their hostname, and otherwise by the address they connected from.
+// Telemetry of devices matching no device group is dropped.
+func telemetryTarget(nodeID string, address string) (string, bool) {
+	c := getConfiguration()
+	for _, candidate := range []string{nodeID, address} {
+		if candidate != "" && c.GetDeviceGroup(candidate) != nil {
+			return candidate, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,152 @@
+package telemetry
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// decoder extracts the series of the rows sent for one encoding path.
+type decoder struct {
+	// keys holds the paths of the keys which are exported as labels following the target.
+	keys   []string
+	fields []decodedField
+}
+
+// decodedField is a series whose value is the sum of the content fields at paths. Missing fields are ignored.
+type decodedField struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	paths     []string
+}
+
+var (
+	interfaceReceiveBytesDesc    *prometheus.Desc
+	interfaceTransmitBytesDesc   *prometheus.Desc
+	interfaceReceivePacketsDesc  *prometheus.Desc
+	interfaceTransmitPacketsDesc *prometheus.Desc
+	interfaceReceiveErrorsDesc   *prometheus.Desc
+	interfaceTransmitErrorsDesc  *prometheus.Desc
+	interfaceReceiveDropsDesc    *prometheus.Desc
+	interfaceTransmitDropsDesc   *prometheus.Desc
+	cpuFiveSecondsDesc           *prometheus.Desc
+	cpuOneMinuteDesc             *prometheus.Desc
+	cpuFiveMinutesDesc           *prometheus.Desc
+	cpuFifteenMinutesDesc        *prometheus.Desc
+	memoryTotalDesc              *prometheus.Desc
+	memoryUsedDesc               *prometheus.Desc
+	memoryFreeDesc               *prometheus.Desc
+)
+
+// decoders maps the supported encoding paths of IOS XR and IOS XE to their decoder.
+var decoders map[string]*decoder
+
+func init() {
+	l := []string{"target", "name"}
+	interfaceReceiveBytesDesc = prometheus.NewDesc(prefix+"interface_receive_bytes", "Received data in bytes", l, nil)
+	interfaceTransmitBytesDesc = prometheus.NewDesc(prefix+"interface_transmit_bytes", "Transmitted data in bytes", l, nil)
+	interfaceReceivePacketsDesc = prometheus.NewDesc(prefix+"interface_receive_packets_total", "Number of received packets", l, nil)
+	interfaceTransmitPacketsDesc = prometheus.NewDesc(prefix+"interface_transmit_packets_total", "Number of transmitted packets", l, nil)
+	interfaceReceiveErrorsDesc = prometheus.NewDesc(prefix+"interface_receive_errors_total", "Number of errors caused by incoming packets", l, nil)
+	interfaceTransmitErrorsDesc = prometheus.NewDesc(prefix+"interface_transmit_errors_total", "Number of errors caused by outgoing packets", l, nil)
+	interfaceReceiveDropsDesc = prometheus.NewDesc(prefix+"interface_receive_drops_total", "Number of dropped incoming packets", l, nil)
+	interfaceTransmitDropsDesc = prometheus.NewDesc(prefix+"interface_transmit_drops_total", "Number of dropped outgoing packets", l, nil)
+
+	// The node is empty on IOS XE, which reports the utilization of the route processor only.
+	l = []string{"target", "node"}
+	cpuFiveSecondsDesc = prometheus.NewDesc(prefix+"cpu_five_seconds_percent", "CPU utilization for five seconds", l, nil)
+	cpuOneMinuteDesc = prometheus.NewDesc(prefix+"cpu_one_minute_percent", "CPU utilization for one minute", l, nil)
+	cpuFiveMinutesDesc = prometheus.NewDesc(prefix+"cpu_five_minutes_percent", "CPU utilization for five minutes", l, nil)
+	cpuFifteenMinutesDesc = prometheus.NewDesc(prefix+"cpu_fifteen_minutes_percent", "CPU utilization for fifteen minutes", l, nil)
+
+	// The name is the node on IOS XR and the memory pool on IOS XE.
+	l = []string{"target", "name"}
+	memoryTotalDesc = prometheus.NewDesc(prefix+"memory_total_bytes", "Total memory in bytes", l, nil)
+	memoryUsedDesc = prometheus.NewDesc(prefix+"memory_used_bytes", "Used memory in bytes", l, nil)
+	memoryFreeDesc = prometheus.NewDesc(prefix+"memory_free_bytes", "Free memory in bytes", l, nil)
+
+	counter := prometheus.CounterValue
+	gauge := prometheus.GaugeValue
+	decoders = map[string]*decoder{
+		"Cisco-IOS-XR-infra-statsd-oper:infra-statistics/interfaces/interface/latest/generic-counters": {
+			keys: []string{"interface-name"},
+			fields: []decodedField{
+				{interfaceReceiveBytesDesc, counter, []string{"bytes-received"}},
+				{interfaceTransmitBytesDesc, counter, []string{"bytes-sent"}},
+				{interfaceReceivePacketsDesc, counter, []string{"packets-received"}},
+				{interfaceTransmitPacketsDesc, counter, []string{"packets-sent"}},
+				{interfaceReceiveErrorsDesc, counter, []string{"input-errors"}},
+				{interfaceTransmitErrorsDesc, counter, []string{"output-errors"}},
+				{interfaceReceiveDropsDesc, counter, []string{"input-drops"}},
+				{interfaceTransmitDropsDesc, counter, []string{"output-drops"}},
+			},
+		},
+		"Cisco-IOS-XE-interfaces-oper:interfaces/interface": {
+			keys: []string{"name"},
+			fields: []decodedField{
+				{interfaceReceiveBytesDesc, counter, []string{"statistics/in-octets"}},
+				{interfaceTransmitBytesDesc, counter, []string{"statistics/out-octets"}},
+				{interfaceReceivePacketsDesc, counter, []string{"statistics/in-unicast-pkts", "statistics/in-broadcast-pkts", "statistics/in-multicast-pkts"}},
+				{interfaceTransmitPacketsDesc, counter, []string{"statistics/out-unicast-pkts", "statistics/out-broadcast-pkts", "statistics/out-multicast-pkts"}},
+				{interfaceReceiveErrorsDesc, counter, []string{"statistics/in-errors"}},
+				{interfaceTransmitErrorsDesc, counter, []string{"statistics/out-errors"}},
+				{interfaceReceiveDropsDesc, counter, []string{"statistics/in-discards"}},
+				{interfaceTransmitDropsDesc, counter, []string{"statistics/out-discards"}},
+			},
+		},
+		"Cisco-IOS-XR-wdsysmon-fd-oper:system-monitoring/cpu-utilization": {
+			keys: []string{"node-name"},
+			fields: []decodedField{
+				{cpuOneMinuteDesc, gauge, []string{"total-cpu-one-minute"}},
+				{cpuFiveMinutesDesc, gauge, []string{"total-cpu-five-minute"}},
+				{cpuFifteenMinutesDesc, gauge, []string{"total-cpu-fifteen-minute"}},
+			},
+		},
+		"Cisco-IOS-XE-process-cpu-oper:cpu-usage/cpu-utilization": {
+			fields: []decodedField{
+				{cpuFiveSecondsDesc, gauge, []string{"five-seconds"}},
+				{cpuOneMinuteDesc, gauge, []string{"one-minute"}},
+				{cpuFiveMinutesDesc, gauge, []string{"five-minutes"}},
+			},
+		},
+		"Cisco-IOS-XR-nto-misc-oper:memory-summary/nodes/node/summary": {
+			keys: []string{"node-name"},
+			fields: []decodedField{
+				{memoryTotalDesc, gauge, []string{"ram-memory"}},
+				{memoryFreeDesc, gauge, []string{"free-physical-memory"}},
+			},
+		},
+		"Cisco-IOS-XE-memory-oper:memory-statistics/memory-statistic": {
+			keys: []string{"name"},
+			fields: []decodedField{
+				{memoryTotalDesc, gauge, []string{"total-memory"}},
+				{memoryUsedDesc, gauge, []string{"used-memory"}},
+				{memoryFreeDesc, gauge, []string{"free-memory"}},
+			},
+		},
+	}
+}
+
+// decode stores the series of a row, whose keys and content were flattened to their paths.
+func (d *decoder) decode(store *Store, target string, keys map[string]string, content map[string]float64) {
+	labelValues := make([]string, len(d.keys))
+	for i, key := range d.keys {
+		labelValues[i] = keys[key]
+	}
+	// The CPU utilization of IOS XE has no keys, but shares its labels with IOS XR.
+	if len(d.keys) == 0 {
+		labelValues = []string{""}
+	}
+
+	for _, field := range d.fields {
+		found := false
+		value := 0.0
+		for _, path := range field.paths {
+			if v, ok := content[path]; ok {
+				found = true
+				value += v
+			}
+		}
+		if found {
+			store.set(field.desc, field.valueType, value, target, labelValues...)
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package mdt holds the protocol buffers of Cisco model-driven telemetry (MDT) dial-out over gRPC.
+package mdt
+
+//go:generate buf generate --template buf.gen.yaml .
@@ -0,0 +1,177 @@
+// Package telemetry receives Cisco model-driven telemetry (MDT), which devices stream using gRPC dial-out.
+// Key-value GPB (kvGPB) encoded interface counters, CPU and memory utilization are decoded and kept in a Store.
+package telemetry
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"gitlab.com/wobcom/cisco-exporter/telemetry/mdt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/common/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// TargetFunc maps the node id a device sends along with its telemetry and the address it connected from to the target label.
+// Telemetry is dropped if it returns false, e.g. because the device is not configured.
+type TargetFunc func(nodeID string, address string) (string, bool)
+
+// Receiver implements the gRPC dial-out service of MDT.
+type Receiver struct {
+	mdt.UnimplementedGRPCMdtDialoutServer
+	store  *Store
+	target TargetFunc
+}
+
+// NewReceiver returns a Receiver storing the received telemetry in store. The node id is used as target if target is nil.
+func NewReceiver(store *Store, target TargetFunc) *Receiver {
+	if target == nil {
+		target = func(nodeID string, address string) (string, bool) {
+			return nodeID, true
+		}
+	}
+	return &Receiver{store: store, target: target}
+}
+
+// Register registers the dial-out service on server.
+func (r *Receiver) Register(server *grpc.Server) {
+	mdt.RegisterGRPCMdtDialoutServer(server, r)
+}
+
+// MdtDialout implements the mdt.GRPCMdtDialoutServer interface's MdtDialout function.
+// It receives the telemetry sent by a device until the device closes the stream.
+func (r *Receiver) MdtDialout(stream mdt.GRPCMdtDialout_MdtDialoutServer) error {
+	address := ""
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		address = p.Addr.String()
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			address = host
+		}
+	}
+
+	for {
+		args, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			log.Debugf("Telemetry stream from %s ended: %v", address, err)
+			return err
+		}
+		if args.Errors != "" {
+			log.Errorf("Telemetry from %s reported errors: %s", address, args.Errors)
+		}
+		if len(args.Data) == 0 {
+			continue
+		}
+
+		telemetry := &mdt.Telemetry{}
+		if err := proto.Unmarshal(args.Data, telemetry); err != nil {
+			log.Errorf("Could not decode telemetry from %s: %v", address, err)
+			continue
+		}
+		r.handle(telemetry, address)
+	}
+}
+
+// handle stores the rows of telemetry, which was sent from address.
+func (r *Receiver) handle(telemetry *mdt.Telemetry, address string) {
+	path := telemetry.GetEncodingPath()
+	target, accepted := r.target(telemetry.GetNodeIdStr(), address)
+	if !accepted {
+		log.Debugf("Dropping telemetry for '%s' from unknown device '%s' (%s)", path, telemetry.GetNodeIdStr(), address)
+		return
+	}
+	r.store.countMessage(target, path)
+
+	if len(telemetry.GetDataGpbkv()) == 0 {
+		if telemetry.GetDataGpb() != nil {
+			log.Debugf("Ignoring telemetry for '%s' from %s: Only the kvGPB encoding is supported", path, target)
+		}
+		return
+	}
+	d, found := decoders[path]
+	if !found {
+		log.Debugf("Ignoring telemetry for unsupported encoding path '%s' from %s", path, target)
+		return
+	}
+
+	for _, row := range telemetry.GetDataGpbkv() {
+		keys := make(map[string]string)
+		content := make(map[string]float64)
+		for _, field := range row.GetFields() {
+			switch field.GetName() {
+			case "keys":
+				flattenKeys(field.GetFields(), "", keys)
+			case "content":
+				flattenContent(field.GetFields(), "", content)
+			}
+		}
+		d.decode(r.store, target, keys, content)
+	}
+}
+
+// flattenKeys collects the keys of a row by their path, e.g. `interface-name`.
+func flattenKeys(fields []*mdt.TelemetryField, parent string, keys map[string]string) {
+	for _, field := range fields {
+		path := parent + field.GetName()
+		if len(field.GetFields()) > 0 {
+			flattenKeys(field.GetFields(), path+"/", keys)
+			continue
+		}
+		switch value := field.GetValueByType().(type) {
+		case *mdt.TelemetryField_StringValue:
+			keys[path] = value.StringValue
+		case nil:
+		default:
+			if v, ok := numericValue(field); ok {
+				keys[path] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+	}
+}
+
+// flattenContent collects the numeric leaves of a row's content by their path, e.g. `statistics/in-octets`.
+func flattenContent(fields []*mdt.TelemetryField, parent string, content map[string]float64) {
+	for _, field := range fields {
+		path := parent + field.GetName()
+		if len(field.GetFields()) > 0 {
+			flattenContent(field.GetFields(), path+"/", content)
+			continue
+		}
+		if value, ok := numericValue(field); ok {
+			content[path] = value
+		}
+	}
+}
+
+func numericValue(field *mdt.TelemetryField) (float64, bool) {
+	switch value := field.GetValueByType().(type) {
+	case *mdt.TelemetryField_Uint32Value:
+		return float64(value.Uint32Value), true
+	case *mdt.TelemetryField_Uint64Value:
+		return float64(value.Uint64Value), true
+	case *mdt.TelemetryField_Sint32Value:
+		return float64(value.Sint32Value), true
+	case *mdt.TelemetryField_Sint64Value:
+		return float64(value.Sint64Value), true
+	case *mdt.TelemetryField_DoubleValue:
+		return value.DoubleValue, true
+	case *mdt.TelemetryField_FloatValue:
+		return float64(value.FloatValue), true
+	case *mdt.TelemetryField_BoolValue:
+		if value.BoolValue {
+			return 1, true
+		}
+		return 0, true
+	case *mdt.TelemetryField_StringValue:
+		// Some 64 bit counters are encoded as strings.
+		v, err := strconv.ParseFloat(strings.TrimSpace(value.StringValue), 64)
+		return v, err == nil
+	}
+	return 0, false
+}
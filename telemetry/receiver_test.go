@@ -0,0 +1,190 @@
+package telemetry
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/wobcom/cisco-exporter/telemetry/mdt"
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// replay sends the telemetry messages captured in testdata to the receiver, like a device using gRPC dial-out would.
+func replay(t *testing.T, receiver *Receiver, files ...string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Could not listen: %v", err)
+	}
+	server := grpc.NewServer()
+	receiver.Register(server)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, listener.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatalf("Could not connect to receiver: %v", err)
+	}
+	defer conn.Close()
+
+	stream, err := mdt.NewGRPCMdtDialoutClient(conn).MdtDialout(ctx)
+	if err != nil {
+		t.Fatalf("Could not open dial-out stream: %v", err)
+	}
+	for i, file := range files {
+		content, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		telemetry := &mdt.Telemetry{}
+		if err := protojson.Unmarshal(content, telemetry); err != nil {
+			t.Fatalf("Could not decode %s: %v", file, err)
+		}
+		data, err := proto.Marshal(telemetry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.Send(&mdt.MdtDialoutArgs{ReqId: int64(i), Data: data}); err != nil {
+			t.Fatalf("Could not send %s: %v", file, err)
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		t.Fatal(err)
+	}
+	// The receiver closes the stream after handling all messages.
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Expected stream to be closed, got %v", err)
+	}
+}
+
+func collect(t *testing.T, collector prometheus.Collector) map[string]float64 {
+	ch := make(chan prometheus.Metric, 100)
+	collector.Collect(ch)
+	close(ch)
+	return util.PrepareMetricsForTesting(ch, t)
+}
+
+func TestReceiver(t *testing.T) {
+	store := NewStore(time.Minute)
+	receiver := NewReceiver(store, func(nodeID string, address string) (string, bool) {
+		if address != "127.0.0.1" {
+			t.Errorf("Expected address 127.0.0.1, got %s", address)
+		}
+		return nodeID + ".example.com", true
+	})
+	replay(t, receiver, "xr-interfaces.json", "xr-cpu.json", "xr-memory.json", "xe-interfaces.json", "xe-cpu.json", "xe-memory.json")
+
+	expected := map[string]float64{
+		"cisco_telemetry_interface_receive_bytes{name=HundredGigE0/0/0/0,target=xr-router.example.com}":                                              2178163283445,
+		"cisco_telemetry_interface_transmit_bytes{name=HundredGigE0/0/0/0,target=xr-router.example.com}":                                             1134812271022,
+		"cisco_telemetry_interface_receive_packets_total{name=HundredGigE0/0/0/0,target=xr-router.example.com}":                                      1932830931,
+		"cisco_telemetry_interface_transmit_packets_total{name=HundredGigE0/0/0/0,target=xr-router.example.com}":                                     1502204471,
+		"cisco_telemetry_interface_receive_drops_total{name=HundredGigE0/0/0/0,target=xr-router.example.com}":                                        12,
+		"cisco_telemetry_interface_receive_errors_total{name=HundredGigE0/0/0/0,target=xr-router.example.com}":                                       3,
+		"cisco_telemetry_interface_transmit_errors_total{name=HundredGigE0/0/0/0,target=xr-router.example.com}":                                      1,
+		"cisco_telemetry_interface_receive_bytes{name=Bundle-Ether1,target=xr-router.example.com}":                                                   301504,
+		"cisco_telemetry_cpu_one_minute_percent{node=0/RP0/CPU0,target=xr-router.example.com}":                                                       4,
+		"cisco_telemetry_cpu_five_minutes_percent{node=0/RP0/CPU0,target=xr-router.example.com}":                                                     5,
+		"cisco_telemetry_cpu_fifteen_minutes_percent{node=0/RP0/CPU0,target=xr-router.example.com}":                                                  6,
+		"cisco_telemetry_memory_total_bytes{name=0/RP0/CPU0,target=xr-router.example.com}":                                                           34359738368,
+		"cisco_telemetry_memory_free_bytes{name=0/RP0/CPU0,target=xr-router.example.com}":                                                            25769803776,
+		"cisco_telemetry_interface_receive_bytes{name=GigabitEthernet0/0/0,target=xe-router.example.com}":                                            9876543210,
+		"cisco_telemetry_interface_receive_packets_total{name=GigabitEthernet0/0/0,target=xe-router.example.com}":                                    1023,
+		"cisco_telemetry_interface_transmit_packets_total{name=GigabitEthernet0/0/0,target=xe-router.example.com}":                                   912,
+		"cisco_telemetry_interface_receive_drops_total{name=GigabitEthernet0/0/0,target=xe-router.example.com}":                                      4,
+		"cisco_telemetry_interface_transmit_drops_total{name=GigabitEthernet0/0/0,target=xe-router.example.com}":                                     6,
+		"cisco_telemetry_cpu_five_seconds_percent{node=,target=xe-router.example.com}":                                                               1,
+		"cisco_telemetry_cpu_five_minutes_percent{node=,target=xe-router.example.com}":                                                               3,
+		"cisco_telemetry_memory_used_bytes{name=Processor,target=xe-router.example.com}":                                                             297398856,
+		"cisco_telemetry_messages_total{encoding_path=Cisco-IOS-XR-wdsysmon-fd-oper:system-monitoring/cpu-utilization,target=xr-router.example.com}": 1,
+	}
+	got := collect(t, store.Collector())
+	util.CompareMetrics(got, expected, t)
+
+	xe := collect(t, store.Collector("xe-router.example.com"))
+	for name := range xe {
+		if _, found := got[name]; !found {
+			t.Errorf("Unexpected metric %s", name)
+		}
+	}
+	if len(xe) != 20 {
+		t.Errorf("Expected 20 metrics for xe-router.example.com, got %d", len(xe))
+	}
+}
+
+func TestReceiverIgnoresUnsupportedTelemetry(t *testing.T) {
+	store := NewStore(0)
+	receiver := NewReceiver(store, nil)
+	receiver.handle(&mdt.Telemetry{
+		NodeId:       &mdt.Telemetry_NodeIdStr{NodeIdStr: "router"},
+		EncodingPath: "Cisco-IOS-XR-infra-statsd-oper:infra-statistics/interfaces/interface/latest/generic-counters",
+		DataGpb:      &mdt.TelemetryGPBTable{Row: []*mdt.TelemetryRowGPB{{Keys: []byte{0x0a}}}},
+	}, "192.0.2.1")
+	receiver.handle(&mdt.Telemetry{
+		NodeId:       &mdt.Telemetry_NodeIdStr{NodeIdStr: "router"},
+		EncodingPath: "Cisco-IOS-XR-ipv4-bgp-oper:bgp/instances/instance/instance-active/default-vrf/neighbors/neighbor",
+		DataGpbkv:    []*mdt.TelemetryField{{Fields: []*mdt.TelemetryField{{Name: "content"}}}},
+	}, "192.0.2.1")
+
+	got := collect(t, store.Collector())
+	if len(got) != 4 {
+		t.Errorf("Expected only the message metrics, got %v", got)
+	}
+}
+
+func TestReceiverDropsUnknownDevices(t *testing.T) {
+	store := NewStore(0)
+	receiver := NewReceiver(store, func(nodeID string, address string) (string, bool) {
+		return nodeID, nodeID == "router"
+	})
+	for _, nodeID := range []string{"router", "rogue"} {
+		receiver.handle(&mdt.Telemetry{
+			NodeId:       &mdt.Telemetry_NodeIdStr{NodeIdStr: nodeID},
+			EncodingPath: "Cisco-IOS-XR-ipv4-bgp-oper:bgp/instances/instance/instance-active/default-vrf/neighbors/neighbor",
+		}, "192.0.2.1")
+	}
+
+	got := collect(t, store.Collector())
+	if len(got) != 2 || got["cisco_telemetry_messages_total{encoding_path=Cisco-IOS-XR-ipv4-bgp-oper:bgp/instances/instance/instance-active/default-vrf/neighbors/neighbor,target=router}"] != 1 {
+		t.Errorf("Expected only the messages of the known device, got %v", got)
+	}
+}
+
+func TestStoreDropsStaleValues(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	store := NewStore(time.Minute)
+	store.now = func() time.Time { return now }
+	store.set(cpuOneMinuteDesc, prometheus.GaugeValue, 3, "router", "")
+	store.countMessage("router", "Cisco-IOS-XE-process-cpu-oper:cpu-usage/cpu-utilization")
+
+	if got := collect(t, store.Collector()); got["cisco_telemetry_cpu_one_minute_percent{node=,target=router}"] != 3 {
+		t.Errorf("Expected fresh value to be exported, got %v", got)
+	}
+	now = now.Add(30 * time.Second)
+	store.countMessage("router", "Cisco-IOS-XE-process-cpu-oper:cpu-usage/cpu-utilization")
+	now = now.Add(45 * time.Second)
+	if got := collect(t, store.Collector()); got["cisco_telemetry_messages_total{encoding_path=Cisco-IOS-XE-process-cpu-oper:cpu-usage/cpu-utilization,target=router}"] != 2 {
+		t.Errorf("Expected message statistics updated within stale-after to be kept, got %v", got)
+	}
+	now = now.Add(2 * time.Minute)
+	got := collect(t, store.Collector())
+	if _, found := got["cisco_telemetry_cpu_one_minute_percent{node=,target=router}"]; found {
+		t.Errorf("Expected stale value to be dropped, got %v", got)
+	}
+	if _, found := got["cisco_telemetry_last_message_timestamp_seconds{encoding_path=Cisco-IOS-XE-process-cpu-oper:cpu-usage/cpu-utilization,target=router}"]; found {
+		t.Errorf("Expected stale message statistics to be dropped, got %v", got)
+	}
+	if len(got) != 0 {
+		t.Errorf("Expected no metrics, got %v", got)
+	}
+}
@@ -0,0 +1,152 @@
+package telemetry
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const prefix string = "cisco_telemetry_"
+
+var (
+	messagesDesc    *prometheus.Desc
+	lastMessageDesc *prometheus.Desc
+)
+
+func init() {
+	l := []string{"target", "encoding_path"}
+	messagesDesc = prometheus.NewDesc(prefix+"messages_total", "Number of telemetry messages received", l, nil)
+	lastMessageDesc = prometheus.NewDesc(prefix+"last_message_timestamp_seconds", "Time the last telemetry message was received", l, nil)
+}
+
+// Store keeps the latest value of every series received by telemetry, until it gets stale.
+type Store struct {
+	staleAfter time.Duration
+	mu         sync.Mutex
+	samples    map[string]*sample
+	messages   map[messageKey]*messageStats
+	now        func() time.Time
+}
+
+type sample struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	// labelValues starts with the target.
+	labelValues []string
+	value       float64
+	updated     time.Time
+}
+
+type messageKey struct {
+	target       string
+	encodingPath string
+}
+
+type messageStats struct {
+	count uint64
+	last  time.Time
+}
+
+// NewStore returns an empty Store. Values which were not updated for staleAfter are dropped, as are the message statistics
+// of targets and encoding paths which received no message for staleAfter. A staleAfter of 0 keeps them forever.
+func NewStore(staleAfter time.Duration) *Store {
+	return &Store{
+		staleAfter: staleAfter,
+		samples:    make(map[string]*sample),
+		messages:   make(map[messageKey]*messageStats),
+		now:        time.Now,
+	}
+}
+
+// set stores the value of the series of desc identified by the target and labelValues.
+func (s *Store) set(desc *prometheus.Desc, valueType prometheus.ValueType, value float64, target string, labelValues ...string) {
+	labelValues = append([]string{target}, labelValues...)
+	key := desc.String() + "\xff" + strings.Join(labelValues, "\xff")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples[key] = &sample{
+		desc:        desc,
+		valueType:   valueType,
+		labelValues: labelValues,
+		value:       value,
+		updated:     s.now(),
+	}
+}
+
+// countMessage records the receipt of a message of target.
+func (s *Store) countMessage(target string, encodingPath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := messageKey{target, encodingPath}
+	stats, found := s.messages[key]
+	if !found {
+		stats = &messageStats{}
+		s.messages[key] = stats
+	}
+	stats.count++
+	stats.last = s.now()
+}
+
+// Collector returns a prometheus.Collector exporting the values of the given targets, or of all targets if none are given.
+func (s *Store) Collector(targets ...string) prometheus.Collector {
+	return &storeCollector{store: s, targets: targets}
+}
+
+type storeCollector struct {
+	store   *Store
+	targets []string
+}
+
+// Describe implements the prometheus.Collector interface's Describe function
+func (c *storeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- messagesDesc
+	ch <- lastMessageDesc
+	for _, d := range decoders {
+		for _, field := range d.fields {
+			ch <- field.desc
+		}
+	}
+}
+
+// Collect implements the prometheus.Collector interface's Collect function
+func (c *storeCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, sample := range s.samples {
+		if s.staleAfter > 0 && now.Sub(sample.updated) > s.staleAfter {
+			delete(s.samples, key)
+			continue
+		}
+		if c.includes(sample.labelValues[0]) {
+			ch <- prometheus.MustNewConstMetric(sample.desc, sample.valueType, sample.value, sample.labelValues...)
+		}
+	}
+	for key, stats := range s.messages {
+		if s.staleAfter > 0 && now.Sub(stats.last) > s.staleAfter {
+			delete(s.messages, key)
+			continue
+		}
+		if c.includes(key.target) {
+			ch <- prometheus.MustNewConstMetric(messagesDesc, prometheus.CounterValue, float64(stats.count), key.target, key.encodingPath)
+			ch <- prometheus.MustNewConstMetric(lastMessageDesc, prometheus.GaugeValue, float64(stats.last.Unix()), key.target, key.encodingPath)
+		}
+	}
+}
+
+func (c *storeCollector) includes(target string) bool {
+	if len(c.targets) == 0 {
+		return true
+	}
+	for _, t := range c.targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,7 @@
 package users
 
 import (
+	"context"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 	"gitlab.com/wobcom/cisco-exporter/util"
@@ -42,14 +43,18 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- pppoeSessionsDesc
 }
 
+// Sessions is the number of sessions of a type listed by `show users summary`.
+type Sessions struct {
+	Type  string
+	Count float64
+}
+
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) Collect(ctx *collector.CollectContext) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
 	sshCtx := connector.NewSSHCommandContext("show users summary")
-	go ctx.Connection.RunCommand(sshCtx)
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	pppoeRegexp := regexp.MustCompile(`PPPOE\s+(\d+)`)
 
@@ -57,12 +62,14 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 		select {
 		case line := <-sshCtx.Output:
 			if matches := pppoeRegexp.FindStringSubmatch(line); matches != nil {
-				ctx.Metrics <- prometheus.MustNewConstMetric(pppoeSessionsDesc, prometheus.GaugeValue, util.Str2float64(matches[1]), ctx.LabelValues...)
+				sessions := &Sessions{Type: "PPPoE", Count: util.Str2float64(matches[1])}
+				collectCtx.Record(sessions)
+				result.AddMetric(prometheus.MustNewConstMetric(pppoeSessionsDesc, prometheus.GaugeValue, sessions.Count, collectCtx.LabelValues...))
 			}
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping users: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping users: %v", err))
 		case <-sshCtx.Done:
-			return
+			return result
 		}
 	}
 }
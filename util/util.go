@@ -5,6 +5,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/log"
+	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
 	"regexp"
 	"strconv"
@@ -57,6 +58,19 @@ func PrepareMetricsForTesting(ch chan prometheus.Metric, t *testing.T) map[strin
 	return receivedMetrics
 }
 
+// PrepareResultForTesting fails the test for every error of the collector.Result and converts its metrics to a map like PrepareMetricsForTesting.
+func PrepareResultForTesting(result *collector.Result, t *testing.T) map[string]float64 {
+	for _, err := range result.Errors {
+		t.Errorf("Got error from parser: %v", err)
+	}
+	ch := make(chan prometheus.Metric, len(result.Metrics))
+	for _, metric := range result.Metrics {
+		ch <- metric
+	}
+	close(ch)
+	return PrepareMetricsForTesting(ch, t)
+}
+
 // PrepareOutputForTesting takes a string and returns a ssh command context that reads the input linewise
 func PrepareOutputForTesting(input string) connector.SSHCommandContext {
 	outputChan := make(chan string)
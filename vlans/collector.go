@@ -1,6 +1,7 @@
 package vlans
 
 import (
+	"context"
 	"fmt"
 	"gitlab.com/wobcom/cisco-exporter/collector"
 	"gitlab.com/wobcom/cisco-exporter/connector"
@@ -44,19 +45,20 @@ func (*Collector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- transmitBytesDesc
 }
 
-func (c *Collector) Collect(ctx *collector.CollectContext) {
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
 
-	if len(ctx.Connection.Device.EnabledVLANs) > 0 {
+	if len(collectCtx.EnabledVLANs()) > 0 {
 		// This is a limitation of VLANs to parse.
 		// This may apply on BNGs with thousands of interfaces.
 
 		wg := sync.WaitGroup{}
 
-		for _, vid := range ctx.Connection.Device.EnabledVLANs {
+		for _, vid := range collectCtx.EnabledVLANs() {
 			vid := vid
 			wg.Add(1)
 			go func() {
-				c.CollectVLAN(ctx, fmt.Sprintf("%v", vid))
+				c.CollectVLAN(ctx, collectCtx, result, fmt.Sprintf("%v", vid))
 				wg.Done()
 			}()
 		}
@@ -65,18 +67,18 @@ func (c *Collector) Collect(ctx *collector.CollectContext) {
 
 	} else {
 		// We want to get all interfaces.
-		c.CollectVLAN(ctx, "")
+		c.CollectVLAN(ctx, collectCtx, result, "")
 	}
+	return result
 }
 
 // Collect implements the collector.Collector interface's Collect function
-func (c *Collector) CollectVLAN(ctx *collector.CollectContext, cmdParams string) {
-	defer func() {
-		ctx.Done <- struct{}{}
-	}()
-
+func (c *Collector) CollectVLAN(ctx context.Context, collectCtx *collector.CollectContext, result *collector.Result, cmdParams string) {
 	sshCtx := connector.NewSSHCommandContext(fmt.Sprintf("show vlans %v", cmdParams))
-	go ctx.Connection.RunCommand(sshCtx)
+	if cmdParams != "" {
+		sshCtx.Template = "show vlans <vlan>"
+	}
+	go collectCtx.Connection.RunCommand(ctx, sshCtx)
 
 	vlans := make(chan *VLANInterface)
 	vlansParsingDone := make(chan struct{})
@@ -86,15 +88,16 @@ func (c *Collector) CollectVLAN(ctx *collector.CollectContext, cmdParams string)
 	for {
 		select {
 		case vlan := <-vlans:
+			collectCtx.Record(vlan)
 			vlansCount++
-			l := append(ctx.LabelValues, vlan.Name)
-			ctx.Metrics <- prometheus.MustNewConstMetric(receiveBytesDesc, prometheus.GaugeValue, vlan.InputBytes, l...)
-			ctx.Metrics <- prometheus.MustNewConstMetric(transmitBytesDesc, prometheus.GaugeValue, vlan.OutputBytes, l...)
+			l := append(collectCtx.LabelValues, vlan.Name)
+			result.AddMetric(prometheus.MustNewConstMetric(receiveBytesDesc, prometheus.GaugeValue, vlan.InputBytes, l...))
+			result.AddMetric(prometheus.MustNewConstMetric(transmitBytesDesc, prometheus.GaugeValue, vlan.OutputBytes, l...))
 		case err := <-sshCtx.Errors:
-			ctx.Errors <- errors.Wrapf(err, "Error scraping VLANs: %v", err)
+			result.AddError(errors.Wrapf(err, "Error scraping VLANs: %v", err))
 		case <-vlansParsingDone:
 			if vlansCount == 0 {
-				ctx.Errors <- errors.New("No VLAN metric was scraped")
+				result.AddError(errors.New("No VLAN metric was scraped"))
 			}
 			return
 		}
@@ -0,0 +1,154 @@
+package vpnsessions
+
+import (
+	"context"
+	"regexp"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/connector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const prefix string = "cisco_asa_vpn_"
+
+var (
+	activeDesc     *prometheus.Desc
+	cumulativeDesc *prometheus.Desc
+	peakDesc       *prometheus.Desc
+	inactiveDesc   *prometheus.Desc
+	capacityDesc   *prometheus.Desc
+	loadDesc       *prometheus.Desc
+)
+
+// Collector gathers the number of VPN sessions of an ASA by running `show vpn-sessiondb summary`.
+type Collector struct {
+}
+
+// NewCollector returns a new vpnsessions.Collector instance.
+func NewCollector() collector.Collector {
+	return &Collector{}
+}
+
+// Name implements the collector.Collector interface's Name function
+func (*Collector) Name() string {
+	return "vpn_sessions"
+}
+
+func init() {
+	l := []string{"target", "context"}
+	activeDesc = prometheus.NewDesc(prefix+"sessions_active", "Number of active VPN sessions", append(l, "type"), nil)
+	cumulativeDesc = prometheus.NewDesc(prefix+"sessions_total", "Number of VPN sessions since the last reboot or clear", append(l, "type"), nil)
+	peakDesc = prometheus.NewDesc(prefix+"sessions_peak", "Highest number of concurrent VPN sessions", append(l, "type"), nil)
+	inactiveDesc = prometheus.NewDesc(prefix+"sessions_inactive", "Number of inactive VPN sessions", append(l, "type"), nil)
+	capacityDesc = prometheus.NewDesc(prefix+"sessions_capacity", "Total number of VPN sessions supported by the device", l, nil)
+	loadDesc = prometheus.NewDesc(prefix+"load_percent", "VPN sessions in use relative to the capacity", l, nil)
+}
+
+// Describe implements the collector.Collector interface's Describe function
+func (*Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeDesc
+	ch <- cumulativeDesc
+	ch <- peakDesc
+	ch <- inactiveDesc
+	ch <- capacityDesc
+	ch <- loadDesc
+}
+
+// Collect implements the collector.Collector interface's Collect function
+func (c *Collector) Collect(ctx context.Context, collectCtx *collector.CollectContext) *collector.Result {
+	result := collector.NewResult()
+
+	for _, securityContext := range collectCtx.SecurityContexts() {
+		sshCtx := connector.NewSSHCommandContextInSecurityContext(collectCtx.Connection.Info(), securityContext, "show vpn-sessiondb summary")
+		go collectCtx.Connection.RunCommand(ctx, sshCtx)
+		c.parse(sshCtx, collectCtx, securityContext, result)
+	}
+	return result
+}
+
+// Summary holds the VPN sessions of a security context parsed from `show vpn-sessiondb summary`.
+type Summary struct {
+	Context  string
+	Types    []*SessionType
+	Capacity *float64
+	Load     *float64
+}
+
+// SessionType holds the session counts of a VPN type, e.g. AnyConnect Client. Inactive is nil if not listed for the type.
+type SessionType struct {
+	Name       string
+	Active     float64
+	Cumulative float64
+	Peak       float64
+	Inactive   *float64
+}
+
+// parse parses the session counts per VPN type. Only the types are exported, not the protocols listed indented below them,
+// as a protocol can appear below multiple types.
+func (c *Collector) parse(sshCtx *connector.SSHCommandContext, collectCtx *collector.CollectContext, securityContext string, result *collector.Result) {
+	typeRegexp := regexp.MustCompile(`^(\S.*?)\s+:\s+(\d+)\s+:\s+(\d+)\s+:\s+(\d+)(?:\s+:\s+(\d+))?\s*$`)
+	capacityRegexp := regexp.MustCompile(`^Device Total VPN Capacity\s+:\s+(\d+)`)
+	loadRegexp := regexp.MustCompile(`^Device Load\s+:\s+(\d+)%`)
+
+	matched := false
+	summary := &Summary{Context: securityContext}
+
+	for {
+		select {
+		case <-sshCtx.Done:
+			if !matched {
+				result.AddError(errors.New("No VPN session metric was extracted"))
+				return
+			}
+			generateMetrics(collectCtx, result, summary)
+			return
+		case err := <-sshCtx.Errors:
+			result.AddError(errors.Wrapf(err, "Error scraping VPN sessions: %v", err))
+		case line := <-sshCtx.Output:
+			if matches := typeRegexp.FindStringSubmatch(line); matches != nil {
+				matched = true
+				sessionType := &SessionType{
+					Name:       matches[1],
+					Active:     util.Str2float64(matches[2]),
+					Cumulative: util.Str2float64(matches[3]),
+					Peak:       util.Str2float64(matches[4]),
+				}
+				if matches[5] != "" {
+					inactive := util.Str2float64(matches[5])
+					sessionType.Inactive = &inactive
+				}
+				summary.Types = append(summary.Types, sessionType)
+			} else if matches := capacityRegexp.FindStringSubmatch(line); matches != nil {
+				matched = true
+				capacity := util.Str2float64(matches[1])
+				summary.Capacity = &capacity
+			} else if matches := loadRegexp.FindStringSubmatch(line); matches != nil {
+				load := util.Str2float64(matches[1])
+				summary.Load = &load
+			}
+		}
+	}
+}
+
+func generateMetrics(collectCtx *collector.CollectContext, result *collector.Result, summary *Summary) {
+	collectCtx.Record(summary)
+	labelValues := append(collectCtx.LabelValues, summary.Context)
+	for _, sessionType := range summary.Types {
+		l := append(labelValues, sessionType.Name)
+		result.AddMetric(prometheus.MustNewConstMetric(activeDesc, prometheus.GaugeValue, sessionType.Active, l...))
+		result.AddMetric(prometheus.MustNewConstMetric(cumulativeDesc, prometheus.CounterValue, sessionType.Cumulative, l...))
+		result.AddMetric(prometheus.MustNewConstMetric(peakDesc, prometheus.GaugeValue, sessionType.Peak, l...))
+		if sessionType.Inactive != nil {
+			result.AddMetric(prometheus.MustNewConstMetric(inactiveDesc, prometheus.GaugeValue, *sessionType.Inactive, l...))
+		}
+	}
+	if summary.Capacity != nil {
+		result.AddMetric(prometheus.MustNewConstMetric(capacityDesc, prometheus.GaugeValue, *summary.Capacity, labelValues...))
+	}
+	if summary.Load != nil {
+		result.AddMetric(prometheus.MustNewConstMetric(loadDesc, prometheus.GaugeValue, *summary.Load, labelValues...))
+	}
+}
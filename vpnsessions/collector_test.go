@@ -0,0 +1,45 @@
+package vpnsessions
+
+import (
+	"testing"
+
+	"gitlab.com/wobcom/cisco-exporter/collector"
+	"gitlab.com/wobcom/cisco-exporter/util"
+)
+
+const showVPNSessionDBSummary = `---------------------------------------------------------------------------
+VPN Session Summary
+---------------------------------------------------------------------------
+                               Active : Cumulative : Peak Concur : Inactive
+                             ----------------------------------------------
+AnyConnect Client            :     12 :       3456 :          40 :        2
+  SSL/TLS/DTLS               :     12 :       3456 :          40 :        2
+Site-to-Site VPN             :      3 :         45 :           3
+  IKEv2 IPsec                :      3 :         45 :           3
+---------------------------------------------------------------------------
+Total Active and Inactive    :     17             Total Cumulative :   3501
+Device Total VPN Capacity    :    300
+Device Load                  :     5%
+---------------------------------------------------------------------------`
+
+func TestParse(t *testing.T) {
+	c := &Collector{}
+	ctx := util.PrepareOutputForTesting(showVPNSessionDBSummary)
+	result := collector.NewResult()
+	c.parse(&ctx, &collector.CollectContext{LabelValues: []string{"test.test"}}, "", result)
+
+	got := util.PrepareResultForTesting(result, t)
+	util.CompareMetrics(got, map[string]float64{
+		"cisco_asa_vpn_sessions_active{context=,target=test.test,type=AnyConnect Client}":   12,
+		"cisco_asa_vpn_sessions_total{context=,target=test.test,type=AnyConnect Client}":    3456,
+		"cisco_asa_vpn_sessions_peak{context=,target=test.test,type=AnyConnect Client}":     40,
+		"cisco_asa_vpn_sessions_inactive{context=,target=test.test,type=AnyConnect Client}": 2,
+		"cisco_asa_vpn_sessions_active{context=,target=test.test,type=Site-to-Site VPN}":    3,
+		"cisco_asa_vpn_sessions_total{context=,target=test.test,type=Site-to-Site VPN}":     45,
+		"cisco_asa_vpn_sessions_capacity{context=,target=test.test}":                        300,
+		"cisco_asa_vpn_load_percent{context=,target=test.test}":                             5,
+	}, t)
+	if len(got) != 9 {
+		t.Errorf("Expected 9 metrics, got %d", len(got))
+	}
+}
@@ -0,0 +1,212 @@
+// Package web serves the exporter's HTTP endpoints using TLS and basic auth as configured in a web configuration file.
+// The file format is compatible with the web-config.yml of the Prometheus exporter-toolkit.
+package web
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of a web configuration file.
+type Config struct {
+	TLSConfig  TLSConfig         `yaml:"tls_server_config"`
+	HTTPConfig HTTPConfig        `yaml:"http_server_config"`
+	Users      map[string]string `yaml:"basic_auth_users"`
+}
+
+// TLSConfig configures the certificate presented to clients and how client certificates are verified.
+// Relative paths are relative to the web configuration file.
+type TLSConfig struct {
+	CertFile                 string     `yaml:"cert_file"`
+	KeyFile                  string     `yaml:"key_file"`
+	ClientAuthType           string     `yaml:"client_auth_type"`
+	ClientCAFile             string     `yaml:"client_ca_file"`
+	CipherSuites             []cipher   `yaml:"cipher_suites"`
+	CurvePreferences         []curve    `yaml:"curve_preferences"`
+	MinVersion               tlsVersion `yaml:"min_version"`
+	MaxVersion               tlsVersion `yaml:"max_version"`
+	PreferServerCipherSuites bool       `yaml:"prefer_server_cipher_suites"`
+}
+
+// HTTPConfig configures the HTTP server.
+type HTTPConfig struct {
+	HTTP2   bool              `yaml:"http2"`
+	Headers map[string]string `yaml:"headers"`
+}
+
+// enabled returns whether TLS is configured.
+func (t *TLSConfig) enabled() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// LoadConfig reads the web configuration file at path. Unknown options are rejected.
+func LoadConfig(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not read the web configuration file '%s'", path)
+	}
+
+	c := &Config{
+		TLSConfig: TLSConfig{
+			MinVersion:               tls.VersionTLS12,
+			MaxVersion:               tls.VersionTLS13,
+			PreferServerCipherSuites: true,
+		},
+		HTTPConfig: HTTPConfig{HTTP2: true},
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && err != io.EOF {
+		return nil, errors.Wrapf(err, "Could not parse the web configuration file '%s'", path)
+	}
+
+	dir := filepath.Dir(path)
+	for _, file := range []*string{&c.TLSConfig.CertFile, &c.TLSConfig.KeyFile, &c.TLSConfig.ClientCAFile} {
+		if *file != "" && !filepath.IsAbs(*file) {
+			*file = filepath.Join(dir, *file)
+		}
+	}
+	if err := c.validate(); err != nil {
+		return nil, errors.Wrapf(err, "Invalid web configuration file '%s'", path)
+	}
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.TLSConfig.enabled() {
+		if c.TLSConfig.CertFile == "" {
+			return fmt.Errorf("tls_server_config: missing cert_file")
+		}
+		if c.TLSConfig.KeyFile == "" {
+			return fmt.Errorf("tls_server_config: missing key_file")
+		}
+	} else if c.TLSConfig.ClientCAFile != "" || c.TLSConfig.ClientAuthType != "" {
+		return fmt.Errorf("tls_server_config: client_auth_type and client_ca_file require cert_file and key_file")
+	}
+	if _, err := c.TLSConfig.clientAuth(); err != nil {
+		return err
+	}
+	if c.TLSConfig.ClientCAFile != "" && c.TLSConfig.ClientAuthType == "" {
+		return fmt.Errorf("tls_server_config: client_ca_file requires client_auth_type")
+	}
+	for user, hash := range c.Users {
+		if hash == "" {
+			return fmt.Errorf("basic_auth_users: missing password hash of user '%s'", user)
+		}
+	}
+	return nil
+}
+
+// clientAuth returns the verification of client certificates selected by client_auth_type.
+func (t *TLSConfig) clientAuth() (tls.ClientAuthType, error) {
+	switch t.ClientAuthType {
+	case "", "NoClientCert":
+		return tls.NoClientCert, nil
+	case "RequestClientCert":
+		return tls.RequestClientCert, nil
+	case "RequireAnyClientCert", "RequireClientCert":
+		return tls.RequireAnyClientCert, nil
+	case "VerifyClientCertIfGiven":
+		return tls.VerifyClientCertIfGiven, nil
+	case "RequireAndVerifyClientCert":
+		return tls.RequireAndVerifyClientCert, nil
+	}
+	return tls.NoClientCert, fmt.Errorf("tls_server_config: unknown client_auth_type '%s'", t.ClientAuthType)
+}
+
+// tlsConfig returns the TLS configuration to serve a connection with. The certificate and CAs are read from disk.
+func (t *TLSConfig) tlsConfig() (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not load the certificate")
+	}
+	clientAuth, err := t.clientAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates:             []tls.Certificate{certificate},
+		ClientAuth:               clientAuth,
+		MinVersion:               uint16(t.MinVersion),
+		MaxVersion:               uint16(t.MaxVersion),
+		PreferServerCipherSuites: t.PreferServerCipherSuites,
+	}
+	for _, c := range t.CipherSuites {
+		config.CipherSuites = append(config.CipherSuites, uint16(c))
+	}
+	for _, c := range t.CurvePreferences {
+		config.CurvePreferences = append(config.CurvePreferences, tls.CurveID(c))
+	}
+	if t.ClientCAFile != "" {
+		content, err := ioutil.ReadFile(t.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "Could not read client_ca_file")
+		}
+		config.ClientCAs = x509.NewCertPool()
+		if !config.ClientCAs.AppendCertsFromPEM(content) {
+			return nil, fmt.Errorf("No certificates found in client_ca_file '%s'", t.ClientCAFile)
+		}
+	}
+	return config, nil
+}
+
+// tlsVersion is a TLS version referred to by its name, e.g. `TLS12`.
+type tlsVersion uint16
+
+var tlsVersions = map[string]uint16{
+	"TLS13": tls.VersionTLS13,
+	"TLS12": tls.VersionTLS12,
+	"TLS11": tls.VersionTLS11,
+	"TLS10": tls.VersionTLS10,
+}
+
+func (v *tlsVersion) UnmarshalYAML(node *yaml.Node) error {
+	version, found := tlsVersions[node.Value]
+	if !found {
+		return fmt.Errorf("line %d: unknown TLS version '%s'", node.Line, node.Value)
+	}
+	*v = tlsVersion(version)
+	return nil
+}
+
+// cipher is a TLS cipher suite referred to by its name as in crypto/tls, e.g. `TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`.
+type cipher uint16
+
+func (c *cipher) UnmarshalYAML(node *yaml.Node) error {
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if suite.Name == node.Value {
+			*c = cipher(suite.ID)
+			return nil
+		}
+	}
+	return fmt.Errorf("line %d: unknown cipher suite '%s'", node.Line, node.Value)
+}
+
+// curve is an elliptic curve referred to by its name, e.g. `X25519`.
+type curve tls.CurveID
+
+var curves = map[string]tls.CurveID{
+	"CurveP256": tls.CurveP256,
+	"CurveP384": tls.CurveP384,
+	"CurveP521": tls.CurveP521,
+	"X25519":    tls.X25519,
+}
+
+func (c *curve) UnmarshalYAML(node *yaml.Node) error {
+	id, found := curves[node.Value]
+	if !found {
+		return fmt.Errorf("line %d: unknown curve '%s'", node.Line, node.Value)
+	}
+	*c = curve(id)
+	return nil
+}
@@ -0,0 +1,149 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/common/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ListenAndServe listens on the address of server and serves it using the web configuration file at configPath.
+// See Serve.
+func ListenAndServe(server *http.Server, configPath string) error {
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	return Serve(listener, server, configPath)
+}
+
+// Serve serves HTTP on listener, or HTTPS if the web configuration file at configPath configures TLS.
+// If configPath is empty, plain HTTP is served without authentication.
+// The web configuration file is read again for every request and TLS handshake, so that certificates and users
+// can be changed without restarting. Whether TLS is used at all is decided on startup.
+func Serve(listener net.Listener, server *http.Server, configPath string) error {
+	if configPath == "" {
+		return server.Serve(listener)
+	}
+	c, err := LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	handler := server.Handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	server.Handler = &authHandler{
+		configPath: configPath,
+		handler:    handler,
+		cache:      make(map[[sha256.Size]byte]bool),
+	}
+
+	if !c.TLSConfig.enabled() {
+		log.Infof("TLS is disabled in web configuration file '%s'", configPath)
+		return server.Serve(listener)
+	}
+
+	// Verify the certificate on startup, later failures only abort the affected handshakes.
+	if _, err := c.TLSConfig.tlsConfig(); err != nil {
+		return err
+	}
+	server.TLSConfig = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c, err := LoadConfig(configPath)
+			if err != nil {
+				log.Errorf("Could not reload the web configuration: %v", err)
+				return nil, err
+			}
+			return c.TLSConfig.tlsConfig()
+		},
+	}
+	if !c.HTTPConfig.HTTP2 {
+		server.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	log.Infof("TLS is enabled in web configuration file '%s'", configPath)
+	return server.ServeTLS(listener, "", "")
+}
+
+type authenticatedKey struct{}
+
+// Authenticated returns whether the client of r authenticated using basic auth or a verified client certificate.
+func Authenticated(r *http.Request) bool {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+	authenticated, _ := r.Context().Value(authenticatedKey{}).(bool)
+	return authenticated
+}
+
+// cacheSize limits the number of cached password checks.
+const cacheSize = 100
+
+// dummyHash is compared against for unknown users, so that they take as long as known ones.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy"), bcrypt.DefaultCost)
+
+// authHandler requires the users listed in the web configuration file to authenticate using basic auth
+// and sets the configured headers.
+type authHandler struct {
+	configPath string
+	handler    http.Handler
+	mu         sync.Mutex
+	// cache holds the outcome of password checks by a hash of user, password hash and password, as bcrypt is slow by design.
+	cache map[[sha256.Size]byte]bool
+}
+
+func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c, err := LoadConfig(h.configPath)
+	if err != nil {
+		log.Errorf("Could not reload the web configuration: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	for name, value := range c.HTTPConfig.Headers {
+		w.Header().Set(name, value)
+	}
+
+	if len(c.Users) == 0 {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	user, password, ok := r.BasicAuth()
+	if ok && h.authenticate(c.Users, user, password) {
+		h.handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), authenticatedKey{}, true)))
+		return
+	}
+	w.Header().Set("WWW-Authenticate", "Basic")
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+// authenticate returns whether password matches the bcrypt hash configured for user.
+func (h *authHandler) authenticate(users map[string]string, user string, password string) bool {
+	hash, found := users[user]
+	if !found {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	key := sha256.Sum256([]byte(user + "\x00" + hash + "\x00" + password))
+	h.mu.Lock()
+	valid, cached := h.cache[key]
+	h.mu.Unlock()
+	if cached {
+		return valid
+	}
+
+	valid = bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+	h.mu.Lock()
+	if len(h.cache) >= cacheSize {
+		h.cache = make(map[[sha256.Size]byte]bool)
+	}
+	h.cache[key] = valid
+	h.mu.Unlock()
+	return valid
+}
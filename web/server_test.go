@@ -0,0 +1,287 @@
+package web
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testCA issues the certificates used in the tests.
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pem         []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, _ := x509.ParseCertificate(der)
+	return &testCA{certificate: certificate, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate and key in PEM format for the given name.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, dir string, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// serve serves a handler answering "ok" using the web configuration file at configPath and returns its address.
+func serve(t *testing.T, configPath string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	errs := make(chan error, 1)
+	go func() {
+		errs <- Serve(listener, server, configPath)
+	}()
+	t.Cleanup(func() { server.Close() })
+
+	select {
+	case err := <-errs:
+		t.Fatalf("Expected server to start: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	return listener.Addr().String()
+}
+
+func get(client *http.Client, url string, user string, password string) (int, error) {
+	request, _ := http.NewRequest("GET", url, nil)
+	if user != "" {
+		request.SetBasicAuth(user, password)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	response.Body.Close()
+	return response.StatusCode, nil
+}
+
+func hash(t *testing.T, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestBasicAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configPath := writeFile(t, dir, "web.yml", []byte("basic_auth_users:\n  prometheus: "+hash(t, "secret")+"\nhttp_server_config:\n  headers:\n    X-Frame-Options: deny\n"))
+	url := "http://" + serve(t, configPath) + "/metrics"
+
+	for _, test := range []struct {
+		user, password string
+		expected       int
+	}{
+		{"prometheus", "secret", http.StatusOK},
+		{"prometheus", "secret", http.StatusOK},
+		{"prometheus", "wrong", http.StatusUnauthorized},
+		{"unknown", "secret", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	} {
+		status, err := get(http.DefaultClient, url, test.user, test.password)
+		if err != nil || status != test.expected {
+			t.Errorf("Expected status %d for user '%s' with password '%s', got %d: %v", test.expected, test.user, test.password, status, err)
+		}
+	}
+
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.Header.Get("X-Frame-Options") != "deny" || response.Header.Get("WWW-Authenticate") != "Basic" {
+		t.Errorf("Unexpected headers %v", response.Header)
+	}
+
+	// Users are reloaded without restarting.
+	writeFile(t, dir, "web.yml", []byte("basic_auth_users:\n  prometheus: "+hash(t, "rotated")+"\n"))
+	if status, err := get(http.DefaultClient, url, "prometheus", "secret"); err != nil || status != http.StatusUnauthorized {
+		t.Errorf("Expected the old password to be rejected after reload, got %d: %v", status, err)
+	}
+	if status, err := get(http.DefaultClient, url, "prometheus", "rotated"); err != nil || status != http.StatusOK {
+		t.Errorf("Expected the new password to be accepted after reload, got %d: %v", status, err)
+	}
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, "exporter", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, "prometheus", x509.ExtKeyUsageClientAuth)
+	writeFile(t, dir, "server.crt", serverCert)
+	writeFile(t, dir, "server.key", serverKey)
+	writeFile(t, dir, "ca.crt", ca.pem)
+	configPath := writeFile(t, dir, "web.yml", []byte(`tls_server_config:
+  cert_file: server.crt
+  key_file: server.key
+  client_auth_type: RequireAndVerifyClientCert
+  client_ca_file: ca.crt
+  min_version: TLS12
+`))
+	url := "https://" + serve(t, configPath) + "/metrics"
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(ca.pem)
+	certificate, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{certificate}}}}
+	if status, err := get(client, url, "", ""); err != nil || status != http.StatusOK {
+		t.Errorf("Expected client with certificate to be served, got %d: %v", status, err)
+	}
+
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if _, err := get(anonymous, url, "", ""); err == nil {
+		t.Errorf("Expected client without certificate to be rejected")
+	}
+
+	// The client authentication is reloaded for new connections.
+	writeFile(t, dir, "web.yml", []byte("tls_server_config:\n  cert_file: server.crt\n  key_file: server.key\n"))
+	anonymous = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+	if status, err := get(anonymous, url, "", ""); err != nil || status != http.StatusOK {
+		t.Errorf("Expected client without certificate to be served after reload, got %d: %v", status, err)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := map[string]string{
+		"tls_server_config:\n  cert_file: server.crt\n":                              "missing key_file",
+		"tls_server_config:\n  client_ca_file: ca.crt\n":                             "require cert_file and key_file",
+		"tls_server_config:\n  cert_file: a\n  key_file: b\n  client_auth_type: x\n": "unknown client_auth_type 'x'",
+		"tls_server_config:\n  min_version: TLS9\n":                                  "unknown TLS version 'TLS9'",
+		"tls_server_config:\n  cipher_suites: [TLS_FOO]\n":                           "unknown cipher suite 'TLS_FOO'",
+		"basic_auth_users:\n  prometheus:\n":                                         "missing password hash of user 'prometheus'",
+		"basic_auth: {}\n":                                                           "field basic_auth not found",
+	}
+	for content, expected := range tests {
+		path := writeFile(t, dir, "web.yml", []byte(content))
+		if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected '%s' in error for %q, got %v", expected, content, err)
+		}
+	}
+
+	path := writeFile(t, dir, "web.yml", []byte("tls_server_config:\n  cert_file: server.crt\n  key_file: /etc/exporter/server.key\n"))
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.TLSConfig.CertFile != filepath.Join(dir, "server.crt") || c.TLSConfig.KeyFile != "/etc/exporter/server.key" || !c.HTTPConfig.HTTP2 {
+		t.Errorf("Unexpected configuration %+v", c)
+	}
+}
+
+func TestAuthenticated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	configPath := writeFile(t, dir, "web.yml", []byte("basic_auth_users:\n  prometheus: "+hash(t, "secret")+"\n"))
+
+	var authenticated bool
+	check := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated = Authenticated(r)
+	})
+	request := httptest.NewRequest("GET", "/debug/command", nil)
+	request.SetBasicAuth("prometheus", "secret")
+	(&authHandler{configPath: configPath, handler: check, cache: make(map[[sha256.Size]byte]bool)}).ServeHTTP(httptest.NewRecorder(), request)
+	if !authenticated {
+		t.Errorf("Expected request with valid basic auth to be authenticated")
+	}
+
+	// Without users configured, requests pass without being authenticated.
+	writeFile(t, dir, "web.yml", []byte("http_server_config:\n  http2: false\n"))
+	(&authHandler{configPath: configPath, handler: check, cache: make(map[[sha256.Size]byte]bool)}).ServeHTTP(httptest.NewRecorder(), request)
+	if authenticated {
+		t.Errorf("Expected request to not be authenticated without users configured")
+	}
+
+	request = httptest.NewRequest("GET", "/debug/command", nil)
+	request.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{newTestCA(t).certificate}}}
+	if !Authenticated(request) {
+		t.Errorf("Expected request with a verified client certificate to be authenticated")
+	}
+}
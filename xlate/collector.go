@@ -0,0 +1,11 @@
+package xlate
+
+import (
+	"gitlab.com/wobcom/cisco-exporter/asa"
+	"gitlab.com/wobcom/cisco-exporter/collector"
+)
+
+// NewCollector returns a collector gathering the number of address translations (xlates) of an ASA by running `show xlate count`.
+func NewCollector() collector.Collector {
+	return asa.NewUsageCollector("xlate", "show xlate count", "cisco_asa_xlates_", "address translations")
+}